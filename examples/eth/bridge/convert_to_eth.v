@@ -15,7 +15,7 @@ fn execute_rpcs(mut client RpcWsClient, mut logger log.Logger, secret string, ne
 
 	stellar_client.load(secret: secret, network: network)!
 
-	balance := stellar_client.balance('')! // fill in your address
+	balance := stellar_client.asset_balance(address: '', asset: 'tft')! // fill in your address
 	logger.info('Stellar tft balance: ${balance}\n')
 
 	// Destination is the ethereum address
@@ -49,8 +49,9 @@ fn execute_rpcs(mut client RpcWsClient, mut logger log.Logger, args Arguments) !
 	quote := eth_client.quote_eth_for_tft(eth_to_swap)!
 	logger.info('should receive ${quote} tft after swap')
 
-	tx := eth_client.swap_eth_for_tft(eth_to_swap)!
+	tx := eth_client.swap_eth_for_tft(amount: eth_to_swap)!
 	logger.info('swapped eth for tft: tx: ${tx}')
+	eth_client.wait_for_transaction(hash: tx)!
 
 	eth_balance = eth_client.balance(address)!
 	logger.info('eth balance: ${eth_balance}')
@@ -58,17 +59,18 @@ fn execute_rpcs(mut client RpcWsClient, mut logger log.Logger, args Arguments) !
 	eth_tft_balance = eth_client.tft_balance()!
 	logger.info('eth tft balance: ${eth_tft_balance}')
 
+	cursor := stellar_client.bridge_cursor('eth')!
 	hash_bridge_to_stellar := eth_client.bridge_to_stellar(
 		destination: stellar_address
 		amount: quote
 	)!
-	stellar_client.await_transaction_on_eth_bridge(hash_bridge_to_stellar)!
+	stellar_client.await_transaction_on_eth_bridge(memo: hash_bridge_to_stellar, cursor: cursor)!
 	logger.info('bridge to stellar done')
 
 	eth_tft_balance = eth_client.tft_balance()!
 	logger.info('eth tft balance: ${eth_tft_balance}')
 
-	mut stellar_balance := stellar_client.balance(stellar_address)!
+	mut stellar_balance := stellar_client.asset_balance(address: stellar_address, asset: 'tft')!
 	logger.info('stellar balance: ${stellar_balance}')
 
 	tfchain_address := tfchain_client.address()!
@@ -84,7 +86,7 @@ fn execute_rpcs(mut client RpcWsClient, mut logger log.Logger, args Arguments) !
 	tfchain_client.await_transaction_on_tfchain_bridge(hash_bridge_to_tfchain)!
 	logger.info('bridge to tfchain done')
 
-	stellar_balance = stellar_client.balance(stellar_address)!
+	stellar_balance = stellar_client.asset_balance(address: stellar_address, asset: 'tft')!
 	logger.info('stellar balance: ${stellar_balance}')
 
 	tfchain_balance = tfchain_client.balance(tfchain_address)!
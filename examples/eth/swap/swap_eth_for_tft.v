@@ -31,7 +31,7 @@ fn execute_rpcs(mut client RpcWsClient, mut logger log.Logger, secret string, am
 		return
 	}
 
-	tx := eth_client.swap_eth_for_tft(amount_in)!
+	tx := eth_client.swap_eth_for_tft(amount: amount_in)!
 	logger.info('tx: ${tx}\n')
 
 	balance_1 := eth_client.tft_balance()!
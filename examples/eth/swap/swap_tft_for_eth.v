@@ -35,9 +35,11 @@ fn execute_rpcs(mut client RpcWsClient, mut logger log.Logger, secret string, am
 	logger.info('approving ${amount_in} tft for spending\n')
 	t := eth_client.approve_eth_tft_spending(amount_in)!
 	logger.info('tx: ${t}\n')
+	eth_client.wait_for_transaction(hash: t)!
 
-	tx := eth_client.swap_tft_for_eth(amount_in)!
+	tx := eth_client.swap_tft_for_eth(amount: amount_in)!
 	logger.info('tx: ${tx}\n')
+	eth_client.wait_for_transaction(hash: tx)!
 
 	balance_1 := eth_client.tft_balance()!
 	logger.info('tft balance after swap: ${balance_1}\n')
@@ -18,7 +18,7 @@ fn execute_rpcs(mut client RpcWsClient, mut logger log.Logger, network string, s
 
 	stellar_client.load(secret: secret, network: network)!
 
-	balance := stellar_client.balance('')!
+	balance := stellar_client.asset_balance(address: '', asset: 'tft')!
 	logger.info('Stellar tft balance: ${balance}\n')
 
 	// Amount in stroops (1 TFT = 10^7 stroops)
@@ -0,0 +1,63 @@
+module bridge
+
+import freeflowuniverse.crystallib.rpcwebsocket { RpcWsClient }
+
+const (
+	default_timeout = 500000
+)
+
+[params]
+pub struct TransferArgs {
+	from_chain  string // stellar, ethereum or tfchain, one of the chains must be stellar
+	to_chain    string
+	amount      string // the amount of TFT to send to the bridge, the bridge fee is deducted from it
+	destination string // an eth address, a twin id on tfchain or a stellar address
+}
+
+[params]
+pub struct EstimateFee {
+	from_chain  string
+	to_chain    string
+	amount      string
+	destination string // only needed to estimate the gas of withdrawals from ethereum
+}
+
+[openrpc: exclude]
+[noinit]
+pub struct BridgeClient {
+mut:
+	client &RpcWsClient
+}
+
+[openrpc: exclude]
+pub fn new(mut client RpcWsClient) BridgeClient {
+	return BridgeClient{
+		client: &client
+	}
+}
+
+// Transfer TFT from one chain to another through a bridge, using the client of the source chain loaded on the
+// connection. The transfer is followed in the background till the funds arrived, also after a restart of the proxy.
+pub fn (mut b BridgeClient) transfer(args TransferArgs) !Transfer {
+	return b.client.send_json_rpc[[]TransferArgs, Transfer]('bridge.Transfer', [args], bridge.default_timeout)!
+}
+
+// Get the status of a transfer by its id, the transfer must be sent from an account loaded on the connection
+pub fn (mut b BridgeClient) status(id string) !Transfer {
+	return b.client.send_json_rpc[[]string, Transfer]('bridge.Status', [id], bridge.default_timeout)!
+}
+
+// List the transfers sent from the accounts loaded on the connection, most recent first
+pub fn (mut b BridgeClient) list() ![]Transfer {
+	return b.client.send_json_rpc[[]string, []Transfer]('bridge.List', []string{}, bridge.default_timeout)!
+}
+
+// Resume following a stalled transfer, the transfer must be sent from an account loaded on the connection
+pub fn (mut b BridgeClient) resume(id string) !Transfer {
+	return b.client.send_json_rpc[[]string, Transfer]('bridge.Resume', [id], bridge.default_timeout)!
+}
+
+// Estimate the fees of a transfer, using the client of the source chain loaded on the connection
+pub fn (mut b BridgeClient) estimate_fee(args EstimateFee) !Fee {
+	return b.client.send_json_rpc[[]EstimateFee, Fee]('bridge.EstimateFee', [args], bridge.default_timeout)!
+}
@@ -0,0 +1,30 @@
+module bridge
+
+// Transfer of TFT through a bridge, as tracked by the proxy
+pub struct Transfer {
+pub:
+	id            string
+	from_chain    string
+	to_chain      string
+	amount        string
+	destination   string
+	source        string // the address the funds were sent from
+	status        string // pending, submitted, seen_by_bridge, completed or failed
+	error         string // why the transfer failed or stalled
+	stalled       bool   // the bridge did not progress in time, the transfer is no longer followed till it is resumed
+	submit_tx     string // the hash of the transaction sending the funds to the bridge
+	seen_tx       string // how the bridge picked up the transfer: a transaction hash, a tfchain burn id or block
+	completed_tx  string // the hash of the transaction paying out the funds, or the tfchain block of the mint
+	payout_amount string // the TFT paid out on stellar, known once tfchain created the burn of a swap to stellar
+	created_at    string
+	updated_at    string
+}
+
+// Fee of a bridge transfer
+pub struct Fee {
+pub:
+	network_fee       string // the maximum fee of the transaction sending the funds to the bridge, empty if unknown
+	network_fee_asset string // the asset the network fee is paid in
+	bridge_fee        string // the TFT the bridge deducts from the amount, empty if the bridge does not publish it
+	received          string // the TFT arriving on the destination chain, empty if the bridge fee is unknown
+}
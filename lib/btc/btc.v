@@ -99,6 +99,56 @@ pub struct Move {
 	comment           string
 }
 
+// args to load a local wallet from a bip39 mnemonic
+[params]
+pub struct LoadWallet {
+	mnemonic   string
+	passphrase string
+	network    string = 'mainnet' // one of mainnet, testnet, signet or regtest
+}
+
+[params]
+pub struct WalletAddress {
+	address_type string = 'p2wpkh' // one of p2wpkh or p2tr
+	change       bool
+}
+
+[params]
+pub struct WalletSend {
+	address     string
+	amount      i64 // amount in satoshi
+	fee_rate    i64 // fee rate in satoshi per vbyte, estimated for conf_target if 0
+	conf_target i64 = 6 // confirmation target in blocks for the fee estimation
+	change_type string = 'p2wpkh' // address type of the change, one of p2wpkh or p2tr
+}
+
+pub struct PsbtInput {
+pub:
+	txid string
+	vout u32
+}
+
+pub struct PsbtOutput {
+pub:
+	address string
+	amount  i64 // amount in satoshi
+}
+
+[params]
+pub struct CreatePsbt {
+	inputs      []PsbtInput // inputs which are always spent, coins of the wallet are added as needed
+	outputs     []PsbtOutput
+	fee_rate    i64 // fee rate in satoshi per vbyte, estimated for conf_target if 0
+	conf_target i64 = 6 // confirmation target in blocks for the fee estimation
+	change_type string = 'p2wpkh' // address type of the change, one of p2wpkh or p2tr
+}
+
+[params]
+pub struct FinalizePsbt {
+	psbt      string // base64 encoded psbt
+	broadcast bool // broadcast the transaction if the psbt is complete
+}
+
 [openrpc: exclude]
 pub fn new(mut client RpcWsClient) BtcClient {
 	return BtcClient{
@@ -298,3 +348,62 @@ pub fn (mut c BtcClient) create_wallet(args CreateWallet) !CreateWalletResult {
 pub fn (mut c BtcClient) move(args Move) !bool {
 	return c.client.send_json_rpc[[]Move, bool]('btc.Move', [args], btc.default_timeout)!
 }
+
+// Loads a local wallet from a bip39 mnemonic. Its keys are derived and used by the proxy, the node is only used to
+// look up coins and broadcast transactions.
+pub fn (mut c BtcClient) load_wallet(args LoadWallet) ! {
+	_ := c.client.send_json_rpc[[]LoadWallet, string]('btc.LoadWallet', [args], btc.default_timeout)!
+}
+
+// Returns the next unused receive or change address of the local wallet.
+pub fn (mut c BtcClient) wallet_address(args WalletAddress) !string {
+	return c.client.send_json_rpc[[]WalletAddress, string]('btc.WalletAddress', [args],
+		btc.default_timeout)!
+}
+
+// Returns the public descriptors of the receive and change addresses of the local wallet.
+pub fn (mut c BtcClient) wallet_descriptors() ![]string {
+	return c.client.send_json_rpc[[]string, []string]('btc.WalletDescriptors', []string{},
+		btc.default_timeout)!
+}
+
+// Imports the public descriptors of the local wallet in the watch-only descriptor wallet of the node.
+// When rescan is true the node looks for past transactions of the wallet.
+pub fn (mut c BtcClient) wallet_import_descriptors(rescan bool) ! {
+	_ := c.client.send_json_rpc[[]bool, string]('btc.WalletImportDescriptors', [rescan],
+		btc.default_timeout)!
+}
+
+// Lists the unspent outputs of the local wallet, including the unconfirmed outputs of the transactions it broadcast.
+pub fn (mut c BtcClient) wallet_list_unspent() ![]WalletUTXO {
+	return c.client.send_json_rpc[[]string, []WalletUTXO]('btc.WalletListUnspent', []string{},
+		btc.default_timeout)!
+}
+
+// Returns the balance of the local wallet in satoshi, the sum of the outputs listed by wallet_list_unspent.
+pub fn (mut c BtcClient) wallet_balance() !i64 {
+	return c.client.send_json_rpc[[]string, i64]('btc.WalletBalance', []string{}, btc.default_timeout)!
+}
+
+// Sends an amount in satoshi from the local wallet to an address and returns the transaction id.
+pub fn (mut c BtcClient) wallet_send(args WalletSend) !string {
+	return c.client.send_json_rpc[[]WalletSend, string]('btc.WalletSend', [args], btc.default_timeout)!
+}
+
+// Creates an unsigned psbt paying the outputs, funded by the coins of the local wallet and the inputs.
+// Returns the base64 encoded psbt. Its change address is only used up once the psbt is broadcast.
+pub fn (mut c BtcClient) create_psbt(args CreatePsbt) !string {
+	return c.client.send_json_rpc[[]CreatePsbt, string]('btc.CreatePsbt', [args], btc.default_timeout)!
+}
+
+// Signs the inputs of a base64 encoded psbt which belong to the local wallet.
+pub fn (mut c BtcClient) sign_psbt(psbt string) !SignPsbtResult {
+	return c.client.send_json_rpc[[]string, SignPsbtResult]('btc.SignPsbt', [psbt], btc.default_timeout)!
+}
+
+// Finalizes the signed inputs of a base64 encoded psbt. Once all inputs are final the signed transaction is returned
+// and, if asked, broadcast.
+pub fn (mut c BtcClient) finalize_psbt(args FinalizePsbt) !FinalizePsbtResult {
+	return c.client.send_json_rpc[[]FinalizePsbt, FinalizePsbtResult]('btc.FinalizePsbt',
+		[args], btc.default_timeout)!
+}
@@ -233,3 +233,25 @@ pub struct CreateWalletResult {
 	name    string
 	warning string
 }
+
+// WalletUTXO is an unspent output of the local wallet.
+pub struct WalletUTXO {
+	txid         string
+	vout         u32
+	address      string
+	address_type string
+	amount       i64 // amount in satoshi
+	height       i64
+}
+
+pub struct SignPsbtResult {
+	psbt          string
+	signed_inputs int
+}
+
+pub struct FinalizePsbtResult {
+	psbt     string
+	complete bool
+	tx       string // hex encoded signed transaction, empty if the psbt is not complete
+	txid     string
+}
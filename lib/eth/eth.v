@@ -10,12 +10,40 @@ const (
 pub struct Load {
 	url    string
 	secret string
+	chain  string
+}
+
+[params]
+pub struct LoadMnemonic {
+	url      string
+	mnemonic string
+	path     string
+	chain    string
+}
+
+pub struct Account {
+pub:
+	address string
+	path    string
+}
+
+[params]
+pub struct ImportKeystore {
+	keystore string
+	password string
+}
+
+[params]
+pub struct ExportKeystore {
+	address  string
+	password string
 }
 
 [params]
 pub struct Transfer {
 	destination string
 	amount      string
+	from        string
 }
 
 [params]
@@ -23,6 +51,7 @@ pub struct TokenTransfer {
 	contract_address string
 	destination      string
 	amount           string
+	from             string
 }
 
 [params]
@@ -31,6 +60,7 @@ pub struct TokenTransferFrom {
 	from             string
 	destination      string
 	amount           string
+	spender          string
 }
 
 [params]
@@ -38,6 +68,7 @@ pub struct ApproveTokenSpending {
 	contract_address string
 	spender          string
 	amount           string
+	from             string
 }
 
 [params]
@@ -45,12 +76,14 @@ pub struct MultisigOwner {
 	contract_address string
 	target           string
 	threshold        i64
+	from             string
 }
 
 [params]
 pub struct ApproveHash {
 	contract_address string
 	hash             string
+	from             string
 }
 
 [params]
@@ -58,6 +91,7 @@ pub struct InitiateMultisigEthTransfer {
 	contract_address string
 	destination      string
 	amount           string
+	from             string
 }
 
 [params]
@@ -66,6 +100,218 @@ pub struct InitiateMultisigTokenTransfer {
 	token_address    string
 	destination      string
 	amount           string
+	from             string
+}
+
+pub struct TokenInfo {
+pub:
+	address      string
+	name         string
+	symbol       string
+	decimals     u8
+	total_supply string
+}
+
+[params]
+pub struct TokenAllowance {
+	contract_address string
+	owner            string
+	spender          string
+}
+
+[params]
+pub struct SignPermit {
+	contract_address string
+	spender          string
+	amount           string
+	validity         u64 = 3600 // in seconds
+	from             string
+}
+
+[params]
+pub struct TftSwap {
+	amount string // eth sold by swap_eth_for_tft, tft sold by swap_tft_for_eth
+	from   string
+}
+
+[params]
+pub struct SafeExecute {
+	safe_tx_hash string
+	from         string
+}
+
+pub struct Permit {
+pub:
+	token    string
+	owner    string
+	spender  string
+	value    string
+	nonce    string
+	deadline i64
+	v        u8
+	r        string
+	s        string
+}
+
+struct ApproveWithPermit {
+	token    string
+	owner    string
+	spender  string
+	value    string
+	nonce    string
+	deadline i64
+	v        u8
+	r        string
+	s        string
+	from     string
+}
+
+[params]
+pub struct Swap {
+	path         []string // token addresses, the native currency symbol or TFT, from the token sold to the token bought
+	fees         []u32    // fee of the pool of every hop, the deepest pools are used if empty
+	amount       string
+	exact_output bool
+	slippage_bps u32 = 50
+	deadline     u64 = 300 // in seconds
+	from         string
+}
+
+pub struct SwapQuote {
+pub:
+	path             []string
+	fees             []u32
+	exact_output     bool
+	amount_in        string
+	amount_out       string
+	minimum_received string
+	maximum_sold     string
+	price_impact     string
+	slippage_bps     u32
+}
+
+pub struct SwapResult {
+pub:
+	hash  string
+	quote SwapQuote
+}
+
+[params]
+pub struct SafeProposeTransaction {
+	contract_address string
+	to               string
+	value            string // in wei
+	data             string // hex encoded
+	operation        u8     // 0 for a call, 1 for a delegate call
+	safe_tx_gas      u64
+	from             string
+}
+
+[params]
+pub struct SafeSignTransaction {
+	safe_tx_hash string
+	signature    string // signature made outside of the proxy, signed with the loaded key if empty
+	from         string
+}
+
+pub struct SafeTransaction {
+pub:
+	safe_tx_hash string
+	safe         string
+	to           string
+	value        string
+	data         string
+	operation    u8
+	safe_tx_gas  string
+	nonce        u64
+	proposer     string
+	proposed_at  i64
+	signatures   map[string]string
+}
+
+[params]
+pub struct NftBalanceOf {
+	contract_address string
+	owner            string
+}
+
+[params]
+pub struct NftToken {
+	contract_address string
+	token_id         string
+}
+
+[params]
+pub struct NftTransfer {
+	contract_address string
+	from             string
+	to               string
+	token_id         string
+}
+
+[params]
+pub struct NftApprove {
+	contract_address string
+	to               string
+	token_id         string
+	from             string
+}
+
+[params]
+pub struct NftSetApprovalForAll {
+	contract_address string
+	operator         string
+	approved         bool
+	from             string
+}
+
+[params]
+pub struct NftIsApprovedForAll {
+	contract_address string
+	owner            string
+	operator         string
+}
+
+pub struct NftMetadata {
+pub:
+	token_uri   string
+	name        string
+	description string
+	image       string
+}
+
+[params]
+pub struct Erc1155BalanceOf {
+	contract_address string
+	owner            string
+	token_id         string
+}
+
+[params]
+pub struct Erc1155BalanceOfBatch {
+	contract_address string
+	owners           []string
+	token_ids        []string
+}
+
+[params]
+pub struct Erc1155Transfer {
+	contract_address string
+	from             string
+	to               string
+	token_id         string
+	amount           string
+	data             string
+}
+
+[params]
+pub struct Erc1155BatchTransfer {
+	contract_address string
+	from             string
+	to               string
+	token_ids        []string
+	amounts          []string
+	data             string
 }
 
 [params]
@@ -115,6 +361,158 @@ pub struct ApprovalForFungible {
 pub struct TftEthTransfer {
 	destination string
 	amount      string
+	from        string
+}
+
+pub struct Fees {
+pub:
+	base_fee                 string
+	max_priority_fee_per_gas string
+	max_fee_per_gas          string
+	gas_price                string
+}
+
+[params]
+pub struct FeeCaps {
+	max_fee_per_gas          string
+	max_priority_fee_per_gas string
+}
+
+pub struct TransactionStatus {
+pub:
+	hash                string
+	status              string
+	block_number        u64
+	confirmations       u64
+	gas_used            u64
+	effective_gas_price string
+}
+
+[params]
+pub struct WaitForTransaction {
+	hash          string
+	confirmations u64 = 1
+	timeout       u64 = 300
+}
+
+[params]
+pub struct TransactionHistory {
+	address    string
+	from_block u64
+	to_block   u64
+}
+
+pub struct HistoryEntry {
+pub:
+	hash           string
+	block_number   u64
+	timestamp      u64
+	tx_index       u32
+	log_index      u32
+	direction      string // in or out
+	asset          string // native, tft or erc20
+	token          string
+	symbol         string
+	from           string
+	to             string
+	value          string // in the smallest unit of the asset
+	amount         string
+	fee            string // in wei, set on native entries of outgoing transactions
+	failed         bool
+	bridge_address string
+	bridge_network string
+}
+
+// a contract argument or output: integers are passed as numbers or decimal or 0x prefixed strings, addresses and bytes
+// as hex strings, arrays as lists and tuples as maps keyed by component name
+pub type ContractValue = []ContractValue | bool | f64 | map[string]ContractValue | string
+
+[params]
+pub struct LogFilter {
+	addresses  []string   // addresses of the contracts emitting the logs
+	topics     [][]string // topics to match per position, every position matches any of the given topics
+	abi        string     // ABI json or name of a registered ABI (erc20, erc721, safe and tft are builtin) to decode the logs
+	event      string     // event name in the ABI to filter on
+	from_block u64        [omitempty] // first block to query, the latest block if 0
+	to_block   u64        [omitempty] // last block to query, the latest block if 0
+}
+
+pub struct Log {
+pub:
+	address      string
+	topics       []string
+	data         string
+	block_number u64
+	block_hash   string
+	tx_hash      string
+	tx_index     u32
+	index        u32
+	removed      bool // the log was reverted by a chain reorganization
+	event        string
+	args         map[string]ContractValue
+}
+
+[params]
+pub struct GetSubscriptionLogs {
+	id    string
+	count u32 // amount of logs to take, all buffered logs if 0
+}
+
+[params]
+pub struct RegisterABI {
+	name string
+	abi  string // the ABI json of the contract
+}
+
+[params]
+pub struct CallContract {
+	contract_address string
+	abi              string // ABI json or the name of a registered ABI
+	method           string
+	args             []ContractValue
+}
+
+[params]
+pub struct TransactContract {
+	contract_address string
+	abi              string // ABI json or the name of a registered ABI
+	method           string
+	args             []ContractValue
+	value            string // amount of wei to send along
+	gas_limit        u64    // estimated if 0
+	from             string // account of the wallet to send from, leave empty for the loaded account
+}
+
+[params]
+pub struct DeployContract {
+	abi       string // ABI json or the name of a registered ABI
+	bytecode  string // hex encoded bytecode of the contract
+	args      []ContractValue // arguments of the constructor
+	value     string // amount of wei to send along
+	gas_limit u64    // estimated if 0
+	from      string // account of the wallet to send from, leave empty for the loaded account
+}
+
+pub struct DeployedContract {
+pub:
+	address string
+	hash    string
+}
+
+[params]
+pub struct Chain {
+pub:
+	name               string
+	chain_id           u64
+	rpc_url            string
+	native_currency    string
+	weth               string
+	tft                string
+	tft_bridge         string
+	swap_router        string
+	quoter             string
+	account_activation string
+	atomic_swap        string
 }
 
 [openrpc: exclude]
@@ -136,6 +534,39 @@ pub fn (mut e EthClient) load(args Load) ! {
 	_ := e.client.send_json_rpc[[]Load, string]('eth.Load', [args], eth.default_timeout)!
 }
 
+// load_mnemonic loads a new eth client with a BIP-44 wallet, the account at index 0 is loaded
+pub fn (mut e EthClient) load_mnemonic(args LoadMnemonic) ! {
+	_ := e.client.send_json_rpc[[]LoadMnemonic, string]('eth.LoadMnemonic', [args], eth.default_timeout)!
+}
+
+// derive_account derives the account at an index of the derivation path and adds it to the wallet
+pub fn (mut e EthClient) derive_account(index u32) !Account {
+	return e.client.send_json_rpc[[]u32, Account]('eth.DeriveAccount', [index], eth.default_timeout)!
+}
+
+// list_accounts returns the accounts of the wallet
+pub fn (mut e EthClient) list_accounts() ![]Account {
+	return e.client.send_json_rpc[[]string, []Account]('eth.ListAccounts', []string{},
+		eth.default_timeout)!
+}
+
+// get_mnemonic returns the mnemonic of the wallet
+pub fn (mut e EthClient) get_mnemonic() !string {
+	return e.client.send_json_rpc[[]string, string]('eth.GetMnemonic', []string{}, eth.default_timeout)!
+}
+
+// import_keystore adds the key of an encrypted V3 JSON keystore to the wallet
+pub fn (mut e EthClient) import_keystore(args ImportKeystore) !Account {
+	return e.client.send_json_rpc[[]ImportKeystore, Account]('eth.ImportKeystore', [args],
+		eth.default_timeout)!
+}
+
+// export_keystore encrypts the key of an account of the wallet as a V3 JSON keystore
+pub fn (mut e EthClient) export_keystore(args ExportKeystore) !string {
+	return e.client.send_json_rpc[[]ExportKeystore, string]('eth.ExportKeystore', [args],
+		eth.default_timeout)!
+}
+
 // transfer eth
 pub fn (mut e EthClient) transfer(args Transfer) !string {
 	return e.client.send_json_rpc[[]Transfer, string]('eth.Transfer', [args], eth.default_timeout)!
@@ -168,6 +599,47 @@ pub fn (mut e EthClient) token_balance(contractAddress string) !string {
 	], eth.default_timeout)!
 }
 
+// token_info returns the name, symbol, decimals and total supply of a token contract.
+pub fn (mut e EthClient) token_info(contract_address string) !TokenInfo {
+	return e.client.send_json_rpc[[]string, TokenInfo]('eth.TokenInfo', [contract_address],
+		eth.default_timeout)!
+}
+
+// token_balance_of returns the balance of any address for the given token contract.
+pub fn (mut e EthClient) token_balance_of(args GetFungibleBalance) !string {
+	return e.client.send_json_rpc[[]GetFungibleBalance, string]('eth.TokenBalanceOf', [args],
+		eth.default_timeout)!
+}
+
+// token_allowance returns the amount of tokens a spender can still transfer from the owner.
+pub fn (mut e EthClient) token_allowance(args TokenAllowance) !string {
+	return e.client.send_json_rpc[[]TokenAllowance, string]('eth.TokenAllowance', [args],
+		eth.default_timeout)!
+}
+
+// sign_permit signs an EIP-2612 permit for a spender without submitting it.
+pub fn (mut e EthClient) sign_permit(args SignPermit) !Permit {
+	return e.client.send_json_rpc[[]SignPermit, Permit]('eth.SignPermit', [args], eth.default_timeout)!
+}
+
+// approve_with_permit submits a signed permit, paid by the from account of the wallet or the loaded account if empty.
+pub fn (mut e EthClient) approve_with_permit(permit Permit, from string) !string {
+	args := ApproveWithPermit{
+		token: permit.token
+		owner: permit.owner
+		spender: permit.spender
+		value: permit.value
+		nonce: permit.nonce
+		deadline: permit.deadline
+		v: permit.v
+		r: permit.r
+		s: permit.s
+		from: from
+	}
+	return e.client.send_json_rpc[[]ApproveWithPermit, string]('eth.ApproveWithPermit', [args],
+		eth.default_timeout)!
+}
+
 // token_transfer transfers tokens to the given address.
 pub fn (mut e EthClient) token_transfer(args TokenTransfer) !string {
 	return e.client.send_json_rpc[[]TokenTransfer, string]('eth.TransferTokens', [
@@ -236,6 +708,133 @@ pub fn (mut e EthClient) initiate_multisig_token_transfer(args InitiateMultisigT
 		[args], eth.default_timeout)!
 }
 
+// quote returns the expected amounts, limits and price impact of a swap
+pub fn (mut e EthClient) quote(args Swap) !SwapQuote {
+	return e.client.send_json_rpc[[]Swap, SwapQuote]('eth.Quote', [args], eth.default_timeout)!
+}
+
+// swap swaps tokens through uniswap V3 pools
+pub fn (mut e EthClient) swap(args Swap) !SwapResult {
+	return e.client.send_json_rpc[[]Swap, SwapResult]('eth.Swap', [args], eth.default_timeout)!
+}
+
+// safe_propose_transaction proposes a transaction for a gnosis safe and signs it, returning the safe tx hash
+pub fn (mut e EthClient) safe_propose_transaction(args SafeProposeTransaction) !string {
+	return e.client.send_json_rpc[[]SafeProposeTransaction, string]('eth.SafeProposeTransaction',
+		[args], eth.default_timeout)!
+}
+
+// safe_sign_transaction adds a signature to a proposed safe transaction
+pub fn (mut e EthClient) safe_sign_transaction(args SafeSignTransaction) !string {
+	return e.client.send_json_rpc[[]SafeSignTransaction, string]('eth.SafeSignTransaction',
+		[args], eth.default_timeout)!
+}
+
+// safe_list_pending returns the proposed transactions of a safe which were not executed yet
+pub fn (mut e EthClient) safe_list_pending(contract_address string) ![]SafeTransaction {
+	return e.client.send_json_rpc[[]string, []SafeTransaction]('eth.SafeListPending',
+		[contract_address], eth.default_timeout)!
+}
+
+// safe_execute executes a proposed safe transaction once enough owners signed it
+pub fn (mut e EthClient) safe_execute(args SafeExecute) !string {
+	return e.client.send_json_rpc[[]SafeExecute, string]('eth.SafeExecute', [args], eth.default_timeout)!
+}
+
+// nft_balance_of returns the amount of erc721 tokens an address owns
+pub fn (mut e EthClient) nft_balance_of(args NftBalanceOf) !string {
+	return e.client.send_json_rpc[[]NftBalanceOf, string]('eth.NftBalanceOf', [args],
+		eth.default_timeout)!
+}
+
+// nft_owner_of returns the owner of an erc721 token
+pub fn (mut e EthClient) nft_owner_of(args NftToken) !string {
+	return e.client.send_json_rpc[[]NftToken, string]('eth.NftOwnerOf', [args], eth.default_timeout)!
+}
+
+// nft_tokens_of lists the erc721 token ids an address owns
+pub fn (mut e EthClient) nft_tokens_of(args NftBalanceOf) ![]string {
+	return e.client.send_json_rpc[[]NftBalanceOf, []string]('eth.NftTokensOf', [args],
+		eth.default_timeout)!
+}
+
+// nft_metadata fetches the metadata an erc721 token uri points to
+pub fn (mut e EthClient) nft_metadata(args NftToken) !NftMetadata {
+	return e.client.send_json_rpc[[]NftToken, NftMetadata]('eth.NftMetadata', [args],
+		eth.default_timeout)!
+}
+
+// nft_transfer transfers an erc721 token
+pub fn (mut e EthClient) nft_transfer(args NftTransfer) !string {
+	return e.client.send_json_rpc[[]NftTransfer, string]('eth.NftTransfer', [args],
+		eth.default_timeout)!
+}
+
+// nft_safe_transfer transfers an erc721 token using safeTransferFrom
+pub fn (mut e EthClient) nft_safe_transfer(args NftTransfer) !string {
+	return e.client.send_json_rpc[[]NftTransfer, string]('eth.NftSafeTransfer', [args],
+		eth.default_timeout)!
+}
+
+// nft_approve approves an address to transfer an erc721 token
+pub fn (mut e EthClient) nft_approve(args NftApprove) !string {
+	return e.client.send_json_rpc[[]NftApprove, string]('eth.NftApprove', [args], eth.default_timeout)!
+}
+
+// nft_get_approved returns the address approved to transfer an erc721 token
+pub fn (mut e EthClient) nft_get_approved(args NftToken) !string {
+	return e.client.send_json_rpc[[]NftToken, string]('eth.NftGetApproved', [args],
+		eth.default_timeout)!
+}
+
+// nft_set_approval_for_all allows or disallows an operator to transfer all erc721 tokens
+pub fn (mut e EthClient) nft_set_approval_for_all(args NftSetApprovalForAll) !string {
+	return e.client.send_json_rpc[[]NftSetApprovalForAll, string]('eth.NftSetApprovalForAll',
+		[args], eth.default_timeout)!
+}
+
+// nft_is_approved_for_all returns whether an operator can transfer all erc721 tokens of an owner
+pub fn (mut e EthClient) nft_is_approved_for_all(args NftIsApprovedForAll) !bool {
+	return e.client.send_json_rpc[[]NftIsApprovedForAll, bool]('eth.NftIsApprovedForAll',
+		[args], eth.default_timeout)!
+}
+
+// erc1155_balance_of returns the amount of an erc1155 token an address owns
+pub fn (mut e EthClient) erc1155_balance_of(args Erc1155BalanceOf) !string {
+	return e.client.send_json_rpc[[]Erc1155BalanceOf, string]('eth.Erc1155BalanceOf',
+		[args], eth.default_timeout)!
+}
+
+// erc1155_balance_of_batch returns the balances of owner and token id pairs
+pub fn (mut e EthClient) erc1155_balance_of_batch(args Erc1155BalanceOfBatch) ![]string {
+	return e.client.send_json_rpc[[]Erc1155BalanceOfBatch, []string]('eth.Erc1155BalanceOfBatch',
+		[args], eth.default_timeout)!
+}
+
+// erc1155_transfer transfers an amount of an erc1155 token
+pub fn (mut e EthClient) erc1155_transfer(args Erc1155Transfer) !string {
+	return e.client.send_json_rpc[[]Erc1155Transfer, string]('eth.Erc1155Transfer',
+		[args], eth.default_timeout)!
+}
+
+// erc1155_batch_transfer transfers amounts of multiple erc1155 tokens in one transaction
+pub fn (mut e EthClient) erc1155_batch_transfer(args Erc1155BatchTransfer) !string {
+	return e.client.send_json_rpc[[]Erc1155BatchTransfer, string]('eth.Erc1155BatchTransfer',
+		[args], eth.default_timeout)!
+}
+
+// erc1155_set_approval_for_all allows or disallows an operator to transfer all erc1155 tokens
+pub fn (mut e EthClient) erc1155_set_approval_for_all(args NftSetApprovalForAll) !string {
+	return e.client.send_json_rpc[[]NftSetApprovalForAll, string]('eth.Erc1155SetApprovalForAll',
+		[args], eth.default_timeout)!
+}
+
+// erc1155_is_approved_for_all returns whether an operator can transfer all erc1155 tokens of an owner
+pub fn (mut e EthClient) erc1155_is_approved_for_all(args NftIsApprovedForAll) !bool {
+	return e.client.send_json_rpc[[]NftIsApprovedForAll, bool]('eth.Erc1155IsApprovedForAll',
+		[args], eth.default_timeout)!
+}
+
 // get_fungible_balance returns the balance of the given fungible token.
 pub fn (mut e EthClient) get_fungible_balance(args GetFungibleBalance) !string {
 	return e.client.send_json_rpc[[]GetFungibleBalance, string]('eth.GetFungibleBalance',
@@ -325,8 +924,8 @@ pub fn (mut e EthClient) quote_eth_for_tft(amount_in string) !string {
 }
 
 // swap_eth_for_tft swaps eth for tft
-pub fn (mut e EthClient) swap_eth_for_tft(amount_in string) !string {
-	return e.client.send_json_rpc[[]string, string]('eth.SwapEthForTft', [amount_in],
+pub fn (mut e EthClient) swap_eth_for_tft(args TftSwap) !string {
+	return e.client.send_json_rpc[[]TftSwap, string]('eth.SwapEthForTft', [args],
 		eth.default_timeout)!
 }
 
@@ -337,8 +936,8 @@ pub fn (mut e EthClient) quote_tft_for_eth(amount_in string) !string {
 }
 
 // swap_tft_for_eth swaps tft for eth
-pub fn (mut e EthClient) swap_tft_for_eth(amount_in string) !string {
-	return e.client.send_json_rpc[[]string, string]('eth.SwapTftForEth', [amount_in],
+pub fn (mut e EthClient) swap_tft_for_eth(args TftSwap) !string {
+	return e.client.send_json_rpc[[]TftSwap, string]('eth.SwapTftForEth', [args],
 		eth.default_timeout)!
 }
 
@@ -347,3 +946,112 @@ pub fn (mut e EthClient) create_and_activate_stellar_account(network string) !st
 	return e.client.send_json_rpc[[]string, string]('eth.CreateAndActivateStellarAccount',
 		[network], eth.default_timeout)!
 }
+
+// estimate_fees suggests the fees in wei for a new transaction
+pub fn (mut e EthClient) estimate_fees() !Fees {
+	return e.client.send_json_rpc[[]string, Fees]('eth.EstimateFees', []string{}, eth.default_timeout)!
+}
+
+// set_fee_caps limits the fees in wei of all transactions sent by the client, empty values are not capped
+pub fn (mut e EthClient) set_fee_caps(args FeeCaps) ! {
+	_ := e.client.send_json_rpc[[]FeeCaps, string]('eth.SetFeeCaps', [args], eth.default_timeout)!
+}
+
+// transaction_status returns the status of a transaction: pending, success, failed or not_found
+pub fn (mut e EthClient) transaction_status(hash string) !TransactionStatus {
+	return e.client.send_json_rpc[[]string, TransactionStatus]('eth.TransactionStatus',
+		[hash], eth.default_timeout)!
+}
+
+// wait_for_transaction waits till a transaction is mined with the requested amount of confirmations
+pub fn (mut e EthClient) wait_for_transaction(args WaitForTransaction) !TransactionStatus {
+	return e.client.send_json_rpc[[]WaitForTransaction, TransactionStatus]('eth.WaitForTransaction',
+		[args], eth.default_timeout)!
+}
+
+// speed_up replaces a pending transaction with the same transaction paying a higher fee
+pub fn (mut e EthClient) speed_up(hash string) !string {
+	return e.client.send_json_rpc[[]string, string]('eth.SpeedUp', [hash], eth.default_timeout)!
+}
+
+// cancel replaces a pending transaction with an empty transaction paying a higher fee
+pub fn (mut e EthClient) cancel(hash string) !string {
+	return e.client.send_json_rpc[[]string, string]('eth.Cancel', [hash], eth.default_timeout)!
+}
+
+// transaction_history returns the transfers of an address between two blocks
+pub fn (mut e EthClient) transaction_history(args TransactionHistory) ![]HistoryEntry {
+	return e.client.send_json_rpc[[]TransactionHistory, []HistoryEntry]('eth.TransactionHistory',
+		[args], eth.default_timeout)!
+}
+
+// register_chain adds a chain which can be selected when loading the client, or overrides a known chain
+pub fn (mut e EthClient) register_chain(args Chain) ! {
+	_ := e.client.send_json_rpc[[]Chain, string]('eth.RegisterChain', [args], eth.default_timeout)!
+}
+
+// list_chains returns the chains which can be loaded
+pub fn (mut e EthClient) list_chains() ![]Chain {
+	return e.client.send_json_rpc[[]string, []Chain]('eth.ListChains', []string{}, eth.default_timeout)!
+}
+
+// chain returns the chain the client is loaded for
+pub fn (mut e EthClient) chain() !Chain {
+	return e.client.send_json_rpc[[]string, Chain]('eth.Chain', []string{}, eth.default_timeout)!
+}
+
+// register_abi registers the ABI json of a contract under a name, so the name can be used as abi in contract calls
+pub fn (mut e EthClient) register_abi(args RegisterABI) ! {
+	_ := e.client.send_json_rpc[[]RegisterABI, string]('eth.RegisterABI', [args], eth.default_timeout)!
+}
+
+// list_abis returns the names of the registered ABIs
+pub fn (mut e EthClient) list_abis() ![]string {
+	return e.client.send_json_rpc[[]string, []string]('eth.ListABIs', []string{}, eth.default_timeout)!
+}
+
+// call_contract calls a read only method of a contract and returns its decoded outputs
+pub fn (mut e EthClient) call_contract(args CallContract) ![]ContractValue {
+	return e.client.send_json_rpc[[]CallContract, []ContractValue]('eth.CallContract', [args],
+		eth.default_timeout)!
+}
+
+// transact_contract submits a transaction calling a method of a contract and returns the transaction hash
+pub fn (mut e EthClient) transact_contract(args TransactContract) !string {
+	return e.client.send_json_rpc[[]TransactContract, string]('eth.TransactContract', [args],
+		eth.default_timeout)!
+}
+
+// deploy_contract deploys a contract and returns its address and the hash of the deployment transaction
+pub fn (mut e EthClient) deploy_contract(args DeployContract) !DeployedContract {
+	return e.client.send_json_rpc[[]DeployContract, DeployedContract]('eth.DeployContract',
+		[args], eth.default_timeout)!
+}
+
+// get_logs returns the logs matching a filter, decoded if an ABI is given
+pub fn (mut e EthClient) get_logs(args LogFilter) ![]Log {
+	return e.client.send_json_rpc[[]LogFilter, []Log]('eth.GetLogs', [args], eth.default_timeout)!
+}
+
+// subscribe_logs subscribes to new logs matching a filter and returns the id of the subscription, the client must be
+// loaded with a websocket url
+pub fn (mut e EthClient) subscribe_logs(args LogFilter) !string {
+	return e.client.send_json_rpc[[]LogFilter, string]('eth.SubscribeLogs', [args], eth.default_timeout)!
+}
+
+// get_subscription_logs takes the buffered logs of a subscription, oldest first
+pub fn (mut e EthClient) get_subscription_logs(args GetSubscriptionLogs) ![]Log {
+	return e.client.send_json_rpc[[]GetSubscriptionLogs, []Log]('eth.GetSubscriptionLogs',
+		[args], eth.default_timeout)!
+}
+
+// get_subscription_ids returns the ids of the active log subscriptions
+pub fn (mut e EthClient) get_subscription_ids() ![]string {
+	return e.client.send_json_rpc[[]string, []string]('eth.GetSubscriptionIds', []string{},
+		eth.default_timeout)!
+}
+
+// close_subscription stops a log subscription and drops its buffered logs
+pub fn (mut e EthClient) close_subscription(id string) ! {
+	_ := e.client.send_json_rpc[[]string, string]('eth.CloseSubscription', [id], eth.default_timeout)!
+}
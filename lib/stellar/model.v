@@ -86,4 +86,84 @@ pub:
 	num_sponsored u32
 	sponsor string
 	paging_token string
-}
\ No newline at end of file
+}
+
+pub struct QuoteResult {
+pub:
+	source_asset string
+	source_amount string
+	destination_asset string
+	destination_amount string
+	path []string
+}
+
+pub struct Asset {
+pub:
+	asset_type string
+	asset_code string
+	asset_issuer string
+}
+
+pub struct Price {
+pub:
+	n int
+	d int
+}
+
+pub struct OfferData {
+pub:
+	id string
+	paging_token string
+	seller string
+	selling Asset
+	buying Asset
+	amount string
+	price_r Price
+	price string
+	last_modified_ledger int
+	last_modified_time string
+	sponsor string
+}
+
+pub struct PriceLevel {
+pub:
+	price_r Price
+	price string
+	amount string
+}
+
+pub struct OrderBookSummary {
+pub:
+	bids []PriceLevel
+	asks []PriceLevel
+	base Asset
+	counter Asset
+}
+
+// the predicate of a claimant as returned by horizon, only one of the fields is set. The operand of not is not decoded.
+pub struct ClaimableBalancePredicate {
+pub:
+	unconditional bool
+	and []ClaimableBalancePredicate
+	or []ClaimableBalancePredicate
+	abs_before string
+	rel_before string
+}
+
+pub struct ClaimableBalanceClaimant {
+pub:
+	destination string
+	predicate ClaimableBalancePredicate
+}
+
+pub struct ClaimableBalance {
+pub:
+	id string
+	paging_token string
+	asset string
+	amount string
+	sponsor string
+	last_modified_ledger u32
+	last_modified_time string
+	claimants []ClaimableBalanceClaimant
+}
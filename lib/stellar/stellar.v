@@ -8,8 +8,10 @@ const (
 
 [params]
 pub struct Load {
-	network string = 'public'
-	secret  string
+	network               string = 'public'
+	secret                string
+	disable_tft_trustline bool // don't add a TFT trustline to the account if it doesn't have one
+	transaction_timeout   u64  // seconds after which submitted transactions are no longer valid, 300 by default
 }
 
 [params]
@@ -17,6 +19,65 @@ pub struct Swap {
 	amount string
 	source_asset string = "xlm"
 	destination_asset string
+	mode string = "strict_send" // strict_send: amount is sent, strict_receive: amount is received
+	slippage string // slippage tolerance in percent, 1 by default
+}
+
+pub struct ClaimPredicate {
+pub:
+	predicate_type string                    [json: 'type'] // unconditional, before_absolute_time, before_relative_time, not, and or or
+	abs_before     i64 // unix timestamp in seconds, for before_absolute_time
+	rel_before     i64 // seconds after the creation of the balance, for before_relative_time
+	predicates     []ClaimPredicate // the operands of not (1), and (2) and or (2)
+}
+
+pub struct Claimant {
+pub:
+	destination string
+	predicate   ClaimPredicate
+}
+
+[params]
+pub struct CreateClaimableBalance {
+	asset     string = 'tft' // tft, xlm or CODE:ISSUER
+	amount    string
+	claimants []Claimant
+}
+
+[params]
+pub struct ListClaimableBalances {
+	claimant string // leave empty for your own account
+	asset    string // leave empty for all assets
+}
+
+[params]
+pub struct Quote {
+	amount            string
+	source_asset      string = 'xlm'
+	destination_asset string
+	mode              string = 'strict_send' // strict_send: amount is sent, strict_receive: amount is received
+}
+
+[params]
+pub struct OrderBook {
+	selling string
+	buying  string
+	limit   u32 // the amount of bids and asks to return, 20 by default
+}
+
+[params]
+pub struct Offer {
+	selling string
+	buying  string
+	amount  string
+	price   string
+}
+
+[params]
+pub struct UpdateOffer {
+	offer_id i64
+	amount   string // leave empty to keep the current amount
+	price    string // leave empty to keep the current price
 }
 
 [params]
@@ -24,6 +85,19 @@ pub struct Transfer {
 	amount      string
 	destination string
 	memo        string
+	asset       string = 'tft' // tft, xlm or CODE:ISSUER
+}
+
+[params]
+pub struct AssetBalance {
+	address string // leave empty for your own account
+	asset   string // tft, xlm or CODE:ISSUER
+}
+
+[params]
+pub struct Trustline {
+	asset string // tft or CODE:ISSUER
+	limit string // leave empty for the maximum limit
 }
 
 [params]
@@ -38,6 +112,22 @@ pub struct TfchainBridgeTransfer {
 	twin_id u32
 }
 
+[params]
+pub struct AwaitTransactionOnEthBridge {
+	memo   string // the memo of the transaction
+	cursor string // only consider payments after this cursor, see bridge_cursor
+}
+
+[params]
+pub struct AwaitBridgePayment {
+	bridge      string = 'eth' // the bridge making the payment, eth or tfchain
+	memo        string // the memo of the transaction
+	cursor      string // only consider payments after this cursor, see bridge_cursor
+	destination string // the destination of the payment, leave empty to not filter on it
+	amount      string // the amount of the payment, leave empty to not filter on it
+	timeout     u64 // how long to wait in seconds, 300 by default
+}
+
 [params]
 pub struct Transactions {
 	account string  // filter the transactions on the account with the address from this argument, leave empty for your account
@@ -71,6 +161,28 @@ pub fn (mut s StellarClient) create_account(network string) !string {
 	return s.client.send_json_rpc[[]string, string]('stellar.CreateAccount', [network], stellar.default_timeout)!
 }
 
+// Set the time in seconds after which submitted transactions are no longer valid, 0 disables the time bounds
+pub fn (mut s StellarClient) set_transaction_timeout(seconds u64) ! {
+	_ := s.client.send_json_rpc[[]u64, string]('stellar.SetTransactionTimeout', [seconds], default_timeout)!
+}
+
+// Create a claimable balance which the claimants can claim while their predicate holds, returns the id of the balance
+pub fn (mut s StellarClient) create_claimable_balance(args CreateClaimableBalance) !string {
+	return s.client.send_json_rpc[[]CreateClaimableBalance, string]('stellar.CreateClaimableBalance',
+		[args], default_timeout)!
+}
+
+// List the claimable balances an account can claim
+pub fn (mut s StellarClient) list_claimable_balances(args ListClaimableBalances) ![]ClaimableBalance {
+	return s.client.send_json_rpc[[]ListClaimableBalances, []ClaimableBalance]('stellar.ListClaimableBalances',
+		[args], default_timeout)!
+}
+
+// Claim a claimable balance, adding a trustline for its asset if needed
+pub fn (mut s StellarClient) claim_balance(balance_id string) ! {
+	_ := s.client.send_json_rpc[[]string, string]('stellar.ClaimBalance', [balance_id], default_timeout)!
+}
+
 // Get the public address of the loaded stellar secret
 pub fn (mut s StellarClient) address() !string {
 	return s.client.send_json_rpc[[]string, string]('stellar.Address', []string{}, default_timeout)!
@@ -81,14 +193,64 @@ pub fn (mut s StellarClient) swap(args Swap) !string {
 	return s.client.send_json_rpc[[]Swap, string]('stellar.Swap', [args], default_timeout)!
 }
 
+// Quote the paths to convert the source asset into the destination asset on the DEX, best path first
+pub fn (mut s StellarClient) quote(args Quote) ![]QuoteResult {
+	return s.client.send_json_rpc[[]Quote, []QuoteResult]('stellar.Quote', [args], default_timeout)!
+}
+
+// Place an offer on the DEX selling an asset, returns the id of the offer
+pub fn (mut s StellarClient) create_sell_offer(args Offer) !i64 {
+	return s.client.send_json_rpc[[]Offer, i64]('stellar.CreateSellOffer', [args], default_timeout)!
+}
+
+// Place an offer on the DEX buying an asset, returns the id of the offer
+pub fn (mut s StellarClient) create_buy_offer(args Offer) !i64 {
+	return s.client.send_json_rpc[[]Offer, i64]('stellar.CreateBuyOffer', [args], default_timeout)!
+}
+
+// Change the amount and/or price of an offer
+pub fn (mut s StellarClient) update_offer(args UpdateOffer) ! {
+	_ := s.client.send_json_rpc[[]UpdateOffer, string]('stellar.UpdateOffer', [args], default_timeout)!
+}
+
+// Remove an offer from the DEX
+pub fn (mut s StellarClient) cancel_offer(offer_id i64) ! {
+	_ := s.client.send_json_rpc[[]i64, string]('stellar.CancelOffer', [offer_id], default_timeout)!
+}
+
+// List the open offers of an account, leave empty for your own account
+pub fn (mut s StellarClient) list_offers(account string) ![]OfferData {
+	return s.client.send_json_rpc[[]string, []OfferData]('stellar.ListOffers', [account], default_timeout)!
+}
+
+// Return the bids and asks of the order book of an asset pair
+pub fn (mut s StellarClient) order_book(args OrderBook) !OrderBookSummary {
+	return s.client.send_json_rpc[[]OrderBook, OrderBookSummary]('stellar.OrderBook', [args], default_timeout)!
+}
+
 // Transfer an amount of TFT from the loaded account to the destination.
 pub fn (mut s StellarClient) transfer(args Transfer) !string {
 	return s.client.send_json_rpc[[]Transfer, string]('stellar.Transfer', [args], default_timeout)!
 }
 
-// Balance of an account for TFT on stellar.
-pub fn (mut s StellarClient) balance(address string) !string {
-	return s.client.send_json_rpc[[]string, string]('stellar.Balance', [address], default_timeout)!
+// Balances of an account for all assets it holds, including XLM
+pub fn (mut s StellarClient) balance(address string) ![]Balance {
+	return s.client.send_json_rpc[[]string, []Balance]('stellar.Balance', [address], default_timeout)!
+}
+
+// Balance of an account for a specific asset
+pub fn (mut s StellarClient) asset_balance(args AssetBalance) !string {
+	return s.client.send_json_rpc[[]AssetBalance, string]('stellar.AssetBalance', [args], default_timeout)!
+}
+
+// Add a trustline for an asset to the loaded account or change its limit
+pub fn (mut s StellarClient) add_trustline(args Trustline) ! {
+	_ := s.client.send_json_rpc[[]Trustline, string]('stellar.AddTrustline', [args], default_timeout)!
+}
+
+// Remove the trustline for an asset from the loaded account, the balance of the asset should be 0
+pub fn (mut s StellarClient) remove_trustline(asset string) ! {
+	_ := s.client.send_json_rpc[[]string, string]('stellar.RemoveTrustline', [asset], default_timeout)!
 }
 
 // bridge_to_eth bridge to eth from stellar
@@ -108,9 +270,21 @@ pub fn (mut s StellarClient) bridge_to_tfchain(args TfchainBridgeTransfer) !stri
  	return s.client.send_json_rpc[[]TfchainBridgeTransfer, string]('stellar.BridgeToTfchain', [args], default_timeout)!
 }
 
-// Await till a transaction is processed on ethereum bridge that contains a specific memo
-pub fn (mut s StellarClient) await_transaction_on_eth_bridge(memo string) ! {
-	_ := s.client.send_json_rpc[[]string, string]('stellar.AwaitTransactionOnEthBridge', [memo], default_timeout)!
+// Await till a transaction is processed on ethereum bridge that contains a specific memo. The cursor is required, take
+// it with bridge_cursor before the bridge transfer.
+pub fn (mut s StellarClient) await_transaction_on_eth_bridge(args AwaitTransactionOnEthBridge) ! {
+	_ := s.client.send_json_rpc[[]AwaitTransactionOnEthBridge, string]('stellar.AwaitTransactionOnEthBridge',
+		[args], default_timeout)!
+}
+
+// Get a cursor pointing at the latest payment of a bridge (eth or tfchain), to be used in await_bridge_payment
+pub fn (mut s StellarClient) bridge_cursor(bridge string) !string {
+	return s.client.send_json_rpc[[]string, string]('stellar.BridgeCursor', [bridge], default_timeout)!
+}
+
+// Await till the bridge makes a payment matching the arguments, returns the hash of the transaction
+pub fn (mut s StellarClient) await_bridge_payment(args AwaitBridgePayment) !string {
+	return s.client.send_json_rpc[[]AwaitBridgePayment, string]('stellar.AwaitBridgePayment', [args], default_timeout)!
 }
 
 // Return a limited amount of transactions bound to a specific account
@@ -50,3 +50,26 @@ pub mut:
 	is_rent_contract bool
 	rent_contract    RentContract
 }
+
+pub struct ContractBillingInfo {
+pub mut:
+	amount_unbilled      f64
+	last_billed          u64
+	previous_nu_reported u64
+}
+
+pub struct ContractCost {
+pub mut:
+	usd_per_month  f64
+	tft_per_month  f64
+	discount_level string
+}
+
+// BatchResult holds the block a batch was included in and the ids of the contracts it created
+pub struct BatchResult {
+pub mut:
+	block_hash           string
+	block_number         u32
+	contract_ids         []u64
+	service_contract_ids []u64
+}
@@ -0,0 +1,33 @@
+module tfchain
+
+pub struct VoteWeight {
+pub:
+	farm_id u32
+	weight  u64
+}
+
+// Proposal is a dao proposal, the status is active while farms can vote on it
+pub struct Proposal {
+pub:
+	hash         string
+	index        u32
+	description  string
+	link         string
+	status       string
+	outcome      string // approved, disapproved or vetoed once the council closed the proposal
+	closed_block u32
+	threshold    u32
+	ayes         []VoteWeight
+	nays         []VoteWeight
+	ayes_count   u32
+	nays_count   u32
+	end_block    u32
+	vetos        []string
+}
+
+// UnvotedProposal is an active proposal with the farms of the loaded twin which did not vote on it
+pub struct UnvotedProposal {
+pub:
+	proposal Proposal
+	farm_ids []u32
+}
@@ -0,0 +1,14 @@
+module tfchain
+
+// EventValue is a field of the data of an event, nested values are lists or maps
+pub type EventValue = []EventValue | bool | f64 | map[string]EventValue | string
+
+// Event emitted by a pallet in a finalized block
+pub struct Event {
+pub:
+	block     u32
+	extrinsic ?u32 // index of the extrinsic which emitted the event, none for events emitted while initializing or finalizing the block
+	pallet    string
+	name      string
+	data      map[string]EventValue
+}
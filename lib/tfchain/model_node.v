@@ -73,3 +73,13 @@ pub struct Interface {
 	mac  string
 	ips  []string
 }
+
+pub struct NodeStatus {
+pub:
+	power_state              string // up or down
+	down_since               u32    // block the node went down in
+	power_target             string // up or down
+	uptime                   u64    // seconds, 0 if the node did not report its uptime recently
+	last_uptime_report       u64    // unix time
+	last_uptime_report_block u32
+}
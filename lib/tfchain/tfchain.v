@@ -13,6 +13,28 @@ pub struct Load {
 pub:
 	network string
 	mnemonic string
+	url string // optional, a tfchain node to connect to instead of the one of the network
+}
+
+[params]
+pub struct AccountFunding {
+pub:
+	method   string = 'activation' // activation, transfer or sudo
+	url      string // activation service, defaults to the one of the network
+	mnemonic string // account funding with a transfer or sudo, sudo defaults to //Alice
+	amount   u64
+}
+
+[params]
+pub struct CreateAccountOptions {
+pub:
+	network         string
+	url             string
+	relay           string
+	funding         AccountFunding
+	terms_link      string
+	terms_hash      string
+	terms_documents []string // https urls of the documents to compute terms_hash from if it is empty
 }
 
 [params]
@@ -29,6 +51,26 @@ pub:
 	pk    []byte
 }
 
+[params]
+pub struct UpdateTwin {
+pub:
+	relay ?string // the current relay is kept if none, removed if empty
+	pk    ?[]byte // the current pk is kept if none
+}
+
+[params]
+pub struct KVSet {
+pub:
+	key   string
+	value string
+}
+
+pub struct KV {
+pub:
+	key   string
+	value string
+}
+
 [params]
 pub struct AcceptTermsAndConditions {
 	link string
@@ -99,6 +141,112 @@ pub struct SwapToStellar {
 	amount u64
 }
 
+[params]
+pub struct AddFarmIP {
+	farm_id u32
+	ip      string // in cidr notation
+	gateway string
+}
+
+[params]
+pub struct RemoveFarmIP {
+	farm_id u32
+	ip      string
+}
+
+[params]
+pub struct SetFarmCertification {
+	farm_id u32
+	gold    bool
+}
+
+[params]
+pub struct SetFarmDedicated {
+	farm_id   u32
+	dedicated bool
+}
+
+[params]
+pub struct SetFarmPayoutStellarAddress {
+	farm_id         u32
+	stellar_address string
+}
+
+[params]
+pub struct SetNodePowerTarget {
+	node_id u32
+	target  string // up or down
+}
+
+pub struct NodePublicConfig {
+	ipv4   string
+	gw4    string
+	ipv6   string
+	gw6    string
+	domain string
+}
+
+[params]
+pub struct SetNodePublicConfig {
+	farm_id       u32
+	node_id       u32
+	public_config NodePublicConfig
+}
+
+struct RemoveNodePublicConfig {
+	farm_id u32
+	node_id u32
+}
+
+[params]
+pub struct EstimateContractCost {
+	cru        u64
+	mru        f64 // in GB
+	sru        f64 // in GB
+	hru        f64 // in GB
+	public_ips u32
+	certified  bool
+	dedicated  bool
+	node_id    u32
+}
+
+[params]
+pub struct ListProposals {
+	status     string // active, closed or empty for all proposals
+	from_block u32    // first block proposals closed by the council are looked for in, the last day if 0
+}
+
+[params]
+pub struct Vote {
+	farm_id       u32
+	proposal_hash string
+	approve       bool
+}
+
+// BatchCall is a call to submit in a batch. The name is the one of the method with the same arguments, like
+// create_node_contract, and args holds those arguments json encoded, like json.encode(CreateNodeContract{...}).
+pub struct BatchCall {
+	name string
+	args string [raw]
+}
+
+[params]
+pub struct EventFilter {
+	pallets     []string // pallets emitting the events, e.g. SmartContractModule, TfgridModule or TFTBridgeModule
+	names       []string // names of the events, e.g. ContractCreated, NodeUptimeReported or MintCompleted
+	account     string   // ss58 address which must appear in the event
+	twin_id     u32      // twin the event must be about
+	node_id     u32      // node the event must be about
+	contract_id u64      // contract the event must be about
+	from_block  u32      // first block to deliver the events of, the next finalized block if 0
+}
+
+[params]
+pub struct GetSubscriptionEvents {
+	id    string
+	count u32 // amount of events to take, all buffered events if 0
+}
+
 [noinit; openrpc: exclude]
 pub struct TfChainClient {
 mut:
@@ -118,6 +266,12 @@ pub fn (mut t TfChainClient) create_account(network string) !string {
 	return t.client.send_json_rpc[[]string, string]('tfchain.CreateAccount', [network], tfchain.default_timeout)!
 }
 
+// Generate a mnemonic, create an account and twin and load it, funding the account as chosen in the options. 
+// This allows creating accounts on a local dev node without the activation service.
+pub fn (mut t TfChainClient) create_account_with_options(args CreateAccountOptions) !string {
+	return t.client.send_json_rpc[[]CreateAccountOptions, string]('tfchain.CreateAccountWithOptions', [args], tfchain.default_timeout)!
+}
+
 // Load your mnemonic with this call. Choose the network while doing so. The network should be one of:
 // mainnet, testnet, qanet, devnet 
 pub fn (mut t TfChainClient) load(args Load) ! {
@@ -163,6 +317,32 @@ pub fn (mut t TfChainClient) create_twin(args CreateTwin) !u32 {
 	return t.client.send_json_rpc[[]CreateTwin, u32]('tfchain.CreateTwin', [args], tfchain.default_timeout)!
 }
 
+// Update the relay and public key of the twin of the loaded account. The result of this call contains
+// your twin id.
+pub fn (mut t TfChainClient) update_twin(args UpdateTwin) !u32 {
+	return t.client.send_json_rpc[[]UpdateTwin, u32]('tfchain.UpdateTwin', [args], tfchain.default_timeout)!
+}
+
+// Set a key in the key-value store of the loaded account. Values are stored as is, encrypt anything secret.
+pub fn (mut t TfChainClient) kv_set(args KVSet) ! {
+	_ := t.client.send_json_rpc[[]KVSet, string]('tfchain.KVSet', [args], tfchain.default_timeout)!
+}
+
+// Get the value of a key in the key-value store of the loaded account
+pub fn (mut t TfChainClient) kv_get(key string) !string {
+	return t.client.send_json_rpc[[]string, string]('tfchain.KVGet', [key], tfchain.default_timeout)!
+}
+
+// Remove a key from the key-value store of the loaded account
+pub fn (mut t TfChainClient) kv_delete(key string) ! {
+	_ := t.client.send_json_rpc[[]string, string]('tfchain.KVDelete', [key], tfchain.default_timeout)!
+}
+
+// List the entries in the key-value store of the loaded account with keys starting with prefix
+pub fn (mut t TfChainClient) kv_list(prefix string) ![]KV {
+	return t.client.send_json_rpc[[]string, []KV]('tfchain.KVList', [prefix], tfchain.default_timeout)!
+}
+
 // Accepts terms and conditions. Provide the document link and document hash while executing this call.
 pub fn (mut t TfChainClient) accept_terms_and_conditions(args AcceptTermsAndConditions) ! {
 	_ := t.client.send_json_rpc[[]AcceptTermsAndConditions, string]('tfchain.AcceptTermsAndConditions',
@@ -194,6 +374,71 @@ pub fn (mut t TfChainClient) create_farm(args CreateFarm) ! {
 	_ := t.client.send_json_rpc[[]CreateFarm, string]('tfchain.CreateFarm', [args], tfchain.default_timeout)!
 }
 
+// Add a public ip with its gateway to a farm of the loaded account.
+pub fn (mut t TfChainClient) add_farm_ip(args AddFarmIP) ! {
+	_ := t.client.send_json_rpc[[]AddFarmIP, string]('tfchain.AddFarmIP', [args], tfchain.default_timeout)!
+}
+
+// Remove a public ip from a farm of the loaded account. The ip can't be in use by a contract.
+pub fn (mut t TfChainClient) remove_farm_ip(args RemoveFarmIP) ! {
+	_ := t.client.send_json_rpc[[]RemoveFarmIP, string]('tfchain.RemoveFarmIP', [args], tfchain.default_timeout)!
+}
+
+// Set the certification of a farm. This is sudo only and not meant for farmers, it only works when the loaded account
+// is the sudo key of the network, like on a local dev node.
+pub fn (mut t TfChainClient) set_farm_certification(args SetFarmCertification) ! {
+	_ := t.client.send_json_rpc[[]SetFarmCertification, string]('tfchain.SetFarmCertification',
+		[args], tfchain.default_timeout)!
+}
+
+// Set whether the nodes of a farm are dedicated. This is sudo only and not meant for farmers, it only works when the
+// loaded account is the sudo key of the network.
+pub fn (mut t TfChainClient) set_farm_dedicated(args SetFarmDedicated) ! {
+	_ := t.client.send_json_rpc[[]SetFarmDedicated, string]('tfchain.SetFarmDedicated', [args],
+		tfchain.default_timeout)!
+}
+
+// Set the stellar address the farming rewards of a farm of the loaded account are paid to.
+pub fn (mut t TfChainClient) set_farm_payout_stellar_address(args SetFarmPayoutStellarAddress) ! {
+	_ := t.client.send_json_rpc[[]SetFarmPayoutStellarAddress, string]('tfchain.SetFarmPayoutStellarAddress',
+		[args], tfchain.default_timeout)!
+}
+
+// Get the stellar address the farming rewards of a farm are paid to.
+pub fn (mut t TfChainClient) get_farm_payout_stellar_address(farm_id u32) !string {
+	return t.client.send_json_rpc[[]u32, string]('tfchain.GetFarmPayoutStellarAddress', [
+		farm_id,
+	], tfchain.default_timeout)!
+}
+
+// Set whether a node of a farm of the loaded account should be up or down.
+pub fn (mut t TfChainClient) set_node_power_target(args SetNodePowerTarget) ! {
+	_ := t.client.send_json_rpc[[]SetNodePowerTarget, string]('tfchain.SetNodePowerTarget', [
+		args,
+	], tfchain.default_timeout)!
+}
+
+// Set the public config of a node of a farm of the loaded account.
+pub fn (mut t TfChainClient) set_node_public_config(args SetNodePublicConfig) ! {
+	_ := t.client.send_json_rpc[[]SetNodePublicConfig, string]('tfchain.SetNodePublicConfig',
+		[args], tfchain.default_timeout)!
+}
+
+// Remove the public config of a node of a farm of the loaded account.
+pub fn (mut t TfChainClient) remove_node_public_config(farm_id u32, node_id u32) ! {
+	_ := t.client.send_json_rpc[[]RemoveNodePublicConfig, string]('tfchain.SetNodePublicConfig',
+		[RemoveNodePublicConfig{
+		farm_id: farm_id
+		node_id: node_id
+	}], tfchain.default_timeout)!
+}
+
+// Get the power state and target of a node and its last uptime report.
+pub fn (mut t TfChainClient) get_node_status(node_id u32) !NodeStatus {
+	return t.client.send_json_rpc[[]u32, NodeStatus]('tfchain.GetNodeStatus', [node_id],
+		tfchain.default_timeout)!
+}
+
 // Get a contract by id. Contract can be any of the following types: node contract, name contract or rent 
 // contract.
 pub fn (mut t TfChainClient) get_contract(contract_id u64) !Contract {
@@ -220,6 +465,19 @@ pub fn (mut t TfChainClient) get_node_contracts(node_id u32) ![]u64 {
 		tfchain.default_timeout)!
 }
 
+// Get the billing information of a contract: the amount in USD which is not billed yet and when it was last billed.
+pub fn (mut t TfChainClient) contract_billing_info(contract_id u64) !ContractBillingInfo {
+	return t.client.send_json_rpc[[]u64, ContractBillingInfo]('tfchain.ContractBillingInfo',
+		[contract_id], tfchain.default_timeout)!
+}
+
+// Estimate the monthly cost of a contract reserving resources. If a node id is given, the pricing policy of its
+// farm and its certification are used. The discount depends on the balance of the loaded account.
+pub fn (mut t TfChainClient) estimate_contract_cost(args EstimateContractCost) !ContractCost {
+	return t.client.send_json_rpc[[]EstimateContractCost, ContractCost]('tfchain.EstimateContractCost',
+		[args], tfchain.default_timeout)!
+}
+
 // Create a name contract. Provide the dns name via this call. Returns the id of the contract it 
 // creates. 
 pub fn (mut t TfChainClient) create_name_contract(name string) !u64 {
@@ -323,8 +581,66 @@ pub fn (mut t TfChainClient) swap_to_stellar(args SwapToStellar) ! {
 		tfchain.default_timeout)!
 }
 
-// Await till a transaction is processed on tfchain bridge that contains a specific memo
-pub fn (mut t TfChainClient) await_transaction_on_tfchain_bridge(memo string) ! {
-	_ := t.client.send_json_rpc[[]string, string]('tfchain.AwaitTransactionOnTfchainBridge', [memo],
+// Await till the deposit of the stellar transaction with hash tx_hash on the tfchain bridge is minted on the loaded account
+pub fn (mut t TfChainClient) await_transaction_on_tfchain_bridge(tx_hash string) ! {
+	_ := t.client.send_json_rpc[[]string, string]('tfchain.AwaitTransactionOnTfchainBridge', [tx_hash],
 		tfchain.default_timeout)!
-}
\ No newline at end of file
+}
+
+// List the dao proposals, optionally only the active or closed ones. Closed proposals are the ones whose voting ended
+// and the ones the council closed from from_block on.
+pub fn (mut t TfChainClient) list_proposals(args ListProposals) ![]Proposal {
+	return t.client.send_json_rpc[[]ListProposals, []Proposal]('tfchain.ListProposals', [args],
+		tfchain.default_timeout)!
+}
+
+// Get the dao proposal with the hex encoded hash
+pub fn (mut t TfChainClient) get_proposal(hash string) !Proposal {
+	return t.client.send_json_rpc[[]string, Proposal]('tfchain.GetProposal', [hash], tfchain.default_timeout)!
+}
+
+// Vote on a dao proposal with a farm of the loaded account
+pub fn (mut t TfChainClient) vote(args Vote) ! {
+	_ := t.client.send_json_rpc[[]Vote, string]('tfchain.Vote', [args], tfchain.default_timeout)!
+}
+
+// List the active dao proposals which farms of the loaded twin did not vote on yet
+pub fn (mut t TfChainClient) list_unvoted_proposals() ![]UnvotedProposal {
+	return t.client.send_json_rpc[[]string, []UnvotedProposal]('tfchain.ListUnvotedProposals', []string{},
+		tfchain.default_timeout)!
+}
+
+// Submit several calls in one extrinsic, either all of them succeed or none does. Returns the ids of the contracts
+// the batch created.
+pub fn (mut t TfChainClient) batch(calls []BatchCall) !BatchResult {
+	return t.client.send_json_rpc[[][]BatchCall, BatchResult]('tfchain.Batch', [calls], tfchain.default_timeout)!
+}
+
+// Estimate the transaction fee in units of 10^-7 TFT the loaded account pays for submitting calls. A single call is
+// estimated on its own, several calls as a batch.
+pub fn (mut t TfChainClient) estimate_fee(calls []BatchCall) !u64 {
+	return t.client.send_json_rpc[[][]BatchCall, u64]('tfchain.EstimateFee', [calls], tfchain.default_timeout)!
+}
+
+// Subscribe to the events matching a filter, which are delivered once their block is finalized. Returns the id of
+// the subscription, the events are buffered in the proxy till they are taken with get_subscription_events.
+pub fn (mut t TfChainClient) subscribe_events(args EventFilter) !string {
+	return t.client.send_json_rpc[[]EventFilter, string]('tfchain.SubscribeEvents', [args], tfchain.default_timeout)!
+}
+
+// Take the buffered events of a subscription, oldest first
+pub fn (mut t TfChainClient) get_subscription_events(args GetSubscriptionEvents) ![]Event {
+	return t.client.send_json_rpc[[]GetSubscriptionEvents, []Event]('tfchain.GetSubscriptionEvents',
+		[args], tfchain.default_timeout)!
+}
+
+// Get the ids of the active event subscriptions
+pub fn (mut t TfChainClient) get_subscription_ids() ![]string {
+	return t.client.send_json_rpc[[]string, []string]('tfchain.GetSubscriptionIds', []string{},
+		tfchain.default_timeout)!
+}
+
+// Close an event subscription, dropping its buffered events
+pub fn (mut t TfChainClient) close_subscription(id string) ! {
+	_ := t.client.send_json_rpc[[]string, string]('tfchain.CloseSubscription', [id], tfchain.default_timeout)!
+}
@@ -0,0 +1,41 @@
+module tfgrid
+
+struct EstimateMachinesCost {
+	machines MachinesModel
+}
+
+struct EstimateK8sCost {
+	k8s K8sCluster
+}
+
+pub struct DeploymentCost {
+pub:
+	usd_per_month f64
+	tft_per_month f64
+	contracts     []ContractCost // one contract for each node, workloads without a node each get their own
+}
+
+pub struct ContractCost {
+pub:
+	node_id        u32 // 0 for workloads which are not assigned a node yet
+	workloads      []string
+	usd_per_month  f64
+	tft_per_month  f64
+	discount_level string // discount the balance of the loaded account gives: none, default, bronze, silver or gold
+}
+
+// Estimates the monthly cost of deploying a machines model, before deploying it.
+pub fn (mut t TFGridClient) estimate_machines_cost(model MachinesModel) !DeploymentCost {
+	return t.client.send_json_rpc[[]EstimateMachinesCost, DeploymentCost]('tfgrid.EstimateCost',
+		[EstimateMachinesCost{
+		machines: model
+	}], t.timeout)!
+}
+
+// Estimates the monthly cost of deploying a kubernetes cluster, before deploying it.
+pub fn (mut t TFGridClient) estimate_k8s_cost(cluster K8sCluster) !DeploymentCost {
+	return t.client.send_json_rpc[[]EstimateK8sCost, DeploymentCost]('tfgrid.EstimateCost',
+		[EstimateK8sCost{
+		k8s: cluster
+	}], t.timeout)!
+}
@@ -20,11 +20,15 @@ pub fn (mut h Web3GWHandler) money_send(action Action) ! {
 
 	if bridge_to != '' {
 		if channel == 'ethereum' && bridge_to == 'stellar' {
+			cursor := h.clients.str_client.bridge_cursor('eth')!
 			hash_bridge_to_stellar := h.clients.eth_client.bridge_to_stellar(
 				amount: amount
 				destination: to
 			)!
-			h.clients.str_client.await_transaction_on_eth_bridge(hash_bridge_to_stellar)!
+			h.clients.str_client.await_transaction_on_eth_bridge(
+				memo: hash_bridge_to_stellar
+				cursor: cursor
+			)!
 			h.logger.info('bridge to stellar done')
 		} else if channel == 'stellar' && bridge_to == 'ethereum' {
 			res := h.clients.str_client.bridge_to_eth(
@@ -97,10 +101,10 @@ pub fn (mut h Web3GWHandler) money_swap(action Action) ! {
 	amount := action.params.get('amount')!
 
 	if from == 'eth' && to == 'tft' {
-		res := h.clients.eth_client.swap_eth_for_tft(amount)!
+		res := h.clients.eth_client.swap_eth_for_tft(amount: amount)!
 		h.logger.info(res)
 	} else if from == 'tft' && to == 'eth' {
-		res := h.clients.eth_client.swap_tft_for_eth(amount)!
+		res := h.clients.eth_client.swap_tft_for_eth(amount: amount)!
 		h.logger.info(res)
 	} else if from == 'tft' && to == 'xlm' {
 		res := h.clients.str_client.swap(
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"strconv"
 	"time"
@@ -108,17 +109,19 @@ const (
 )
 
 var (
-	// contract address on the sepolia teest network
-	contractAddress = common.HexToAddress("0x17f54245073bfed168a51c3d13b536e39e406063")
-	// contract address on the goerli network
-	// contractAddress = common.HexToAddress("0x8420c8271d602F6D0B190856Cea8E74D09A0d3cF")
 	// TFT asset on the stellar testnet
 	testnetTftAsset = mustStellarTestnetTftAsset()
-
-	// goerliChainID = big.NewInt(5)
-	sepoliaChainId = big.NewInt(11155111)
 )
 
+// swapContract returns the atomic swap contract and the chain id of the chain the eth client is loaded for
+func (d *Driver) swapContract() (common.Address, *big.Int, error) {
+	chain := d.eth.Chain
+	if chain.AtomicSwap == (common.Address{}) {
+		return common.Address{}, nil, fmt.Errorf("no atomic swap contract on chain %s", chain.Name)
+	}
+	return chain.AtomicSwap, new(big.Int).SetUint64(chain.ChainID), nil
+}
+
 func initDriver(nostr *nostr.Client, eth *goethclient.Client, stellar *stellargoclient.Client) *Driver {
 	return &Driver{
 		nostr:   nostr,
@@ -230,7 +233,12 @@ func (d *Driver) handleBuyAcceptMessage(ctx context.Context, sender string, req
 		return
 	}
 	cancel()
-	sct, err := eth.NewSwapContractTransactor(ctx, client, contractAddress, d.eth.Key, sepoliaChainId)
+	contractAddress, chainID, err := d.swapContract()
+	if err != nil {
+		log.Error().Err(err).Msg("Atomic swaps are not supported on the loaded chain")
+		return
+	}
+	sct, err := eth.NewSwapContractTransactor(ctx, client, contractAddress, d.eth.Key, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to construct swap contract transactor")
 		return
@@ -290,7 +298,12 @@ func (d *Driver) handleInitiateEthMessage(ctx context.Context, sender string, re
 		return
 	}
 	cancel()
-	sct, err := eth.NewSwapContractTransactor(ctx, client, contractAddress, d.eth.Key, sepoliaChainId)
+	contractAddress, chainID, err := d.swapContract()
+	if err != nil {
+		log.Error().Err(err).Msg("Atomic swaps are not supported on the loaded chain")
+		return
+	}
+	sct, err := eth.NewSwapContractTransactor(ctx, client, contractAddress, d.eth.Key, chainID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to construct swap contract transactor")
 		return
@@ -0,0 +1,58 @@
+package bridge
+
+import (
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	"github.com/stellar/go/amount"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+// TfchainDepositFee returns the TFT the tfchain bridge deducts from deposits coming from stellar
+func TfchainDepositFee(client *substrate.Substrate) (string, error) {
+	fee, err := client.GetDepositFee()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get deposit fee")
+	}
+	return amount.StringFromInt64(fee), nil
+}
+
+// TfchainWithdrawFee returns the TFT the tfchain bridge deducts from swaps to stellar
+func TfchainWithdrawFee(client *substrate.Substrate) (string, error) {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := types.CreateStorageKey(meta, "TFTBridgeModule", "WithdrawFee", nil, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create storage key")
+	}
+
+	var fee types.U64
+	ok, err := cl.RPC.State.GetStorageLatest(key, &fee)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get withdraw fee")
+	}
+	if !ok {
+		return "", errors.New("withdraw fee not found")
+	}
+
+	return amount.StringFromInt64(int64(fee)), nil
+}
+
+// Received returns the TFT arriving on the destination chain when the bridge deducts a fee from an amount
+func Received(sent, fee string) (string, error) {
+	sentUnits, err := amount.ParseInt64(sent)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid amount %s", sent)
+	}
+	feeUnits, err := amount.ParseInt64(fee)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid fee %s", fee)
+	}
+	if sentUnits <= feeUnits {
+		return "", errors.Errorf("amount %s does not cover the bridge fee of %s", sent, fee)
+	}
+
+	return amount.StringFromInt64(sentUnits - feeUnits), nil
+}
@@ -0,0 +1,51 @@
+package bridge
+
+import (
+	"fmt"
+
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
+)
+
+// TfchainNetwork returns the tfchain network bridged to a stellar network
+func TfchainNetwork(stellarNetwork string) (string, error) {
+	switch stellarNetwork {
+	case "public":
+		return "main", nil
+	case "testnet":
+		return "dev", nil
+	default:
+		return "", fmt.Errorf("no tfchain bridge on stellar network %s", stellarNetwork)
+	}
+}
+
+// StellarNetworkOfTfchain returns the stellar network a tfchain network is bridged to
+func StellarNetworkOfTfchain(tfchainNetwork string) (string, error) {
+	switch tfchainNetwork {
+	case "main":
+		return "public", nil
+	case "dev":
+		return "testnet", nil
+	default:
+		return "", fmt.Errorf("no stellar bridge on tfchain network %s", tfchainNetwork)
+	}
+}
+
+// EthChain returns the eth chain bridged to a stellar network
+func EthChain(stellarNetwork string) (goethclient.Chain, error) {
+	switch stellarNetwork {
+	case "public":
+		return goethclient.Chains["mainnet"], nil
+	case "testnet":
+		return goethclient.Chains["goerli"], nil
+	default:
+		return goethclient.Chain{}, fmt.Errorf("no eth bridge on stellar network %s", stellarNetwork)
+	}
+}
+
+// StellarNetworkOfEth returns the stellar network an eth chain is bridged to
+func StellarNetworkOfEth(chain goethclient.Chain) string {
+	if chain.ChainID == goethclient.EthGoerliId {
+		return "testnet"
+	}
+	return "public"
+}
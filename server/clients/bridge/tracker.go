@@ -0,0 +1,358 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrTransferNotFound is returned when looking up an unknown transfer
+	ErrTransferNotFound = errors.New("transfer not found")
+
+	// time a transfer may stay in a status before it is considered stalled
+	statusTimeouts = map[string]time.Duration{
+		StatusPending:      time.Hour,
+		StatusSubmitted:    time.Hour,
+		StatusSeenByBridge: 6 * time.Hour,
+	}
+	// delay before retrying a watcher which returned an error
+	retryDelay = 10 * time.Second
+)
+
+// Watcher follows a transfer on the chains involved. Watchers may update the scan positions of the transfer they are
+// given, which are saved with the next status.
+type Watcher interface {
+	// AwaitSeen waits till the bridge picked up the transfer, returning the reference stored in SeenTx
+	AwaitSeen(ctx context.Context, t *Transfer) (string, error)
+	// AwaitCompleted waits till the funds arrived on the destination chain, returning the reference stored in
+	// CompletedTx
+	AwaitCompleted(ctx context.Context, t *Transfer) (string, error)
+	// Close the connections of the watcher, it is called once the transfer is no longer followed
+	Close()
+}
+
+// rejectedError is returned by watchers when a transfer can no longer complete, so it is not retried
+type rejectedError struct {
+	reason string
+}
+
+func (e rejectedError) Error() string {
+	return e.reason
+}
+
+// rejected creates an error failing the transfer
+func rejected(format string, args ...interface{}) error {
+	return rejectedError{reason: fmt.Sprintf(format, args...)}
+}
+
+// Tracker keeps the transfers and drives them to a final status in the background. Transfers are persisted in a
+// directory so they can be resumed after a restart.
+type Tracker struct {
+	// dir the transfers are persisted in, they are kept in memory if empty
+	dir string
+
+	lock      sync.RWMutex
+	transfers map[string]*Transfer
+	// ids of the transfers which are being followed
+	following map[string]bool
+}
+
+// NewTracker creates a tracker persisting transfers in dir, loading the transfers which are already there
+func NewTracker(dir string) (*Tracker, error) {
+	tr := &Tracker{transfers: map[string]*Transfer{}, following: map[string]bool{}}
+	if dir == "" {
+		return tr, nil
+	}
+	tr.dir = filepath.Join(dir, "bridge")
+
+	entries, err := os.ReadDir(tr.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return tr, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read bridge transfers in %s", tr.dir)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(tr.dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read bridge transfer %s", path)
+		}
+		var t Transfer
+		if err := json.Unmarshal(content, &t); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode bridge transfer %s", path)
+		}
+		tr.transfers[t.ID] = &t
+	}
+
+	return tr, nil
+}
+
+// Create saves a transfer which is about to be sent to the bridge as pending, so it is known before the funds move
+func (tr *Tracker) Create(t Transfer) (Transfer, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return Transfer{}, errors.Wrap(err, "failed to generate transfer id")
+	}
+	now := time.Now()
+	t.ID = id.String()
+	t.Status = StatusPending
+	t.CreatedAt = now
+	t.UpdatedAt = now
+
+	if err := tr.save(t); err != nil {
+		return Transfer{}, err
+	}
+	return t, nil
+}
+
+// Start following a created transfer once its funds were sent to the bridge, till it is done. The funds moved, so
+// failing to save the transfer is only logged.
+func (tr *Tracker) Start(ctx context.Context, t Transfer, w Watcher) Transfer {
+	t.Status = StatusSubmitted
+	t.UpdatedAt = time.Now()
+	if err := tr.save(t); err != nil {
+		log.Error().Err(err).Msgf("failed to save bridge transfer %s", t.ID)
+	}
+
+	tr.claim(t.ID)
+	go tr.follow(ctx, t, w)
+
+	return t
+}
+
+// Stall saves a transfer which was sent to the bridge but can not be followed now, so it can be resumed later
+func (tr *Tracker) Stall(t Transfer, reason error) Transfer {
+	t.Status = StatusSubmitted
+	t.Stalled = true
+	t.Error = reason.Error()
+	t.UpdatedAt = time.Now()
+	if err := tr.save(t); err != nil {
+		log.Error().Err(err).Msgf("failed to save bridge transfer %s", t.ID)
+	}
+	return t
+}
+
+// Fail marks a created transfer as failed because its funds could not be sent to the bridge
+func (tr *Tracker) Fail(t Transfer, reason error) {
+	if err := t.fail(reason.Error(), time.Now()); err != nil {
+		log.Error().Err(err).Msgf("failed to update bridge transfer %s", t.ID)
+		return
+	}
+	if err := tr.save(t); err != nil {
+		log.Error().Err(err).Msgf("failed to save bridge transfer %s", t.ID)
+	}
+}
+
+// Resume following the transfers which are not done yet, creating their watchers with watcherFor. Stalled transfers
+// are resumed as well. Pending transfers were interrupted while their funds were sent, so they are marked stalled
+// instead: they are only followed again with ResumeTransfer.
+func (tr *Tracker) Resume(ctx context.Context, watcherFor func(Transfer) (Watcher, error)) {
+	for _, t := range tr.List() {
+		if t.Done() {
+			continue
+		}
+		if t.Status == StatusPending {
+			if !t.Stalled {
+				t.Stalled = true
+				t.Error = "interrupted while sending the funds to the bridge, they may not have been sent"
+				if err := tr.save(t); err != nil {
+					log.Error().Err(err).Msgf("failed to save bridge transfer %s", t.ID)
+				}
+			}
+			continue
+		}
+		if _, err := tr.ResumeTransfer(ctx, t.ID, watcherFor); err != nil {
+			log.Error().Err(err).Msgf("failed to resume bridge transfer %s", t.ID)
+		}
+	}
+}
+
+// ResumeTransfer follows a transfer again which is not done and no longer followed, like a stalled transfer, creating
+// its watcher with watcherFor
+func (tr *Tracker) ResumeTransfer(ctx context.Context, id string, watcherFor func(Transfer) (Watcher, error)) (Transfer, error) {
+	t, err := tr.Get(id)
+	if err != nil {
+		return Transfer{}, err
+	}
+	if t.Done() {
+		return Transfer{}, fmt.Errorf("transfer %s is already %s", t.ID, t.Status)
+	}
+	if !tr.claim(t.ID) {
+		return Transfer{}, fmt.Errorf("transfer %s is already followed", t.ID)
+	}
+
+	w, err := watcherFor(t)
+	if err != nil {
+		tr.release(t.ID)
+		return Transfer{}, err
+	}
+
+	t.Stalled, t.Error = false, ""
+	if err := tr.save(t); err != nil {
+		log.Error().Err(err).Msgf("failed to save bridge transfer %s", t.ID)
+	}
+
+	log.Debug().Msgf("resuming bridge transfer %s, which is %s", t.ID, t.Status)
+	go tr.follow(ctx, t, w)
+
+	return t, nil
+}
+
+// claim marks a transfer as followed, returning false if it is followed already
+func (tr *Tracker) claim(id string) bool {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+
+	if tr.following[id] {
+		return false
+	}
+	tr.following[id] = true
+	return true
+}
+
+// release marks a transfer as no longer followed
+func (tr *Tracker) release(id string) {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+
+	delete(tr.following, id)
+}
+
+// Get a transfer by id
+func (tr *Tracker) Get(id string) (Transfer, error) {
+	tr.lock.RLock()
+	defer tr.lock.RUnlock()
+
+	t, ok := tr.transfers[id]
+	if !ok {
+		return Transfer{}, errors.Wrap(ErrTransferNotFound, id)
+	}
+	return *t, nil
+}
+
+// List the transfers, most recent first
+func (tr *Tracker) List() []Transfer {
+	tr.lock.RLock()
+	defer tr.lock.RUnlock()
+
+	transfers := make([]Transfer, 0, len(tr.transfers))
+	for _, t := range tr.transfers {
+		transfers = append(transfers, *t)
+	}
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].CreatedAt.After(transfers[j].CreatedAt)
+	})
+	return transfers
+}
+
+// follow drives a transfer to a final status. It returns without changing the transfer if ctx is cancelled, so it
+// can be resumed later. A transfer which does not progress in time is marked stalled and no longer followed, it keeps
+// its status so it can still complete once it is resumed. The watcher is closed when follow returns.
+func (tr *Tracker) follow(ctx context.Context, t Transfer, w Watcher) {
+	defer tr.release(t.ID)
+	defer w.Close()
+
+	// a resumed transfer gets the full timeout of its status again
+	started := time.Now()
+	for !t.Done() {
+		since := t.UpdatedAt
+		if since.Before(started) {
+			since = started
+		}
+		deadline := since.Add(statusTimeouts[t.Status])
+		awaitCtx, cancel := context.WithDeadline(ctx, deadline)
+		next, ref, err := await(awaitCtx, w, &t)
+		cancel()
+
+		now := time.Now()
+		var rejection rejectedError
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.As(err, &rejection):
+			err = t.fail(rejection.reason, now)
+		case err != nil && !now.Before(deadline):
+			t.Stalled = true
+			t.Error = fmt.Sprintf("bridge did not progress after %s: %s", t.Status, err)
+			if err := tr.save(t); err != nil {
+				log.Error().Err(err).Msgf("failed to save bridge transfer %s", t.ID)
+			}
+			return
+		case err != nil:
+			log.Debug().Err(err).Msgf("failed to follow bridge transfer %s", t.ID)
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+			}
+			continue
+		default:
+			err = t.advance(next, ref, now)
+		}
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to update bridge transfer %s", t.ID)
+			return
+		}
+
+		if err := tr.save(t); err != nil {
+			log.Error().Err(err).Msgf("failed to save bridge transfer %s", t.ID)
+		}
+	}
+}
+
+// await the next status of a transfer
+func await(ctx context.Context, w Watcher, t *Transfer) (string, string, error) {
+	if t.Status == StatusPending || t.Status == StatusSubmitted {
+		ref, err := w.AwaitSeen(ctx, t)
+		return StatusSeenByBridge, ref, err
+	}
+	ref, err := w.AwaitCompleted(ctx, t)
+	return StatusCompleted, ref, err
+}
+
+// save a transfer, writing it to its file if transfers are persisted
+func (tr *Tracker) save(t Transfer) error {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+
+	tr.transfers[t.ID] = &t
+	if tr.dir == "" {
+		return nil
+	}
+
+	content, err := json.Marshal(t)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode bridge transfer")
+	}
+
+	if err := os.MkdirAll(tr.dir, 0o700); err != nil {
+		return errors.Wrap(err, "failed to create bridge transfer directory")
+	}
+
+	// write to a temporary file first so an interrupted write does not corrupt the transfer
+	path := filepath.Join(tr.dir, t.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write bridge transfer %s", tmp)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrapf(err, "failed to write bridge transfer %s", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWatcher returns the results queued for each status
+type fakeWatcher struct {
+	seen      []error
+	completed []error
+	closed    atomic.Bool
+}
+
+func (w *fakeWatcher) AwaitSeen(ctx context.Context, t *Transfer) (string, error) {
+	t.TfchainBlock = 42
+	return next(ctx, &w.seen, "seen")
+}
+
+func (w *fakeWatcher) AwaitCompleted(ctx context.Context, t *Transfer) (string, error) {
+	return next(ctx, &w.completed, "completed")
+}
+
+func (w *fakeWatcher) Close() {
+	w.closed.Store(true)
+}
+
+func next(ctx context.Context, results *[]error, ref string) (string, error) {
+	if len(*results) == 0 {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	err := (*results)[0]
+	*results = (*results)[1:]
+	if err != nil {
+		return "", err
+	}
+	return ref, nil
+}
+
+func awaitDone(t *testing.T, tr *Tracker, id string) Transfer {
+	var transfer Transfer
+	require.Eventually(t, func() bool {
+		var err error
+		transfer, err = tr.Get(id)
+		require.NoError(t, err)
+		return transfer.Done()
+	}, time.Second, time.Millisecond)
+	return transfer
+}
+
+// start creates a transfer and starts following it as if its funds were sent
+func start(t *testing.T, ctx context.Context, tr *Tracker, transfer Transfer, w Watcher) Transfer {
+	created, err := tr.Create(transfer)
+	require.NoError(t, err)
+	return tr.Start(ctx, created, w)
+}
+
+func TestTrackerFollow(t *testing.T) {
+	delay := retryDelay
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = delay }()
+
+	t.Run("completed", func(t *testing.T) {
+		tr, err := NewTracker(t.TempDir())
+		require.NoError(t, err)
+
+		w := &fakeWatcher{seen: []error{errors.New("connection reset"), nil}, completed: []error{nil}}
+		started := start(t, context.Background(), tr, Transfer{FromChain: ChainTfchain, ToChain: ChainStellar}, w)
+		assert.NotEmpty(t, started.ID)
+		assert.Equal(t, StatusSubmitted, started.Status)
+
+		transfer := awaitDone(t, tr, started.ID)
+		assert.Equal(t, StatusCompleted, transfer.Status)
+		assert.Equal(t, "seen", transfer.SeenTx)
+		assert.Equal(t, "completed", transfer.CompletedTx)
+		assert.Equal(t, uint32(42), transfer.TfchainBlock)
+		assert.Eventually(t, w.closed.Load, time.Second, time.Millisecond)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		tr, err := NewTracker("")
+		require.NoError(t, err)
+
+		w := &fakeWatcher{seen: []error{nil}, completed: []error{rejected("mint of %s expired", "abc")}}
+		started := start(t, context.Background(), tr, Transfer{}, w)
+
+		transfer := awaitDone(t, tr, started.ID)
+		assert.Equal(t, StatusFailed, transfer.Status)
+		assert.Equal(t, "mint of abc expired", transfer.Error)
+		assert.Equal(t, "seen", transfer.SeenTx)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		timeout := statusTimeouts[StatusSubmitted]
+		statusTimeouts[StatusSubmitted] = 10 * time.Millisecond
+		defer func() { statusTimeouts[StatusSubmitted] = timeout }()
+
+		tr, err := NewTracker("")
+		require.NoError(t, err)
+
+		w := &fakeWatcher{}
+		started := start(t, context.Background(), tr, Transfer{}, w)
+
+		var transfer Transfer
+		require.Eventually(t, func() bool {
+			transfer, err = tr.Get(started.ID)
+			require.NoError(t, err)
+			return transfer.Stalled
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, StatusSubmitted, transfer.Status)
+		assert.Contains(t, transfer.Error, "bridge did not progress after submitted")
+		assert.Eventually(t, w.closed.Load, time.Second, time.Millisecond)
+
+		// a stalled transfer can still complete once it is resumed
+		resumed, err := tr.ResumeTransfer(context.Background(), started.ID, func(Transfer) (Watcher, error) {
+			return &fakeWatcher{seen: []error{nil}, completed: []error{nil}}, nil
+		})
+		require.NoError(t, err)
+		assert.False(t, resumed.Stalled)
+		assert.Empty(t, resumed.Error)
+
+		transfer = awaitDone(t, tr, started.ID)
+		assert.Equal(t, StatusCompleted, transfer.Status)
+		assert.False(t, transfer.Stalled)
+	})
+}
+
+func TestTrackerCreate(t *testing.T) {
+	t.Run("failed", func(t *testing.T) {
+		tr, err := NewTracker("")
+		require.NoError(t, err)
+
+		created, err := tr.Create(Transfer{Amount: "10"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, created.ID)
+		assert.Equal(t, StatusPending, created.Status)
+
+		tr.Fail(created, errors.New("insufficient balance"))
+		transfer, err := tr.Get(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusFailed, transfer.Status)
+		assert.Equal(t, "insufficient balance", transfer.Error)
+	})
+
+	t.Run("stalled", func(t *testing.T) {
+		tr, err := NewTracker("")
+		require.NoError(t, err)
+
+		created, err := tr.Create(Transfer{})
+		require.NoError(t, err)
+		created.SubmitTx = "abc"
+
+		stalled := tr.Stall(created, errors.New("connection refused"))
+		assert.Equal(t, StatusSubmitted, stalled.Status)
+		assert.True(t, stalled.Stalled)
+		assert.Equal(t, "abc", stalled.SubmitTx)
+
+		_, err = tr.ResumeTransfer(context.Background(), created.ID, func(Transfer) (Watcher, error) {
+			return &fakeWatcher{seen: []error{nil}, completed: []error{nil}}, nil
+		})
+		require.NoError(t, err)
+		transfer := awaitDone(t, tr, created.ID)
+		assert.Equal(t, StatusCompleted, transfer.Status)
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		dir := t.TempDir()
+		tr, err := NewTracker(dir)
+		require.NoError(t, err)
+
+		created, err := tr.Create(Transfer{})
+		require.NoError(t, err)
+
+		resumed, err := NewTracker(dir)
+		require.NoError(t, err)
+		resumed.Resume(context.Background(), func(Transfer) (Watcher, error) {
+			t.Fatal("a pending transfer is not followed after a restart")
+			return nil, nil
+		})
+
+		transfer, err := resumed.Get(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusPending, transfer.Status)
+		assert.True(t, transfer.Stalled)
+	})
+}
+
+func TestTrackerResumeTransferFollowed(t *testing.T) {
+	tr, err := NewTracker("")
+	require.NoError(t, err)
+
+	started := start(t, context.Background(), tr, Transfer{}, &fakeWatcher{})
+
+	_, err = tr.ResumeTransfer(context.Background(), started.ID, func(Transfer) (Watcher, error) {
+		t.Fatal("no watcher is created for a followed transfer")
+		return nil, nil
+	})
+	assert.Error(t, err)
+}
+
+func TestTrackerResume(t *testing.T) {
+	dir := t.TempDir()
+	tr, err := NewTracker(dir)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &fakeWatcher{seen: []error{nil}}
+	started := start(t, ctx, tr, Transfer{FromChain: ChainStellar, ToChain: ChainEthereum, Amount: "10"}, w)
+
+	require.Eventually(t, func() bool {
+		transfer, err := tr.Get(started.ID)
+		require.NoError(t, err)
+		return transfer.Status == StatusSeenByBridge
+	}, time.Second, time.Millisecond)
+	// stopping the proxy leaves the transfer as it is
+	cancel()
+
+	resumed, err := NewTracker(dir)
+	require.NoError(t, err)
+	transfers := resumed.List()
+	require.Len(t, transfers, 1)
+	assert.Equal(t, StatusSeenByBridge, transfers[0].Status)
+	assert.Equal(t, "10", transfers[0].Amount)
+
+	resumed.Resume(context.Background(), func(transfer Transfer) (Watcher, error) {
+		assert.Equal(t, started.ID, transfer.ID)
+		return &fakeWatcher{completed: []error{nil}}, nil
+	})
+	transfer := awaitDone(t, resumed, started.ID)
+	assert.Equal(t, StatusCompleted, transfer.Status)
+
+	_, err = resumed.Get("unknown")
+	assert.True(t, errors.Is(err, ErrTransferNotFound))
+}
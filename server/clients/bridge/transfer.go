@@ -0,0 +1,152 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Chains connected by the bridges
+const (
+	ChainStellar  = "stellar"
+	ChainEthereum = "ethereum"
+	ChainTfchain  = "tfchain"
+)
+
+// Status of a transfer. A transfer only moves forward, from pending to completed, or to failed.
+const (
+	// StatusPending means the funds are being sent to the bridge
+	StatusPending = "pending"
+	// StatusSubmitted means the funds were sent to the bridge
+	StatusSubmitted = "submitted"
+	// StatusSeenByBridge means the bridge picked up the transfer
+	StatusSeenByBridge = "seen_by_bridge"
+	// StatusCompleted means the funds were minted or paid out on the destination chain
+	StatusCompleted = "completed"
+	// StatusFailed means the transfer was rejected
+	StatusFailed = "failed"
+)
+
+var (
+	// ErrUnsupportedDirection is returned for a pair of chains which is not connected by a bridge
+	ErrUnsupportedDirection = errors.New("no bridge between these chains")
+
+	// order of the statuses a transfer moves through
+	statusOrder = map[string]int{
+		StatusPending:      0,
+		StatusSubmitted:    1,
+		StatusSeenByBridge: 2,
+		StatusCompleted:    3,
+	}
+)
+
+// Transfer of TFT from one chain to another through a bridge. Transfers are persisted so they can be followed after
+// a restart, so they hold everything needed to reconnect to the chains involved.
+type Transfer struct {
+	ID        string `json:"id"`
+	FromChain string `json:"from_chain"`
+	ToChain   string `json:"to_chain"`
+	// Amount of TFT sent to the bridge, the bridge fee is deducted from it
+	Amount      string `json:"amount"`
+	Destination string `json:"destination"`
+	// Source is the address the funds were sent from
+	Source string `json:"source"`
+	Status string `json:"status"`
+	// Error explains why the transfer failed, or why it stalled
+	Error string `json:"error,omitempty"`
+	// Stalled is set when the bridge did not progress in time. The transfer keeps its status and is no longer
+	// followed till it is resumed.
+	Stalled bool `json:"stalled,omitempty"`
+
+	// SubmitTx is the hash of the transaction sending the funds to the bridge, empty on tfchain which does not
+	// return it
+	SubmitTx string `json:"submit_tx,omitempty"`
+	// SeenTx identifies how the bridge picked up the transfer: a transaction hash or a tfchain burn id
+	SeenTx string `json:"seen_tx,omitempty"`
+	// CompletedTx is the hash of the transaction paying out the funds, or the tfchain block the mint completed in
+	CompletedTx string `json:"completed_tx,omitempty"`
+
+	// PayoutAmount is the TFT paid out on stellar, known once tfchain created the burn of a swap to stellar
+	PayoutAmount string `json:"payout_amount,omitempty"`
+	// Memo of the stellar deposit, if the transfer starts on stellar
+	Memo string `json:"memo,omitempty"`
+	// StellarNetwork is either public or testnet
+	StellarNetwork string `json:"stellar_network"`
+	// StellarCursor points at the payments of the bridge account before the transfer was submitted
+	StellarCursor  string `json:"stellar_cursor,omitempty"`
+	TfchainNetwork string `json:"tfchain_network,omitempty"`
+	// TfchainBlock is the first tfchain block the bridge events of the transfer can be in
+	TfchainBlock uint32 `json:"tfchain_block,omitempty"`
+	EthUrl       string `json:"eth_url,omitempty"`
+	// EthToken is the TFT contract minting and burning the bridged TFT
+	EthToken string `json:"eth_token,omitempty"`
+	// EthBlock is the first eth block the bridge events of the transfer can be in
+	EthBlock uint64 `json:"eth_block,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ParseChain normalizes the name of a chain
+func ParseChain(chain string) (string, error) {
+	switch strings.ToLower(chain) {
+	case ChainStellar:
+		return ChainStellar, nil
+	case ChainEthereum, "eth":
+		return ChainEthereum, nil
+	case ChainTfchain:
+		return ChainTfchain, nil
+	default:
+		return "", fmt.Errorf("unknown chain %s", chain)
+	}
+}
+
+// CheckDirection verifies a bridge exists between two chains. All bridges go through stellar.
+func CheckDirection(from, to string) error {
+	if from == to || (from != ChainStellar && to != ChainStellar) {
+		return errors.Wrapf(ErrUnsupportedDirection, "%s to %s", from, to)
+	}
+	return nil
+}
+
+// Done checks if the transfer reached a final status
+func (t *Transfer) Done() bool {
+	return t.Status == StatusCompleted || t.Status == StatusFailed
+}
+
+// advance moves the transfer forward to a status, recording the transaction which caused it
+func (t *Transfer) advance(status, tx string, now time.Time) error {
+	if t.Done() {
+		return fmt.Errorf("transfer %s is already %s", t.ID, t.Status)
+	}
+	next, ok := statusOrder[status]
+	if !ok || next <= statusOrder[t.Status] {
+		return fmt.Errorf("transfer %s can not move from %s to %s", t.ID, t.Status, status)
+	}
+
+	switch status {
+	case StatusSeenByBridge:
+		t.SeenTx = tx
+	case StatusCompleted:
+		t.CompletedTx = tx
+	}
+	t.Status = status
+	t.UpdatedAt = now
+
+	return nil
+}
+
+// fail marks the transfer as failed, unless it is already done
+func (t *Transfer) fail(reason string, now time.Time) error {
+	if t.Done() {
+		return fmt.Errorf("transfer %s is already %s", t.ID, t.Status)
+	}
+
+	t.Status = StatusFailed
+	t.Error = reason
+	t.UpdatedAt = now
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDirection(t *testing.T) {
+	assert.NoError(t, CheckDirection(ChainStellar, ChainEthereum))
+	assert.NoError(t, CheckDirection(ChainTfchain, ChainStellar))
+	assert.True(t, errors.Is(CheckDirection(ChainEthereum, ChainTfchain), ErrUnsupportedDirection))
+	assert.True(t, errors.Is(CheckDirection(ChainStellar, ChainStellar), ErrUnsupportedDirection))
+}
+
+func TestParseChain(t *testing.T) {
+	chain, err := ParseChain("ETH")
+	require.NoError(t, err)
+	assert.Equal(t, ChainEthereum, chain)
+
+	_, err = ParseChain("bsc")
+	assert.Error(t, err)
+}
+
+func TestTransferAdvance(t *testing.T) {
+	now := time.Now()
+
+	t.Run("forward", func(t *testing.T) {
+		tr := Transfer{ID: "1", Status: StatusSubmitted}
+		require.NoError(t, tr.advance(StatusSeenByBridge, "seen", now))
+		require.NoError(t, tr.advance(StatusCompleted, "done", now))
+		assert.Equal(t, StatusCompleted, tr.Status)
+		assert.Equal(t, "seen", tr.SeenTx)
+		assert.Equal(t, "done", tr.CompletedTx)
+		assert.True(t, tr.Done())
+	})
+
+	t.Run("skip_seen", func(t *testing.T) {
+		tr := Transfer{ID: "1", Status: StatusSubmitted}
+		require.NoError(t, tr.advance(StatusCompleted, "done", now))
+		assert.Empty(t, tr.SeenTx)
+	})
+
+	t.Run("backwards", func(t *testing.T) {
+		tr := Transfer{ID: "1", Status: StatusSeenByBridge}
+		assert.Error(t, tr.advance(StatusSubmitted, "", now))
+		assert.Error(t, tr.advance(StatusSeenByBridge, "", now))
+		assert.Error(t, tr.advance(StatusFailed, "", now))
+	})
+
+	t.Run("done", func(t *testing.T) {
+		tr := Transfer{ID: "1", Status: StatusCompleted}
+		assert.Error(t, tr.fail("too late", now))
+
+		tr = Transfer{ID: "1", Status: StatusSeenByBridge}
+		require.NoError(t, tr.fail("expired", now))
+		assert.Equal(t, StatusFailed, tr.Status)
+		assert.Equal(t, "expired", tr.Error)
+		assert.Error(t, tr.advance(StatusCompleted, "", now))
+	})
+}
+
+func TestReceived(t *testing.T) {
+	received, err := Received("10", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "9.0000000", received)
+
+	_, err = Received("1", "1")
+	assert.Error(t, err)
+}
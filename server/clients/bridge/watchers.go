@@ -0,0 +1,259 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/pkg/errors"
+	"github.com/stellar/go/amount"
+	tft "github.com/threefoldfoundation/tft/bridge/stellar/contracts/tokenv1"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	stellargoclient "github.com/threefoldtech/web3_proxy/server/clients/stellar"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+)
+
+const (
+	ethPollInterval = 15 * time.Second
+	// timeout of stellar awaits if the context has no deadline
+	defaultStellarTimeout = time.Hour
+)
+
+// Clients used by the watchers. Only the clients of the chains involved in a transfer have to be set. The watcher
+// owns the clients and closes them when the transfer is no longer followed, so they must not be shared with a
+// connection.
+type Clients struct {
+	Stellar *stellargoclient.Client
+	Eth     *ethclient.Client
+	Tfchain *substrate.Substrate
+}
+
+// Close the eth and tfchain clients, stellar clients do not keep a connection open
+func (c Clients) Close() {
+	if c.Eth != nil {
+		c.Eth.Close()
+	}
+	if c.Tfchain != nil {
+		c.Tfchain.Close()
+	}
+}
+
+// NewWatcher creates the watcher following a transfer in the direction of the transfer
+func NewWatcher(t Transfer, clients Clients) (Watcher, error) {
+	if err := CheckDirection(t.FromChain, t.ToChain); err != nil {
+		return nil, err
+	}
+	if clients.Stellar == nil {
+		return nil, errors.New("a stellar client is required")
+	}
+	if (t.FromChain == ChainEthereum || t.ToChain == ChainEthereum) && clients.Eth == nil {
+		return nil, errors.New("an eth client is required")
+	}
+	if (t.FromChain == ChainTfchain || t.ToChain == ChainTfchain) && clients.Tfchain == nil {
+		return nil, errors.New("a tfchain client is required")
+	}
+
+	switch {
+	case t.ToChain == ChainEthereum:
+		return stellarToEth(clients), nil
+	case t.ToChain == ChainTfchain:
+		return stellarToTfchain(clients), nil
+	case t.FromChain == ChainEthereum:
+		return ethToStellar(clients), nil
+	default:
+		return tfchainToStellar(clients), nil
+	}
+}
+
+type (
+	stellarToEth     Clients
+	stellarToTfchain Clients
+	ethToStellar     Clients
+	tfchainToStellar Clients
+)
+
+// Close implements Watcher
+func (w stellarToEth) Close() { Clients(w).Close() }
+
+// Close implements Watcher
+func (w stellarToTfchain) Close() { Clients(w).Close() }
+
+// Close implements Watcher
+func (w ethToStellar) Close() { Clients(w).Close() }
+
+// Close implements Watcher
+func (w tfchainToStellar) Close() { Clients(w).Close() }
+
+// AwaitSeen waits till the deposit shows up in the payments of the bridge account
+func (w stellarToEth) AwaitSeen(ctx context.Context, t *Transfer) (string, error) {
+	return awaitStellarDeposit(ctx, w.Stellar, ChainEthereum, t)
+}
+
+// AwaitCompleted waits till the bridge minted the deposit on eth
+func (w stellarToEth) AwaitCompleted(ctx context.Context, t *Transfer) (string, error) {
+	filterer, err := tft.NewTokenFilterer(common.HexToAddress(t.EthToken), w.Eth)
+	if err != nil {
+		return "", err
+	}
+	receiver := []common.Address{common.HexToAddress(t.Destination)}
+
+	for {
+		head, err := w.Eth.BlockNumber(ctx)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get current height")
+		}
+		if head >= t.EthBlock {
+			mints, err := filterer.FilterMint(&bind.FilterOpts{Start: t.EthBlock, End: &head, Context: ctx}, receiver, []string{t.SubmitTx})
+			if err != nil {
+				return "", errors.Wrap(err, "failed to filter mints")
+			}
+			found := mints.Next()
+			mints.Close()
+			if found {
+				return mints.Event.Raw.TxHash.Hex(), nil
+			}
+			t.EthBlock = head + 1
+		}
+
+		select {
+		case <-time.After(ethPollInterval):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// AwaitSeen waits till the bridge validators proposed to mint the deposit, returning the tfchain block of the
+// proposal
+func (w stellarToTfchain) AwaitSeen(ctx context.Context, t *Transfer) (string, error) {
+	if _, err := awaitStellarDeposit(ctx, w.Stellar, ChainTfchain, t); err != nil {
+		return "", err
+	}
+
+	return scanTfchain(ctx, w.Tfchain, t, tfchainclient.MintProposed(t.SubmitTx))
+}
+
+// AwaitCompleted waits till the deposit is minted on the account of the destination twin, returning the tfchain
+// block of the mint
+func (w stellarToTfchain) AwaitCompleted(ctx context.Context, t *Transfer) (string, error) {
+	twinID, err := strconv.ParseUint(t.Destination, 10, 32)
+	if err != nil {
+		return "", rejected("invalid twin id %s", t.Destination)
+	}
+	twin, err := w.Tfchain.GetTwin(uint32(twinID))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get twin %d", twinID)
+	}
+	ref, err := scanTfchain(ctx, w.Tfchain, t, tfchainclient.MintCompleted(twin.Account, t.SubmitTx))
+	if errors.Is(err, tfchainclient.ErrMintExpired) {
+		return "", rejected("mint of %s expired", t.SubmitTx)
+	}
+	return ref, err
+}
+
+// AwaitSeen waits till the withdrawal is mined
+func (w ethToStellar) AwaitSeen(ctx context.Context, t *Transfer) (string, error) {
+	hash := common.HexToHash(t.SubmitTx)
+	for {
+		receipt, err := w.Eth.TransactionReceipt(ctx, hash)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+		case err != nil:
+			return "", errors.Wrapf(err, "failed to load receipt of transaction %s", t.SubmitTx)
+		case receipt.Status != types.ReceiptStatusSuccessful:
+			return "", rejected("withdraw transaction %s failed", t.SubmitTx)
+		default:
+			return t.SubmitTx, nil
+		}
+
+		select {
+		case <-time.After(ethPollInterval):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// AwaitCompleted waits till the eth bridge paid out the withdrawal on stellar. The payout has the hash of the
+// withdraw transaction as memo.
+func (w ethToStellar) AwaitCompleted(ctx context.Context, t *Transfer) (string, error) {
+	filter := stellargoclient.PaymentFilter{Destination: t.Destination, Memo: t.SubmitTx}
+	return awaitStellarPayout(ctx, w.Stellar, ChainEthereum, t, filter)
+}
+
+// AwaitSeen waits till tfchain created the burn transaction of the swap, returning its id
+func (w tfchainToStellar) AwaitSeen(ctx context.Context, t *Transfer) (string, error) {
+	source, err := substrate.FromAddress(t.Source)
+	if err != nil {
+		return "", rejected("invalid source %s", t.Source)
+	}
+
+	burnID := ""
+	_, err = scanTfchain(ctx, w.Tfchain, t, tfchainclient.BurnCreated(source, t.Destination, func(id, paid uint64) {
+		burnID = fmt.Sprint(id)
+		t.PayoutAmount = amount.StringFromInt64(int64(paid))
+	}))
+	return burnID, err
+}
+
+// AwaitCompleted waits till the tfchain bridge paid out the burn on stellar. The payout has the burn id, which is
+// in SeenTx, as text memo.
+func (w tfchainToStellar) AwaitCompleted(ctx context.Context, t *Transfer) (string, error) {
+	filter := stellargoclient.PaymentFilter{Destination: t.Destination, Amount: t.PayoutAmount, Memo: t.SeenTx}
+	return awaitStellarPayout(ctx, w.Stellar, ChainTfchain, t, filter)
+}
+
+// awaitStellarDeposit waits till the deposit of a transfer is in the payments of a bridge account
+func awaitStellarDeposit(ctx context.Context, client *stellargoclient.Client, bridge string, t *Transfer) (string, error) {
+	bridgeAddress, err := client.GetBridgeAddress(StellarBridge(bridge))
+	if err != nil {
+		return "", rejected("%s", err)
+	}
+
+	filter := stellargoclient.PaymentFilter{Memo: t.Memo, Destination: bridgeAddress, Amount: t.Amount}
+	return client.AwaitPayment(ctx, bridgeAddress, t.StellarCursor, filter, stellarTimeout(ctx))
+}
+
+// awaitStellarPayout waits till a bridge account makes the payout of a transfer matching filter
+func awaitStellarPayout(ctx context.Context, client *stellargoclient.Client, bridge string, t *Transfer, filter stellargoclient.PaymentFilter) (string, error) {
+	bridgeAddress, err := client.GetBridgeAddress(StellarBridge(bridge))
+	if err != nil {
+		return "", rejected("%s", err)
+	}
+
+	return client.AwaitPayment(ctx, bridgeAddress, t.StellarCursor, filter, stellarTimeout(ctx))
+}
+
+// StellarBridge returns the name the stellar client uses for the bridge to a chain
+func StellarBridge(chain string) string {
+	if chain == ChainEthereum {
+		return "eth"
+	}
+	return chain
+}
+
+// stellarTimeout returns the time left till the deadline of ctx
+func stellarTimeout(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return defaultStellarTimeout
+	}
+	return time.Until(deadline)
+}
+
+// scanTfchain awaits the first block matching from the TfchainBlock of the transfer on. The number of the matching
+// block is returned, and left in TfchainBlock so later scans start from there.
+func scanTfchain(ctx context.Context, client *substrate.Substrate, t *Transfer, match tfchainclient.Matcher) (string, error) {
+	block, err := tfchainclient.ScannerFor(client).Await(ctx, t.TfchainBlock, match)
+	if err != nil {
+		return "", err
+	}
+	t.TfchainBlock = block
+	return fmt.Sprint(block), nil
+}
@@ -0,0 +1,59 @@
+package btc
+
+import "strings"
+
+// characters of a descriptor and of its checksum, see BIP-380
+const (
+	descriptorCharset = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	checksumCharset   = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+var descriptorGenerator = [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+func descriptorPolymod(c uint64, value int) uint64 {
+	top := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ uint64(value)
+	for i, g := range descriptorGenerator {
+		if (top>>i)&1 == 1 {
+			c ^= g
+		}
+	}
+	return c
+}
+
+// DescriptorChecksum returns the checksum of a descriptor, empty if it contains invalid characters
+func DescriptorChecksum(descriptor string) string {
+	c := uint64(1)
+	cls, clsCount := 0, 0
+	for _, ch := range descriptor {
+		pos := strings.IndexRune(descriptorCharset, ch)
+		if pos < 0 {
+			return ""
+		}
+		c = descriptorPolymod(c, pos&31)
+		cls = cls*3 + pos>>5
+		clsCount++
+		if clsCount == 3 {
+			c = descriptorPolymod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = descriptorPolymod(c, cls)
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolymod(c, 0)
+	}
+	c ^= 1
+
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = checksumCharset[(c>>(5*(7-i)))&31]
+	}
+	return string(checksum)
+}
+
+// withChecksum appends the checksum to a descriptor
+func withChecksum(descriptor string) string {
+	return descriptor + "#" + DescriptorChecksum(descriptor)
+}
@@ -0,0 +1,14 @@
+package btc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptorChecksum(t *testing.T) {
+	// test vectors of BIP-380
+	assert.Equal(t, "89f8spxm", DescriptorChecksum("raw(deadbeef)"))
+	assert.Equal(t, "", DescriptorChecksum("raw(deadbeef)é"))
+	assert.Equal(t, "raw(deadbeef)#89f8spxm", withChecksum("raw(deadbeef)"))
+}
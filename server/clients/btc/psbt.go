@@ -0,0 +1,363 @@
+package btc
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+// transaction sizes in weight units, inputs are estimated as single key segwit spends
+const (
+	// version, locktime, input and output counts and the segwit marker and flag
+	txOverheadWeight = 4*(4+4+1+1) + 2
+	// outpoint, empty script and sequence
+	inputBaseWeight = 4 * (36 + 1 + 4)
+	// item count, signature and compressed public key
+	p2wpkhWitnessWeight = 1 + 1 + 72 + 1 + 33
+	// item count and schnorr signature with the default sighash
+	p2trWitnessWeight = 1 + 1 + 64
+
+	// DustLimit is the smallest change output which is created, smaller change goes to the fee
+	DustLimit btcutil.Amount = 546
+
+	// sequence of the inputs, which signals replace by fee
+	rbfSequence = wire.MaxTxInSequenceNum - 2
+)
+
+// ErrInsufficientFunds is returned when the coins of a wallet can't pay for the outputs and the fee
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+func inputWeight(pkScript []byte) int64 {
+	if txscript.IsPayToTaproot(pkScript) {
+		return inputBaseWeight + p2trWitnessWeight
+	}
+	return inputBaseWeight + p2wpkhWitnessWeight
+}
+
+func outputWeight(pkScript []byte) int64 {
+	return 4 * int64(8+1+len(pkScript))
+}
+
+// feeForWeight returns the fee for a transaction of a weight at a fee rate in satoshi per vbyte
+func feeForWeight(weight int64, feeRate btcutil.Amount) btcutil.Amount {
+	return btcutil.Amount((weight+3)/4) * feeRate
+}
+
+// selection is the result of coin selection
+type selection struct {
+	inputs []UTXO
+	change btcutil.Amount
+	fee    btcutil.Amount
+}
+
+// selectCoins selects the coins to pay for outputs at a fee rate. All required coins are spent, available coins are
+// added from the largest down until the outputs and fee are paid for. Change smaller than DustLimit is added to the
+// fee.
+func selectCoins(required, available []UTXO, outputs []*wire.TxOut, changeScript []byte, feeRate btcutil.Amount) (selection, error) {
+	var target btcutil.Amount
+	weight := int64(txOverheadWeight)
+	for _, out := range outputs {
+		target += btcutil.Amount(out.Value)
+		weight += outputWeight(out.PkScript)
+	}
+
+	var in btcutil.Amount
+	selected := make([]UTXO, 0, len(required))
+	spent := map[wire.OutPoint]bool{}
+	for _, utxo := range required {
+		selected = append(selected, utxo)
+		spent[utxo.OutPoint] = true
+		in += utxo.Amount
+		weight += inputWeight(utxo.PkScript)
+	}
+
+	candidates := make([]UTXO, 0, len(available))
+	for _, utxo := range available {
+		if !spent[utxo.OutPoint] {
+			candidates = append(candidates, utxo)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Amount > candidates[j].Amount })
+
+	for {
+		if len(selected) > 0 && in >= target+feeForWeight(weight, feeRate) {
+			fee := feeForWeight(weight+outputWeight(changeScript), feeRate)
+			if change := in - target - fee; change >= DustLimit {
+				return selection{inputs: selected, change: change, fee: fee}, nil
+			}
+			return selection{inputs: selected, fee: in - target}, nil
+		}
+
+		if len(candidates) == 0 {
+			return selection{}, errors.Wrapf(ErrInsufficientFunds, "%s available, %s needed before fees", in, target)
+		}
+		selected = append(selected, candidates[0])
+		in += candidates[0].Amount
+		weight += inputWeight(candidates[0].PkScript)
+		candidates = candidates[1:]
+	}
+}
+
+// CreatePsbt creates an unsigned psbt paying outputs at a fee rate in satoshi per vbyte. All required coins are spent,
+// coins in available are added as needed. Change goes to the next unused change address of changeType, which is not
+// used up until TrackBroadcast is called once the transaction is broadcast. Inputs and change of the wallet carry
+// their derivation, so the wallet and hardware signers can sign them.
+func (w *Wallet) CreatePsbt(required, available []UTXO, outputs []*wire.TxOut, feeRate btcutil.Amount, changeType AddressType) (*psbt.Packet, error) {
+	if len(outputs) == 0 {
+		return nil, errors.New("no outputs to pay")
+	}
+	if feeRate < MinFeeRate {
+		feeRate = MinFeeRate
+	}
+
+	change, err := w.PeekAddress(changeType, true)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectCoins(required, available, outputs, change.PkScript, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	outs := append([]*wire.TxOut{}, outputs...)
+	if selected.change > 0 {
+		outs = append(outs, wire.NewTxOut(int64(selected.change), change.PkScript))
+	} else {
+		change = nil
+	}
+
+	outpoints := make([]*wire.OutPoint, 0, len(selected.inputs))
+	sequences := make([]uint32, 0, len(selected.inputs))
+	for i := range selected.inputs {
+		outpoints = append(outpoints, &selected.inputs[i].OutPoint)
+		sequences = append(sequences, rbfSequence)
+	}
+
+	packet, err := psbt.New(outpoints, outs, 2, 0, sequences)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create psbt")
+	}
+
+	for i, utxo := range selected.inputs {
+		packet.Inputs[i].WitnessUtxo = wire.NewTxOut(int64(utxo.Amount), utxo.PkScript)
+		if utxo.Key != nil {
+			w.addInputDerivation(&packet.Inputs[i], utxo.Key)
+		}
+	}
+	if change != nil {
+		w.addOutputDerivation(&packet.Outputs[len(outs)-1], change)
+	}
+
+	return packet, nil
+}
+
+// TrackBroadcast records the transaction of a psbt once it is broadcast. The addresses of the wallet it pays to are
+// marked as used, so new addresses are derived after them, and ListUnspent leaves out the coins it spends and lists
+// the outputs paying to the wallet till the transaction confirms.
+func (w *Wallet) TrackBroadcast(packet *psbt.Packet) {
+	hash := packet.UnsignedTx.TxHash()
+	for i, out := range packet.Outputs {
+		var paths [][]uint32
+		for _, derivation := range out.Bip32Derivation {
+			if derivation.MasterKeyFingerprint == w.fingerprint {
+				paths = append(paths, derivation.Bip32Path)
+			}
+		}
+		for _, derivation := range out.TaprootBip32Derivation {
+			if derivation.MasterKeyFingerprint == w.fingerprint {
+				paths = append(paths, derivation.Bip32Path)
+			}
+		}
+
+		txOut := packet.UnsignedTx.TxOut[i]
+		for _, path := range paths {
+			if len(path) != 5 || path[3] > 1 {
+				continue
+			}
+			for _, t := range AddressTypes {
+				key, err := w.Key(t, path[3] == 1, path[4])
+				if err != nil {
+					continue
+				}
+				if bytes.Equal(key.PkScript, txOut.PkScript) {
+					w.markUsed(key)
+					w.addPending(UTXO{
+						OutPoint: *wire.NewOutPoint(&hash, uint32(i)),
+						Amount:   btcutil.Amount(txOut.Value),
+						PkScript: txOut.PkScript,
+						Key:      key,
+					})
+				}
+			}
+		}
+	}
+
+	for _, in := range packet.UnsignedTx.TxIn {
+		w.addSpent(in.PreviousOutPoint)
+	}
+}
+
+func (w *Wallet) addInputDerivation(in *psbt.PInput, key *Key) {
+	switch key.Type {
+	case AddressP2WPKH:
+		in.Bip32Derivation = append(in.Bip32Derivation, &psbt.Bip32Derivation{
+			PubKey:               key.PubKey.SerializeCompressed(),
+			MasterKeyFingerprint: w.fingerprint,
+			Bip32Path:            key.Path,
+		})
+	case AddressP2TR:
+		in.TaprootInternalKey = schnorr.SerializePubKey(key.PubKey)
+		in.TaprootBip32Derivation = append(in.TaprootBip32Derivation, &psbt.TaprootBip32Derivation{
+			XOnlyPubKey:          in.TaprootInternalKey,
+			MasterKeyFingerprint: w.fingerprint,
+			Bip32Path:            key.Path,
+		})
+	}
+}
+
+func (w *Wallet) addOutputDerivation(out *psbt.POutput, key *Key) {
+	switch key.Type {
+	case AddressP2WPKH:
+		out.Bip32Derivation = append(out.Bip32Derivation, &psbt.Bip32Derivation{
+			PubKey:               key.PubKey.SerializeCompressed(),
+			MasterKeyFingerprint: w.fingerprint,
+			Bip32Path:            key.Path,
+		})
+	case AddressP2TR:
+		out.TaprootInternalKey = schnorr.SerializePubKey(key.PubKey)
+		out.TaprootBip32Derivation = append(out.TaprootBip32Derivation, &psbt.TaprootBip32Derivation{
+			XOnlyPubKey:          out.TaprootInternalKey,
+			MasterKeyFingerprint: w.fingerprint,
+			Bip32Path:            key.Path,
+		})
+	}
+}
+
+// inputUtxo returns the output an input of a psbt spends, nil if the psbt does not have it
+func inputUtxo(packet *psbt.Packet, i int) *wire.TxOut {
+	in := packet.Inputs[i]
+	if in.WitnessUtxo != nil {
+		return in.WitnessUtxo
+	}
+	if in.NonWitnessUtxo != nil {
+		index := packet.UnsignedTx.TxIn[i].PreviousOutPoint.Index
+		if int(index) < len(in.NonWitnessUtxo.TxOut) {
+			return in.NonWitnessUtxo.TxOut[index]
+		}
+	}
+	return nil
+}
+
+// SignPsbt signs the inputs of a psbt which are derived from the wallet, as found in their bip32 derivations. P2WPKH
+// inputs and BIP-86 key path spends of P2TR inputs are signed. The number of signed inputs is returned.
+func (w *Wallet) SignPsbt(packet *psbt.Packet) (int, error) {
+	tx := packet.UnsignedTx
+	prevOuts := map[wire.OutPoint]*wire.TxOut{}
+	for i := range packet.Inputs {
+		if utxo := inputUtxo(packet, i); utxo != nil {
+			prevOuts[tx.TxIn[i].PreviousOutPoint] = utxo
+		}
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, txscript.NewMultiPrevOutFetcher(prevOuts))
+
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return 0, err
+	}
+
+	signed := 0
+	for i := range packet.Inputs {
+		in := &packet.Inputs[i]
+		if len(in.FinalScriptWitness) > 0 || len(in.FinalScriptSig) > 0 {
+			continue
+		}
+		utxo := prevOuts[tx.TxIn[i].PreviousOutPoint]
+		if utxo == nil {
+			continue
+		}
+
+		switch {
+		case txscript.IsPayToWitnessPubKeyHash(utxo.PkScript):
+			for _, derivation := range in.Bip32Derivation {
+				if derivation.MasterKeyFingerprint != w.fingerprint {
+					continue
+				}
+				key, err := w.keyAt(AddressP2WPKH, derivation.Bip32Path)
+				if err != nil {
+					return signed, err
+				}
+				if !bytes.Equal(key.PkScript, utxo.PkScript) {
+					continue
+				}
+
+				sig, err := txscript.RawTxInWitnessSignature(tx, sigHashes, i, utxo.Value, utxo.PkScript, txscript.SigHashAll, key.privKey)
+				if err != nil {
+					return signed, errors.Wrapf(err, "failed to sign input %d", i)
+				}
+				if _, err := updater.Sign(i, sig, key.PubKey.SerializeCompressed(), nil, nil); err != nil {
+					return signed, errors.Wrapf(err, "failed to add signature of input %d", i)
+				}
+				signed++
+				break
+			}
+
+		case txscript.IsPayToTaproot(utxo.PkScript):
+			if len(in.TaprootKeySpendSig) > 0 {
+				continue
+			}
+			for _, derivation := range in.TaprootBip32Derivation {
+				if derivation.MasterKeyFingerprint != w.fingerprint {
+					continue
+				}
+				key, err := w.keyAt(AddressP2TR, derivation.Bip32Path)
+				if err != nil {
+					return signed, err
+				}
+				if !bytes.Equal(key.PkScript, utxo.PkScript) {
+					continue
+				}
+				// the taproot sighash commits to the outputs all inputs spend
+				if len(prevOuts) != len(tx.TxIn) {
+					return signed, errors.Errorf("signing taproot input %d needs the utxos of all inputs", i)
+				}
+
+				sig, err := txscript.RawTxInTaprootSignature(tx, sigHashes, i, utxo.Value, utxo.PkScript, nil, txscript.SigHashDefault, key.privKey)
+				if err != nil {
+					return signed, errors.Wrapf(err, "failed to sign input %d", i)
+				}
+				in.TaprootKeySpendSig = sig
+				signed++
+				break
+			}
+		}
+	}
+
+	return signed, nil
+}
+
+// FinalizePsbt finalizes the inputs of a psbt which have all their signatures. If all inputs are final, the signed
+// transaction is returned, otherwise the transaction is nil.
+func FinalizePsbt(packet *psbt.Packet) (*wire.MsgTx, error) {
+	for i := range packet.Inputs {
+		if _, err := psbt.MaybeFinalize(packet, i); err != nil && !errors.Is(err, psbt.ErrNotFinalizable) {
+			return nil, errors.Wrapf(err, "failed to finalize input %d", i)
+		}
+	}
+
+	if !packet.IsComplete() {
+		return nil, nil
+	}
+
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract transaction")
+	}
+	return tx, nil
+}
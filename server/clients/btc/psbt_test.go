@@ -0,0 +1,147 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUTXO(t *testing.T, key *Key, n byte, amount btcutil.Amount) UTXO {
+	return UTXO{
+		OutPoint: wire.OutPoint{Hash: chainhash.Hash{n}, Index: uint32(n)},
+		Amount:   amount,
+		PkScript: key.PkScript,
+		Key:      key,
+	}
+}
+
+func TestSelectCoins(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	key, err := wallet.Key(AddressP2WPKH, false, 0)
+	require.NoError(t, err)
+
+	small := testUTXO(t, key, 1, 10_000)
+	large := testUTXO(t, key, 2, 100_000)
+	outputs := []*wire.TxOut{wire.NewTxOut(50_000, key.PkScript)}
+
+	// the largest coin is selected first
+	selected, err := selectCoins(nil, []UTXO{small, large}, outputs, key.PkScript, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []UTXO{large}, selected.inputs)
+	assert.Equal(t, btcutil.Amount(100_000-50_000)-selected.fee, selected.change)
+	// one input and two outputs
+	assert.Equal(t, btcutil.Amount(141*2), selected.fee)
+
+	// required coins are always spent
+	selected, err = selectCoins([]UTXO{small}, []UTXO{small, large}, outputs, key.PkScript, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []UTXO{small, large}, selected.inputs)
+
+	// dust change goes to the fee
+	outputs = []*wire.TxOut{wire.NewTxOut(99_500, key.PkScript)}
+	selected, err = selectCoins(nil, []UTXO{large}, outputs, key.PkScript, 1)
+	require.NoError(t, err)
+	assert.Equal(t, btcutil.Amount(0), selected.change)
+	assert.Equal(t, btcutil.Amount(500), selected.fee)
+
+	_, err = selectCoins(nil, []UTXO{small}, outputs, key.PkScript, 1)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCreatePsbtChange(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	key, err := wallet.Key(AddressP2WPKH, false, 0)
+	require.NoError(t, err)
+	coins := []UTXO{testUTXO(t, key, 1, 100_000)}
+	outputs := []*wire.TxOut{wire.NewTxOut(50_000, key.PkScript)}
+
+	first, err := wallet.CreatePsbt(nil, coins, outputs, 2, AddressP2WPKH)
+	require.NoError(t, err)
+	second, err := wallet.CreatePsbt(nil, coins, outputs, 2, AddressP2WPKH)
+	require.NoError(t, err)
+
+	// the change address is not used up until the psbt is broadcast
+	change, err := wallet.Key(AddressP2WPKH, true, 0)
+	require.NoError(t, err)
+	assert.Equal(t, change.PkScript, first.UnsignedTx.TxOut[1].PkScript)
+	assert.Equal(t, change.PkScript, second.UnsignedTx.TxOut[1].PkScript)
+
+	wallet.TrackBroadcast(first)
+	next, err := wallet.PeekAddress(AddressP2WPKH, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), next.Index)
+
+	// outputs of other wallets are not marked
+	other, err := NewWallet(testMnemonic, "other", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	other.TrackBroadcast(first)
+	next, err = other.PeekAddress(AddressP2WPKH, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), next.Index)
+}
+
+func TestSignPsbt(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+
+	segwit, err := wallet.Key(AddressP2WPKH, false, 3)
+	require.NoError(t, err)
+	taproot, err := wallet.Key(AddressP2TR, false, 1)
+	require.NoError(t, err)
+	coins := []UTXO{testUTXO(t, segwit, 1, 40_000), testUTXO(t, taproot, 2, 30_000)}
+
+	other, err := NewWallet(testMnemonic, "other", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	dest, err := other.Key(AddressP2TR, false, 0)
+	require.NoError(t, err)
+
+	packet, err := wallet.CreatePsbt(nil, coins, []*wire.TxOut{wire.NewTxOut(60_000, dest.PkScript)}, 5, AddressP2TR)
+	require.NoError(t, err)
+	require.Len(t, packet.UnsignedTx.TxIn, 2)
+	require.Len(t, packet.UnsignedTx.TxOut, 2)
+	assert.NotEmpty(t, packet.Outputs[1].TaprootBip32Derivation)
+
+	// a psbt survives serialization, as it does when passed between signers
+	encoded, err := packet.B64Encode()
+	require.NoError(t, err)
+	packet, err = psbt.NewFromRawBytes(bytes.NewReader([]byte(encoded)), true)
+	require.NoError(t, err)
+
+	// other wallets don't sign
+	signed, err := other.SignPsbt(packet)
+	require.NoError(t, err)
+	assert.Equal(t, 0, signed)
+	tx, err := FinalizePsbt(packet)
+	require.NoError(t, err)
+	assert.Nil(t, tx)
+
+	signed, err = wallet.SignPsbt(packet)
+	require.NoError(t, err)
+	assert.Equal(t, 2, signed)
+
+	tx, err = FinalizePsbt(packet)
+	require.NoError(t, err)
+	require.NotNil(t, tx)
+
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for _, coin := range coins {
+		prevOuts.AddPrevOut(coin.OutPoint, wire.NewTxOut(int64(coin.Amount), coin.PkScript))
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, in := range tx.TxIn {
+		prev := prevOuts.FetchPrevOutput(in.PreviousOutPoint)
+		engine, err := txscript.NewEngine(prev.PkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prev.Value, prevOuts)
+		require.NoError(t, err)
+		assert.NoError(t, engine.Execute(), "input %d", i)
+	}
+}
@@ -0,0 +1,327 @@
+package btc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+)
+
+const (
+	// GapLimit is the number of unused addresses after the last used one which are scanned for coins
+	GapLimit = 20
+	// MinFeeRate is the minimal relay fee rate in satoshi per vbyte
+	MinFeeRate btcutil.Amount = 1
+)
+
+// UTXO is an unspent transaction output
+type UTXO struct {
+	OutPoint wire.OutPoint
+	Amount   btcutil.Amount
+	PkScript []byte
+	// Height of the block the output was created in, 0 if it is unconfirmed
+	Height int64
+	// Key of the wallet the output pays to, nil if it is not an output of the wallet
+	Key *Key
+}
+
+type scanResult struct {
+	Success  bool `json:"success"`
+	Unspents []struct {
+		TxID         string  `json:"txid"`
+		Vout         uint32  `json:"vout"`
+		ScriptPubKey string  `json:"scriptPubKey"`
+		Amount       float64 `json:"amount"`
+		Height       int64   `json:"height"`
+	} `json:"unspents"`
+}
+
+// ListUnspent returns the unspent outputs of the wallet, scanning the utxo set of the node for the addresses of the
+// wallet. The scan continues until GapLimit addresses after the last used one have no coins. The utxo set only holds
+// confirmed outputs, so the outputs spent by transactions broadcast with TrackBroadcast are left out and their outputs
+// paying to the wallet are added, as long as the mempool of the node has them.
+func (w *Wallet) ListUnspent(client *rpcclient.Client) ([]UTXO, error) {
+	for {
+		end := w.nextIndex() + GapLimit
+
+		keys := map[string]*Key{}
+		var descriptors []interface{}
+		for _, t := range AddressTypes {
+			for _, change := range []bool{false, true} {
+				descriptor, err := w.Descriptor(t, change)
+				if err != nil {
+					return nil, err
+				}
+				descriptors = append(descriptors, map[string]interface{}{"desc": descriptor, "range": end - 1})
+
+				for i := uint32(0); i < end; i++ {
+					key, err := w.Key(t, change, i)
+					if err != nil {
+						return nil, err
+					}
+					keys[hex.EncodeToString(key.PkScript)] = key
+				}
+			}
+		}
+
+		raw, err := rawRequest(client, "scantxoutset", "start", descriptors)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan utxo set")
+		}
+
+		utxos, err := parseScan(raw, keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, utxo := range utxos {
+			w.markUsed(utxo.Key)
+		}
+
+		// coins close to the end of the scanned range mean more addresses could be in use
+		if w.nextIndex()+GapLimit <= end {
+			return w.withBroadcasts(utxos, func(outpoint wire.OutPoint) (bool, error) {
+				out, err := client.GetTxOut(&outpoint.Hash, outpoint.Index, true)
+				if err != nil {
+					return false, errors.Wrapf(err, "failed to get output %s", outpoint)
+				}
+				return out != nil, nil
+			})
+		}
+	}
+}
+
+// addSpent records an output spent by a broadcast transaction
+func (w *Wallet) addSpent(outpoint wire.OutPoint) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.spent[outpoint] = struct{}{}
+}
+
+// addPending records an output of a broadcast transaction paying to the wallet
+func (w *Wallet) addPending(utxo UTXO) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending[utxo.OutPoint] = utxo
+}
+
+// withBroadcasts updates the confirmed outputs of the wallet with the transactions it broadcast. unspent tells
+// whether the node has an output unspent, including the transactions in its mempool. Outputs which confirmed or left
+// the mempool are no longer tracked.
+func (w *Wallet) withBroadcasts(confirmed []UTXO, unspent func(wire.OutPoint) (bool, error)) ([]UTXO, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	scanned := make(map[wire.OutPoint]bool, len(confirmed))
+	utxos := make([]UTXO, 0, len(confirmed)+len(w.pending))
+	for _, utxo := range confirmed {
+		scanned[utxo.OutPoint] = true
+		delete(w.pending, utxo.OutPoint)
+		if _, ok := w.spent[utxo.OutPoint]; ok {
+			ok, err := unspent(utxo.OutPoint)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			// the transaction spending it left the mempool
+			delete(w.spent, utxo.OutPoint)
+		}
+		utxos = append(utxos, utxo)
+	}
+
+	for outpoint, utxo := range w.pending {
+		ok, err := unspent(outpoint)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			utxos = append(utxos, utxo)
+			continue
+		}
+		// outputs the wallet spent are kept till they confirm, so the spend is still known then
+		if _, ok := w.spent[outpoint]; !ok {
+			// spent by another transaction, or the transaction left the mempool
+			delete(w.pending, outpoint)
+		}
+	}
+
+	// a spend is confirmed once its output is gone from the utxo set
+	for outpoint := range w.spent {
+		if _, ok := w.pending[outpoint]; !ok && !scanned[outpoint] {
+			delete(w.spent, outpoint)
+		}
+	}
+
+	return utxos, nil
+}
+
+// parseScan parses the result of scantxoutset, keys are the wallet keys by hex encoded pkScript
+func parseScan(raw json.RawMessage, keys map[string]*Key) ([]UTXO, error) {
+	var result scanResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to decode scan result")
+	}
+	if !result.Success {
+		return nil, errors.New("scanning the utxo set did not succeed")
+	}
+
+	utxos := make([]UTXO, 0, len(result.Unspents))
+	for _, unspent := range result.Unspents {
+		hash, err := chainhash.NewHashFromStr(unspent.TxID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid txid %s", unspent.TxID)
+		}
+		amount, err := btcutil.NewAmount(unspent.Amount)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keys[unspent.ScriptPubKey]
+		if !ok {
+			return nil, errors.Errorf("utxo %s:%d is not of the wallet", unspent.TxID, unspent.Vout)
+		}
+
+		utxos = append(utxos, UTXO{
+			OutPoint: *wire.NewOutPoint(hash, unspent.Vout),
+			Amount:   amount,
+			PkScript: key.PkScript,
+			Height:   unspent.Height,
+			Key:      key,
+		})
+	}
+
+	return utxos, nil
+}
+
+// LookupUTXOs returns the unspent outputs at outpoints, using the outputs of the wallet in own and looking up others
+// on the node
+func LookupUTXOs(client *rpcclient.Client, outpoints []wire.OutPoint, own []UTXO) ([]UTXO, error) {
+	utxos := make([]UTXO, 0, len(outpoints))
+outpoints:
+	for _, outpoint := range outpoints {
+		for _, utxo := range own {
+			if utxo.OutPoint == outpoint {
+				utxos = append(utxos, utxo)
+				continue outpoints
+			}
+		}
+
+		out, err := client.GetTxOut(&outpoint.Hash, outpoint.Index, true)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get output %s", outpoint)
+		}
+		if out == nil {
+			return nil, errors.Errorf("output %s is spent or does not exist", outpoint)
+		}
+		pkScript, err := hex.DecodeString(out.ScriptPubKey.Hex)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid script of output %s", outpoint)
+		}
+		amount, err := btcutil.NewAmount(out.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		utxos = append(utxos, UTXO{OutPoint: outpoint, Amount: amount, PkScript: pkScript})
+	}
+
+	return utxos, nil
+}
+
+// ImportDescriptors imports the public descriptors of the wallet in the watch-only descriptor wallet the client is
+// connected to, so the node tracks the transactions of the wallet. With rescan the node looks for transactions in
+// the whole chain, otherwise only for new ones.
+func (w *Wallet) ImportDescriptors(client *rpcclient.Client, rescan bool) error {
+	end := w.nextIndex() + GapLimit
+
+	var timestamp interface{} = "now"
+	if rescan {
+		timestamp = 0
+	}
+
+	var requests []interface{}
+	for _, t := range AddressTypes {
+		for _, change := range []bool{false, true} {
+			descriptor, err := w.Descriptor(t, change)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, map[string]interface{}{
+				"desc":      descriptor,
+				"active":    true,
+				"internal":  change,
+				"range":     []uint32{0, end - 1},
+				"timestamp": timestamp,
+			})
+		}
+	}
+
+	raw, err := rawRequest(client, "importdescriptors", requests)
+	if err != nil {
+		return errors.Wrap(err, "failed to import descriptors")
+	}
+
+	var results []struct {
+		Success bool `json:"success"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &results); err != nil {
+		return errors.Wrap(err, "failed to decode import result")
+	}
+	for i, result := range results {
+		if !result.Success {
+			msg := "unknown error"
+			if result.Error != nil {
+				msg = result.Error.Message
+			}
+			return errors.Errorf("failed to import descriptor %d: %s", i, msg)
+		}
+	}
+
+	return nil
+}
+
+// EstimateFeeRate returns the fee rate in satoshi per vbyte for a transaction to confirm within confTarget blocks. If
+// the node has no estimate, like on a fresh regtest chain, MinFeeRate is returned.
+func EstimateFeeRate(client *rpcclient.Client, confTarget int64) (btcutil.Amount, error) {
+	mode := btcjson.EstimateModeConservative
+	estimate, err := client.EstimateSmartFee(confTarget, &mode)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to estimate fee")
+	}
+	if estimate.FeeRate == nil {
+		return MinFeeRate, nil
+	}
+
+	// the estimate is in btc per kvbyte
+	perKvB, err := btcutil.NewAmount(*estimate.FeeRate)
+	if err != nil {
+		return 0, err
+	}
+	rate := (perKvB + 999) / 1000
+	if rate < MinFeeRate {
+		rate = MinFeeRate
+	}
+	return rate, nil
+}
+
+func rawRequest(client *rpcclient.Client, method string, params ...interface{}) (json.RawMessage, error) {
+	raw := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		encoded, err := json.Marshal(param)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode params of %s", method)
+		}
+		raw = append(raw, encoded)
+	}
+	return client.RawRequest(method, raw)
+}
@@ -0,0 +1,112 @@
+package btc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScan(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	key, err := wallet.Key(AddressP2TR, true, 4)
+	require.NoError(t, err)
+	script := hex.EncodeToString(key.PkScript)
+	keys := map[string]*Key{script: key}
+
+	raw := fmt.Sprintf(`{"success": true, "unspents": [{"txid": "%064x", "vout": 1, "scriptPubKey": "%s", "amount": 0.0015, "height": 120}]}`, 1, script)
+	utxos, err := parseScan([]byte(raw), keys)
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, btcutil.Amount(150_000), utxos[0].Amount)
+	assert.Equal(t, uint32(1), utxos[0].OutPoint.Index)
+	assert.Equal(t, int64(120), utxos[0].Height)
+	assert.Equal(t, key, utxos[0].Key)
+
+	wallet.markUsed(utxos[0].Key)
+	assert.Equal(t, uint32(5), wallet.nextIndex())
+
+	_, err = parseScan([]byte(`{"success": false}`), keys)
+	assert.Error(t, err)
+
+	raw = fmt.Sprintf(`{"success": true, "unspents": [{"txid": "%064x", "vout": 0, "scriptPubKey": "0014ab", "amount": 1}]}`, 1)
+	_, err = parseScan([]byte(raw), keys)
+	assert.Error(t, err)
+}
+
+func TestWithBroadcasts(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	key, err := wallet.Key(AddressP2WPKH, false, 0)
+	require.NoError(t, err)
+	other, err := NewWallet(testMnemonic, "other", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	external, err := other.Key(AddressP2WPKH, false, 0)
+	require.NoError(t, err)
+
+	coin := testUTXO(t, key, 1, 100_000)
+	first, err := wallet.CreatePsbt(nil, []UTXO{coin}, []*wire.TxOut{wire.NewTxOut(30_000, external.PkScript)}, 1, AddressP2WPKH)
+	require.NoError(t, err)
+	wallet.TrackBroadcast(first)
+	change := wire.OutPoint{Hash: first.UnsignedTx.TxHash(), Index: 1}
+
+	// outputs are unspent if they are in this set, standing in for the utxo set and mempool of the node
+	mempool := map[wire.OutPoint]bool{change: true}
+	unspent := func(outpoint wire.OutPoint) (bool, error) {
+		return mempool[outpoint], nil
+	}
+
+	// the coin is spent by the unconfirmed transaction, which pays the change to the wallet
+	utxos, err := wallet.withBroadcasts([]UTXO{coin}, unspent)
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, change, utxos[0].OutPoint)
+	assert.Equal(t, int64(0), utxos[0].Height)
+	assert.Equal(t, btcutil.Amount(first.UnsignedTx.TxOut[1].Value), utxos[0].Amount)
+	assert.Equal(t, uint32(0), utxos[0].Key.Index)
+	assert.True(t, utxos[0].Key.Change)
+
+	// the unconfirmed change is spent in turn
+	second, err := wallet.CreatePsbt(nil, utxos, []*wire.TxOut{wire.NewTxOut(20_000, external.PkScript)}, 1, AddressP2WPKH)
+	require.NoError(t, err)
+	wallet.TrackBroadcast(second)
+	secondChange := wire.OutPoint{Hash: second.UnsignedTx.TxHash(), Index: 1}
+	mempool = map[wire.OutPoint]bool{secondChange: true}
+
+	utxos, err = wallet.withBroadcasts([]UTXO{coin}, unspent)
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, secondChange, utxos[0].OutPoint)
+
+	// the first transaction confirms, its change is still spent by the second one
+	confirmedChange := testUTXO(t, key, 0, 0)
+	confirmedChange.OutPoint = change
+	utxos, err = wallet.withBroadcasts([]UTXO{confirmedChange}, unspent)
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Equal(t, secondChange, utxos[0].OutPoint)
+
+	// once the second transaction confirms nothing is tracked anymore
+	confirmedSecond := testUTXO(t, key, 0, 0)
+	confirmedSecond.OutPoint = secondChange
+	utxos, err = wallet.withBroadcasts([]UTXO{confirmedSecond}, unspent)
+	require.NoError(t, err)
+	require.Len(t, utxos, 1)
+	assert.Empty(t, wallet.spent)
+	assert.Empty(t, wallet.pending)
+
+	// a coin whose spending transaction left the mempool is listed again
+	dropped := testUTXO(t, key, 2, 50_000)
+	wallet.addSpent(dropped.OutPoint)
+	mempool = map[wire.OutPoint]bool{dropped.OutPoint: true}
+	utxos, err = wallet.withBroadcasts([]UTXO{dropped}, unspent)
+	require.NoError(t, err)
+	assert.Equal(t, []UTXO{dropped}, utxos)
+	assert.Empty(t, wallet.spent)
+}
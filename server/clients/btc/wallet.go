@@ -0,0 +1,318 @@
+package btc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/cosmos/go-bip39"
+	"github.com/pkg/errors"
+)
+
+// AddressType is the type of the addresses of a wallet account
+type AddressType string
+
+const (
+	// AddressP2WPKH are native segwit addresses derived following BIP-84
+	AddressP2WPKH AddressType = "p2wpkh"
+	// AddressP2TR are taproot addresses derived following BIP-86
+	AddressP2TR AddressType = "p2tr"
+)
+
+// AddressTypes are the address types a wallet derives
+var AddressTypes = []AddressType{AddressP2WPKH, AddressP2TR}
+
+// ErrUnknownAddressType is returned for address types a wallet does not derive
+var ErrUnknownAddressType = errors.New("unknown address type")
+
+// purpose returns the BIP-43 purpose of an address type
+func (t AddressType) purpose() (uint32, error) {
+	switch t {
+	case AddressP2WPKH:
+		return 84, nil
+	case AddressP2TR:
+		return 86, nil
+	}
+	return 0, errors.Wrapf(ErrUnknownAddressType, "%s", t)
+}
+
+// Key is a key of a wallet with the address it receives on
+type Key struct {
+	Type   AddressType
+	Change bool
+	Index  uint32
+	// Path is the full derivation path from the master key
+	Path     []uint32
+	Address  btcutil.Address
+	PkScript []byte
+	PubKey   *btcec.PublicKey
+
+	privKey *btcec.PrivateKey
+}
+
+// Wallet is a hierarchical deterministic wallet deriving keys from a seed. Keys never leave the wallet, the node is
+// only used to look up coins and broadcast transactions.
+type Wallet struct {
+	params      *chaincfg.Params
+	master      *hdkeychain.ExtendedKey
+	fingerprint uint32
+
+	mu sync.Mutex
+	// next unused index per address type, for receive and change addresses
+	next map[AddressType]*[2]uint32
+	// outputs spent by broadcast transactions which may not be confirmed yet
+	spent map[wire.OutPoint]struct{}
+	// outputs of broadcast transactions paying to the wallet which may not be confirmed yet
+	pending map[wire.OutPoint]UTXO
+}
+
+// NewWallet creates a wallet from a bip39 mnemonic and an optional passphrase for a network
+func NewWallet(mnemonic, passphrase string, params *chaincfg.Params) (*Wallet, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid mnemonic")
+	}
+
+	return NewWalletFromSeed(seed, params)
+}
+
+// NewWalletFromSeed creates a wallet from a seed for a network
+func NewWalletFromSeed(seed []byte, params *chaincfg.Params) (*Wallet, error) {
+	master, err := hdkeychain.NewMaster(seed, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create master key")
+	}
+
+	pub, err := master.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	next := map[AddressType]*[2]uint32{}
+	for _, t := range AddressTypes {
+		next[t] = &[2]uint32{}
+	}
+
+	return &Wallet{
+		params:      params,
+		master:      master,
+		fingerprint: binary.LittleEndian.Uint32(btcutil.Hash160(pub.SerializeCompressed())[:4]),
+		next:        next,
+		spent:       map[wire.OutPoint]struct{}{},
+		pending:     map[wire.OutPoint]UTXO{},
+	}, nil
+}
+
+// Params of the network of the wallet
+func (w *Wallet) Params() *chaincfg.Params {
+	return w.params
+}
+
+// Fingerprint of the master key, as used in psbt derivations
+func (w *Wallet) Fingerprint() uint32 {
+	return w.fingerprint
+}
+
+// accountPath returns the path of the account of an address type, m/purpose'/coin'/0'
+func (w *Wallet) accountPath(t AddressType) ([]uint32, error) {
+	purpose, err := t.purpose()
+	if err != nil {
+		return nil, err
+	}
+
+	coin := uint32(1)
+	if w.params.Net == chaincfg.MainNetParams.Net {
+		coin = 0
+	}
+
+	return []uint32{
+		purpose + hdkeychain.HardenedKeyStart,
+		coin + hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart,
+	}, nil
+}
+
+func (w *Wallet) derive(path []uint32) (*hdkeychain.ExtendedKey, error) {
+	key := w.master
+	for _, i := range path {
+		var err error
+		if key, err = key.Derive(i); err != nil {
+			return nil, errors.Wrap(err, "failed to derive key")
+		}
+	}
+	return key, nil
+}
+
+// Key returns the key of an address type at an index
+func (w *Wallet) Key(t AddressType, change bool, index uint32) (*Key, error) {
+	path, err := w.accountPath(t)
+	if err != nil {
+		return nil, err
+	}
+	path = append(path, branch(change), index)
+
+	return w.keyAt(t, path)
+}
+
+// keyAt returns the key at a full derivation path for an address type
+func (w *Wallet) keyAt(t AddressType, path []uint32) (*Key, error) {
+	extended, err := w.derive(path)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := extended.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	pub := priv.PubKey()
+
+	var address btcutil.Address
+	switch t {
+	case AddressP2WPKH:
+		address, err = btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pub.SerializeCompressed()), w.params)
+	case AddressP2TR:
+		address, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(pub)), w.params)
+	default:
+		err = errors.Wrapf(ErrUnknownAddressType, "%s", t)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		Type:     t,
+		Path:     path,
+		Address:  address,
+		PkScript: pkScript,
+		PubKey:   pub,
+		privKey:  priv,
+	}
+	if len(path) == 5 {
+		key.Change = path[3] == 1
+		key.Index = path[4]
+	}
+	return key, nil
+}
+
+// NewAddress returns the key of the next unused receive or change address of an address type
+func (w *Wallet) NewAddress(t AddressType, change bool) (*Key, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, ok := w.next[t]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownAddressType, "%s", t)
+	}
+
+	key, err := w.Key(t, change, next[branch(change)])
+	if err != nil {
+		return nil, err
+	}
+	next[branch(change)]++
+
+	return key, nil
+}
+
+// PeekAddress returns the key of the next unused receive or change address of an address type, without using it up.
+// The same address is returned until it is used, by NewAddress, TrackBroadcast or found with coins.
+func (w *Wallet) PeekAddress(t AddressType, change bool) (*Key, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, ok := w.next[t]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownAddressType, "%s", t)
+	}
+
+	return w.Key(t, change, next[branch(change)])
+}
+
+// markUsed makes sure new addresses are derived after a used key
+func (w *Wallet) markUsed(key *Key) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next := w.next[key.Type]
+	if next != nil && next[branch(key.Change)] <= key.Index {
+		next[branch(key.Change)] = key.Index + 1
+	}
+}
+
+// nextIndex returns the highest next unused index over all address types and branches
+func (w *Wallet) nextIndex() uint32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var max uint32
+	for _, next := range w.next {
+		for _, i := range next {
+			if i > max {
+				max = i
+			}
+		}
+	}
+	return max
+}
+
+// Descriptor returns the public descriptor of the receive or change addresses of an address type, with checksum
+func (w *Wallet) Descriptor(t AddressType, change bool) (string, error) {
+	path, err := w.accountPath(t)
+	if err != nil {
+		return "", err
+	}
+	account, err := w.derive(path)
+	if err != nil {
+		return "", err
+	}
+	xpub, err := account.Neuter()
+	if err != nil {
+		return "", err
+	}
+
+	var fingerprint [4]byte
+	binary.LittleEndian.PutUint32(fingerprint[:], w.fingerprint)
+
+	key := fmt.Sprintf("[%x/%s]%s/%d/*", fingerprint, formatPath(path), xpub.String(), branch(change))
+
+	switch t {
+	case AddressP2WPKH:
+		return withChecksum("wpkh(" + key + ")"), nil
+	case AddressP2TR:
+		return withChecksum("tr(" + key + ")"), nil
+	}
+	return "", errors.Wrapf(ErrUnknownAddressType, "%s", t)
+}
+
+// branch is the derivation index of receive or change addresses
+func branch(change bool) uint32 {
+	if change {
+		return 1
+	}
+	return 0
+}
+
+// formatPath formats a derivation path without the leading m, hardened indexes are marked with h
+func formatPath(path []uint32) string {
+	parts := make([]string, 0, len(path))
+	for _, i := range path {
+		if i >= hdkeychain.HardenedKeyStart {
+			parts = append(parts, fmt.Sprintf("%dh", i-hdkeychain.HardenedKeyStart))
+		} else {
+			parts = append(parts, fmt.Sprint(i))
+		}
+	}
+	return strings.Join(parts, "/")
+}
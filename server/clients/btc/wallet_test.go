@@ -0,0 +1,68 @@
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestWalletAddresses(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	// test vectors of BIP-84 and BIP-86
+	key, err := wallet.Key(AddressP2WPKH, false, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", key.Address.EncodeAddress())
+
+	key, err = wallet.Key(AddressP2WPKH, true, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el", key.Address.EncodeAddress())
+
+	key, err = wallet.Key(AddressP2TR, false, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", key.Address.EncodeAddress())
+
+	_, err = wallet.Key("p2pkh", false, 0)
+	assert.ErrorIs(t, err, ErrUnknownAddressType)
+}
+
+func TestWalletNewAddress(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+
+	first, err := wallet.NewAddress(AddressP2WPKH, false)
+	require.NoError(t, err)
+	second, err := wallet.NewAddress(AddressP2WPKH, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), first.Index)
+	assert.Equal(t, uint32(1), second.Index)
+
+	used, err := wallet.Key(AddressP2TR, true, 5)
+	require.NoError(t, err)
+	wallet.markUsed(used)
+	change, err := wallet.NewAddress(AddressP2TR, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(6), change.Index)
+	assert.True(t, change.Change)
+	assert.Equal(t, uint32(7), wallet.nextIndex())
+}
+
+func TestWalletDescriptor(t *testing.T) {
+	wallet, err := NewWallet(testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	descriptor, err := wallet.Descriptor(AddressP2WPKH, false)
+	require.NoError(t, err)
+	assert.Equal(t, "wpkh([73c5da0a/84h/0h/0h]xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V/0/*)", descriptor[:len(descriptor)-9])
+	assert.Equal(t, DescriptorChecksum(descriptor[:len(descriptor)-9]), descriptor[len(descriptor)-8:])
+
+	descriptor, err = wallet.Descriptor(AddressP2TR, true)
+	require.NoError(t, err)
+	assert.Contains(t, descriptor, "tr([73c5da0a/86h/0h/0h]xpub")
+	assert.Contains(t, descriptor, "/1/*)#")
+}
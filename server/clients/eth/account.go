@@ -20,8 +20,6 @@ import (
 )
 
 const (
-	contractAddress      = "0xE04a9665bbA9B7954572802A9864dD1d03326792"
-	gasLimit             = 210000
 	timeoutCreateAccount = 300
 )
 
@@ -53,8 +51,12 @@ func (c *Client) CreateAndActivateStellarAccount(ctx context.Context, network st
 		return "", errors.Wrap(err, "failed to generate keypair")
 	}
 
+	if err := c.requireContract(c.Chain.AccountActivation, "account activation"); err != nil {
+		return "", err
+	}
+
 	// Fetch the price for activating an account on the Stellar network
-	contractCaller, err := contract.NewAccountActivationCaller(common.HexToAddress(contractAddress), c.Eth)
+	contractCaller, err := contract.NewAccountActivationCaller(c.Chain.AccountActivation, c.Eth)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to create account activation caller")
 	}
@@ -66,27 +68,15 @@ func (c *Client) CreateAndActivateStellarAccount(ctx context.Context, network st
 	}
 
 	// Call the ActivateAccount function
-	contractTransactor, err := contract.NewAccountActivationTransactor(common.HexToAddress(contractAddress), c.Eth)
+	contractTransactor, err := contract.NewAccountActivationTransactor(c.Chain.AccountActivation, c.Eth)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to create account activation transactor")
 	}
 
-	chainID, err := c.Eth.ChainID(ctx)
-	if err != nil {
-		return "", errors.Wrap(err, "failed getting the chain id")
-	}
-
-	pubKey, _ := c.Key.Public().(*ecdsa.PublicKey)
-	ethereumAddress := crypto.PubkeyToAddress(*pubKey)
-	_, err = contractTransactor.ActivateAccount(&bind.TransactOpts{
-		Context: ctx,
-		From:    ethereumAddress,
-		Signer: func(a common.Address, t *types.Transaction) (*types.Transaction, error) {
-			return types.SignTx(t, types.LatestSignerForChainID(chainID), c.Key)
-		},
-		Value:    cost,
-		GasLimit: gasLimit,
-	}, "stellar", kp.Address())
+	_, err = c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		opts.Value = cost
+		return contractTransactor.ActivateAccount(opts, "stellar", kp.Address())
+	})
 	if err != nil {
 		return "", errors.Wrap(err, "failed to activate account")
 	}
@@ -0,0 +1,167 @@
+package goethclient
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daoleno/uniswapv3-sdk/examples/helper"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	EthMainnetId = 1
+	EthGoerliId  = 5
+	EthSepoliaId = 11155111
+	BscMainnetId = 56
+	PolygonId    = 137
+	AnvilId      = 31337
+)
+
+// Chain describes an EVM chain and the contracts the client uses on it. Zero addresses mean the contract is not
+// available on the chain, and the functionality depending on it is disabled.
+type Chain struct {
+	Name    string `json:"name"`
+	ChainID uint64 `json:"chain_id"`
+	// RPCURL is the default rpc endpoint, used if no url is given when loading a client
+	RPCURL string `json:"rpc_url"`
+	// NativeCurrency symbol, the native currency always has 18 decimals
+	NativeCurrency string `json:"native_currency"`
+	// Weth is the wrapped native currency used for swaps
+	Weth common.Address `json:"weth"`
+	// Tft token contract
+	Tft common.Address `json:"tft"`
+	// TftBridge is the contract burning TFT to withdraw it to stellar. This is the token contract itself for the
+	// bridges deployed by threefold.
+	TftBridge common.Address `json:"tft_bridge"`
+	// SwapRouter and Quoter are uniswap V3 compatible (V1 periphery) contracts
+	SwapRouter common.Address `json:"swap_router"`
+	Quoter     common.Address `json:"quoter"`
+	// AccountActivation is the contract paying for stellar account activations
+	AccountActivation common.Address `json:"account_activation"`
+	// AtomicSwap is the contract used for the eth side of atomic swaps
+	AtomicSwap common.Address `json:"atomic_swap"`
+}
+
+var (
+	uniswapV3Router = common.HexToAddress(helper.ContractV3SwapRouterV1)
+	uniswapV3Quoter = common.HexToAddress(helper.ContractV3Quoter)
+
+	// Chains known by the client, keyed by name
+	Chains = map[string]Chain{
+		"mainnet": {
+			Name:              "mainnet",
+			ChainID:           EthMainnetId,
+			RPCURL:            "https://ethereum.publicnode.com",
+			NativeCurrency:    "ETH",
+			Weth:              common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+			Tft:               MainnetEthTftContractAddress,
+			TftBridge:         MainnetEthTftContractAddress,
+			SwapRouter:        uniswapV3Router,
+			Quoter:            uniswapV3Quoter,
+			AccountActivation: common.HexToAddress("0xE04a9665bbA9B7954572802A9864dD1d03326792"),
+		},
+		"goerli": {
+			Name:           "goerli",
+			ChainID:        EthGoerliId,
+			RPCURL:         "https://ethereum-goerli.publicnode.com",
+			NativeCurrency: "ETH",
+			Weth:           common.HexToAddress("0xB4FBF271143F4FBf7B91A5ded31805e42b2208d6"),
+			Tft:            GoerliTestnetEthTftContractAddress,
+			TftBridge:      GoerliTestnetEthTftContractAddress,
+			SwapRouter:     uniswapV3Router,
+			Quoter:         uniswapV3Quoter,
+			AtomicSwap:     common.HexToAddress("0x8420c8271d602F6D0B190856Cea8E74D09A0d3cF"),
+		},
+		"sepolia": {
+			Name:           "sepolia",
+			ChainID:        EthSepoliaId,
+			RPCURL:         "https://ethereum-sepolia.publicnode.com",
+			NativeCurrency: "ETH",
+			Weth:           common.HexToAddress("0xfFf9976782d46CC05630D1f6eBAb18b2324d6B14"),
+			AtomicSwap:     common.HexToAddress("0x17f54245073bfed168a51c3d13b536e39e406063"),
+		},
+		"bsc": {
+			Name:           "bsc",
+			ChainID:        BscMainnetId,
+			RPCURL:         "https://bsc-dataseed.binance.org",
+			NativeCurrency: "BNB",
+			Weth:           common.HexToAddress("0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c"),
+			Tft:            common.HexToAddress("0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf"),
+			TftBridge:      common.HexToAddress("0x8f0FB159380176D324542b3a7933F0C2Fd0c2bbf"),
+		},
+		"polygon": {
+			Name:           "polygon",
+			ChainID:        PolygonId,
+			RPCURL:         "https://polygon-rpc.com",
+			NativeCurrency: "MATIC",
+			Weth:           common.HexToAddress("0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270"),
+			SwapRouter:     uniswapV3Router,
+			Quoter:         uniswapV3Quoter,
+		},
+		"anvil": {
+			Name:           "anvil",
+			ChainID:        AnvilId,
+			RPCURL:         "http://127.0.0.1:8545",
+			NativeCurrency: "ETH",
+		},
+	}
+)
+
+// LookupChain finds a chain by name or chain id in the given chains, and in the known chains
+func LookupChain(chains map[string]Chain, nameOrID string) (Chain, bool) {
+	nameOrID = strings.ToLower(nameOrID)
+	for _, registry := range []map[string]Chain{chains, Chains} {
+		if chain, ok := registry[nameOrID]; ok {
+			return chain, true
+		}
+		if id, err := strconv.ParseUint(nameOrID, 10, 64); err == nil {
+			if chain, ok := chainByID(registry, id); ok {
+				return chain, true
+			}
+		}
+	}
+	return Chain{}, false
+}
+
+// ListChains returns the given chains and the known chains which are not overridden, sorted by name
+func ListChains(chains map[string]Chain) []Chain {
+	list := make([]Chain, 0, len(chains)+len(Chains))
+	for _, chain := range chains {
+		list = append(list, chain)
+	}
+	for name, chain := range Chains {
+		if _, ok := chains[name]; !ok {
+			list = append(list, chain)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func chainByID(chains map[string]Chain, id uint64) (Chain, bool) {
+	for _, chain := range chains {
+		if chain.ChainID == id {
+			return chain, true
+		}
+	}
+	return Chain{}, false
+}
+
+// unknownChain describes a chain which is not in the registry, without any contracts
+func unknownChain(id uint64) Chain {
+	return Chain{
+		Name:           fmt.Sprintf("chain-%d", id),
+		ChainID:        id,
+		NativeCurrency: "ETH",
+	}
+}
+
+// requireContract returns an error if a contract is not available on the chain of the client
+func (c *Client) requireContract(address common.Address, name string) error {
+	if address == (common.Address{}) {
+		return fmt.Errorf("%s is not available on chain %s", name, c.Chain.Name)
+	}
+	return nil
+}
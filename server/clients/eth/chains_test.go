@@ -0,0 +1,37 @@
+package goethclient
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupChain(t *testing.T) {
+	custom := map[string]Chain{
+		"devnet":  {Name: "devnet", ChainID: 1337},
+		"mainnet": {Name: "mainnet", ChainID: EthMainnetId, Tft: common.HexToAddress("0x01")},
+	}
+
+	chain, ok := LookupChain(nil, "BSC")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(BscMainnetId), chain.ChainID)
+
+	chain, ok = LookupChain(nil, "11155111")
+	assert.True(t, ok)
+	assert.Equal(t, "sepolia", chain.Name)
+
+	chain, ok = LookupChain(custom, "1337")
+	assert.True(t, ok)
+	assert.Equal(t, "devnet", chain.Name)
+
+	// registered chains override the known ones
+	chain, ok = LookupChain(custom, "mainnet")
+	assert.True(t, ok)
+	assert.Equal(t, common.HexToAddress("0x01"), chain.Tft)
+
+	_, ok = LookupChain(custom, "unknown")
+	assert.False(t, ok)
+
+	assert.Len(t, ListChains(custom), len(Chains)+1)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"math/big"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -19,56 +21,176 @@ type Client struct {
 	Eth     *ethclient.Client
 	Key     *ecdsa.PrivateKey
 	Address common.Address
+	// Chain the client is connected to
+	Chain Chain
+
+	feeCaps FeeCaps
+
+	// shared by the client and the account views returned by Account
+	*shared
 }
 
-const (
-	EthMainnetId = 1
-	EthGoerliId  = 5
-	GasLimit     = 210000
-)
+// shared state of a client and its accounts
+type shared struct {
+	abisLock sync.RWMutex
+	// abis registered by name
+	abis map[string]abi.ABI
 
-func NewClient(url, secret string) (*Client, error) {
-	eth, err := ethclient.DialContext(context.Background(), url)
-	if err != nil {
-		return nil, err
-	}
+	subscriptionsLock sync.Mutex
+	subscriptions     map[string]*logSubscription
 
-	cl := Client{
-		Url: url,
-		Eth: eth,
-	}
+	wallet wallet
+}
 
+// NewClient connects to the rpc endpoint at the given url. The chain is looked up in the known chains by the chain id
+// of the endpoint.
+func NewClient(url, secret string) (*Client, error) {
+	return NewClientForChain(url, secret, nil)
+}
+
+// NewClientForChain connects to a chain. If url is empty, the default rpc url of the chain is used. If chain is nil,
+// it is looked up in the known chains by the chain id of the endpoint, otherwise the endpoint must serve the chain.
+// A random key is generated if secret is empty.
+func NewClientForChain(url, secret string, chain *Chain) (*Client, error) {
+	var key *ecdsa.PrivateKey
+	var err error
 	if secret == "" {
-		kp, err := GenerateKeypair()
+		key, err = GenerateKeypair()
 		if err != nil {
 			return nil, err
 		}
-		cl.Key = kp
 	} else {
-		kp, err := KeyFromSecret(secret)
+		key, err = KeyFromSecret(secret)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to import key")
 		}
-		cl.Key = kp
 	}
 
-	cl.Address = crypto.PubkeyToAddress(cl.Key.PublicKey)
+	cl, err := dial(url, chain)
+	if err != nil {
+		return nil, err
+	}
 
-	return &cl, nil
+	cl.Key = key
+	cl.Address = cl.wallet.addKey(key, "")
+
+	return cl, nil
 }
 
-func (c *Client) getDefaultTransactionOpts(ctx context.Context) (*bind.TransactOpts, error) {
-	nonce, err := c.Eth.PendingNonceAt(ctx, c.Address)
+// NewClientFromMnemonic connects to a chain like NewClientForChain, loading a BIP-44 wallet from the mnemonic. Accounts
+// are derived at the base path followed by the account index, DefaultBasePath is used if path is empty. The account
+// at index 0 is loaded. A new mnemonic is generated if mnemonic is empty.
+func NewClientFromMnemonic(url, mnemonic, path string, chain *Chain) (*Client, error) {
+	cl, err := dial(url, chain)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get nonce")
+		return nil, err
+	}
+
+	if err := cl.wallet.loadMnemonic(mnemonic, path); err != nil {
+		cl.Eth.Close()
+		return nil, err
+	}
+
+	account, key, err := cl.wallet.derive(0)
+	if err != nil {
+		cl.Eth.Close()
+		return nil, err
 	}
+	cl.Key = key
+	cl.Address = account.Address
 
-	gasPrice, err := c.Eth.SuggestGasPrice(ctx)
+	return cl, nil
+}
+
+// dial connects to the rpc endpoint and resolves the chain it serves
+func dial(url string, chain *Chain) (*Client, error) {
+	if url == "" {
+		if chain == nil || chain.RPCURL == "" {
+			return nil, errors.New("no rpc url given")
+		}
+		url = chain.RPCURL
+	}
+
+	eth, err := ethclient.DialContext(context.Background(), url)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to suggest gas price")
+		return nil, err
 	}
 
-	chainID, err := c.Eth.NetworkID(ctx)
+	chainID, err := eth.ChainID(context.Background())
+	if err != nil {
+		eth.Close()
+		return nil, errors.Wrap(err, "failed to get chainID")
+	}
+
+	cl := Client{
+		Url:    url,
+		Eth:    eth,
+		shared: &shared{},
+	}
+
+	switch {
+	case chain == nil:
+		known, ok := chainByID(Chains, chainID.Uint64())
+		if !ok {
+			known = unknownChain(chainID.Uint64())
+		}
+		cl.Chain = known
+	case chain.ChainID != chainID.Uint64():
+		eth.Close()
+		return nil, errors.Errorf("rpc url serves chain %d, expected chain %s (%d)", chainID, chain.Name, chain.ChainID)
+	default:
+		cl.Chain = *chain
+	}
+
+	return &cl, nil
+}
+
+// Account returns a view of the client which signs with an account of the wallet. The view shares the connection
+// and registered state of the client, it must not be closed. The client itself is returned if from is empty or the
+// loaded address.
+func (c *Client) Account(from string) (*Client, error) {
+	if from == "" {
+		return c, nil
+	}
+	if !common.IsHexAddress(from) {
+		return nil, errors.Errorf("%s is not a valid address", from)
+	}
+
+	address := common.HexToAddress(from)
+	if address == c.Address {
+		return c, nil
+	}
+
+	key, ok := c.wallet.key(address)
+	if !ok {
+		return nil, errors.Errorf("account %s is not in the wallet", from)
+	}
+
+	account := *c
+	account.Key = key
+	account.Address = address
+	return &account, nil
+}
+
+// Close stops all log subscriptions and closes the connection to the rpc endpoint
+func (c *Client) Close() {
+	c.subscriptionsLock.Lock()
+	for id, s := range c.subscriptions {
+		s.cancel()
+		delete(c.subscriptions, id)
+	}
+	c.subscriptionsLock.Unlock()
+
+	c.Eth.Close()
+}
+
+func (c *Client) getDefaultTransactionOpts(ctx context.Context) (*bind.TransactOpts, error) {
+	fees, err := c.EstimateFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := c.Eth.ChainID(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get chainID")
 	}
@@ -78,14 +200,19 @@ func (c *Client) getDefaultTransactionOpts(ctx context.Context) (*bind.TransactO
 		return nil, errors.Wrap(err, "failed to create signer")
 	}
 
-	return &bind.TransactOpts{
-		From:     addr,
-		GasPrice: gasPrice,
-		Signer:   signerFn,
-		GasLimit: GasLimit,
-		Nonce:    big.NewInt(int64(nonce)),
-		Context:  ctx,
-	}, nil
+	opts := &bind.TransactOpts{
+		From:    addr,
+		Signer:  signerFn,
+		Context: ctx,
+	}
+	if fees.BaseFee != nil {
+		opts.GasFeeCap = fees.MaxFeePerGas
+		opts.GasTipCap = fees.MaxPriorityFeePerGas
+	} else {
+		opts.GasPrice = fees.GasPrice
+	}
+
+	return opts, nil
 }
 
 // newSigner creates a signer func using the flag-passed
@@ -96,11 +223,6 @@ func newSigner(privKey *ecdsa.PrivateKey, chainID *big.Int) (bind.SignerFn, comm
 		if address != keyAddr {
 			return nil, errors.New("not authorized to sign this account")
 		}
-		s := types.NewEIP155Signer(chainID)
-		signature, err := crypto.Sign(s.Hash(tx).Bytes(), privKey)
-		if err != nil {
-			return nil, err
-		}
-		return tx.WithSignature(s, signature)
+		return types.SignTx(tx, types.LatestSignerForChainID(chainID), privKey)
 	}, keyAddr, nil
 }
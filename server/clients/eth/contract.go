@@ -0,0 +1,425 @@
+package goethclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	tft "github.com/threefoldfoundation/tft/bridge/stellar/contracts/tokenv1"
+	"github.com/threefoldtech/web3_proxy/server/clients/eth/erc20"
+	"github.com/threefoldtech/web3_proxy/server/clients/eth/erc721"
+	"github.com/threefoldtech/web3_proxy/server/clients/eth/gnosis"
+)
+
+var (
+	bigIntType = reflect.TypeOf(&big.Int{})
+
+	// builtinABIs can be referenced by name without registering them
+	builtinABIs = map[string]string{
+		"erc20":  erc20.Erc20ABI,
+		"erc721": erc721.Erc721ABI,
+		"safe":   gnosis.GnosisABI,
+		"tft":    tft.TokenABI,
+	}
+)
+
+// DeployedContract is the result of a contract deployment
+type DeployedContract struct {
+	// Address the contract will have once the deployment is mined
+	Address string
+	// Hash of the deployment transaction
+	Hash string
+}
+
+// RegisterABI parses and stores an ABI under a name. The name can be used instead of the ABI json in contract calls.
+// Registering an ABI under an existing name replaces it, including the builtin erc20, erc721, safe and tft ABIs.
+func (c *Client) RegisterABI(name string, abiJSON string) error {
+	if name == "" {
+		return errors.New("ABI name is required")
+	}
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return errors.Wrap(err, "invalid ABI")
+	}
+
+	c.abisLock.Lock()
+	defer c.abisLock.Unlock()
+
+	if c.abis == nil {
+		c.abis = map[string]abi.ABI{}
+	}
+	c.abis[name] = parsed
+	return nil
+}
+
+// ListABIs returns the names of the registered and builtin ABIs
+func (c *Client) ListABIs() []string {
+	c.abisLock.RLock()
+	defer c.abisLock.RUnlock()
+
+	names := make([]string, 0, len(c.abis)+len(builtinABIs))
+	for name := range c.abis {
+		names = append(names, name)
+	}
+	for name := range builtinABIs {
+		if _, ok := c.abis[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveABI returns the ABI registered under the given name, a builtin ABI, or parses it as ABI json
+func (c *Client) resolveABI(abiOrName string) (abi.ABI, error) {
+	c.abisLock.RLock()
+	parsed, ok := c.abis[abiOrName]
+	c.abisLock.RUnlock()
+	if ok {
+		return parsed, nil
+	}
+	if builtin, ok := builtinABIs[abiOrName]; ok {
+		abiOrName = builtin
+	}
+
+	if !strings.HasPrefix(strings.TrimSpace(abiOrName), "[") {
+		return abi.ABI{}, fmt.Errorf("no ABI registered with name %s", abiOrName)
+	}
+	parsed, err := abi.JSON(strings.NewReader(abiOrName))
+	if err != nil {
+		return abi.ABI{}, errors.Wrap(err, "invalid ABI")
+	}
+	return parsed, nil
+}
+
+// CallContract executes a read only call of a contract method and returns the decoded outputs. Args are json
+// decoded values, see packArguments. Overloaded methods are named as in the go-ethereum abi package (e.g. name0).
+func (c *Client) CallContract(ctx context.Context, contractAddress string, abiOrName string, method string, args []interface{}) ([]interface{}, error) {
+	parsed, err := c.resolveABI(abiOrName)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := parsed.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method %s not found in ABI", method)
+	}
+
+	packed, err := packArguments(m.Inputs, args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid arguments for %s", method)
+	}
+
+	address := common.HexToAddress(contractAddress)
+	output, err := c.Eth.CallContract(ctx, ethereum.CallMsg{
+		From: c.Address,
+		To:   &address,
+		Data: append(m.ID, packed...),
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to call %s", method)
+	}
+
+	values, err := m.Outputs.Unpack(output)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode output of %s", method)
+	}
+
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, fromABIValue(reflect.ValueOf(value)))
+	}
+	return result, nil
+}
+
+// TransactContract submits a transaction calling a state changing contract method and returns the transaction
+// hash. Value is the amount of wei sent along, gasLimit is estimated if 0.
+func (c *Client) TransactContract(ctx context.Context, contractAddress string, abiOrName string, method string, args []interface{}, value *big.Int, gasLimit uint64) (string, error) {
+	parsed, err := c.resolveABI(abiOrName)
+	if err != nil {
+		return "", err
+	}
+	m, ok := parsed.Methods[method]
+	if !ok {
+		return "", fmt.Errorf("method %s not found in ABI", method)
+	}
+
+	packed, err := packArguments(m.Inputs, args)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid arguments for %s", method)
+	}
+
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	address := common.HexToAddress(contractAddress)
+	tx, err := c.sendNewTransaction(ctx, &address, value, append(m.ID, packed...), gasLimit)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to transact %s", method)
+	}
+
+	return tx.Hash().Hex(), nil
+}
+
+// DeployContract submits a transaction deploying a contract from its hex encoded bytecode, with the given
+// constructor arguments. Value is the amount of wei sent along, gasLimit is estimated if 0.
+func (c *Client) DeployContract(ctx context.Context, abiOrName string, bytecode string, args []interface{}, value *big.Int, gasLimit uint64) (DeployedContract, error) {
+	parsed, err := c.resolveABI(abiOrName)
+	if err != nil {
+		return DeployedContract{}, err
+	}
+
+	code, err := hexutil.Decode(ensureHexPrefix(bytecode))
+	if err != nil || len(code) == 0 {
+		return DeployedContract{}, errors.New("invalid bytecode")
+	}
+
+	packed, err := packArguments(parsed.Constructor.Inputs, args)
+	if err != nil {
+		return DeployedContract{}, errors.Wrap(err, "invalid constructor arguments")
+	}
+
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	tx, err := c.sendNewTransaction(ctx, nil, value, append(code, packed...), gasLimit)
+	if err != nil {
+		return DeployedContract{}, errors.Wrap(err, "failed to deploy contract")
+	}
+
+	return DeployedContract{
+		Address: crypto.CreateAddress(c.Address, tx.Nonce()).Hex(),
+		Hash:    tx.Hash().Hex(),
+	}, nil
+}
+
+// packArguments converts json decoded arguments to the go types of the ABI and packs them. Integers can be given
+// as numbers or decimal or 0x prefixed strings, addresses and bytes as hex strings, arrays as lists and tuples as
+// objects keyed by component name or as lists.
+func packArguments(inputs abi.Arguments, args []interface{}) ([]byte, error) {
+	if len(args) != len(inputs) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(inputs), len(args))
+	}
+
+	values := make([]interface{}, 0, len(args))
+	for i, input := range inputs {
+		value, err := toABIValue(input.Type, args[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "argument %d (%s)", i, input.Name)
+		}
+		values = append(values, value.Interface())
+	}
+
+	return inputs.Pack(values...)
+}
+
+// toABIValue converts a json decoded value to the go type used by the abi package for the given type
+func toABIValue(t abi.Type, v interface{}) (reflect.Value, error) {
+	goType := t.GetType()
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := toBigInt(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if !fitsInteger(n, t.Size, t.T == abi.IntTy) {
+			return reflect.Value{}, fmt.Errorf("value %s out of range for %s", n, t)
+		}
+		if goType == bigIntType {
+			return reflect.ValueOf(n), nil
+		}
+		value := reflect.New(goType).Elem()
+		if t.T == abi.IntTy {
+			value.SetInt(n.Int64())
+		} else {
+			value.SetUint(n.Uint64())
+		}
+		return value, nil
+
+	case abi.BoolTy:
+		b, ok := v.(bool)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a bool, got %v", v)
+		}
+		return reflect.ValueOf(b), nil
+
+	case abi.StringTy:
+		s, ok := v.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a string, got %v", v)
+		}
+		return reflect.ValueOf(s), nil
+
+	case abi.AddressTy:
+		s, ok := v.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("expected an address, got %v", v)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.BytesTy, abi.FixedBytesTy:
+		s, ok := v.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a hex string, got %v", v)
+		}
+		b, err := hexutil.Decode(ensureHexPrefix(s))
+		if err != nil {
+			return reflect.Value{}, errors.Wrapf(err, "invalid hex string %s", s)
+		}
+		if t.T == abi.BytesTy {
+			return reflect.ValueOf(b), nil
+		}
+		if len(b) > t.Size {
+			return reflect.Value{}, fmt.Errorf("%d bytes do not fit in %s", len(b), t)
+		}
+		value := reflect.New(goType).Elem()
+		reflect.Copy(value, reflect.ValueOf(b))
+		return value, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := v.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("expected a list, got %v", v)
+		}
+		var value reflect.Value
+		if t.T == abi.ArrayTy {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d items, got %d", t.Size, len(items))
+			}
+			value = reflect.New(goType).Elem()
+		} else {
+			value = reflect.MakeSlice(goType, len(items), len(items))
+		}
+		for i, item := range items {
+			elem, err := toABIValue(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, errors.Wrapf(err, "item %d", i)
+			}
+			value.Index(i).Set(elem)
+		}
+		return value, nil
+
+	case abi.TupleTy:
+		value := reflect.New(goType).Elem()
+		for i, elemType := range t.TupleElems {
+			var item interface{}
+			switch fields := v.(type) {
+			case map[string]interface{}:
+				item = fields[t.TupleRawNames[i]]
+			case []interface{}:
+				if len(fields) != len(t.TupleElems) {
+					return reflect.Value{}, fmt.Errorf("expected %d tuple components, got %d", len(t.TupleElems), len(fields))
+				}
+				item = fields[i]
+			default:
+				return reflect.Value{}, fmt.Errorf("expected an object or list, got %v", v)
+			}
+			elem, err := toABIValue(*elemType, item)
+			if err != nil {
+				return reflect.Value{}, errors.Wrapf(err, "component %s", t.TupleRawNames[i])
+			}
+			value.Field(i).Set(elem)
+		}
+		return value, nil
+
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// toBigInt converts a json number or a decimal or 0x prefixed string to a big int
+func toBigInt(v interface{}) (*big.Int, error) {
+	var s string
+	switch n := v.(type) {
+	case json.Number:
+		s = n.String()
+	case string:
+		s = n
+	case float64:
+		f := new(big.Float).SetFloat64(n)
+		if !f.IsInt() {
+			return nil, fmt.Errorf("%v is not an integer", n)
+		}
+		i, _ := f.Int(nil)
+		return i, nil
+	default:
+		return nil, fmt.Errorf("expected an integer, got %v", v)
+	}
+
+	i, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an integer", s)
+	}
+	return i, nil
+}
+
+// fitsInteger checks if n is in the range of a signed or unsigned integer of the given amount of bits
+func fitsInteger(n *big.Int, bits int, signed bool) bool {
+	if !signed {
+		return n.Sign() >= 0 && n.BitLen() <= bits
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+	// -2^(bits-1) <= n < 2^(bits-1)
+	return n.Cmp(new(big.Int).Neg(limit)) >= 0 && n.Cmp(limit) < 0
+}
+
+// fromABIValue converts a value decoded by the abi package to a json friendly value. Big integers are returned as
+// decimal strings, addresses and bytes as hex strings and tuples as objects.
+func fromABIValue(v reflect.Value) interface{} {
+	switch value := v.Interface().(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return value.Hex()
+	case []byte:
+		return hexutil.Encode(value)
+	}
+
+	switch v.Kind() {
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, fromABIValue(v.Index(i)))
+		}
+		return items
+	case reflect.Struct:
+		fields := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name := field.Tag.Get("json")
+			if name == "" {
+				name = field.Name
+			}
+			fields[name] = fromABIValue(v.Field(i))
+		}
+		return fields
+	default:
+		return v.Interface()
+	}
+}
+
+func ensureHexPrefix(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s
+	}
+	return "0x" + s
+}
@@ -0,0 +1,79 @@
+package goethclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testABI = `[
+	{"type":"function","name":"transfer","stateMutability":"nonpayable",
+	 "inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],
+	 "outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"submit","stateMutability":"nonpayable",
+	 "inputs":[{"name":"order","type":"tuple","components":[
+		{"name":"id","type":"uint64"},{"name":"tag","type":"bytes4"},{"name":"owners","type":"address[]"}]},
+	 {"name":"delta","type":"int8"}],
+	 "outputs":[]}
+]`
+
+func TestPackArguments(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testABI))
+	require.NoError(t, err)
+
+	t.Run("simple", func(t *testing.T) {
+		args := []interface{}{"0x00000000000000000000000000000000000000ff", json.Number("1000000000000000000000")}
+		packed, err := packArguments(parsed.Methods["transfer"].Inputs, args)
+		require.NoError(t, err)
+
+		values, err := parsed.Methods["transfer"].Inputs.Unpack(packed)
+		require.NoError(t, err)
+		assert.Equal(t, "0x00000000000000000000000000000000000000ff", fromABIValue(reflect.ValueOf(values[0])))
+		assert.Equal(t, "1000000000000000000000", fromABIValue(reflect.ValueOf(values[1])))
+	})
+
+	t.Run("tuple", func(t *testing.T) {
+		order := map[string]interface{}{
+			"id":     json.Number("7"),
+			"tag":    "0xdeadbeef",
+			"owners": []interface{}{"0x00000000000000000000000000000000000000ff"},
+		}
+		packed, err := packArguments(parsed.Methods["submit"].Inputs, []interface{}{order, "-128"})
+		require.NoError(t, err)
+
+		values, err := parsed.Methods["submit"].Inputs.Unpack(packed)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"id":     uint64(7),
+			"tag":    "0xdeadbeef",
+			"owners": []interface{}{"0x00000000000000000000000000000000000000ff"},
+		}, fromABIValue(reflect.ValueOf(values[0])))
+		assert.Equal(t, int8(-128), values[1])
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := packArguments(parsed.Methods["transfer"].Inputs, []interface{}{"0xff"})
+		assert.Error(t, err)
+		_, err = packArguments(parsed.Methods["transfer"].Inputs, []interface{}{"not an address", "1"})
+		assert.Error(t, err)
+		_, err = packArguments(parsed.Methods["transfer"].Inputs, []interface{}{"0x00000000000000000000000000000000000000ff", "-1"})
+		assert.Error(t, err)
+		order := []interface{}{"1", "0x01", []interface{}{}}
+		_, err = packArguments(parsed.Methods["submit"].Inputs, []interface{}{order, "128"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFitsInteger(t *testing.T) {
+	assert.True(t, fitsInteger(big.NewInt(255), 8, false))
+	assert.False(t, fitsInteger(big.NewInt(256), 8, false))
+	assert.True(t, fitsInteger(big.NewInt(-128), 8, true))
+	assert.False(t, fitsInteger(big.NewInt(128), 8, true))
+	assert.False(t, fitsInteger(big.NewInt(-129), 8, true))
+}
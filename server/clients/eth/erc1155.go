@@ -0,0 +1,106 @@
+package goethclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var erc1155ABI = mustParseABI(`[
+	{"name":"balanceOf","type":"function","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"name":"balanceOfBatch","type":"function","stateMutability":"view","inputs":[{"name":"accounts","type":"address[]"},{"name":"ids","type":"uint256[]"}],"outputs":[{"name":"","type":"uint256[]"}]},
+	{"name":"isApprovedForAll","type":"function","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"operator","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+	{"name":"setApprovalForAll","type":"function","stateMutability":"nonpayable","inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]},
+	{"name":"safeTransferFrom","type":"function","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"name":"safeBatchTransferFrom","type":"function","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"ids","type":"uint256[]"},{"name":"amounts","type":"uint256[]"},{"name":"data","type":"bytes"}],"outputs":[]}
+]`)
+
+// Erc1155BalanceOf returns the amount of a token owned by an address
+func (c *Client) Erc1155BalanceOf(ctx context.Context, contractAddress, owner string, id *big.Int) (*big.Int, error) {
+	out, err := c.callABI(ctx, erc1155ABI, common.HexToAddress(contractAddress), "balanceOf", common.HexToAddress(owner), id)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+// Erc1155BalanceOfBatch returns the amounts of tokens owned by addresses, the balance at every index is the balance
+// of the owner at that index for the id at that index
+func (c *Client) Erc1155BalanceOfBatch(ctx context.Context, contractAddress string, owners []string, ids []*big.Int) ([]*big.Int, error) {
+	if len(owners) != len(ids) {
+		return nil, fmt.Errorf("got %d owners for %d ids", len(owners), len(ids))
+	}
+	accounts := make([]common.Address, 0, len(owners))
+	for _, owner := range owners {
+		accounts = append(accounts, common.HexToAddress(owner))
+	}
+
+	out, err := c.callABI(ctx, erc1155ABI, common.HexToAddress(contractAddress), "balanceOfBatch", accounts, ids)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].([]*big.Int), nil
+}
+
+// Erc1155IsApprovedForAll returns whether an operator can transfer all tokens of the owner
+func (c *Client) Erc1155IsApprovedForAll(ctx context.Context, contractAddress, owner, operator string) (bool, error) {
+	out, err := c.callABI(ctx, erc1155ABI, common.HexToAddress(contractAddress), "isApprovedForAll", common.HexToAddress(owner), common.HexToAddress(operator))
+	if err != nil {
+		return false, err
+	}
+	return out[0].(bool), nil
+}
+
+// Erc1155SetApprovalForAll allows or disallows an operator to transfer all tokens of the loaded address
+func (c *Client) Erc1155SetApprovalForAll(ctx context.Context, contractAddress, operator string, approved bool) (string, error) {
+	operatorAddress, err := parseAddress(operator)
+	if err != nil {
+		return "", err
+	}
+	return c.sendErc1155(ctx, contractAddress, "setApprovalForAll", operatorAddress, approved)
+}
+
+// Erc1155Transfer transfers an amount of a token
+func (c *Client) Erc1155Transfer(ctx context.Context, contractAddress, from, to string, id, amount *big.Int, data []byte) (string, error) {
+	addresses, err := parseAddresses(from, to)
+	if err != nil {
+		return "", err
+	}
+	return c.sendErc1155(ctx, contractAddress, "safeTransferFrom", addresses[0], addresses[1], id, amount, data)
+}
+
+// Erc1155BatchTransfer transfers amounts of multiple tokens in one transaction
+func (c *Client) Erc1155BatchTransfer(ctx context.Context, contractAddress, from, to string, ids, amounts []*big.Int, data []byte) (string, error) {
+	if len(ids) != len(amounts) {
+		return "", fmt.Errorf("got %d amounts for %d ids", len(amounts), len(ids))
+	}
+	addresses, err := parseAddresses(from, to)
+	if err != nil {
+		return "", err
+	}
+	return c.sendErc1155(ctx, contractAddress, "safeBatchTransferFrom", addresses[0], addresses[1], ids, amounts, data)
+}
+
+func (c *Client) sendErc1155(ctx context.Context, contractAddress, method string, args ...interface{}) (string, error) {
+	address, err := parseAddress(contractAddress)
+	if err != nil {
+		return "", err
+	}
+	data, err := erc1155ABI.Pack(method, args...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to encode %s", method)
+	}
+
+	tx, err := c.sendNewTransaction(ctx, &address, nil, data, 0)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debug().Msgf("erc1155 %s tx submitted: %s", method, tx.Hash().Hex())
+
+	return tx.Hash().Hex(), nil
+}
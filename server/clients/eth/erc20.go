@@ -1,110 +1,440 @@
 package goethclient
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"math/big"
+	"time"
 
-	"github.com/daoleno/uniswapv3-sdk/examples/helper"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/threefoldtech/web3_proxy/server/clients/eth/erc20"
 )
 
-func (c *Client) GetTokenBalance(contractAddress string) (string, error) {
-	token, err := erc20.NewErc20(common.HexToAddress(contractAddress), c.Eth)
+var (
+	// erc20MetadataABI has the optional metadata methods of erc20 tokens and the EIP-2612 permit methods, which are
+	// not part of the IERC20 binding
+	erc20MetadataABI = mustParseABI(`[
+		{"name":"name","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+		{"name":"symbol","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+		{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+		{"name":"nonces","type":"function","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"name":"DOMAIN_SEPARATOR","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+		{"name":"permit","type":"function","stateMutability":"nonpayable","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"},{"name":"value","type":"uint256"},{"name":"deadline","type":"uint256"},{"name":"v","type":"uint8"},{"name":"r","type":"bytes32"},{"name":"s","type":"bytes32"}],"outputs":[]}
+	]`)
+	// erc20LegacyMetadataABI is used for tokens returning their name and symbol as bytes32, like MKR
+	erc20LegacyMetadataABI = mustParseABI(`[
+		{"name":"name","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]},
+		{"name":"symbol","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]}
+	]`)
+
+	permitTypeHash = crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+	permitArgs     = abi.Arguments{
+		{Type: mustNewABIType("bytes32")},
+		{Type: mustNewABIType("address")},
+		{Type: mustNewABIType("address")},
+		{Type: mustNewABIType("uint256")},
+		{Type: mustNewABIType("uint256")},
+		{Type: mustNewABIType("uint256")},
+	}
+)
+
+type (
+	// TokenInfo is the metadata of an erc20 token. Amounts are expressed in tokens, using the decimals of the token.
+	TokenInfo struct {
+		Address     string
+		Name        string
+		Symbol      string
+		Decimals    uint8
+		TotalSupply string
+	}
+
+	// Permit is an EIP-2612 approval signed by the owner of the tokens, which anyone can submit
+	Permit struct {
+		Token   string
+		Owner   string
+		Spender string
+		// Value in the smallest unit of the token
+		Value string
+		Nonce string
+		// Deadline as unix timestamp
+		Deadline int64
+		V        uint8
+		R        string
+		S        string
+	}
+)
+
+// TokenInfo returns the metadata of an erc20 token. Name and symbol are empty if the token does not implement them.
+func (c *Client) TokenInfo(ctx context.Context, contractAddress string) (TokenInfo, error) {
+	address, err := parseAddress(contractAddress)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	token, err := erc20.NewErc20(address, c.Eth)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+
+	decimals, err := c.tokenDecimals(ctx, address)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	supply, err := token.TotalSupply(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return TokenInfo{}, errors.Wrap(err, "failed to get total supply")
+	}
+
+	return TokenInfo{
+		Address:     address.Hex(),
+		Name:        c.tokenText(ctx, address, "name"),
+		Symbol:      c.tokenText(ctx, address, "symbol"),
+		Decimals:    decimals,
+		TotalSupply: formatUnits(supply, decimals),
+	}, nil
+}
+
+// GetTokenBalance returns the balance of the loaded address
+func (c *Client) GetTokenBalance(ctx context.Context, contractAddress string) (string, error) {
+	return c.TokenBalanceOf(ctx, contractAddress, c.Address.Hex())
+}
+
+// TokenBalanceOf returns the balance of an address, in tokens
+func (c *Client) TokenBalanceOf(ctx context.Context, contractAddress, owner string) (string, error) {
+	address, err := parseAddress(contractAddress)
+	if err != nil {
+		return "", err
+	}
+	ownerAddress, err := parseAddress(owner)
+	if err != nil {
+		return "", err
+	}
+	token, err := erc20.NewErc20(address, c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	b, err := token.BalanceOf(&bind.CallOpts{}, c.Address)
+	decimals, err := c.tokenDecimals(ctx, address)
+	if err != nil {
+		return "", err
+	}
+	b, err := token.BalanceOf(&bind.CallOpts{Context: ctx}, ownerAddress)
 	if err != nil {
 		return "", err
 	}
 
-	return WeiToString(b), nil
+	return formatUnits(b, decimals), nil
 }
 
-func (c *Client) TransferTokens(ctx context.Context, contractAddress common.Address, target string, amount string) (string, error) {
-	token, err := erc20.NewErc20(contractAddress, c.Eth)
+// TokenAllowance returns the amount of tokens the spender can still transfer from the owner
+func (c *Client) TokenAllowance(ctx context.Context, contractAddress, owner, spender string) (string, error) {
+	address, err := parseAddress(contractAddress)
+	if err != nil {
+		return "", err
+	}
+	ownerAddress, err := parseAddress(owner)
+	if err != nil {
+		return "", err
+	}
+	spenderAddress, err := parseAddress(spender)
+	if err != nil {
+		return "", err
+	}
+	token, err := erc20.NewErc20(address, c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	decimals, err := c.tokenDecimals(ctx, address)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get default transaction opts")
+		return "", err
+	}
+	allowance, err := token.Allowance(&bind.CallOpts{Context: ctx}, ownerAddress, spenderAddress)
+	if err != nil {
+		return "", err
 	}
 
-	amountIn := helper.FloatStringToBigInt(amount, EthDecimals)
-	tx, err := token.Transfer(opts, common.HexToAddress(target), amountIn)
+	return formatUnits(allowance, decimals), nil
+}
+
+func (c *Client) TransferTokens(ctx context.Context, contractAddress, target string, amount string) (string, error) {
+	address, err := parseAddress(contractAddress)
+	if err != nil {
+		return "", err
+	}
+	targetAddress, err := parseAddress(target)
+	if err != nil {
+		return "", err
+	}
+	token, err := erc20.NewErc20(address, c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	r, err := bind.WaitMined(ctx, c.Eth, tx)
+	amountIn, err := c.tokenUnits(ctx, address, amount)
+	if err != nil {
+		return "", err
+	}
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.Transfer(opts, targetAddress, amountIn)
+	})
 	if err != nil {
-		log.Err(err).Msg("failed to wait for tft approval")
 		return "", err
 	}
 
-	log.Debug().Msgf("Approve spend tx mined: %s, block %d, gas: %d, status: %d", tx.Hash().Hex(), r.BlockNumber, r.GasUsed, r.Status)
+	log.Debug().Msgf("Token transfer tx submitted: %s", tx.Hash().Hex())
 
 	return tx.Hash().Hex(), nil
 }
 
 func (c *Client) ApproveTokenSpending(ctx context.Context, contractAddress, spender string, amount string) (string, error) {
-	token, err := erc20.NewErc20(common.HexToAddress(contractAddress), c.Eth)
+	address, err := parseAddress(contractAddress)
 	if err != nil {
 		return "", err
 	}
-
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	spenderAddress, err := parseAddress(spender)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get default transaction opts")
+		return "", err
 	}
-
-	amountIn := helper.FloatStringToBigInt(amount, EthDecimals)
-	tx, err := token.Approve(opts, common.HexToAddress(spender), amountIn)
+	token, err := erc20.NewErc20(address, c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	r, err := bind.WaitMined(ctx, c.Eth, tx)
+	amountIn, err := c.tokenUnits(ctx, address, amount)
+	if err != nil {
+		return "", err
+	}
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.Approve(opts, spenderAddress, amountIn)
+	})
 	if err != nil {
-		log.Err(err).Msg("failed to wait for tft approval")
 		return "", err
 	}
 
-	log.Debug().Msgf("Approve spend tx mined: %s, block %d, gas: %d, status: %d", tx.Hash().Hex(), r.BlockNumber, r.GasUsed, r.Status)
+	log.Debug().Msgf("Approve spend tx submitted: %s", tx.Hash().Hex())
 
 	return tx.Hash().Hex(), nil
 }
 
 func (c *Client) TransferFromTokens(ctx context.Context, contractAddress, from, to string, amount string) (string, error) {
-	token, err := erc20.NewErc20(common.HexToAddress(contractAddress), c.Eth)
+	address, err := parseAddress(contractAddress)
 	if err != nil {
 		return "", err
 	}
-
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	fromAddress, err := parseAddress(from)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get default transaction opts")
+		return "", err
+	}
+	toAddress, err := parseAddress(to)
+	if err != nil {
+		return "", err
+	}
+	token, err := erc20.NewErc20(address, c.Eth)
+	if err != nil {
+		return "", err
 	}
 
-	amountIn := helper.FloatStringToBigInt(amount, EthDecimals)
-	tx, err := token.TransferFrom(opts, common.HexToAddress(from), common.HexToAddress(to), amountIn)
+	amountIn, err := c.tokenUnits(ctx, address, amount)
 	if err != nil {
 		return "", err
 	}
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return token.TransferFrom(opts, fromAddress, toAddress, amountIn)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	log.Debug().Msgf("Token transfer from tx submitted: %s", tx.Hash().Hex())
+
+	return tx.Hash().Hex(), nil
+}
+
+// SignPermit signs an EIP-2612 permit with the loaded key, allowing the spender to transfer an amount of tokens
+// until the deadline. The permit is not submitted, it can be handed to the spender or submitted with
+// ApproveWithPermit.
+func (c *Client) SignPermit(ctx context.Context, contractAddress, spender, amount string, validity time.Duration) (Permit, error) {
+	address, err := parseAddress(contractAddress)
+	if err != nil {
+		return Permit{}, err
+	}
+	spenderAddress, err := parseAddress(spender)
+	if err != nil {
+		return Permit{}, err
+	}
+
+	value, err := c.tokenUnits(ctx, address, amount)
+	if err != nil {
+		return Permit{}, err
+	}
+
+	out, err := c.callABI(ctx, erc20MetadataABI, address, "nonces", c.Address)
+	if err != nil {
+		return Permit{}, errors.Wrap(err, "failed to get permit nonce, the token might not support permits")
+	}
+	nonce := out[0].(*big.Int)
+
+	out, err = c.callABI(ctx, erc20MetadataABI, address, "DOMAIN_SEPARATOR")
+	if err != nil {
+		return Permit{}, errors.Wrap(err, "failed to get permit domain, the token might not support permits")
+	}
+	domainSeparator := common.Hash(out[0].([32]byte))
+
+	deadline := time.Now().Add(validity).Unix()
+	digest, err := permitDigest(domainSeparator, c.Address, spenderAddress, value, nonce, big.NewInt(deadline))
+	if err != nil {
+		return Permit{}, err
+	}
+
+	signature, err := crypto.Sign(digest[:], c.Key)
+	if err != nil {
+		return Permit{}, errors.Wrap(err, "failed to sign permit")
+	}
+
+	return Permit{
+		Token:    address.Hex(),
+		Owner:    c.Address.Hex(),
+		Spender:  spenderAddress.Hex(),
+		Value:    value.String(),
+		Nonce:    nonce.String(),
+		Deadline: deadline,
+		V:        signature[crypto.RecoveryIDOffset] + 27,
+		R:        hexutil.Encode(signature[:32]),
+		S:        hexutil.Encode(signature[32:64]),
+	}, nil
+}
 
-	r, err := bind.WaitMined(ctx, c.Eth, tx)
+// ApproveWithPermit submits a signed permit, setting the allowance of the spender without a transaction of the
+// owner. The loaded address pays for the transaction. The transaction hash is returned.
+func (c *Client) ApproveWithPermit(ctx context.Context, permit Permit) (string, error) {
+	value, ok := new(big.Int).SetString(permit.Value, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid permit value %s", permit.Value)
+	}
+	if time.Now().Unix() > permit.Deadline {
+		return "", errors.New("permit is expired")
+	}
+	r, err := hexutil.Decode(permit.R)
+	if err != nil || len(r) != 32 {
+		return "", errors.New("invalid permit signature r")
+	}
+	s, err := hexutil.Decode(permit.S)
+	if err != nil || len(s) != 32 {
+		return "", errors.New("invalid permit signature s")
+	}
+
+	owner, err := parseAddress(permit.Owner)
+	if err != nil {
+		return "", err
+	}
+	spender, err := parseAddress(permit.Spender)
+	if err != nil {
+		return "", err
+	}
+	token, err := parseAddress(permit.Token)
 	if err != nil {
-		log.Err(err).Msg("failed to wait for tft approval")
 		return "", err
 	}
 
-	log.Debug().Msgf("Approve spend tx mined: %s, block %d, gas: %d, status: %d", tx.Hash().Hex(), r.BlockNumber, r.GasUsed, r.Status)
+	data, err := erc20MetadataABI.Pack("permit",
+		owner,
+		spender,
+		value,
+		big.NewInt(permit.Deadline),
+		permit.V,
+		common.BytesToHash(r),
+		common.BytesToHash(s),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode permit")
+	}
+
+	tx, err := c.sendNewTransaction(ctx, &token, nil, data, 0)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to submit permit")
+	}
+
+	log.Debug().Msgf("Permit tx submitted: %s", tx.Hash().Hex())
 
 	return tx.Hash().Hex(), nil
 }
+
+// tokenUnits converts an amount of tokens to the smallest unit of the token
+func (c *Client) tokenUnits(ctx context.Context, contractAddress common.Address, amount string) (*big.Int, error) {
+	decimals, err := c.tokenDecimals(ctx, contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	return parseUnits(amount, decimals)
+}
+
+// tokenDecimals returns the decimals of an erc20 token. The method is optional in the standard, and not part of the
+// IERC20 binding.
+func (c *Client) tokenDecimals(ctx context.Context, contractAddress common.Address) (uint8, error) {
+	out, err := c.callABI(ctx, erc20MetadataABI, contractAddress, "decimals")
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get decimals of token %s", contractAddress.Hex())
+	}
+	return out[0].(uint8), nil
+}
+
+// tokenText returns the name or symbol of a token, or an empty string if the token does not implement it
+func (c *Client) tokenText(ctx context.Context, contractAddress common.Address, method string) string {
+	if out, err := c.callABI(ctx, erc20MetadataABI, contractAddress, method); err == nil {
+		return out[0].(string)
+	}
+	if out, err := c.callABI(ctx, erc20LegacyMetadataABI, contractAddress, method); err == nil {
+		text := out[0].([32]byte)
+		return string(bytes.TrimRight(text[:], "\x00"))
+	}
+	log.Debug().Msgf("token %s does not implement %s", contractAddress.Hex(), method)
+	return ""
+}
+
+// permitDigest returns the EIP-712 digest of a permit for a token with the given domain separator
+func permitDigest(domainSeparator common.Hash, owner, spender common.Address, value, nonce, deadline *big.Int) (common.Hash, error) {
+	encoded, err := permitArgs.Pack([32]byte(permitTypeHash), owner, spender, value, nonce, deadline)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to encode permit")
+	}
+	structHash := crypto.Keccak256(encoded)
+
+	return crypto.Keccak256Hash([]byte("\x19\x01"), domainSeparator[:], structHash), nil
+}
+
+// parseAddress converts a hex address, returning an error instead of the zero address if it is invalid
+func parseAddress(address string) (common.Address, error) {
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("invalid address %s", address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+// parseAddresses converts hex addresses, returning an error for the first invalid one
+func parseAddresses(addresses ...string) ([]common.Address, error) {
+	parsed := make([]common.Address, 0, len(addresses))
+	for _, address := range addresses {
+		a, err := parseAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, a)
+	}
+	return parsed, nil
+}
+
+func mustNewABIType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
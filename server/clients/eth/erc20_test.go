@@ -0,0 +1,90 @@
+package goethclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermitDigest(t *testing.T) {
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	spender := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	token := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Token",
+			Version:           "1",
+			ChainId:           math.NewHexOrDecimal256(1),
+			VerifyingContract: token.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner":    owner.Hex(),
+			"spender":  spender.Hex(),
+			"value":    "1000",
+			"nonce":    "3",
+			"deadline": "1700000000",
+		},
+	}
+	expected, _, err := apitypes.TypedDataAndHash(typedData)
+	require.NoError(t, err)
+
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	require.NoError(t, err)
+
+	digest, err := permitDigest(common.BytesToHash(domainSeparator), owner, spender, big.NewInt(1000), big.NewInt(3), big.NewInt(1700000000))
+	require.NoError(t, err)
+	assert.Equal(t, common.BytesToHash(expected), digest)
+}
+
+func TestParseAddress(t *testing.T) {
+	address, err := parseAddress("0x1111111111111111111111111111111111111111")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x1111111111111111111111111111111111111111"), address)
+
+	// typos must not silently become the zero address
+	_, err = parseAddress("0x11111111111111111111111111111111111111")
+	assert.Error(t, err)
+	_, err = parseAddress("")
+	assert.Error(t, err)
+}
+
+func TestTokenWritesRejectInvalidAddresses(t *testing.T) {
+	c := &Client{}
+	token := "0x1111111111111111111111111111111111111111"
+	typo := "0x11111111111111111111111111111111111111"
+
+	// addresses are validated before anything is sent
+	_, err := c.TransferTokens(context.Background(), token, typo, "1")
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.TransferTokens(context.Background(), typo, token, "1")
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.ApproveTokenSpending(context.Background(), token, typo, "1")
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.TransferFromTokens(context.Background(), token, typo, token, "1")
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.TransferFromTokens(context.Background(), token, token, "", "1")
+	assert.ErrorContains(t, err, "invalid address")
+}
@@ -2,31 +2,84 @@ package goethclient
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
 	"github.com/threefoldtech/web3_proxy/server/clients/eth/erc721"
+	"github.com/threefoldtech/web3_proxy/server/clients/safehttp"
 )
 
-// GetFungibleBalance returns the balance of the given address for the given fungible token contract
-func (c *Client) GetFungibleBalance(contractAddress, target string) (*big.Int, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+const (
+	// ipfsGateway is used for ipfs content which can't be fetched through the ipfs peer of the proxy
+	ipfsGateway = "https://ipfs.io/ipfs/"
+	// maximum size of a metadata document
+	maxMetadataSize = 1 << 20
+	// metadataFetchTimeout bounds fetching a metadata document, through http or ipfs
+	metadataFetchTimeout = 30 * time.Second
+)
+
+var (
+	// metadataClient fetches metadata documents, token uris are set by the contract so internal addresses are refused
+	metadataClient = safehttp.NewClient(metadataFetchTimeout)
+
+	// erc721EnumerableInterface is the ERC-165 interface id of ERC721Enumerable
+	erc721EnumerableInterface = [4]byte{0x78, 0x0e, 0x9d, 0x63}
+
+	// erc721ExtensionsABI has the metadata and enumerable extensions, which are not part of the IERC721 binding
+	erc721ExtensionsABI = mustParseABI(`[
+		{"name":"tokenURI","type":"function","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+		{"name":"tokenOfOwnerByIndex","type":"function","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"index","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]}
+	]`)
+
+	erc721TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+type (
+	// IPFSFetcher returns the content of an ipfs cid, failing if it is larger than maxSize
+	IPFSFetcher func(ctx context.Context, contentID string, maxSize int64) ([]byte, error)
+
+	// NftMetadata is the metadata document of a token, as defined by the ERC-721 metadata JSON schema
+	NftMetadata struct {
+		TokenURI    string
+		Name        string
+		Description string
+		Image       string
+		Attributes  []interface{}
+		// Raw is the complete metadata document
+		Raw map[string]interface{}
+	}
+)
+
+// NftBalanceOf returns the amount of tokens owned by an address
+func (c *Client) NftBalanceOf(ctx context.Context, contractAddress, owner string) (*big.Int, error) {
+	nft, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
 	if err != nil {
 		return nil, err
 	}
 
-	return fungible.BalanceOf(&bind.CallOpts{}, common.HexToAddress(target))
+	return nft.BalanceOf(&bind.CallOpts{Context: ctx}, common.HexToAddress(owner))
 }
 
-// ownerOfFungible returns the owner of the given fungible token
-func (c *Client) OwnerOfFungible(contractAddress string, token int64) (string, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+// NftOwnerOf returns the owner of a token
+func (c *Client) NftOwnerOf(ctx context.Context, contractAddress string, tokenID *big.Int) (string, error) {
+	nft, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	owner, err := fungible.OwnerOf(&bind.CallOpts{}, big.NewInt(token))
+	owner, err := nft.OwnerOf(&bind.CallOpts{Context: ctx}, tokenID)
 	if err != nil {
 		return "", err
 	}
@@ -34,19 +87,20 @@ func (c *Client) OwnerOfFungible(contractAddress string, token int64) (string, e
 	return owner.Hex(), nil
 }
 
-// SafeTransferFungible transfers a fungible token from the given address to the given target address
-func (c *Client) SafeTransferFungible(ctx context.Context, contractAddress, from, to string, tokenId int64) (string, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+// NftSafeTransfer transfers a token, checking that a contract receiving it can handle tokens
+func (c *Client) NftSafeTransfer(ctx context.Context, contractAddress, from, to string, tokenID *big.Int) (string, error) {
+	addresses, err := parseAddresses(contractAddress, from, to)
 	if err != nil {
 		return "", err
 	}
-
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	nft, err := erc721.NewErc721(addresses[0], c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	tx, err := fungible.SafeTransferFrom(opts, common.HexToAddress(from), common.HexToAddress(to), big.NewInt(tokenId))
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nft.SafeTransferFrom(opts, addresses[1], addresses[2], tokenID)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -54,19 +108,20 @@ func (c *Client) SafeTransferFungible(ctx context.Context, contractAddress, from
 	return tx.Hash().Hex(), nil
 }
 
-// Transfer transfers the given fungible token from the given address to the given target address
-func (c *Client) TransferFungible(ctx context.Context, contractAddress, from, to string, tokenId int64) (string, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+// NftTransfer transfers a token without checking the receiver
+func (c *Client) NftTransfer(ctx context.Context, contractAddress, from, to string, tokenID *big.Int) (string, error) {
+	addresses, err := parseAddresses(contractAddress, from, to)
 	if err != nil {
 		return "", err
 	}
-
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	nft, err := erc721.NewErc721(addresses[0], c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	tx, err := fungible.TransferFrom(opts, common.HexToAddress(from), common.HexToAddress(to), big.NewInt(tokenId))
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nft.TransferFrom(opts, addresses[1], addresses[2], tokenID)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -74,19 +129,20 @@ func (c *Client) TransferFungible(ctx context.Context, contractAddress, from, to
 	return tx.Hash().Hex(), nil
 }
 
-// SetFungibleApproval approves the given address to spend the given amount of the given fungible token
-func (c *Client) SetFungibleApproval(ctx context.Context, contractAddress, from, to string, amount int64) (string, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+// NftApprove allows an address to transfer a token of the loaded address
+func (c *Client) NftApprove(ctx context.Context, contractAddress, to string, tokenID *big.Int) (string, error) {
+	addresses, err := parseAddresses(contractAddress, to)
 	if err != nil {
 		return "", err
 	}
-
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	nft, err := erc721.NewErc721(addresses[0], c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	tx, err := fungible.Approve(opts, common.HexToAddress(to), big.NewInt(amount))
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nft.Approve(opts, addresses[1], tokenID)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -94,19 +150,35 @@ func (c *Client) SetFungibleApproval(ctx context.Context, contractAddress, from,
 	return tx.Hash().Hex(), nil
 }
 
-// SetFungibleApprovalForAll approves the given address to spend all the given fungible token
-func (c *Client) SetFungibleApprovalForAll(ctx context.Context, contractAddress, from, to string, approved bool) (string, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+// NftGetApproved returns the address allowed to transfer a token, the zero address if there is none
+func (c *Client) NftGetApproved(ctx context.Context, contractAddress string, tokenID *big.Int) (string, error) {
+	nft, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+	if err != nil {
+		return "", err
+	}
+
+	approved, err := nft.GetApproved(&bind.CallOpts{Context: ctx}, tokenID)
 	if err != nil {
 		return "", err
 	}
 
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	return approved.Hex(), nil
+}
+
+// NftSetApprovalForAll allows or disallows an operator to transfer all tokens of the loaded address
+func (c *Client) NftSetApprovalForAll(ctx context.Context, contractAddress, operator string, approved bool) (string, error) {
+	addresses, err := parseAddresses(contractAddress, operator)
+	if err != nil {
+		return "", err
+	}
+	nft, err := erc721.NewErc721(addresses[0], c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	tx, err := fungible.SetApprovalForAll(opts, common.HexToAddress(to), approved)
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return nft.SetApprovalForAll(opts, addresses[1], approved)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -114,22 +186,159 @@ func (c *Client) SetFungibleApprovalForAll(ctx context.Context, contractAddress,
 	return tx.Hash().Hex(), nil
 }
 
-// GetApprovalForFungible returns the approval status of the given address for the given fungible token
-func (c *Client) GetApprovalForFungible(contractAddress, owner, operator string) (bool, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+// NftIsApprovedForAll returns whether an operator can transfer all tokens of the owner
+func (c *Client) NftIsApprovedForAll(ctx context.Context, contractAddress, owner, operator string) (bool, error) {
+	nft, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
 	if err != nil {
 		return false, err
 	}
 
-	return fungible.IsApprovedForAll(&bind.CallOpts{}, common.HexToAddress(owner), common.HexToAddress(operator))
+	return nft.IsApprovedForAll(&bind.CallOpts{Context: ctx}, common.HexToAddress(owner), common.HexToAddress(operator))
 }
 
-// GetApprovalForAllFungible returns the approval status of the given address for the given fungible token
-func (c *Client) GetApprovalForAllFungible(contractAddress, owner, operator string) (bool, error) {
-	fungible, err := erc721.NewErc721(common.HexToAddress(contractAddress), c.Eth)
+// NftTokensOf returns the ids of the tokens owned by an address. Contracts implementing ERC721Enumerable are
+// queried directly, for other contracts the tokens are found in the transfer logs, which not all rpc endpoints
+// allow to query over the full chain.
+func (c *Client) NftTokensOf(ctx context.Context, contractAddress, owner string) ([]*big.Int, error) {
+	address := common.HexToAddress(contractAddress)
+	ownerAddress := common.HexToAddress(owner)
+	nft, err := erc721.NewErc721(address, c.Eth)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	opts := &bind.CallOpts{Context: ctx}
 
-	return fungible.IsApprovedForAll(&bind.CallOpts{}, common.HexToAddress(owner), common.HexToAddress(operator))
+	enumerable, err := nft.SupportsInterface(opts, erc721EnumerableInterface)
+	if err != nil {
+		// contracts without ERC-165 revert
+		enumerable = false
+	}
+	if !enumerable {
+		return c.nftTokensFromLogs(ctx, nft, address, ownerAddress)
+	}
+
+	balance, err := nft.BalanceOf(opts, ownerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*big.Int, 0, balance.Int64())
+	for i := int64(0); i < balance.Int64(); i++ {
+		out, err := c.callABI(ctx, erc721ExtensionsABI, address, "tokenOfOwnerByIndex", ownerAddress, big.NewInt(i))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get token %d of owner", i)
+		}
+		tokens = append(tokens, out[0].(*big.Int))
+	}
+
+	return tokens, nil
+}
+
+// nftTokensFromLogs finds the tokens transferred to the owner in the transfer logs, and keeps those it still owns
+func (c *Client) nftTokensFromLogs(ctx context.Context, nft *erc721.Erc721, address, owner common.Address) ([]*big.Int, error) {
+	logs, err := c.Eth.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: big.NewInt(0),
+		Addresses: []common.Address{address},
+		Topics:    [][]common.Hash{{erc721TransferTopic}, nil, {common.BytesToHash(owner.Bytes())}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "contract is not enumerable and its transfer logs could not be fetched")
+	}
+
+	seen := map[string]bool{}
+	tokens := []*big.Int{}
+	for _, l := range logs {
+		// erc20 transfers have the same signature but a non indexed amount
+		if len(l.Topics) != 4 {
+			continue
+		}
+		tokenID := l.Topics[3].Big()
+		if seen[tokenID.String()] {
+			continue
+		}
+		seen[tokenID.String()] = true
+
+		current, err := nft.OwnerOf(&bind.CallOpts{Context: ctx}, tokenID)
+		if err != nil {
+			// burned tokens revert
+			continue
+		}
+		if current == owner {
+			tokens = append(tokens, tokenID)
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Cmp(tokens[j]) < 0 })
+	return tokens, nil
+}
+
+// NftMetadata fetches and parses the metadata document of a token. ipfs uris are fetched through fetchIPFS if it is
+// not nil and the uri is a plain cid, otherwise through a public gateway.
+func (c *Client) NftMetadata(ctx context.Context, contractAddress string, tokenID *big.Int, fetchIPFS IPFSFetcher) (NftMetadata, error) {
+	out, err := c.callABI(ctx, erc721ExtensionsABI, common.HexToAddress(contractAddress), "tokenURI", tokenID)
+	if err != nil {
+		return NftMetadata{}, errors.Wrap(err, "failed to get token uri, the contract might not implement metadata")
+	}
+	uri := out[0].(string)
+
+	content, err := fetchURI(ctx, uri, fetchIPFS)
+	if err != nil {
+		return NftMetadata{}, errors.Wrapf(err, "failed to fetch metadata from %s", uri)
+	}
+
+	return parseNftMetadata(uri, content)
+}
+
+// parseNftMetadata parses a metadata document, keeping the well known fields of the schema when they have the
+// expected type
+func parseNftMetadata(uri string, content []byte) (NftMetadata, error) {
+	metadata := NftMetadata{TokenURI: uri}
+	if err := json.Unmarshal(content, &metadata.Raw); err != nil {
+		return NftMetadata{}, errors.Wrap(err, "invalid metadata document")
+	}
+
+	metadata.Name, _ = metadata.Raw["name"].(string)
+	metadata.Description, _ = metadata.Raw["description"].(string)
+	metadata.Image, _ = metadata.Raw["image"].(string)
+	metadata.Attributes, _ = metadata.Raw["attributes"].([]interface{})
+
+	return metadata, nil
+}
+
+// fetchURI returns the content of a data, ipfs or http(s) uri. The uri comes from the contract, so http content is
+// only fetched from public addresses and no content can be larger than maxMetadataSize.
+func fetchURI(ctx context.Context, uri string, fetchIPFS IPFSFetcher) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+	defer cancel()
+
+	switch {
+	case strings.HasPrefix(uri, "data:"):
+		return decodeDataURI(uri)
+	case strings.HasPrefix(uri, "ipfs://"):
+		path := strings.TrimPrefix(strings.TrimPrefix(uri, "ipfs://"), "ipfs/")
+		if fetchIPFS != nil && !strings.Contains(path, "/") {
+			return fetchIPFS(ctx, path, maxMetadataSize)
+		}
+		return safehttp.Get(ctx, metadataClient, ipfsGateway+path, maxMetadataSize)
+	case strings.HasPrefix(uri, "https://"), strings.HasPrefix(uri, "http://"):
+		return safehttp.Get(ctx, metadataClient, uri, maxMetadataSize)
+	default:
+		return nil, fmt.Errorf("unsupported uri %s", uri)
+	}
+}
+
+// decodeDataURI returns the content of a data uri, like data:application/json;base64,eyJ...
+func decodeDataURI(uri string) ([]byte, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, errors.New("invalid data uri")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(decoded), nil
 }
@@ -0,0 +1,90 @@
+package goethclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeDataURI(t *testing.T) {
+	content, err := decodeDataURI("data:application/json;base64,eyJuYW1lIjoidGVzdCJ9")
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"test"}`, string(content))
+
+	content, err = decodeDataURI(`data:application/json,{"name":"a%20b"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"a b"}`, string(content))
+
+	_, err = decodeDataURI("data:application/json")
+	assert.Error(t, err)
+}
+
+func TestFetchURIUsesIPFSFetcher(t *testing.T) {
+	var fetched string
+	var limit int64
+	fetcher := func(ctx context.Context, contentID string, maxSize int64) ([]byte, error) {
+		fetched, limit = contentID, maxSize
+		return []byte(`{}`), nil
+	}
+
+	_, err := fetchURI(context.Background(), "ipfs://ipfs/QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG", fetcher)
+	require.NoError(t, err)
+	assert.Equal(t, "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG", fetched)
+	assert.Equal(t, int64(maxMetadataSize), limit)
+
+	_, err = fetchURI(context.Background(), "ftp://example.com/1.json", fetcher)
+	assert.Error(t, err)
+
+	// token uris pointing to the host are refused
+	_, err = fetchURI(context.Background(), "http://127.0.0.1:1/1.json", fetcher)
+	assert.ErrorContains(t, err, "refusing to connect")
+}
+
+func TestParseNftMetadata(t *testing.T) {
+	metadata, err := parseNftMetadata("ipfs://cid", []byte(`{
+		"name": "Node #1",
+		"description": "A node",
+		"image": "ipfs://image",
+		"attributes": [{"trait_type": "farm", "value": 1}],
+		"external_url": "https://example.com"
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "ipfs://cid", metadata.TokenURI)
+	assert.Equal(t, "Node #1", metadata.Name)
+	assert.Equal(t, "A node", metadata.Description)
+	assert.Equal(t, "ipfs://image", metadata.Image)
+	assert.Len(t, metadata.Attributes, 1)
+	assert.Equal(t, "https://example.com", metadata.Raw["external_url"])
+
+	_, err = parseNftMetadata("ipfs://cid", []byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestNftWritesRejectInvalidAddresses(t *testing.T) {
+	c := &Client{}
+	ctx := context.Background()
+	contract := "0x1111111111111111111111111111111111111111"
+	typo := "0x11111111111111111111111111111111111111"
+	id := big.NewInt(1)
+
+	// addresses are validated before anything is sent
+	_, err := c.NftSafeTransfer(ctx, contract, contract, typo, id)
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.NftTransfer(ctx, typo, contract, contract, id)
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.NftApprove(ctx, contract, typo, id)
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.NftSetApprovalForAll(ctx, contract, "", true)
+	assert.ErrorContains(t, err, "invalid address")
+
+	_, err = c.Erc1155SetApprovalForAll(ctx, contract, typo, true)
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.Erc1155Transfer(ctx, contract, typo, contract, id, id, nil)
+	assert.ErrorContains(t, err, "invalid address")
+	_, err = c.Erc1155BatchTransfer(ctx, typo, contract, contract, []*big.Int{id}, []*big.Int{id}, nil)
+	assert.ErrorContains(t, err, "invalid address")
+}
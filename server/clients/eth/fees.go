@@ -0,0 +1,203 @@
+package goethclient
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// GasLimitMargin is the percentage added on top of the estimated gas of a transaction
+	GasLimitMargin = 20
+	// BaseFeeMultiplier is applied to the current base fee when computing the max fee per gas, so the transaction
+	// stays valid if the base fee rises over the next blocks
+	BaseFeeMultiplier = 2
+)
+
+type (
+	// Fees suggested for a new transaction, in wei
+	Fees struct {
+		// BaseFee of the latest block, nil if the chain does not support EIP-1559
+		BaseFee *big.Int
+		// MaxPriorityFeePerGas is the tip for the block producer
+		MaxPriorityFeePerGas *big.Int
+		// MaxFeePerGas is the maximum total fee per gas the transaction pays
+		MaxFeePerGas *big.Int
+		// GasPrice is the suggested price for legacy transactions
+		GasPrice *big.Int
+	}
+
+	// FeeCaps limit the fees paid for transactions, in wei. Nil values are not capped.
+	FeeCaps struct {
+		MaxFeePerGas         *big.Int
+		MaxPriorityFeePerGas *big.Int
+	}
+)
+
+// SetFeeCaps limits the fees of all transactions sent by the client
+func (c *Client) SetFeeCaps(caps FeeCaps) {
+	c.feeCaps = caps
+}
+
+// EstimateFees returns the fees for a new transaction, taking the configured caps into account
+func (c *Client) EstimateFees(ctx context.Context) (Fees, error) {
+	gasPrice, err := c.Eth.SuggestGasPrice(ctx)
+	if err != nil {
+		return Fees{}, errors.Wrap(err, "failed to suggest gas price")
+	}
+	fees := Fees{GasPrice: gasPrice}
+
+	head, err := c.Eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return Fees{}, errors.Wrap(err, "failed to get latest block header")
+	}
+	if head.BaseFee == nil {
+		// Pre London chain, only legacy transactions are possible
+		return fees, nil
+	}
+	fees.BaseFee = head.BaseFee
+
+	tip, err := c.Eth.SuggestGasTipCap(ctx)
+	if err != nil {
+		return Fees{}, errors.Wrap(err, "failed to suggest gas tip cap")
+	}
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(BaseFeeMultiplier)), tip)
+
+	fees.MaxPriorityFeePerGas, fees.MaxFeePerGas = c.feeCaps.apply(tip, feeCap)
+
+	return fees, nil
+}
+
+// apply caps a suggested tip and fee cap. The tip never exceeds the fee cap.
+func (caps FeeCaps) apply(tip, feeCap *big.Int) (*big.Int, *big.Int) {
+	if caps.MaxFeePerGas != nil && feeCap.Cmp(caps.MaxFeePerGas) > 0 {
+		feeCap = new(big.Int).Set(caps.MaxFeePerGas)
+	}
+	if caps.MaxPriorityFeePerGas != nil && tip.Cmp(caps.MaxPriorityFeePerGas) > 0 {
+		tip = new(big.Int).Set(caps.MaxPriorityFeePerGas)
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	return tip, feeCap
+}
+
+// estimateGas estimates the gas used by a call and adds a safety margin
+func (c *Client) estimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	gas, err := c.Eth.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to estimate gas")
+	}
+	return gas + gas*GasLimitMargin/100, nil
+}
+
+// newTransaction creates an unsigned transaction from the loaded address, with the fees set from the current fee
+// market. If gasLimit is 0, the gas limit is estimated. A dynamic fee transaction is created if the chain supports it.
+func (c *Client) newTransaction(ctx context.Context, nonce uint64, to *common.Address, value *big.Int, data []byte, gasLimit uint64) (*types.Transaction, error) {
+	chainID, err := c.Eth.ChainID(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get chainID")
+	}
+
+	fees, err := c.EstimateFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := ethereum.CallMsg{From: c.Address, To: to, Value: value, Data: data}
+	if fees.BaseFee != nil {
+		msg.GasFeeCap, msg.GasTipCap = fees.MaxFeePerGas, fees.MaxPriorityFeePerGas
+	} else {
+		msg.GasPrice = fees.GasPrice
+	}
+	gas := gasLimit
+	if gas == 0 {
+		gas, err = c.estimateGas(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fees.BaseFee == nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.GasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}), nil
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.MaxPriorityFeePerGas,
+		GasFeeCap: fees.MaxFeePerGas,
+		Gas:       gas,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	}), nil
+}
+
+// sendNewTransaction creates a transaction with the next nonce of the loaded address and submits it, without
+// waiting for it to be mined. If gasLimit is 0, the gas limit is estimated.
+func (c *Client) sendNewTransaction(ctx context.Context, to *common.Address, value *big.Int, data []byte, gasLimit uint64) (*types.Transaction, error) {
+	return c.withNonce(ctx, func(nonce uint64) (*types.Transaction, error) {
+		tx, err := c.newTransaction(ctx, nonce, to, value, data, gasLimit)
+		if err != nil {
+			return nil, err
+		}
+		return c.sendTransaction(ctx, tx)
+	})
+}
+
+// transact executes a contract binding call with transaction options from the loaded key and the next nonce of the
+// loaded address. The call is first built without sending to estimate its gas, then sent with the estimated gas plus
+// a safety margin. The transaction is not waited for.
+func (c *Client) transact(ctx context.Context, call func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	opts, err := c.getDefaultTransactionOpts(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get default transaction opts")
+	}
+
+	return c.withNonce(ctx, func(nonce uint64) (*types.Transaction, error) {
+		opts.Nonce = new(big.Int).SetUint64(nonce)
+		return c.transactWithOpts(ctx, opts, call)
+	})
+}
+
+func (c *Client) transactWithOpts(ctx context.Context, opts *bind.TransactOpts, call func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	// A non zero gas limit prevents the binding from estimating the gas itself
+	opts.NoSend = true
+	opts.GasLimit = 1
+	draft, err := call(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := c.estimateGas(ctx, ethereum.CallMsg{
+		From:      c.Address,
+		To:        draft.To(),
+		Value:     draft.Value(),
+		Data:      draft.Data(),
+		GasPrice:  opts.GasPrice,
+		GasFeeCap: opts.GasFeeCap,
+		GasTipCap: opts.GasTipCap,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().Msgf("estimated gas limit %d", gas)
+
+	opts.NoSend = false
+	opts.GasLimit = gas
+	return call(opts)
+}
@@ -0,0 +1,30 @@
+package goethclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeCapsApply(t *testing.T) {
+	t.Run("no_caps", func(t *testing.T) {
+		tip, feeCap := FeeCaps{}.apply(big.NewInt(2), big.NewInt(100))
+		assert.Equal(t, big.NewInt(2), tip)
+		assert.Equal(t, big.NewInt(100), feeCap)
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		caps := FeeCaps{MaxFeePerGas: big.NewInt(50), MaxPriorityFeePerGas: big.NewInt(1)}
+		tip, feeCap := caps.apply(big.NewInt(2), big.NewInt(100))
+		assert.Equal(t, big.NewInt(1), tip)
+		assert.Equal(t, big.NewInt(50), feeCap)
+	})
+
+	t.Run("tip_above_fee_cap", func(t *testing.T) {
+		caps := FeeCaps{MaxFeePerGas: big.NewInt(5)}
+		tip, feeCap := caps.apply(big.NewInt(10), big.NewInt(100))
+		assert.Equal(t, big.NewInt(5), tip)
+		assert.Equal(t, big.NewInt(5), feeCap)
+	})
+}
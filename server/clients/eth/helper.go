@@ -1,8 +1,10 @@
 package goethclient
 
 import (
+	"fmt"
 	"math"
 	"math/big"
+	"strings"
 )
 
 func WeiToString(wei *big.Int) string {
@@ -22,3 +24,32 @@ func CurrencyToString(units *big.Int, decimals int) string {
 	w = new(big.Float).Quo(w, big.NewFloat(math.Pow10(decimals)))
 	return w.String()
 }
+
+// parseUnits converts a decimal amount to the smallest unit of a currency with the given decimals, without losing
+// precision
+func parseUnits(amount string, decimals uint8) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok || strings.Contains(amount, "/") || r.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %s", amount)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(pow10(decimals)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("amount %s has more than %d decimals", amount, decimals)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// formatUnits converts an amount in the smallest unit of a currency with the given decimals to an exact decimal
+// string
+func formatUnits(units *big.Int, decimals uint8) string {
+	s := new(big.Rat).SetFrac(units, pow10(decimals)).FloatString(int(decimals))
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
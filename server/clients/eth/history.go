@@ -0,0 +1,652 @@
+package goethclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	tft "github.com/threefoldfoundation/tft/bridge/stellar/contracts/tokenv1"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// historyConfirmations is the amount of blocks on top of a block before it is added to the history index. More
+	// recent blocks can still be reorganized, so they are scanned again on every query.
+	historyConfirmations = 12
+	// historyMaxBlocks is the maximum amount of blocks scanned for a single query
+	historyMaxBlocks = 100000
+	// historyLogRange is the amount of blocks queried for logs at once, rpc providers limit the range of a log query
+	historyLogRange = 2000
+	// historyWorkers is the amount of blocks fetched concurrently
+	historyWorkers = 8
+
+	HistoryIncoming = "in"
+	HistoryOutgoing = "out"
+
+	AssetNative = "native"
+	AssetTft    = "tft"
+	AssetErc20  = "erc20"
+)
+
+var (
+	historyIndexesLock sync.Mutex
+	// history indexes, keyed by file path or by chain and address if they are not persisted, shared by all clients
+	historyIndexes = map[string]*historyIndex{}
+
+	withdrawTopic = common.HexToHash("0xbf4bee5506452a156854c54e249d6b04b0cd83287ba208202be81a4f87a55739")
+)
+
+// HistoryEntry is a transfer of value to or from an address
+type HistoryEntry struct {
+	Hash        string `json:"hash"`
+	BlockNumber uint64 `json:"block_number"`
+	// Timestamp of the block, in seconds since the unix epoch
+	Timestamp uint64 `json:"timestamp"`
+	TxIndex   uint   `json:"tx_index"`
+	// LogIndex of the transfer event in the block, 0 for native transfers
+	LogIndex uint `json:"log_index"`
+	// Direction is in or out, relative to the address of the history
+	Direction string `json:"direction"`
+	// Asset is native, tft or erc20
+	Asset string `json:"asset"`
+	// Token contract, empty for the native currency
+	Token  string `json:"token"`
+	Symbol string `json:"symbol"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	// Value in the smallest unit of the asset
+	Value string `json:"value"`
+	// Amount in units of the asset, empty if the decimals of the token are unknown
+	Amount string `json:"amount"`
+	// Fee paid in wei, only set on native entries of transactions sent by the address
+	Fee string `json:"fee,omitempty"`
+	// Failed is set for transactions which reverted, only their fee was paid
+	Failed bool `json:"failed,omitempty"`
+	// BridgeAddress and BridgeNetwork are the destination of TFT withdrawn through the bridge
+	BridgeAddress string `json:"bridge_address,omitempty"`
+	BridgeNetwork string `json:"bridge_network,omitempty"`
+}
+
+// historyIndex holds the history of an address for the ranges of confirmed blocks which were scanned
+type historyIndex struct {
+	lock sync.Mutex
+	// path of the file the index is persisted in, empty if it is only kept in memory
+	path string
+
+	// Ranges are the disjoint ranges of blocks which were scanned, sorted, with the first and last block of each range
+	Ranges  [][2]uint64    `json:"ranges"`
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// TransactionHistory returns the native and erc20 transfers of an address between two blocks, including fees paid and
+// TFT withdrawn through the bridge. Confirmed blocks are only scanned once, their history is kept in an index which
+// is persisted in dir. The index is kept in memory if dir is empty. If toBlock is 0 or beyond the chain height, the
+// history up to the latest block is returned.
+// Native currency received through internal transactions, like a contract forwarding value to the address, is not part
+// of the history: only the value of the transactions themselves is visible without tracing them.
+func (c *Client) TransactionHistory(ctx context.Context, dir, address string, fromBlock, toBlock uint64) ([]HistoryEntry, error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.Errorf("%s is not a valid address", address)
+	}
+	account := common.HexToAddress(address)
+
+	head, err := c.Eth.BlockNumber(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get chain height")
+	}
+	if toBlock == 0 || toBlock > head {
+		toBlock = head
+	}
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("from block %d is after to block %d", fromBlock, toBlock)
+	}
+
+	idx, err := loadHistoryIndex(dir, c.Chain.ChainID, account)
+	if err != nil {
+		return nil, err
+	}
+
+	// confirmed blocks are added to the index, more recent blocks are scanned but not kept
+	var indexed [][2]uint64
+	unconfirmedFrom := fromBlock
+	if head >= historyConfirmations && fromBlock <= head-historyConfirmations {
+		confirmedTo := head - historyConfirmations
+		if confirmedTo > toBlock {
+			confirmedTo = toBlock
+		}
+		idx.lock.Lock()
+		indexed = idx.missing(fromBlock, confirmedTo)
+		idx.lock.Unlock()
+		unconfirmedFrom = confirmedTo + 1
+	}
+
+	blocks := uint64(0)
+	for _, r := range indexed {
+		blocks += r[1] - r[0] + 1
+	}
+	if unconfirmedFrom <= toBlock {
+		blocks += toBlock - unconfirmedFrom + 1
+	}
+	if blocks > historyMaxBlocks {
+		return nil, fmt.Errorf("query requires scanning %d blocks, at most %d blocks are scanned at once", blocks, historyMaxBlocks)
+	}
+
+	// the index is not locked while scanning, so queries for other ranges are not held up by a long scan
+	scanned := make([][]HistoryEntry, len(indexed))
+	for i, r := range indexed {
+		if scanned[i], err = c.scanHistory(ctx, account, r[0], r[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	idx.lock.Lock()
+	for i, r := range indexed {
+		idx.add(r[0], r[1], scanned[i])
+	}
+	if len(indexed) > 0 {
+		if err := idx.save(); err != nil {
+			idx.lock.Unlock()
+			return nil, err
+		}
+	}
+
+	history := []HistoryEntry{}
+	for _, entry := range idx.Entries {
+		if entry.BlockNumber >= fromBlock && entry.BlockNumber <= toBlock {
+			history = append(history, entry)
+		}
+	}
+	idx.lock.Unlock()
+
+	if unconfirmedFrom <= toBlock {
+		entries, err := c.scanHistory(ctx, account, unconfirmedFrom, toBlock)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, entries...)
+	}
+
+	return history, nil
+}
+
+// scanHistory collects the history of an address between two blocks
+func (c *Client) scanHistory(ctx context.Context, account common.Address, from, to uint64) ([]HistoryEntry, error) {
+	log.Debug().Msgf("scanning history of %s from block %d to %d", account.Hex(), from, to)
+
+	entries, timestamps, err := c.scanNativeTransfers(ctx, account, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := c.scanTokenTransfers(ctx, account, from, to, timestamps)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, transfers...)
+
+	sortHistory(entries)
+	return entries, nil
+}
+
+// rpcBlock holds the fields of a block needed for the history. Blocks are decoded from the raw rpc response, so
+// transaction types which are not known by the client do not break the scan.
+type rpcBlock struct {
+	Number       hexutil.Uint64   `json:"number"`
+	Timestamp    hexutil.Uint64   `json:"timestamp"`
+	Transactions []rpcTransaction `json:"transactions"`
+}
+
+type rpcTransaction struct {
+	Hash             common.Hash     `json:"hash"`
+	TransactionIndex hexutil.Uint    `json:"transactionIndex"`
+	From             common.Address  `json:"from"`
+	To               *common.Address `json:"to"`
+	Value            *hexutil.Big    `json:"value"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+}
+
+type rpcReceipt struct {
+	Status            hexutil.Uint64 `json:"status"`
+	GasUsed           hexutil.Uint64 `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+}
+
+// scanNativeTransfers walks the blocks in the range, collecting the transactions sent by the address and the native
+// currency sent to it. The timestamps of the blocks are returned as well.
+func (c *Client) scanNativeTransfers(ctx context.Context, account common.Address, from, to uint64) ([]HistoryEntry, map[uint64]uint64, error) {
+	var lock sync.Mutex
+	entries := []HistoryEntry{}
+	timestamps := make(map[uint64]uint64, to-from+1)
+
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(historyWorkers)
+	for number := from; number <= to; number++ {
+		number := number
+		group.Go(func() error {
+			var block *rpcBlock
+			if err := c.Eth.Client().CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(number), true); err != nil {
+				return errors.Wrapf(err, "failed to get block %d", number)
+			}
+			if block == nil {
+				return fmt.Errorf("block %d not found", number)
+			}
+
+			found := []HistoryEntry{}
+			for _, tx := range block.Transactions {
+				value := (*big.Int)(tx.Value)
+				outgoing := tx.From == account
+				incoming := tx.To != nil && *tx.To == account && value != nil && value.Sign() > 0
+				if !outgoing && !incoming {
+					continue
+				}
+
+				entry, err := c.nativeEntry(ctx, block, tx, outgoing)
+				if err != nil {
+					return err
+				}
+				found = append(found, entry)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			timestamps[number] = uint64(block.Timestamp)
+			entries = append(entries, found...)
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return entries, timestamps, nil
+}
+
+// nativeEntry creates the entry for a transaction sent by or to the address of a history
+func (c *Client) nativeEntry(ctx context.Context, block *rpcBlock, tx rpcTransaction, outgoing bool) (HistoryEntry, error) {
+	var receipt *rpcReceipt
+	if err := c.Eth.Client().CallContext(ctx, &receipt, "eth_getTransactionReceipt", tx.Hash); err != nil {
+		return HistoryEntry{}, errors.Wrapf(err, "failed to get receipt of %s", tx.Hash.Hex())
+	}
+	if receipt == nil {
+		return HistoryEntry{}, fmt.Errorf("receipt of %s not found", tx.Hash.Hex())
+	}
+
+	value := big.NewInt(0)
+	if tx.Value != nil {
+		value = tx.Value.ToInt()
+	}
+
+	entry := HistoryEntry{
+		Hash:        tx.Hash.Hex(),
+		BlockNumber: uint64(block.Number),
+		Timestamp:   uint64(block.Timestamp),
+		TxIndex:     uint(tx.TransactionIndex),
+		Direction:   HistoryIncoming,
+		Asset:       AssetNative,
+		Symbol:      c.Chain.NativeCurrency,
+		From:        tx.From.Hex(),
+		Value:       value.String(),
+		Amount:      formatUnits(value, EthDecimals),
+		Failed:      receipt.Status != hexutil.Uint64(types.ReceiptStatusSuccessful),
+	}
+	if tx.To != nil {
+		entry.To = tx.To.Hex()
+	}
+	if entry.Failed {
+		entry.Value, entry.Amount = "0", "0"
+	}
+
+	if outgoing {
+		entry.Direction = HistoryOutgoing
+		gasPrice := receipt.EffectiveGasPrice
+		if gasPrice == nil {
+			gasPrice = tx.GasPrice
+		}
+		fee := new(big.Int)
+		if gasPrice != nil {
+			fee.Mul(gasPrice.ToInt(), new(big.Int).SetUint64(uint64(receipt.GasUsed)))
+		}
+		entry.Fee = fee.String()
+	}
+
+	return entry, nil
+}
+
+// scanTokenTransfers collects the erc20 transfers from and to the address, and the TFT it withdrew through the bridge
+func (c *Client) scanTokenTransfers(ctx context.Context, account common.Address, from, to uint64, timestamps map[uint64]uint64) ([]HistoryEntry, error) {
+	accountTopic := common.BytesToHash(account.Bytes())
+
+	// erc20 transfers share their signature with erc721 transfers, the amount is not indexed for erc20 though
+	queries := []ethereum.FilterQuery{
+		{Topics: [][]common.Hash{{erc721TransferTopic}, {accountTopic}}},
+		{Topics: [][]common.Hash{{erc721TransferTopic}, nil, {accountTopic}}},
+	}
+	if c.Chain.TftBridge != (common.Address{}) {
+		queries = append(queries, ethereum.FilterQuery{
+			Addresses: []common.Address{c.Chain.TftBridge},
+			Topics:    [][]common.Hash{{withdrawTopic}, {accountTopic}},
+		})
+	}
+
+	logs := []types.Log{}
+	for start := from; start <= to; start += historyLogRange {
+		end := start + historyLogRange - 1
+		if end > to {
+			end = to
+		}
+		for _, query := range queries {
+			query.FromBlock = new(big.Int).SetUint64(start)
+			query.ToBlock = new(big.Int).SetUint64(end)
+			found, err := c.Eth.FilterLogs(ctx, query)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get logs from block %d to %d", start, end)
+			}
+			logs = append(logs, found...)
+		}
+	}
+
+	entries := []HistoryEntry{}
+	withdrawals := []types.Log{}
+	seen := map[string]bool{}
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+		if l.Topics[0] == withdrawTopic {
+			withdrawals = append(withdrawals, l)
+			continue
+		}
+		if len(l.Topics) != 3 {
+			continue
+		}
+
+		// transfers to self match both queries
+		key := fmt.Sprintf("%s/%d", l.TxHash.Hex(), l.Index)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		entry, err := c.tokenEntry(ctx, account, l, timestamps[l.BlockNumber])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return c.addWithdrawals(account, entries, withdrawals, timestamps)
+}
+
+// tokenEntry creates the entry for an erc20 transfer log
+func (c *Client) tokenEntry(ctx context.Context, account common.Address, l types.Log, timestamp uint64) (HistoryEntry, error) {
+	from := common.BytesToAddress(l.Topics[1].Bytes())
+	value := new(big.Int).SetBytes(l.Data)
+
+	entry := HistoryEntry{
+		Hash:        l.TxHash.Hex(),
+		BlockNumber: l.BlockNumber,
+		Timestamp:   timestamp,
+		TxIndex:     l.TxIndex,
+		LogIndex:    l.Index,
+		Direction:   HistoryIncoming,
+		Asset:       AssetErc20,
+		Token:       l.Address.Hex(),
+		From:        from.Hex(),
+		To:          common.BytesToAddress(l.Topics[2].Bytes()).Hex(),
+		Value:       value.String(),
+	}
+	if from == account {
+		entry.Direction = HistoryOutgoing
+	}
+
+	if l.Address == c.Chain.Tft {
+		entry.Asset = AssetTft
+		entry.Symbol = "TFT"
+		entry.Amount = formatUnits(value, TftDecimals)
+		return entry, nil
+	}
+
+	token := c.cachedTokenInfo(ctx, l.Address)
+	entry.Symbol = token.symbol
+	if token.decimals != nil {
+		entry.Amount = formatUnits(value, *token.decimals)
+	}
+
+	return entry, nil
+}
+
+// addWithdrawals marks the TFT burned by bridge withdrawals with the destination of the withdrawal
+func (c *Client) addWithdrawals(account common.Address, entries []HistoryEntry, withdrawals []types.Log, timestamps map[uint64]uint64) ([]HistoryEntry, error) {
+	if len(withdrawals) == 0 {
+		return entries, nil
+	}
+
+	filterer, err := tft.NewTokenFilterer(c.Chain.TftBridge, c.Eth)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create TFT bridge filterer")
+	}
+
+	for _, l := range withdrawals {
+		if l.Removed {
+			continue
+		}
+		withdrawal, err := filterer.ParseWithdraw(l)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode withdrawal in %s", l.TxHash.Hex())
+		}
+
+		burned := false
+		for i := range entries {
+			entry := &entries[i]
+			if entry.Hash == l.TxHash.Hex() && entry.Asset == AssetTft && entry.Direction == HistoryOutgoing &&
+				entry.Value == withdrawal.Tokens.String() && entry.BridgeAddress == "" {
+				entry.BridgeAddress = withdrawal.BlockchainAddress
+				entry.BridgeNetwork = withdrawal.Network
+				burned = true
+				break
+			}
+		}
+		if burned {
+			continue
+		}
+
+		entries = append(entries, HistoryEntry{
+			Hash:          l.TxHash.Hex(),
+			BlockNumber:   l.BlockNumber,
+			Timestamp:     timestamps[l.BlockNumber],
+			TxIndex:       l.TxIndex,
+			LogIndex:      l.Index,
+			Direction:     HistoryOutgoing,
+			Asset:         AssetTft,
+			Token:         c.Chain.Tft.Hex(),
+			Symbol:        "TFT",
+			From:          account.Hex(),
+			To:            c.Chain.TftBridge.Hex(),
+			Value:         withdrawal.Tokens.String(),
+			Amount:        formatUnits(withdrawal.Tokens, TftDecimals),
+			BridgeAddress: withdrawal.BlockchainAddress,
+			BridgeNetwork: withdrawal.Network,
+		})
+	}
+
+	return entries, nil
+}
+
+// historyToken is the metadata of a token needed for history entries
+type historyToken struct {
+	symbol string
+	// decimals of the token, nil if the token does not expose them
+	decimals *uint8
+}
+
+var (
+	historyTokensLock sync.Mutex
+	// token metadata by chain id and address
+	historyTokens = map[string]historyToken{}
+)
+
+// cachedTokenInfo returns the symbol and decimals of a token, which are only fetched once
+func (c *Client) cachedTokenInfo(ctx context.Context, address common.Address) historyToken {
+	key := fmt.Sprintf("%d/%s", c.Chain.ChainID, address.Hex())
+
+	historyTokensLock.Lock()
+	token, ok := historyTokens[key]
+	historyTokensLock.Unlock()
+	if ok {
+		return token
+	}
+
+	token.symbol = c.tokenText(ctx, address, "symbol")
+	if decimals, err := c.tokenDecimals(ctx, address); err == nil {
+		token.decimals = &decimals
+	}
+	if token.symbol == "" && token.decimals == nil {
+		// the calls might have failed temporarily
+		return token
+	}
+
+	historyTokensLock.Lock()
+	historyTokens[key] = token
+	historyTokensLock.Unlock()
+
+	return token
+}
+
+// sortHistory orders entries by block, transaction and log, native entries come before the logs of their transaction
+func sortHistory(entries []HistoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		if a.TxIndex != b.TxIndex {
+			return a.TxIndex < b.TxIndex
+		}
+		if (a.Asset == AssetNative) != (b.Asset == AssetNative) {
+			return a.Asset == AssetNative
+		}
+		return a.LogIndex < b.LogIndex
+	})
+}
+
+// loadHistoryIndex returns the shared history index of an address, reading it from dir the first time it is used
+func loadHistoryIndex(dir string, chainID uint64, account common.Address) (*historyIndex, error) {
+	key := fmt.Sprintf("%d/%s", chainID, strings.ToLower(account.Hex()))
+	path := ""
+	if dir != "" {
+		path = filepath.Join(dir, "eth", fmt.Sprint(chainID), strings.ToLower(account.Hex())+".json")
+		key = path
+	}
+
+	historyIndexesLock.Lock()
+	defer historyIndexesLock.Unlock()
+
+	if idx, ok := historyIndexes[key]; ok {
+		return idx, nil
+	}
+
+	idx := &historyIndex{path: path}
+	if path != "" {
+		content, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, errors.Wrapf(err, "failed to read history index %s", path)
+		default:
+			if err := json.Unmarshal(content, idx); err != nil {
+				return nil, errors.Wrapf(err, "failed to decode history index %s", path)
+			}
+		}
+	}
+
+	historyIndexes[key] = idx
+	return idx, nil
+}
+
+// missing returns the ranges between from and to which are not in the index yet
+func (idx *historyIndex) missing(from, to uint64) [][2]uint64 {
+	ranges := [][2]uint64{}
+	for _, r := range idx.Ranges {
+		if r[1] < from {
+			continue
+		}
+		if r[0] > to {
+			break
+		}
+		if r[0] > from {
+			ranges = append(ranges, [2]uint64{from, r[0] - 1})
+		}
+		if r[1] >= to {
+			return ranges
+		}
+		from = r[1] + 1
+	}
+	return append(ranges, [2]uint64{from, to})
+}
+
+// add the entries of a scanned range. Parts of the range which were indexed in the meantime, by a concurrent query,
+// keep their entries.
+func (idx *historyIndex) add(from, to uint64, entries []HistoryEntry) {
+	for _, r := range idx.missing(from, to) {
+		for _, entry := range entries {
+			if entry.BlockNumber >= r[0] && entry.BlockNumber <= r[1] {
+				idx.Entries = append(idx.Entries, entry)
+			}
+		}
+		idx.Ranges = append(idx.Ranges, r)
+	}
+	sortHistory(idx.Entries)
+
+	// merge adjacent ranges
+	sort.Slice(idx.Ranges, func(i, j int) bool { return idx.Ranges[i][0] < idx.Ranges[j][0] })
+	merged := [][2]uint64{}
+	for _, r := range idx.Ranges {
+		if last := len(merged) - 1; last >= 0 && merged[last][1]+1 >= r[0] {
+			if r[1] > merged[last][1] {
+				merged[last][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	idx.Ranges = merged
+}
+
+// save writes the index to its file, if it is persisted
+func (idx *historyIndex) save() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	content, err := json.Marshal(idx)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode history index")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create history index directory")
+	}
+
+	// write to a temporary file first so an interrupted write does not corrupt the index
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write history index %s", tmp)
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		return errors.Wrapf(err, "failed to write history index %s", idx.path)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package goethclient
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryIndexMissing(t *testing.T) {
+	idx := &historyIndex{}
+	assert.Equal(t, [][2]uint64{{10, 20}}, idx.missing(10, 20))
+
+	idx.add(10, 20, nil)
+	assert.Empty(t, idx.missing(12, 18))
+	assert.Equal(t, [][2]uint64{{5, 9}}, idx.missing(5, 15))
+	assert.Equal(t, [][2]uint64{{21, 30}}, idx.missing(15, 30))
+	assert.Equal(t, [][2]uint64{{5, 9}, {21, 30}}, idx.missing(5, 30))
+	// far away ranges are scanned on their own
+	assert.Equal(t, [][2]uint64{{35, 40}}, idx.missing(35, 40))
+
+	idx.add(35, 40, nil)
+	assert.Equal(t, [][2]uint64{{10, 20}, {35, 40}}, idx.Ranges)
+	assert.Equal(t, [][2]uint64{{21, 34}, {41, 50}}, idx.missing(15, 50))
+
+	// filling the gap merges the ranges
+	idx.add(21, 34, nil)
+	assert.Equal(t, [][2]uint64{{10, 40}}, idx.Ranges)
+}
+
+func TestHistoryIndexAddConcurrentScan(t *testing.T) {
+	idx := &historyIndex{}
+	idx.add(10, 20, []HistoryEntry{{Hash: "a", BlockNumber: 15}})
+
+	// a concurrent query scanned an overlapping range, the indexed part is not added twice
+	idx.add(5, 25, []HistoryEntry{{Hash: "b", BlockNumber: 7}, {Hash: "a", BlockNumber: 15}, {Hash: "c", BlockNumber: 22}})
+	assert.Equal(t, [][2]uint64{{5, 25}}, idx.Ranges)
+
+	hashes := []string{}
+	for _, entry := range idx.Entries {
+		hashes = append(hashes, entry.Hash)
+	}
+	assert.Equal(t, []string{"b", "a", "c"}, hashes)
+}
+
+func TestSortHistory(t *testing.T) {
+	entries := []HistoryEntry{
+		{Hash: "d", BlockNumber: 2, TxIndex: 0, LogIndex: 1, Asset: AssetErc20},
+		{Hash: "c", BlockNumber: 1, TxIndex: 3, LogIndex: 7, Asset: AssetTft},
+		{Hash: "b", BlockNumber: 1, TxIndex: 3, Asset: AssetNative},
+		{Hash: "a", BlockNumber: 1, TxIndex: 0, Asset: AssetNative},
+	}
+	sortHistory(entries)
+
+	hashes := []string{}
+	for _, entry := range entries {
+		hashes = append(hashes, entry.Hash)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, hashes)
+}
+
+func TestHistoryIndexPersisted(t *testing.T) {
+	dir := t.TempDir()
+	account := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	idx, err := loadHistoryIndex(dir, 1, account)
+	require.NoError(t, err)
+	idx.add(100, 200, []HistoryEntry{{Hash: "0x01", BlockNumber: 150, Asset: AssetNative}})
+	require.NoError(t, idx.save())
+
+	same, err := loadHistoryIndex(dir, 1, account)
+	require.NoError(t, err)
+	assert.Same(t, idx, same)
+
+	// drop the shared index so it is read from disk again
+	historyIndexesLock.Lock()
+	delete(historyIndexes, idx.path)
+	historyIndexesLock.Unlock()
+
+	loaded, err := loadHistoryIndex(dir, 1, account)
+	require.NoError(t, err)
+	assert.NotSame(t, idx, loaded)
+	assert.Equal(t, [][2]uint64{{100, 200}}, loaded.Ranges)
+	assert.Equal(t, idx.Entries, loaded.Entries)
+}
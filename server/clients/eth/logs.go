@@ -0,0 +1,397 @@
+package goethclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maximum amount of logs kept for a subscription, older logs are dropped when it is full
+	logBufferSize = 1000
+	// delay before resubscribing after a broken subscription, doubled on every consecutive failure
+	resubscribeDelay    = time.Second
+	maxResubscribeDelay = 30 * time.Second
+)
+
+// ErrSubscriptionsUnsupported is returned when subscribing over a connection which does not support notifications
+var ErrSubscriptionsUnsupported = errors.New("log subscriptions require a websocket rpc url")
+
+type (
+	// LogFilter selects logs. Empty fields are not filtered on.
+	LogFilter struct {
+		// Addresses of the contracts emitting the logs
+		Addresses []string
+		// Topics to match per position, every position matches any of the given topics
+		Topics [][]string
+		// ABI json or name of a registered ABI, used to decode the logs
+		ABI string
+		// Event name in the ABI, its signature is used as the first topic
+		Event string
+		// FromBlock is the first block to query, nil for the latest block
+		FromBlock *big.Int
+		// ToBlock is the last block to query, nil for the latest block
+		ToBlock *big.Int
+	}
+
+	// Log emitted by a contract
+	Log struct {
+		Address     string
+		Topics      []string
+		Data        string
+		BlockNumber uint64
+		BlockHash   string
+		TxHash      string
+		TxIndex     uint
+		Index       uint
+		// Removed is set if the log was reverted by a chain reorganization
+		Removed bool
+		// Event name, if the log was decoded
+		Event string
+		// Args of the event, if the log was decoded
+		Args map[string]interface{}
+	}
+
+	// logSubscription follows the logs matching a filter and buffers them until they are taken
+	logSubscription struct {
+		id     string
+		cancel context.CancelFunc
+
+		lock sync.Mutex
+		logs []Log
+	}
+)
+
+// GetLogs returns the logs matching the filter, decoded if an ABI is given
+func (c *Client) GetLogs(ctx context.Context, filter LogFilter) ([]Log, error) {
+	query, decoder, err := c.filterQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := c.Eth.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get logs")
+	}
+
+	result := make([]Log, 0, len(logs))
+	for _, l := range logs {
+		result = append(result, decoder.decode(l))
+	}
+	return result, nil
+}
+
+// SubscribeLogs subscribes to new logs matching the filter. FromBlock and ToBlock are ignored. The logs are buffered
+// until they are taken with SubscriptionLogs. The id of the subscription is returned.
+func (c *Client) SubscribeLogs(filter LogFilter) (string, error) {
+	filter.FromBlock, filter.ToBlock = nil, nil
+	query, decoder, err := c.filterQuery(filter)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	// logs of blocks after the current head are delivered by the subscription, or backfilled if it breaks first
+	head, err := c.Eth.BlockNumber(ctx)
+	if err != nil {
+		cancel()
+		return "", errors.Wrap(err, "failed to get the current block")
+	}
+
+	logs := make(chan types.Log, 128)
+	sub, err := c.Eth.SubscribeFilterLogs(ctx, query, logs)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		cancel()
+		return "", ErrSubscriptionsUnsupported
+	}
+	if err != nil {
+		cancel()
+		return "", errors.Wrap(err, "failed to subscribe to logs")
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		cancel()
+		sub.Unsubscribe()
+		return "", err
+	}
+
+	s := &logSubscription{id: hex.EncodeToString(id), cancel: cancel}
+	go c.followLogs(ctx, s, query, decoder, sub, logs, head+1)
+
+	c.subscriptionsLock.Lock()
+	defer c.subscriptionsLock.Unlock()
+	if c.subscriptions == nil {
+		c.subscriptions = map[string]*logSubscription{}
+	}
+	c.subscriptions[s.id] = s
+
+	return s.id, nil
+}
+
+// SubscriptionLogs takes up to count buffered logs of a subscription, oldest first. All logs are taken if count is 0.
+func (c *Client) SubscriptionLogs(id string, count uint32) ([]Log, error) {
+	c.subscriptionsLock.Lock()
+	s, ok := c.subscriptions[id]
+	c.subscriptionsLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("subscription %s not found", id)
+	}
+
+	return s.take(count), nil
+}
+
+// SubscriptionIds returns the ids of the active log subscriptions
+func (c *Client) SubscriptionIds() []string {
+	c.subscriptionsLock.Lock()
+	defer c.subscriptionsLock.Unlock()
+
+	ids := make([]string, 0, len(c.subscriptions))
+	for id := range c.subscriptions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// CloseSubscription stops a log subscription and drops its buffered logs
+func (c *Client) CloseSubscription(id string) {
+	c.subscriptionsLock.Lock()
+	defer c.subscriptionsLock.Unlock()
+
+	if s, ok := c.subscriptions[id]; ok {
+		s.cancel()
+		delete(c.subscriptions, id)
+	}
+}
+
+// followLogs buffers the logs of a subscription until the context is canceled. If the subscription breaks, it is
+// restored and the logs emitted in the meantime are fetched, starting from the block of the last received log or
+// from the first block of the subscription if no log was received yet. If fetching the missed logs fails, the
+// subscription is restored again so they are fetched on the next attempt.
+func (c *Client) followLogs(ctx context.Context, s *logSubscription, query ethereum.FilterQuery, decoder logDecoder, sub ethereum.Subscription, logs chan types.Log, fromBlock uint64) {
+	var last *types.Log
+	delay := resubscribeDelay
+
+	for {
+		if sub != nil {
+			err := s.receive(ctx, sub, logs, decoder, &last)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Debug().Err(err).Msgf("log subscription %s interrupted, resubscribing in %s", s.id, delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		if delay < maxResubscribeDelay {
+			delay *= 2
+		}
+
+		var err error
+		sub, err = c.Eth.SubscribeFilterLogs(ctx, query, logs)
+		if err != nil {
+			log.Debug().Err(err).Msgf("failed to resubscribe log subscription %s", s.id)
+			sub = nil
+			continue
+		}
+
+		backfill := query
+		backfill.FromBlock = new(big.Int).SetUint64(fromBlock)
+		if last != nil {
+			backfill.FromBlock = new(big.Int).SetUint64(last.BlockNumber)
+		}
+		missed, err := c.Eth.FilterLogs(ctx, backfill)
+		if err != nil {
+			log.Debug().Err(err).Msgf("failed to get missed logs of subscription %s", s.id)
+			sub.Unsubscribe()
+			sub = nil
+			continue
+		}
+		delay = resubscribeDelay
+		for _, l := range missed {
+			s.push(l, decoder, &last)
+		}
+	}
+}
+
+// receive buffers logs from a subscription until it fails or the context is canceled
+func (s *logSubscription) receive(ctx context.Context, sub ethereum.Subscription, logs chan types.Log, decoder logDecoder, last **types.Log) error {
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case l := <-logs:
+			s.push(l, decoder, last)
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// push buffers a log, skipping logs which were already seen. Removed logs are always buffered.
+func (s *logSubscription) push(l types.Log, decoder logDecoder, last **types.Log) {
+	if !l.Removed {
+		if *last != nil && !logAfter(l, **last) {
+			return
+		}
+		*last = &l
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.logs) >= logBufferSize {
+		s.logs = s.logs[1:]
+	}
+	s.logs = append(s.logs, decoder.decode(l))
+}
+
+// take removes and returns up to count logs from the buffer, all if count is 0
+func (s *logSubscription) take(count uint32) []Log {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	n := len(s.logs)
+	if count != 0 && int(count) < n {
+		n = int(count)
+	}
+	taken := make([]Log, n)
+	copy(taken, s.logs[:n])
+	s.logs = s.logs[n:]
+	return taken
+}
+
+// logAfter checks if log a was emitted after log b
+func logAfter(a, b types.Log) bool {
+	if a.BlockNumber != b.BlockNumber {
+		return a.BlockNumber > b.BlockNumber
+	}
+	return a.Index > b.Index
+}
+
+// filterQuery converts a filter to a query, and returns a decoder for the ABI of the filter
+func (c *Client) filterQuery(filter LogFilter) (ethereum.FilterQuery, logDecoder, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: filter.FromBlock,
+		ToBlock:   filter.ToBlock,
+	}
+	for _, address := range filter.Addresses {
+		if !common.IsHexAddress(address) {
+			return query, logDecoder{}, fmt.Errorf("invalid address %s", address)
+		}
+		query.Addresses = append(query.Addresses, common.HexToAddress(address))
+	}
+	for _, position := range filter.Topics {
+		topics := make([]common.Hash, 0, len(position))
+		for _, topic := range position {
+			topics = append(topics, common.HexToHash(topic))
+		}
+		query.Topics = append(query.Topics, topics)
+	}
+
+	var decoder logDecoder
+	if filter.ABI != "" {
+		parsed, err := c.resolveABI(filter.ABI)
+		if err != nil {
+			return query, logDecoder{}, err
+		}
+		decoder.abi = &parsed
+	}
+
+	if filter.Event != "" {
+		if decoder.abi == nil {
+			return query, logDecoder{}, errors.New("an ABI is required to filter on an event")
+		}
+		event, ok := decoder.abi.Events[filter.Event]
+		if !ok {
+			return query, logDecoder{}, fmt.Errorf("event %s not found in ABI", filter.Event)
+		}
+		if len(query.Topics) == 0 {
+			query.Topics = [][]common.Hash{{event.ID}}
+		} else {
+			query.Topics[0] = []common.Hash{event.ID}
+		}
+	}
+
+	return query, decoder, nil
+}
+
+// logDecoder decodes logs with an ABI, if any
+type logDecoder struct {
+	abi *abi.ABI
+}
+
+// decode converts a log, decoding its event and arguments if it matches an event of the ABI. Events with the same
+// signature but different indexed arguments, like the erc20 and erc721 Transfer, are only decoded if the amount of
+// topics matches.
+func (d logDecoder) decode(l types.Log) Log {
+	result := Log{
+		Address:     l.Address.Hex(),
+		Topics:      make([]string, 0, len(l.Topics)),
+		Data:        hexutil.Encode(l.Data),
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash.Hex(),
+		TxHash:      l.TxHash.Hex(),
+		TxIndex:     l.TxIndex,
+		Index:       l.Index,
+		Removed:     l.Removed,
+	}
+	for _, topic := range l.Topics {
+		result.Topics = append(result.Topics, topic.Hex())
+	}
+
+	if d.abi == nil || len(l.Topics) == 0 {
+		return result
+	}
+	event, err := d.abi.EventByID(l.Topics[0])
+	if err != nil {
+		return result
+	}
+
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(indexed) != len(l.Topics)-1 {
+		return result
+	}
+
+	args := map[string]interface{}{}
+	if err := event.Inputs.UnpackIntoMap(args, l.Data); err != nil {
+		log.Debug().Err(err).Msgf("failed to decode data of %s log", event.Name)
+		return result
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, l.Topics[1:]); err != nil {
+		log.Debug().Err(err).Msgf("failed to decode topics of %s log", event.Name)
+		return result
+	}
+
+	result.Event = event.Name
+	result.Args = make(map[string]interface{}, len(args))
+	for name, value := range args {
+		result.Args[name] = fromABIValue(reflect.ValueOf(value))
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+package goethclient
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/web3_proxy/server/clients/eth/erc20"
+)
+
+func TestLogDecoder(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
+	require.NoError(t, err)
+	decoder := logDecoder{abi: &parsed}
+
+	from := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	transfer := types.Log{
+		Topics: []common.Hash{
+			parsed.Events["Transfer"].ID,
+			common.BytesToHash(from.Bytes()),
+			common.BytesToHash(to.Bytes()),
+		},
+		Data:        common.LeftPadBytes(big.NewInt(1000).Bytes(), 32),
+		BlockNumber: 10,
+	}
+
+	t.Run("erc20_transfer", func(t *testing.T) {
+		decoded := decoder.decode(transfer)
+		assert.Equal(t, "Transfer", decoded.Event)
+		assert.Equal(t, map[string]interface{}{
+			"from":  from.Hex(),
+			"to":    to.Hex(),
+			"value": "1000",
+		}, decoded.Args)
+	})
+
+	t.Run("erc721_transfer", func(t *testing.T) {
+		// Same signature, but with the token id as third topic and no data
+		nft := transfer
+		nft.Topics = append(nft.Topics, common.BigToHash(big.NewInt(1)))
+		nft.Data = nil
+		decoded := decoder.decode(nft)
+		assert.Empty(t, decoded.Event)
+		assert.Len(t, decoded.Topics, 4)
+	})
+
+	t.Run("no_abi", func(t *testing.T) {
+		decoded := logDecoder{}.decode(transfer)
+		assert.Empty(t, decoded.Event)
+		assert.Equal(t, "0x00000000000000000000000000000000000000000000000000000000000003e8", decoded.Data)
+	})
+}
+
+func TestLogSubscriptionBuffer(t *testing.T) {
+	s := &logSubscription{}
+	var last *types.Log
+
+	s.push(types.Log{BlockNumber: 1, Index: 0}, logDecoder{}, &last)
+	s.push(types.Log{BlockNumber: 1, Index: 1}, logDecoder{}, &last)
+	// already seen, e.g. when fetching missed logs after resubscribing
+	s.push(types.Log{BlockNumber: 1, Index: 1}, logDecoder{}, &last)
+	s.push(types.Log{BlockNumber: 1, Index: 1, Removed: true}, logDecoder{}, &last)
+	s.push(types.Log{BlockNumber: 2, Index: 0}, logDecoder{}, &last)
+
+	first := s.take(2)
+	require.Len(t, first, 2)
+	assert.Equal(t, uint(1), first[1].Index)
+
+	rest := s.take(0)
+	require.Len(t, rest, 2)
+	assert.True(t, rest[0].Removed)
+	assert.Equal(t, uint64(2), rest[1].BlockNumber)
+	assert.Empty(t, s.take(0))
+}
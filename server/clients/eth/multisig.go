@@ -1,12 +1,17 @@
 package goethclient
 
 import (
+	"context"
 	"math/big"
+	"strings"
 
 	"github.com/pkg/errors"
 
+	"github.com/daoleno/uniswapv3-sdk/examples/helper"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/threefoldtech/web3_proxy/server/clients/eth/erc20"
 	"github.com/threefoldtech/web3_proxy/server/clients/eth/gnosis"
@@ -58,13 +63,16 @@ func (c *Client) GetThreshold(contractAddress string) (*big.Int, error) {
 	return ms.GetThreshold(&bind.CallOpts{})
 }
 
-func (c *Client) AddOwner(contractAddress, target string, treshold int64) (string, error) {
+// AddOwner proposes a safe transaction adding an owner and changing the threshold. Owners can only be changed by the
+// safe itself, so the change needs to be signed and executed like any other safe transaction. The safe tx hash is
+// returned.
+func (c *Client) AddOwner(ctx context.Context, contractAddress, target string, treshold int64) (string, error) {
 	ms, err := gnosis.NewGnosis(common.HexToAddress(contractAddress), c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	isOwner, err := ms.IsOwner(&bind.CallOpts{}, common.HexToAddress(target))
+	isOwner, err := ms.IsOwner(&bind.CallOpts{Context: ctx}, common.HexToAddress(target))
 	if err != nil {
 		return "", err
 	}
@@ -73,44 +81,44 @@ func (c *Client) AddOwner(contractAddress, target string, treshold int64) (strin
 		return "", errors.New("target is already owner")
 	}
 
-	tx, err := ms.AddOwnerWithThreshold(&bind.TransactOpts{}, common.HexToAddress(target), big.NewInt(treshold))
-	if err != nil {
-		return "", err
-	}
-
-	return tx.Hash().Hex(), nil
+	return c.proposeSafeCall(ctx, contractAddress, "addOwnerWithThreshold", common.HexToAddress(target), big.NewInt(treshold))
 }
 
-func (c *Client) RemoveOwner(contractAddress, target string, treshold int64) (string, error) {
+// RemoveOwner proposes a safe transaction removing an owner and changing the threshold. The safe tx hash is returned.
+func (c *Client) RemoveOwner(ctx context.Context, contractAddress, target string, treshold int64) (string, error) {
 	ms, err := gnosis.NewGnosis(common.HexToAddress(contractAddress), c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	isOwner, err := ms.IsOwner(&bind.CallOpts{}, common.HexToAddress(target))
+	owners, err := ms.GetOwners(&bind.CallOpts{Context: ctx})
 	if err != nil {
 		return "", err
 	}
 
-	if !isOwner {
-		return "", errors.New("target is not an owner")
-	}
-
-	tx, err := ms.RemoveOwner(&bind.TransactOpts{}, common.HexToAddress(target), common.HexToAddress(target), big.NewInt(treshold))
-	if err != nil {
-		return "", err
+	// owners are stored as a linked list, removing one requires the owner pointing to it
+	targetAddress := common.HexToAddress(target)
+	prevOwner := sentinelOwner
+	for _, owner := range owners {
+		if owner == targetAddress {
+			return c.proposeSafeCall(ctx, contractAddress, "removeOwner", prevOwner, targetAddress, big.NewInt(treshold))
+		}
+		prevOwner = owner
 	}
 
-	return tx.Hash().Hex(), nil
+	return "", errors.New("target is not an owner")
 }
 
-func (c *Client) ApproveHash(contractAddress, hash string) (string, error) {
+// ApproveHash approves a safe tx hash on chain for the loaded address, as an alternative to an off-chain signature
+func (c *Client) ApproveHash(ctx context.Context, contractAddress, hash string) (string, error) {
 	ms, err := gnosis.NewGnosis(common.HexToAddress(contractAddress), c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	tx, err := ms.ApproveHash(&bind.TransactOpts{}, common.HexToHash(hash))
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return ms.ApproveHash(opts, common.HexToHash(hash))
+	})
 	if err != nil {
 		return "", err
 	}
@@ -132,91 +140,51 @@ func (c *Client) IsApproved(contractAddress string, hash string) (bool, error) {
 	return h.Int64() == 1, nil
 }
 
-func (c *Client) InitiateMultisigEthTransfer(safeContractAddress, destination string, amount string) (string, error) {
-	ms, err := gnosis.NewGnosis(common.HexToAddress(safeContractAddress), c.Eth)
-	if err != nil {
-		return "", err
-	}
-
-	tx, err := c.createTransferTransaction(amount, destination)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to create transfer transaction")
-	}
-
-	msTx, err := ms.ExecTransaction(
-		&bind.TransactOpts{},
-		// toAddress,
-		*tx.To(),
-		// value
-		tx.Value(),
-		// data
-		tx.Data(),
-		// operation (optional)
-		0,
-		// safeTxGas (optional)
-		nil,
-		// baseGas (optional)
-		tx.GasPrice(),
-		// gasPrice (optional)
-		tx.GasPrice(),
-		// gasToken (optional)
-		common.HexToAddress("0x0000000000000000000000000000000000000000"),
-		// refundReceiver (optional)
-		c.AddressFromKey(),
-		// signatures
-		[]byte{},
-	)
-
-	if err != nil {
-		return "", err
-	}
-
-	return msTx.Hash().Hex(), nil
+// InitiateMultisigEthTransfer proposes a safe transaction transferring eth from the safe. The safe tx hash is
+// returned.
+func (c *Client) InitiateMultisigEthTransfer(ctx context.Context, safeContractAddress, destination string, amount string) (string, error) {
+	return c.SafeProposeTransaction(ctx, safeContractAddress, SafeProposal{
+		To:    common.HexToAddress(destination),
+		Value: helper.FloatStringToBigInt(amount, EthDecimals),
+	})
 }
 
-func (c *Client) InitiateMultisigTokenTransfer(safeContractAddress, tokenAddress, destination string, amount int64) (string, error) {
-	ms, err := gnosis.NewGnosis(common.HexToAddress(safeContractAddress), c.Eth)
+// InitiateMultisigTokenTransfer proposes a safe transaction transferring erc20 tokens from the safe. The amount is
+// expressed in tokens, using the decimals of the token contract. The safe tx hash is returned.
+func (c *Client) InitiateMultisigTokenTransfer(ctx context.Context, safeContractAddress, tokenAddress, destination string, amount string) (string, error) {
+	amountIn, err := c.tokenUnits(ctx, common.HexToAddress(tokenAddress), amount)
 	if err != nil {
 		return "", err
 	}
 
-	token, err := erc20.NewErc20(common.HexToAddress(tokenAddress), c.Eth)
+	token, err := abi.JSON(strings.NewReader(erc20.Erc20ABI))
 	if err != nil {
 		return "", err
 	}
-
-	tx, err := token.Transfer(&bind.TransactOpts{}, common.HexToAddress(destination), big.NewInt(amount))
+	data, err := token.Pack("transfer", common.HexToAddress(destination), amountIn)
 	if err != nil {
 		return "", err
 	}
 
-	msTx, err := ms.ExecTransaction(
-		&bind.TransactOpts{},
-		// toAddress,
-		*tx.To(),
-		// value
-		tx.Value(),
-		// data
-		tx.Data(),
-		// operation (optional)
-		0,
-		// safeTxGas (optional)
-		nil,
-		// baseGas (optional)
-		tx.GasPrice(),
-		// gasPrice (optional)
-		tx.GasPrice(),
-		// gasToken (optional)
-		common.HexToAddress("0x0000000000000000000000000000000000000000"),
-		// refundReceiver (optional)
-		c.AddressFromKey(),
-		// signatures
-		[]byte{},
-	)
+	return c.SafeProposeTransaction(ctx, safeContractAddress, SafeProposal{
+		To:   common.HexToAddress(tokenAddress),
+		Data: data,
+	})
+}
 
+// proposeSafeCall proposes a safe transaction calling a method of the safe itself
+func (c *Client) proposeSafeCall(ctx context.Context, safeContractAddress string, method string, args ...interface{}) (string, error) {
+	safe, err := abi.JSON(strings.NewReader(gnosis.GnosisABI))
 	if err != nil {
 		return "", err
 	}
+	data, err := safe.Pack(method, args...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to encode %s", method)
+	}
 
-	return msTx.Hash().Hex(), nil
+	return c.SafeProposeTransaction(ctx, safeContractAddress, SafeProposal{
+		To:   common.HexToAddress(safeContractAddress),
+		Data: data,
+	})
 }
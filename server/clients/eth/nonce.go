@@ -0,0 +1,149 @@
+package goethclient
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// time after which a transaction the node does not know anymore is considered dropped, so nodes behind a load
+// balancer which did not see a fresh transaction yet don't cause its nonce to be reused
+const droppedAfter = time.Minute
+
+var (
+	nonceManagersLock sync.Mutex
+	// nonce managers, keyed by rpc url and address, shared by all clients
+	nonceManagers = map[string]*nonceManager{}
+)
+
+// submittedTx is a transaction submitted through a nonce manager
+type submittedTx struct {
+	hash common.Hash
+	at   time.Time
+}
+
+// nonceManager hands out the nonces of an address. Transactions from the same address are submitted one at a
+// time, so concurrent calls never reuse a nonce and a failed submission does not leave a gap.
+type nonceManager struct {
+	lock sync.Mutex
+	// next is the nonce following the last transaction submitted through this manager
+	next uint64
+	// submitted are the transactions submitted through this manager which the node did not mine yet, by nonce
+	submitted map[uint64]submittedTx
+}
+
+// nonces returns the shared nonce manager for the loaded address
+func (c *Client) nonces() *nonceManager {
+	key := c.Url + c.Address.Hex()
+
+	nonceManagersLock.Lock()
+	defer nonceManagersLock.Unlock()
+
+	m, ok := nonceManagers[key]
+	if !ok {
+		m = &nonceManager{submitted: map[uint64]submittedTx{}}
+		nonceManagers[key] = m
+	}
+	return m
+}
+
+// withNonce calls submit with the next nonce of the loaded address. The nonce is only consumed if submit succeeds.
+// The pending nonce of the node is preferred if it is ahead, e.g. because transactions were sent by another wallet,
+// while the local nonce covers transactions the node did not add to its pending state yet. If the node rejects the
+// nonce as too low, the nonce is synced with the node and the submission is retried once.
+func (c *Client) withNonce(ctx context.Context, submit func(nonce uint64) (*types.Transaction, error)) (*types.Transaction, error) {
+	m := c.nonces()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	nonce, err := c.nextNonce(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := submit(nonce)
+	if err != nil && isNonceTooLow(err) {
+		log.Debug().Err(err).Msgf("nonce %d of %s rejected, syncing with the node", nonce, c.Address.Hex())
+		m.reset()
+		if nonce, err = c.nextNonce(ctx, m); err != nil {
+			return nil, err
+		}
+		tx, err = submit(nonce)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.next = nonce + 1
+	m.submitted[nonce] = submittedTx{hash: tx.Hash(), at: time.Now()}
+	log.Debug().Msgf("submitted tx %s with nonce %d", tx.Hash().Hex(), nonce)
+
+	return tx, nil
+}
+
+// nextNonce returns the nonce for the next transaction, the lock of the manager must be held. If the local nonce is
+// ahead of the node while the node does not know the transaction at its pending nonce, that transaction was dropped,
+// replaced or evicted from the mempool. The pending nonce of the node is then used to close the gap, after which the
+// later transactions of the address can be mined again.
+func (c *Client) nextNonce(ctx context.Context, m *nonceManager) (uint64, error) {
+	pending, err := c.Eth.PendingNonceAt(ctx, c.Address)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get nonce")
+	}
+
+	// transactions below the pending nonce of the node are mined or replaced
+	for nonce := range m.submitted {
+		if nonce < pending {
+			delete(m.submitted, nonce)
+		}
+	}
+	if m.next <= pending {
+		return pending, nil
+	}
+
+	if tx, ok := m.submitted[pending]; ok {
+		if time.Since(tx.at) < droppedAfter {
+			return m.next, nil
+		}
+		_, _, err := c.Eth.TransactionByHash(ctx, tx.hash)
+		if err == nil {
+			return m.next, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return 0, errors.Wrapf(err, "failed to load transaction %s", tx.hash.Hex())
+		}
+	}
+
+	log.Debug().Msgf("transaction of %s with nonce %d was dropped, reusing its nonce", c.Address.Hex(), pending)
+	m.next = pending
+	return pending, nil
+}
+
+// replaced records the replacement of a transaction submitted through the manager
+func (m *nonceManager) replaced(nonce uint64, hash common.Hash) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.submitted[nonce]; ok {
+		m.submitted[nonce] = submittedTx{hash: hash, at: time.Now()}
+	}
+}
+
+// reset forgets the local nonce, so the next nonce is the pending nonce of the node
+func (m *nonceManager) reset() {
+	m.next = 0
+	m.submitted = map[uint64]submittedTx{}
+}
+
+// isNonceTooLow checks if a node rejected a transaction because its nonce was already used
+func isNonceTooLow(err error) bool {
+	return strings.Contains(err.Error(), "nonce too low")
+}
@@ -0,0 +1,35 @@
+package goethclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonceManager(t *testing.T) {
+	t.Run("nonce_too_low", func(t *testing.T) {
+		assert.True(t, isNonceTooLow(errors.New("nonce too low: address 0x1, tx: 4 state: 5")))
+		assert.False(t, isNonceTooLow(errors.New("replacement transaction underpriced")))
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		m := &nonceManager{submitted: map[uint64]submittedTx{}}
+		m.submitted[3] = submittedTx{hash: common.HexToHash("0x1")}
+
+		m.replaced(3, common.HexToHash("0x2"))
+		assert.Equal(t, common.HexToHash("0x2"), m.submitted[3].hash)
+
+		// replacements of transactions not sent through the manager are not tracked
+		m.replaced(4, common.HexToHash("0x3"))
+		assert.NotContains(t, m.submitted, uint64(4))
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		m := &nonceManager{next: 5, submitted: map[uint64]submittedTx{4: {}}}
+		m.reset()
+		assert.Zero(t, m.next)
+		assert.Empty(t, m.submitted)
+	})
+}
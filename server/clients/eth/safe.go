@@ -0,0 +1,460 @@
+package goethclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/web3_proxy/server/clients/eth/gnosis"
+)
+
+const (
+	// SafeCall and SafeDelegateCall are the operations a safe transaction can perform
+	SafeCall         uint8 = 0
+	SafeDelegateCall uint8 = 1
+)
+
+var (
+	safeProposalsLock sync.Mutex
+	// proposed safe transactions, keyed by safe tx hash, shared by all clients so owners on other connections can
+	// sign them. The safe tx hash commits to the chain and the safe, so proposals of different chains don't collide.
+	// Proposals are only kept in memory, they are lost when the server restarts.
+	safeProposals = map[common.Hash]*safeProposal{}
+
+	// sentinelOwner is the head of the linked list of owners in the safe contract
+	sentinelOwner = common.HexToAddress("0x0000000000000000000000000000000000000001")
+)
+
+type (
+	// SafeProposal is a transaction to be executed by a safe
+	SafeProposal struct {
+		To    common.Address
+		Value *big.Int
+		Data  []byte
+		// Operation is SafeCall or SafeDelegateCall
+		Operation uint8
+		// SafeTxGas is the gas available to the transaction in the safe, 0 to use all gas
+		SafeTxGas *big.Int
+		// Nonce of the safe to use, nil to use the nonce following the pending proposals
+		Nonce *uint64
+	}
+
+	// SafeTransaction is a proposed safe transaction and the signatures collected for it
+	SafeTransaction struct {
+		SafeTxHash string
+		Safe       string
+		To         string
+		Value      *big.Int
+		Data       string
+		Operation  uint8
+		SafeTxGas  *big.Int
+		Nonce      uint64
+		Proposer   string
+		ProposedAt time.Time
+		// Signatures by owner address, hex encoded
+		Signatures map[string]string
+	}
+
+	// safeProposal is a stored proposal. Only the signatures change after it is created, guarded by
+	// safeProposalsLock.
+	safeProposal struct {
+		hash       common.Hash
+		safe       common.Address
+		proposal   SafeProposal
+		nonce      uint64
+		proposer   common.Address
+		proposedAt time.Time
+		signatures map[common.Address][]byte
+	}
+)
+
+// SafeProposeTransaction stores a transaction for a safe so its owners can sign it, and signs it with the loaded
+// key, which must be an owner. The safe tx hash is returned.
+func (c *Client) SafeProposeTransaction(ctx context.Context, safeAddress string, proposal SafeProposal) (string, error) {
+	if !common.IsHexAddress(safeAddress) {
+		return "", fmt.Errorf("invalid safe address %s", safeAddress)
+	}
+	if proposal.Operation != SafeCall && proposal.Operation != SafeDelegateCall {
+		return "", fmt.Errorf("invalid operation %d", proposal.Operation)
+	}
+	if proposal.Value == nil {
+		proposal.Value = big.NewInt(0)
+	}
+	if proposal.SafeTxGas == nil {
+		proposal.SafeTxGas = big.NewInt(0)
+	}
+
+	safe := common.HexToAddress(safeAddress)
+	ms, err := gnosis.NewGnosis(safe, c.Eth)
+	if err != nil {
+		return "", err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+
+	if err := c.requireSafeOwner(opts, ms, c.Address); err != nil {
+		return "", err
+	}
+
+	var nonce uint64
+	if proposal.Nonce != nil {
+		nonce = *proposal.Nonce
+	} else {
+		nonce, err = nextSafeNonce(opts, ms, safe)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	rawHash, err := ms.GetTransactionHash(
+		opts,
+		proposal.To,
+		proposal.Value,
+		proposal.Data,
+		proposal.Operation,
+		proposal.SafeTxGas,
+		// no gas refunds, so base gas, gas price, gas token and refund receiver are 0
+		big.NewInt(0),
+		big.NewInt(0),
+		common.Address{},
+		common.Address{},
+		new(big.Int).SetUint64(nonce),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get safe transaction hash")
+	}
+	hash := common.Hash(rawHash)
+
+	signature, err := c.signSafeHash(hash)
+	if err != nil {
+		return "", err
+	}
+
+	safeProposalsLock.Lock()
+	defer safeProposalsLock.Unlock()
+
+	p, ok := safeProposals[hash]
+	if !ok {
+		p = &safeProposal{
+			hash:       hash,
+			safe:       safe,
+			proposal:   proposal,
+			nonce:      nonce,
+			proposer:   c.Address,
+			proposedAt: time.Now(),
+			signatures: map[common.Address][]byte{},
+		}
+		safeProposals[hash] = p
+	}
+	p.signatures[c.Address] = signature
+
+	return hash.Hex(), nil
+}
+
+// SafeSignTransaction signs a proposed safe transaction with the loaded key, which must be an owner of the safe.
+// The signature is stored with the proposal and returned.
+func (c *Client) SafeSignTransaction(ctx context.Context, safeTxHash string) (string, error) {
+	p, err := getSafeProposal(safeTxHash)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := c.signSafeHash(p.hash)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.addSafeSignature(ctx, p, c.Address, signature); err != nil {
+		return "", err
+	}
+
+	return hexutil.Encode(signature), nil
+}
+
+// SafeAddSignature adds a signature made outside of the proxy, e.g. with eth_signTypedData, to a proposed safe
+// transaction. The signer must be an owner of the safe.
+func (c *Client) SafeAddSignature(ctx context.Context, safeTxHash, signature string) error {
+	p, err := getSafeProposal(safeTxHash)
+	if err != nil {
+		return err
+	}
+
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+
+	signer, err := recoverSafeSigner(p.hash, sig)
+	if err != nil {
+		return err
+	}
+
+	return c.addSafeSignature(ctx, p, signer, sig)
+}
+
+// SafeListPending returns the proposals of a safe which can still be executed, ordered by nonce. Proposals with a
+// nonce below the nonce of the safe were executed or replaced, and are dropped.
+func (c *Client) SafeListPending(ctx context.Context, safeAddress string) ([]SafeTransaction, error) {
+	if !common.IsHexAddress(safeAddress) {
+		return nil, fmt.Errorf("invalid safe address %s", safeAddress)
+	}
+	safe := common.HexToAddress(safeAddress)
+
+	ms, err := gnosis.NewGnosis(safe, c.Eth)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := ms.Nonce(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get safe nonce")
+	}
+
+	safeProposalsLock.Lock()
+	defer safeProposalsLock.Unlock()
+
+	pruneSafeProposals(safe, nonce.Uint64())
+
+	pending := []SafeTransaction{}
+	for _, p := range safeProposals {
+		if p.safe == safe {
+			pending = append(pending, p.transaction())
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Nonce != pending[j].Nonce {
+			return pending[i].Nonce < pending[j].Nonce
+		}
+		return pending[i].ProposedAt.Before(pending[j].ProposedAt)
+	})
+
+	return pending, nil
+}
+
+// SafeExecute submits a proposed safe transaction with the collected signatures of the current owners. If the
+// loaded key is an owner which did not sign, its approval is implied by sending the transaction. The transaction
+// hash is returned.
+func (c *Client) SafeExecute(ctx context.Context, safeTxHash string) (string, error) {
+	p, err := getSafeProposal(safeTxHash)
+	if err != nil {
+		return "", err
+	}
+
+	ms, err := gnosis.NewGnosis(p.safe, c.Eth)
+	if err != nil {
+		return "", err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+
+	nonce, err := ms.Nonce(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get safe nonce")
+	}
+	if nonce.Uint64() != p.nonce {
+		return "", fmt.Errorf("transaction has nonce %d but the safe is at nonce %d", p.nonce, nonce.Uint64())
+	}
+
+	threshold, err := ms.GetThreshold(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get safe threshold")
+	}
+	owners, err := ms.GetOwners(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get safe owners")
+	}
+
+	signatures := map[common.Address][]byte{}
+	safeProposalsLock.Lock()
+	for _, owner := range owners {
+		if signature, ok := p.signatures[owner]; ok {
+			signatures[owner] = signature
+		}
+	}
+	safeProposalsLock.Unlock()
+
+	if _, ok := signatures[c.Address]; !ok {
+		for _, owner := range owners {
+			if owner == c.Address {
+				signatures[c.Address] = senderApprovalSignature(c.Address)
+			}
+		}
+	}
+
+	if int64(len(signatures)) < threshold.Int64() {
+		return "", fmt.Errorf("transaction has %d signatures of owners but the threshold is %s", len(signatures), threshold)
+	}
+
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return ms.ExecTransaction(
+			opts,
+			p.proposal.To,
+			p.proposal.Value,
+			p.proposal.Data,
+			p.proposal.Operation,
+			p.proposal.SafeTxGas,
+			big.NewInt(0),
+			big.NewInt(0),
+			common.Address{},
+			common.Address{},
+			encodeSafeSignatures(signatures),
+		)
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to execute safe transaction")
+	}
+
+	return tx.Hash().Hex(), nil
+}
+
+// addSafeSignature stores the signature of an owner of the safe with a proposal
+func (c *Client) addSafeSignature(ctx context.Context, p *safeProposal, owner common.Address, signature []byte) error {
+	ms, err := gnosis.NewGnosis(p.safe, c.Eth)
+	if err != nil {
+		return err
+	}
+	if err := c.requireSafeOwner(&bind.CallOpts{Context: ctx}, ms, owner); err != nil {
+		return err
+	}
+
+	safeProposalsLock.Lock()
+	defer safeProposalsLock.Unlock()
+
+	p.signatures[owner] = signature
+	return nil
+}
+
+// requireSafeOwner returns an error if the address is not an owner of the safe
+func (c *Client) requireSafeOwner(opts *bind.CallOpts, ms *gnosis.Gnosis, address common.Address) error {
+	isOwner, err := ms.IsOwner(opts, address)
+	if err != nil {
+		return errors.Wrap(err, "failed to check safe owner")
+	}
+	if !isOwner {
+		return fmt.Errorf("%s is not an owner of the safe", address.Hex())
+	}
+	return nil
+}
+
+// signSafeHash signs a safe tx hash. The hash is already the EIP-712 digest of the transaction, so it is signed as
+// is, with v in the 27/28 form expected by the safe.
+func (c *Client) signSafeHash(hash common.Hash) ([]byte, error) {
+	signature, err := crypto.Sign(hash[:], c.Key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign safe transaction")
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// nextSafeNonce returns the nonce following the pending proposals of a safe
+func nextSafeNonce(opts *bind.CallOpts, ms *gnosis.Gnosis, safe common.Address) (uint64, error) {
+	current, err := ms.Nonce(opts)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get safe nonce")
+	}
+	nonce := current.Uint64()
+
+	safeProposalsLock.Lock()
+	defer safeProposalsLock.Unlock()
+
+	pruneSafeProposals(safe, nonce)
+	for _, p := range safeProposals {
+		if p.safe == safe && p.nonce >= nonce {
+			nonce = p.nonce + 1
+		}
+	}
+	return nonce, nil
+}
+
+// pruneSafeProposals drops the proposals of a safe with a nonce below the nonce of the safe, they were executed or
+// replaced. The caller must hold safeProposalsLock.
+func pruneSafeProposals(safe common.Address, nonce uint64) {
+	for hash, p := range safeProposals {
+		if p.safe == safe && p.nonce < nonce {
+			delete(safeProposals, hash)
+		}
+	}
+}
+
+func getSafeProposal(safeTxHash string) (*safeProposal, error) {
+	safeProposalsLock.Lock()
+	defer safeProposalsLock.Unlock()
+
+	p, ok := safeProposals[common.HexToHash(safeTxHash)]
+	if !ok {
+		return nil, fmt.Errorf("safe transaction %s not found", safeTxHash)
+	}
+	return p, nil
+}
+
+// recoverSafeSigner returns the address which signed a safe tx hash
+func recoverSafeSigner(hash common.Hash, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("signature must be %d bytes", crypto.SignatureLength)
+	}
+	sig := common.CopyBytes(signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "invalid signature")
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// senderApprovalSignature is the signature of an owner which approves by sending the transaction itself: r is the
+// owner, s is unused and v is 1
+func senderApprovalSignature(owner common.Address) []byte {
+	signature := make([]byte, crypto.SignatureLength)
+	copy(signature[12:32], owner.Bytes())
+	signature[crypto.RecoveryIDOffset] = 1
+	return signature
+}
+
+// encodeSafeSignatures concatenates signatures ordered by owner address, as required by the safe
+func encodeSafeSignatures(signatures map[common.Address][]byte) []byte {
+	owners := make([]common.Address, 0, len(signatures))
+	for owner := range signatures {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool { return bytes.Compare(owners[i].Bytes(), owners[j].Bytes()) < 0 })
+
+	encoded := make([]byte, 0, len(owners)*crypto.SignatureLength)
+	for _, owner := range owners {
+		encoded = append(encoded, signatures[owner]...)
+	}
+	return encoded
+}
+
+// transaction converts a proposal, the caller must hold safeProposalsLock
+func (p *safeProposal) transaction() SafeTransaction {
+	signatures := make(map[string]string, len(p.signatures))
+	for owner, signature := range p.signatures {
+		signatures[owner.Hex()] = hexutil.Encode(signature)
+	}
+
+	return SafeTransaction{
+		SafeTxHash: p.hash.Hex(),
+		Safe:       p.safe.Hex(),
+		To:         p.proposal.To.Hex(),
+		Value:      p.proposal.Value,
+		Data:       hexutil.Encode(p.proposal.Data),
+		Operation:  p.proposal.Operation,
+		SafeTxGas:  p.proposal.SafeTxGas,
+		Nonce:      p.nonce,
+		Proposer:   p.proposer.Hex(),
+		ProposedAt: p.proposedAt,
+		Signatures: signatures,
+	}
+}
@@ -0,0 +1,47 @@
+package goethclient
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	c := &Client{Key: key, Address: crypto.PubkeyToAddress(key.PublicKey)}
+
+	hash := crypto.Keccak256Hash([]byte("safe transaction"))
+	signature, err := c.signSafeHash(hash)
+	require.NoError(t, err)
+	assert.Contains(t, []byte{27, 28}, signature[crypto.RecoveryIDOffset])
+
+	signer, err := recoverSafeSigner(hash, signature)
+	require.NoError(t, err)
+	assert.Equal(t, c.Address, signer)
+
+	_, err = recoverSafeSigner(hash, signature[:64])
+	assert.Error(t, err)
+}
+
+func TestEncodeSafeSignatures(t *testing.T) {
+	low := common.HexToAddress("0x0000000000000000000000000000000000000aaa")
+	high := common.HexToAddress("0xfff0000000000000000000000000000000000000")
+
+	encoded := encodeSafeSignatures(map[common.Address][]byte{
+		high: senderApprovalSignature(high),
+		low:  senderApprovalSignature(low),
+	})
+
+	require.Len(t, encoded, 2*crypto.SignatureLength)
+	assert.Equal(t, senderApprovalSignature(low), encoded[:crypto.SignatureLength])
+	assert.Equal(t, senderApprovalSignature(high), encoded[crypto.SignatureLength:])
+
+	approval := senderApprovalSignature(low)
+	assert.Equal(t, common.LeftPadBytes(low.Bytes(), 32), approval[:32])
+	assert.Equal(t, make([]byte, 32), approval[32:64])
+	assert.Equal(t, byte(1), approval[64])
+}
@@ -2,207 +2,599 @@ package goethclient
 
 import (
 	"context"
+	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	coreEntities "github.com/daoleno/uniswap-sdk-core/entities"
-	"github.com/pkg/errors"
-
-	"github.com/daoleno/uniswapv3-sdk/constants"
-	"github.com/daoleno/uniswapv3-sdk/entities"
-	"github.com/daoleno/uniswapv3-sdk/examples/contract"
-	"github.com/daoleno/uniswapv3-sdk/examples/helper"
-	"github.com/daoleno/uniswapv3-sdk/periphery"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/web3_proxy/server/clients/eth/erc20"
 )
 
 const (
-	SlippageAmount = 3000
-
-	GoerliChainId  = 5
-	MainnetChainId = 1
+	// DefaultSlippageBps is the slippage tolerance, in basis points, used if none is given
+	DefaultSlippageBps = 50
+	// DefaultSwapDeadline is the time a swap can stay pending before the router rejects it
+	DefaultSwapDeadline = 5 * time.Minute
+
+	// maximum slippage tolerance in basis points
+	maxSlippageBps = 5000
+	bpsDenominator = 10000
 )
 
 var (
-	GoerliWethContract  = common.HexToAddress("0xB4FBF271143F4FBf7B91A5ded31805e42b2208d6")
-	MainnetWethContract = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	// feeTiers of the uniswap V3 pools, tried for hops without a fee
+	feeTiers = []uint32{100, 500, 3000, 10000}
+
+	swapRouterABI = mustParseABI(`[
+		{"name":"factory","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+		{"name":"exactInput","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"path","type":"bytes"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountIn","type":"uint256"},{"name":"amountOutMinimum","type":"uint256"}]}],"outputs":[{"name":"amountOut","type":"uint256"}]},
+		{"name":"exactOutput","type":"function","stateMutability":"payable","inputs":[{"name":"params","type":"tuple","components":[{"name":"path","type":"bytes"},{"name":"recipient","type":"address"},{"name":"deadline","type":"uint256"},{"name":"amountOut","type":"uint256"},{"name":"amountInMaximum","type":"uint256"}]}],"outputs":[{"name":"amountIn","type":"uint256"}]},
+		{"name":"multicall","type":"function","stateMutability":"payable","inputs":[{"name":"data","type":"bytes[]"}],"outputs":[{"name":"results","type":"bytes[]"}]},
+		{"name":"unwrapWETH9","type":"function","stateMutability":"payable","inputs":[{"name":"amountMinimum","type":"uint256"},{"name":"recipient","type":"address"}],"outputs":[]},
+		{"name":"refundETH","type":"function","stateMutability":"payable","inputs":[],"outputs":[]}
+	]`)
+	quoterABI = mustParseABI(`[
+		{"name":"quoteExactInput","type":"function","stateMutability":"nonpayable","inputs":[{"name":"path","type":"bytes"},{"name":"amountIn","type":"uint256"}],"outputs":[{"name":"amountOut","type":"uint256"}]},
+		{"name":"quoteExactOutput","type":"function","stateMutability":"nonpayable","inputs":[{"name":"path","type":"bytes"},{"name":"amountOut","type":"uint256"}],"outputs":[{"name":"amountIn","type":"uint256"}]}
+	]`)
+	uniswapFactoryABI = mustParseABI(`[
+		{"name":"getPool","type":"function","stateMutability":"view","inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"},{"name":"fee","type":"uint24"}],"outputs":[{"name":"pool","type":"address"}]}
+	]`)
+	uniswapPoolABI = mustParseABI(`[
+		{"name":"liquidity","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint128"}]},
+		{"name":"slot0","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"sqrtPriceX96","type":"uint160"},{"name":"tick","type":"int24"},{"name":"observationIndex","type":"uint16"},{"name":"observationCardinality","type":"uint16"},{"name":"observationCardinalityNext","type":"uint16"},{"name":"feeProtocol","type":"uint8"},{"name":"unlocked","type":"bool"}]}
+	]`)
+)
 
-	GoerliTft  = coreEntities.NewToken(GoerliChainId, GoerliTestnetEthTftContractAddress, 7, "TFT", "TFT on Ethereum")
-	GoerliWeth = coreEntities.NewToken(GoerliChainId, GoerliWethContract, 18, "WETH", "Wrapped Ether")
+type (
+	// SwapRequest describes a swap through uniswap V3 pools
+	SwapRequest struct {
+		// Path of tokens from the token sold to the token bought. Tokens are contract addresses, the symbol of the
+		// native currency or TFT.
+		Path []string
+		// Fees of the pool of every hop, in hundredths of a basis point. If empty, the pool with the most liquidity
+		// is used for every hop.
+		Fees []uint32
+		// Amount of the token sold for an exact input swap, or of the token bought for an exact output swap
+		Amount      string
+		ExactOutput bool
+		// SlippageBps is the slippage tolerance in basis points, DefaultSlippageBps if 0
+		SlippageBps uint32
+		// Deadline after which the swap is rejected, DefaultSwapDeadline if 0
+		Deadline time.Duration
+	}
 
-	MainnetTft  = coreEntities.NewToken(MainnetChainId, MainnetEthTftContractAddress, 7, "TFT", "TFT on Ethereum")
-	MainnetWeth = coreEntities.NewToken(MainnetChainId, MainnetWethContract, 18, "WETH", "Wrapped Ether")
+	// SwapQuote is the expected outcome of a swap
+	SwapQuote struct {
+		// Path of token addresses, the wrapped native currency is used for the native currency
+		Path        []string
+		Fees        []uint32
+		ExactOutput bool
+		AmountIn    string
+		AmountOut   string
+		// MinimumReceived is the amount bought below which an exact input swap fails
+		MinimumReceived string
+		// MaximumSold is the amount sold above which an exact output swap fails
+		MaximumSold string
+		// PriceImpact of the swap in percent, including the fees of the pools
+		PriceImpact string
+		SlippageBps uint32
+
+		amountIn  *big.Int
+		amountOut *big.Int
+		// limit is the minimum received for exact input, and the maximum sold for exact output
+		limit *big.Int
+	}
 
-	SwapRouter = common.HexToAddress(helper.ContractV3SwapRouterV1)
+	swapToken struct {
+		address  common.Address
+		decimals uint8
+		// native is set for the native currency, which is swapped as its wrapped token
+		native bool
+	}
+
+	swapRoute struct {
+		tokens []swapToken
+		fees   []uint32
+	}
+
+	exactInputParams struct {
+		Path             []byte
+		Recipient        common.Address
+		Deadline         *big.Int
+		AmountIn         *big.Int
+		AmountOutMinimum *big.Int
+	}
+
+	exactOutputParams struct {
+		Path            []byte
+		Recipient       common.Address
+		Deadline        *big.Int
+		AmountOut       *big.Int
+		AmountInMaximum *big.Int
+	}
 )
 
-func (c *Client) QuoteEthForTft(ctx context.Context, amount string) (string, error) {
-	tft, err := c.GetTftTokenContract()
+// Quote returns the expected outcome of a swap without submitting it
+func (c *Client) Quote(ctx context.Context, req SwapRequest) (SwapQuote, error) {
+	route, err := c.swapRoute(ctx, req)
 	if err != nil {
-		return "", err
+		return SwapQuote{}, err
+	}
+
+	return c.quote(ctx, route, req)
+}
+
+// Swap submits a swap through the swap router of the chain. If the router is not allowed to spend enough of the sold
+// token, an approval is submitted first and waited for. The quote the limits of the swap are based on, and the
+// transaction hash are returned.
+func (c *Client) Swap(ctx context.Context, req SwapRequest) (SwapQuote, string, error) {
+	if err := c.requireContract(c.Chain.SwapRouter, "swap router"); err != nil {
+		return SwapQuote{}, "", err
 	}
 
-	weth, err := c.GetWethTokenContract()
+	route, err := c.swapRoute(ctx, req)
 	if err != nil {
-		return "", err
+		return SwapQuote{}, "", err
+	}
+	quote, err := c.quote(ctx, route, req)
+	if err != nil {
+		return SwapQuote{}, "", err
 	}
 
-	return c.quoteTokens(ctx, amount, weth, tft)
-}
+	deadline := req.Deadline
+	if deadline == 0 {
+		deadline = DefaultSwapDeadline
+	}
+	deadlineTimestamp := big.NewInt(time.Now().Add(deadline).Unix())
 
-func (c *Client) QuoteTftForEth(ctx context.Context, amount string) (string, error) {
-	tft, err := c.GetTftTokenContract()
+	in, out := route.tokens[0], route.tokens[len(route.tokens)-1]
+	// the router keeps the wrapped native currency bought, to unwrap it for the sender
+	recipient := c.Address
+	if out.native {
+		recipient = c.Chain.SwapRouter
+	}
+
+	var call []byte
+	maxIn, minOut := quote.amountIn, quote.amountOut
+	if req.ExactOutput {
+		maxIn = quote.limit
+		call, err = swapRouterABI.Pack("exactOutput", exactOutputParams{
+			Path:            route.encode(true),
+			Recipient:       recipient,
+			Deadline:        deadlineTimestamp,
+			AmountOut:       quote.amountOut,
+			AmountInMaximum: quote.limit,
+		})
+	} else {
+		minOut = quote.limit
+		call, err = swapRouterABI.Pack("exactInput", exactInputParams{
+			Path:             route.encode(false),
+			Recipient:        recipient,
+			Deadline:         deadlineTimestamp,
+			AmountIn:         quote.amountIn,
+			AmountOutMinimum: quote.limit,
+		})
+	}
 	if err != nil {
-		return "", err
+		return SwapQuote{}, "", errors.Wrap(err, "failed to encode swap")
+	}
+
+	calls := [][]byte{call}
+	if out.native {
+		unwrap, err := swapRouterABI.Pack("unwrapWETH9", minOut, c.Address)
+		if err != nil {
+			return SwapQuote{}, "", errors.Wrap(err, "failed to encode unwrap")
+		}
+		calls = append(calls, unwrap)
+	}
+	if in.native && req.ExactOutput {
+		// the native currency which was not needed is refunded
+		refund, err := swapRouterABI.Pack("refundETH")
+		if err != nil {
+			return SwapQuote{}, "", errors.Wrap(err, "failed to encode refund")
+		}
+		calls = append(calls, refund)
+	}
+
+	data := call
+	if len(calls) > 1 {
+		data, err = swapRouterABI.Pack("multicall", calls)
+		if err != nil {
+			return SwapQuote{}, "", errors.Wrap(err, "failed to encode multicall")
+		}
+	}
+
+	value := big.NewInt(0)
+	if in.native {
+		value = maxIn
+	} else if err := c.ensureRouterAllowance(ctx, in.address, maxIn); err != nil {
+		return SwapQuote{}, "", err
 	}
 
-	weth, err := c.GetWethTokenContract()
+	tx, err := c.sendNewTransaction(ctx, &c.Chain.SwapRouter, value, data, 0)
 	if err != nil {
-		return "", err
+		return SwapQuote{}, "", errors.Wrap(err, "failed to send swap transaction")
 	}
+	log.Debug().Msgf("swap tx submitted: %s, in %s out %s", tx.Hash().Hex(), quote.AmountIn, quote.AmountOut)
 
-	return c.quoteTokens(ctx, amount, tft, weth)
+	return quote, tx.Hash().Hex(), nil
 }
 
-func (c *Client) quoteTokens(ctx context.Context, input string, token0 *coreEntities.Token, token1 *coreEntities.Token) (string, error) {
-	quoterContract, err := contract.NewUniswapv3Quoter(common.HexToAddress(helper.ContractV3Quoter), c.Eth)
+func (c *Client) QuoteEthForTft(ctx context.Context, amount string) (string, error) {
+	quote, err := c.Quote(ctx, SwapRequest{Path: []string{c.Chain.NativeCurrency, "TFT"}, Amount: amount})
 	if err != nil {
-		log.Err(err).Msg("failed to create quoter contract")
 		return "", err
 	}
-	// 0.03% slippage
-	fee := big.NewInt(SlippageAmount)
-
-	amountIn := helper.FloatStringToBigInt(input, int(token0.Decimals()))
-	sqrtPriceLimitX96 := big.NewInt(0)
+	return quote.AmountOut, nil
+}
 
-	var out []interface{}
-	rawCaller := &contract.Uniswapv3QuoterRaw{Contract: quoterContract}
-	err = rawCaller.Call(nil, &out, "quoteExactInputSingle", token0.Address, token1.Address,
-		fee, amountIn, sqrtPriceLimitX96)
+func (c *Client) QuoteTftForEth(ctx context.Context, amount string) (string, error) {
+	quote, err := c.Quote(ctx, SwapRequest{Path: []string{"TFT", c.Chain.NativeCurrency}, Amount: amount})
 	if err != nil {
-		log.Err(err).Msg("failed to call quoteExactInputSingle")
 		return "", err
 	}
+	return quote.AmountOut, nil
+}
 
-	log.Debug().Msgf("Quote: input: %s, output: %s", input, out[0].(*big.Int).String())
+func (c *Client) SwapEthForTft(ctx context.Context, amountIn string) (string, error) {
+	_, hash, err := c.Swap(ctx, SwapRequest{Path: []string{c.Chain.NativeCurrency, "TFT"}, Amount: amountIn})
+	return hash, err
+}
 
-	return CurrencyToString(out[0].(*big.Int), int(token1.Decimals())), nil
+func (c *Client) SwapTftForEth(ctx context.Context, amountIn string) (string, error) {
+	_, hash, err := c.Swap(ctx, SwapRequest{Path: []string{"TFT", c.Chain.NativeCurrency}, Amount: amountIn})
+	return hash, err
 }
 
-func (c *Client) SwapEthForTft(ctx context.Context, amountIn string) (string, error) {
-	tft, err := c.GetTftTokenContract()
-	if err != nil {
-		return "", err
+// quote gets the amounts of a swap from the quoter, and derives the limits and price impact
+func (c *Client) quote(ctx context.Context, route swapRoute, req SwapRequest) (SwapQuote, error) {
+	if err := c.requireContract(c.Chain.Quoter, "swap quoter"); err != nil {
+		return SwapQuote{}, err
+	}
+
+	slippage := req.SlippageBps
+	if slippage == 0 {
+		slippage = DefaultSlippageBps
+	}
+	if slippage > maxSlippageBps {
+		return SwapQuote{}, fmt.Errorf("slippage tolerance can be at most %d basis points", maxSlippageBps)
 	}
 
-	weth, err := c.GetWethTokenContract()
+	in, out := route.tokens[0], route.tokens[len(route.tokens)-1]
+	quote := SwapQuote{
+		Fees:        route.fees,
+		ExactOutput: req.ExactOutput,
+		SlippageBps: slippage,
+	}
+	for _, token := range route.tokens {
+		quote.Path = append(quote.Path, token.address.Hex())
+	}
+
+	var err error
+	if req.ExactOutput {
+		quote.amountOut, err = parseUnits(req.Amount, out.decimals)
+		if err != nil {
+			return SwapQuote{}, err
+		}
+		quote.amountIn, err = c.callQuoter(ctx, "quoteExactOutput", route.encode(true), quote.amountOut)
+		if err != nil {
+			return SwapQuote{}, err
+		}
+		quote.limit = applySlippage(quote.amountIn, slippage, true)
+		quote.MaximumSold = formatUnits(quote.limit, in.decimals)
+	} else {
+		quote.amountIn, err = parseUnits(req.Amount, in.decimals)
+		if err != nil {
+			return SwapQuote{}, err
+		}
+		quote.amountOut, err = c.callQuoter(ctx, "quoteExactInput", route.encode(false), quote.amountIn)
+		if err != nil {
+			return SwapQuote{}, err
+		}
+		quote.limit = applySlippage(quote.amountOut, slippage, false)
+		quote.MinimumReceived = formatUnits(quote.limit, out.decimals)
+	}
+	quote.AmountIn = formatUnits(quote.amountIn, in.decimals)
+	quote.AmountOut = formatUnits(quote.amountOut, out.decimals)
+
+	midPrice, err := c.midPrice(ctx, route)
 	if err != nil {
-		return "", err
+		return SwapQuote{}, err
 	}
+	quote.PriceImpact = priceImpact(midPrice, quote.amountIn, quote.amountOut)
 
-	return c.makeSwap(ctx, amountIn, weth, tft)
+	return quote, nil
 }
 
-func (c *Client) SwapTftForEth(ctx context.Context, amountIn string) (string, error) {
-	tft, err := c.GetTftTokenContract()
+func (c *Client) callQuoter(ctx context.Context, method string, path []byte, amount *big.Int) (*big.Int, error) {
+	out, err := c.callABI(ctx, quoterABI, c.Chain.Quoter, method, path, amount)
 	if err != nil {
-		return "", err
+		return nil, errors.Wrap(err, "failed to get quote, the route might not have enough liquidity")
+	}
+	return out[0].(*big.Int), nil
+}
+
+// swapRoute resolves the tokens of a swap, and the pools between them
+func (c *Client) swapRoute(ctx context.Context, req SwapRequest) (swapRoute, error) {
+	if len(req.Path) < 2 {
+		return swapRoute{}, errors.New("a swap path needs at least 2 tokens")
+	}
+	if len(req.Fees) != 0 && len(req.Fees) != len(req.Path)-1 {
+		return swapRoute{}, fmt.Errorf("a swap path of %d tokens needs %d fees", len(req.Path), len(req.Path)-1)
+	}
+
+	route := swapRoute{fees: req.Fees}
+	for i, name := range req.Path {
+		token, err := c.resolveSwapToken(ctx, name)
+		if err != nil {
+			return swapRoute{}, err
+		}
+		if token.native && i != 0 && i != len(req.Path)-1 {
+			return swapRoute{}, fmt.Errorf("%s can only be swapped at the start or end of a path", name)
+		}
+		if i > 0 && route.tokens[i-1].address == token.address {
+			return swapRoute{}, fmt.Errorf("swap path swaps %s for itself", name)
+		}
+		route.tokens = append(route.tokens, token)
 	}
 
-	weth, err := c.GetWethTokenContract()
+	if len(route.fees) != 0 {
+		return route, nil
+	}
+
+	factory, err := c.uniswapFactory(ctx)
 	if err != nil {
-		return "", err
+		return swapRoute{}, err
+	}
+	for i := 1; i < len(route.tokens); i++ {
+		fee, err := c.deepestPool(ctx, factory, route.tokens[i-1].address, route.tokens[i].address)
+		if err != nil {
+			return swapRoute{}, err
+		}
+		route.fees = append(route.fees, fee)
 	}
 
-	return c.makeSwap(ctx, amountIn, tft, weth)
+	return route, nil
+}
+
+// resolveSwapToken resolves a token address, the native currency symbol or TFT
+func (c *Client) resolveSwapToken(ctx context.Context, token string) (swapToken, error) {
+	switch {
+	case strings.EqualFold(token, c.Chain.NativeCurrency):
+		if err := c.requireContract(c.Chain.Weth, "wrapped "+c.Chain.NativeCurrency); err != nil {
+			return swapToken{}, err
+		}
+		return swapToken{address: c.Chain.Weth, decimals: EthDecimals, native: true}, nil
+	case strings.EqualFold(token, "TFT"):
+		if err := c.requireContract(c.Chain.Tft, "TFT"); err != nil {
+			return swapToken{}, err
+		}
+		return swapToken{address: c.Chain.Tft, decimals: TftDecimals}, nil
+	case common.IsHexAddress(token):
+		address := common.HexToAddress(token)
+		decimals, err := c.tokenDecimals(ctx, address)
+		if err != nil {
+			return swapToken{}, err
+		}
+		return swapToken{address: address, decimals: decimals}, nil
+	default:
+		return swapToken{}, fmt.Errorf("unknown token %s", token)
+	}
 }
 
-func (c *Client) makeSwap(ctx context.Context, input string, token0 *coreEntities.Token, token1 *coreEntities.Token) (string, error) {
-	pool, err := helper.ConstructV3Pool(c.Eth, token0, token1, uint64(constants.FeeMedium))
+// uniswapFactory returns the pool factory the swap router uses
+func (c *Client) uniswapFactory(ctx context.Context) (common.Address, error) {
+	if err := c.requireContract(c.Chain.SwapRouter, "swap router"); err != nil {
+		return common.Address{}, err
+	}
+	out, err := c.callABI(ctx, swapRouterABI, c.Chain.SwapRouter, "factory")
 	if err != nil {
-		log.Err(err).Msg("failed to construct pool")
-		return "", err
+		return common.Address{}, errors.Wrap(err, "failed to get pool factory")
+	}
+	return out[0].(common.Address), nil
+}
+
+// deepestPool returns the fee of the pool between two tokens with the most liquidity
+func (c *Client) deepestPool(ctx context.Context, factory, tokenA, tokenB common.Address) (uint32, error) {
+	var best uint32
+	var bestLiquidity *big.Int
+	for _, fee := range feeTiers {
+		pool, err := c.uniswapPool(ctx, factory, tokenA, tokenB, fee)
+		if err != nil {
+			return 0, err
+		}
+		if pool == (common.Address{}) {
+			continue
+		}
+		out, err := c.callABI(ctx, uniswapPoolABI, pool, "liquidity")
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to get pool liquidity")
+		}
+		liquidity := out[0].(*big.Int)
+		if bestLiquidity == nil || liquidity.Cmp(bestLiquidity) > 0 {
+			best, bestLiquidity = fee, liquidity
+		}
 	}
 
-	// 0.03% slippage
-	slippageTolerance := coreEntities.NewPercent(big.NewInt(1), big.NewInt(SlippageAmount))
-	//after 5 minutes
-	d := time.Now().Add(time.Minute * time.Duration(5)).Unix()
-	deadline := big.NewInt(d)
+	if bestLiquidity == nil || bestLiquidity.Sign() == 0 {
+		return 0, fmt.Errorf("no pool with liquidity for %s and %s", tokenA.Hex(), tokenB.Hex())
+	}
+	return best, nil
+}
 
-	// single trade input
-	// single-hop exact input
-	r, err := entities.NewRoute([]*entities.Pool{pool}, token0, token1)
+func (c *Client) uniswapPool(ctx context.Context, factory, tokenA, tokenB common.Address, fee uint32) (common.Address, error) {
+	out, err := c.callABI(ctx, uniswapFactoryABI, factory, "getPool", tokenA, tokenB, new(big.Int).SetUint64(uint64(fee)))
 	if err != nil {
-		log.Err(err).Msg("failed to create route")
-		return "", err
+		return common.Address{}, errors.Wrap(err, "failed to get pool")
 	}
+	return out[0].(common.Address), nil
+}
 
-	swapValue := helper.FloatStringToBigInt(input, int(token0.Decimals()))
+// midPrice returns the current price of the route, in smallest units of the token bought per smallest unit of the
+// token sold, before fees
+func (c *Client) midPrice(ctx context.Context, route swapRoute) (*big.Rat, error) {
+	factory, err := c.uniswapFactory(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	trade, err := entities.FromRoute(r, coreEntities.FromRawAmount(token0, swapValue), coreEntities.ExactInput)
+	price := big.NewRat(1, 1)
+	for i := 1; i < len(route.tokens); i++ {
+		tokenIn, tokenOut := route.tokens[i-1].address, route.tokens[i].address
+		pool, err := c.uniswapPool(ctx, factory, tokenIn, tokenOut, route.fees[i-1])
+		if err != nil {
+			return nil, err
+		}
+		if pool == (common.Address{}) {
+			return nil, fmt.Errorf("no pool with fee %d for %s and %s", route.fees[i-1], tokenIn.Hex(), tokenOut.Hex())
+		}
+		out, err := c.callABI(ctx, uniswapPoolABI, pool, "slot0")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get pool price")
+		}
+		price.Mul(price, poolPrice(out[0].(*big.Int), tokenIn, tokenOut))
+	}
+	return price, nil
+}
+
+// ensureRouterAllowance approves the swap router to spend an amount of a token if its allowance is too low, and
+// waits for the approval so the swap can be estimated
+func (c *Client) ensureRouterAllowance(ctx context.Context, token common.Address, amount *big.Int) error {
+	contract, err := erc20.NewErc20(token, c.Eth)
 	if err != nil {
-		log.Err(err).Msg("failed to create trade")
-		return "", err
+		return err
+	}
+	allowance, err := contract.Allowance(&bind.CallOpts{Context: ctx}, c.Address, c.Chain.SwapRouter)
+	if err != nil {
+		return errors.Wrap(err, "failed to get allowance of the swap router")
+	}
+	if allowance.Cmp(amount) >= 0 {
+		return nil
 	}
 
-	log.Printf("Swap: input %v ouput %v", trade.Swaps[0].InputAmount.Quotient(), trade.Swaps[0].OutputAmount.Wrapped().Quotient())
-	params, err := periphery.SwapCallParameters([]*entities.Trade{trade}, &periphery.SwapOptions{
-		SlippageTolerance: slippageTolerance,
-		Recipient:         c.Address,
-		Deadline:          deadline,
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.Approve(opts, c.Chain.SwapRouter, amount)
 	})
 	if err != nil {
-		log.Err(err).Msg("failed to get swap call parameters")
-		return "", err
+		return errors.Wrap(err, "failed to approve the swap router")
 	}
+	log.Debug().Msgf("swap router approval tx submitted: %s", tx.Hash().Hex())
 
-	gasPrice, err := c.Eth.SuggestGasPrice(context.Background())
+	status, err := c.WaitForTransaction(ctx, tx.Hash().Hex(), 1, DefaultSwapDeadline)
 	if err != nil {
-		return "", err
+		return errors.Wrap(err, "failed to wait for the approval of the swap router")
+	}
+	if status.Status != TransactionStatusSuccess {
+		return fmt.Errorf("approval of the swap router %s failed", tx.Hash().Hex())
 	}
+	return nil
+}
 
-	log.Debug().Msgf("gasLimit %d gasprice %d", GasLimit, gasPrice.Uint64())
-	nounc, err := c.Eth.NonceAt(context.Background(), c.Address, nil)
+// callABI calls a read only method of a contract with an ABI
+func (c *Client) callABI(ctx context.Context, contractABI abi.ABI, address common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	data, err := contractABI.Pack(method, args...)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	output, err := c.Eth.CallContract(ctx, ethereum.CallMsg{From: c.Address, To: &address, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return contractABI.Unpack(method, output)
+}
 
-	tx := types.NewTransaction(nounc, SwapRouter, swapValue,
-		GasLimit, gasPrice, params.Calldata)
+// encode packs the route in the uniswap path format: token, fee, token, ... The path of exact output swaps is
+// reversed, from the token bought to the token sold.
+func (r swapRoute) encode(reverse bool) []byte {
+	path := make([]byte, 0, len(r.tokens)*common.AddressLength+len(r.fees)*3)
+	for i := range r.tokens {
+		// the fee of the hop to token i, from the previous token
+		token, fee := i, i-1
+		if reverse {
+			token, fee = len(r.tokens)-1-i, len(r.fees)-i
+		}
+		if i > 0 {
+			path = append(path, byte(r.fees[fee]>>16), byte(r.fees[fee]>>8), byte(r.fees[fee]))
+		}
+		path = append(path, r.tokens[token].address.Bytes()...)
+	}
+	return path
+}
 
-	return c.sendTransaction(ctx, tx)
+// applySlippage returns the minimum amount received, or with up set the maximum amount sold, for a slippage
+// tolerance in basis points
+func applySlippage(amount *big.Int, slippageBps uint32, up bool) *big.Int {
+	factor := int64(bpsDenominator - slippageBps)
+	if up {
+		factor = int64(bpsDenominator + slippageBps)
+	}
+	limit := new(big.Int).Mul(amount, big.NewInt(factor))
+	if up {
+		// rounded up, so the limit never rejects the quoted amount
+		limit.Add(limit, big.NewInt(bpsDenominator-1))
+	}
+	return limit.Div(limit, big.NewInt(bpsDenominator))
 }
 
-func (c *Client) GetTftTokenContract() (*coreEntities.Token, error) {
-	chainID, err := c.Eth.NetworkID(context.Background())
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get chainID")
+// poolPrice converts the square root price of a pool to the price of tokenIn in tokenOut. The pool price is the
+// amount of token1 per token0, where token0 is the token with the lowest address.
+func poolPrice(sqrtPriceX96 *big.Int, tokenIn, tokenOut common.Address) *big.Rat {
+	q192 := new(big.Int).Lsh(big.NewInt(1), 192)
+	priceX192 := new(big.Int).Mul(sqrtPriceX96, sqrtPriceX96)
+	if tokenIn.Hash().Big().Cmp(tokenOut.Hash().Big()) < 0 {
+		return new(big.Rat).SetFrac(priceX192, q192)
 	}
+	if priceX192.Sign() == 0 {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFrac(q192, priceX192)
+}
 
-	if chainID.Cmp(big.NewInt(EthMainnetId)) == 0 {
-		return MainnetTft, nil
-	} else if chainID.Cmp(big.NewInt(EthGoerliId)) == 0 {
-		return GoerliTft, nil
-	} else {
-		return nil, errors.New("unsupported chainID")
+// priceImpact returns how much less is received than at the mid price, in percent with 2 decimals
+func priceImpact(midPrice *big.Rat, amountIn, amountOut *big.Int) string {
+	spot := new(big.Rat).Mul(midPrice, new(big.Rat).SetInt(amountIn))
+	if spot.Sign() == 0 {
+		return "0.00"
+	}
+	impact := new(big.Rat).Sub(spot, new(big.Rat).SetInt(amountOut))
+	impact.Quo(impact, spot)
+	impact.Mul(impact, big.NewRat(100, 1))
+	if impact.Sign() < 0 {
+		impact.SetInt64(0)
 	}
+	return impact.FloatString(2)
 }
 
-func (c *Client) GetWethTokenContract() (*coreEntities.Token, error) {
-	chainID, err := c.Eth.NetworkID(context.Background())
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get chainID")
+		panic(err)
 	}
+	return parsed
+}
 
-	if chainID.Cmp(big.NewInt(EthMainnetId)) == 0 {
-		return MainnetWeth, nil
-	} else if chainID.Cmp(big.NewInt(EthGoerliId)) == 0 {
-		return GoerliWeth, nil
-	} else {
-		return nil, errors.New("unsupported chainID")
+// GetTftTokenContract returns the TFT token of the loaded chain
+func (c *Client) GetTftTokenContract() (*coreEntities.Token, error) {
+	if err := c.requireContract(c.Chain.Tft, "TFT"); err != nil {
+		return nil, err
+	}
+	return coreEntities.NewToken(uint(c.Chain.ChainID), c.Chain.Tft, TftDecimals, "TFT", "TFT on "+c.Chain.Name), nil
+}
+
+// GetWethTokenContract returns the wrapped native currency token of the loaded chain
+func (c *Client) GetWethTokenContract() (*coreEntities.Token, error) {
+	if err := c.requireContract(c.Chain.Weth, "wrapped "+c.Chain.NativeCurrency); err != nil {
+		return nil, err
 	}
+	symbol := "W" + c.Chain.NativeCurrency
+	return coreEntities.NewToken(uint(c.Chain.ChainID), c.Chain.Weth, EthDecimals, symbol, "Wrapped "+c.Chain.NativeCurrency), nil
 }
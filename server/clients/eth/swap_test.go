@@ -0,0 +1,67 @@
+package goethclient
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwapRouteEncode(t *testing.T) {
+	usdc := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	weth := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tft := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	route := swapRoute{
+		tokens: []swapToken{{address: usdc}, {address: weth}, {address: tft}},
+		fees:   []uint32{500, 3000},
+	}
+
+	assert.Equal(t,
+		"1111111111111111111111111111111111111111"+"0001f4"+"2222222222222222222222222222222222222222"+"000bb8"+"3333333333333333333333333333333333333333",
+		hex.EncodeToString(route.encode(false)))
+	assert.Equal(t,
+		"3333333333333333333333333333333333333333"+"000bb8"+"2222222222222222222222222222222222222222"+"0001f4"+"1111111111111111111111111111111111111111",
+		hex.EncodeToString(route.encode(true)))
+}
+
+func TestApplySlippage(t *testing.T) {
+	assert.Equal(t, "995", applySlippage(big.NewInt(1000), 50, false).String())
+	assert.Equal(t, "1005", applySlippage(big.NewInt(1000), 50, true).String())
+	// the maximum sold is rounded up
+	assert.Equal(t, "2", applySlippage(big.NewInt(1), 50, true).String())
+}
+
+func TestPriceImpact(t *testing.T) {
+	low := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	high := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	// sqrt price of 2 token1 per token0
+	sqrtPrice := new(big.Int).Lsh(big.NewInt(1), 96)
+	sqrtPrice.Mul(sqrtPrice, big.NewInt(2))
+
+	assert.Equal(t, "4", poolPrice(sqrtPrice, low, high).RatString())
+	assert.Equal(t, "1/4", poolPrice(sqrtPrice, high, low).RatString())
+
+	assert.Equal(t, "1.00", priceImpact(big.NewRat(4, 1), big.NewInt(100), big.NewInt(396)))
+	assert.Equal(t, "0.00", priceImpact(big.NewRat(4, 1), big.NewInt(100), big.NewInt(400)))
+}
+
+func TestUnits(t *testing.T) {
+	units, err := parseUnits("1.5", 18)
+	require.NoError(t, err)
+	assert.Equal(t, "1500000000000000000", units.String())
+	assert.Equal(t, "1.5", formatUnits(units, 18))
+
+	units, err = parseUnits("12", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "12", formatUnits(units, 7))
+
+	_, err = parseUnits("0.123", 2)
+	assert.Error(t, err)
+	_, err = parseUnits("-1", 2)
+	assert.Error(t, err)
+	_, err = parseUnits("abc", 2)
+	assert.Error(t, err)
+}
@@ -2,11 +2,15 @@ package goethclient
 
 import (
 	"context"
+	"math/big"
 	"time"
 
 	"github.com/daoleno/uniswapv3-sdk/examples/helper"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	tft "github.com/threefoldfoundation/tft/bridge/stellar/contracts/tokenv1"
 )
@@ -32,61 +36,73 @@ func (c *Client) TransferEthTft(ctx context.Context, destination string, amount
 	ctxWithCancel, cancel := context.WithTimeout(ctx, time.Minute*10)
 	defer cancel()
 
-	opts, err := c.getDefaultTransactionOpts(ctxWithCancel)
-	if err != nil {
-		return "", err
-	}
-
 	// Convert amount to big.Int
 	amountIn := helper.FloatStringToBigInt(amount, TftDecimals)
-	tx, err := tft.Transfer(opts, SwapRouter, amountIn)
+	tx, err := c.transact(ctxWithCancel, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return tft.Transfer(opts, common.HexToAddress(destination), amountIn)
+	})
 	if err != nil {
 		log.Err(err).Msg("failed to approve tft spending")
 		return "", err
 	}
 
-	r, err := bind.WaitMined(ctxWithCancel, c.Eth, tx)
-	if err != nil {
-		log.Err(err).Msg("failed to wait for tft approval")
-		return "", err
-	}
-
-	log.Debug().Msgf("Approve spend tx mined: %s, block %d, gas: %d, status: %d", tx.Hash().Hex(), r.BlockNumber, r.GasUsed, r.Status)
+	log.Debug().Msgf("TFT transfer tx submitted: %s", tx.Hash().Hex())
 
 	return tx.Hash().Hex(), nil
 }
 
 func (c *Client) BridgeToStellar(ctx context.Context, destination string, amount string) (string, error) {
-	tftC, err := c.GetTftTokenContract()
-	if err != nil {
+	if err := c.requireContract(c.Chain.TftBridge, "TFT bridge"); err != nil {
 		return "", err
 	}
-	tft, err := tft.NewToken(tftC.Address, c.Eth)
+	tft, err := tft.NewToken(c.Chain.TftBridge, c.Eth)
 	if err != nil {
 		return "", err
 	}
 
-	opts, err := c.getDefaultTransactionOpts(ctx)
+	amountIn := helper.FloatStringToBigInt(amount, TftDecimals)
+	log.Info().Msgf("Withdrawing %s TFT to %s", amount, destination)
+	tx, err := c.transact(ctx, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return tft.Withdraw(opts, amountIn, destination, "stellar")
+	})
 	if err != nil {
 		return "", err
 	}
 
-	amountIn := helper.FloatStringToBigInt(amount, TftDecimals)
-	log.Info().Msgf("Withdrawing %s TFT to %s", amount, destination)
-	tx, err := tft.Withdraw(opts, amountIn, destination, "stellar")
+	log.Debug().Msgf("Withdraw tx submitted: %s", tx.Hash().Hex())
+
+	return tx.Hash().Hex(), nil
+}
+
+// EstimateBridgeToStellarFee estimates the maximum fee in wei of withdrawing TFT to stellar with BridgeToStellar
+func (c *Client) EstimateBridgeToStellarFee(ctx context.Context, destination string, amount string) (*big.Int, error) {
+	if err := c.requireContract(c.Chain.TftBridge, "TFT bridge"); err != nil {
+		return nil, err
+	}
+	parsed, err := tft.TokenMetaData.GetAbi()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	r, err := bind.WaitMined(ctx, c.Eth, tx)
+	data, err := parsed.Pack("withdraw", helper.FloatStringToBigInt(amount, TftDecimals), destination, "stellar")
 	if err != nil {
-		log.Err(err).Msg("failed to wait for tft approval")
-		return "", err
+		return nil, errors.Wrap(err, "failed to encode withdrawal")
+	}
+	gas, err := c.estimateGas(ctx, ethereum.CallMsg{From: c.Address, To: &c.Chain.TftBridge, Data: data})
+	if err != nil {
+		return nil, err
 	}
 
-	log.Debug().Msgf("Withdraw tx mined: %s, block %d, gas: %d, status: %d", tx.Hash().Hex(), r.BlockNumber, r.GasUsed, r.Status)
+	fees, err := c.EstimateFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	price := fees.MaxFeePerGas
+	if price == nil {
+		price = fees.GasPrice
+	}
 
-	return tx.Hash().Hex(), nil
+	return new(big.Int).Mul(price, new(big.Int).SetUint64(gas)), nil
 }
 
 func (c *Client) GetEthTftBalance(ctx context.Context) (string, error) {
@@ -116,6 +132,9 @@ func (c *Client) GetEthTftBalance(ctx context.Context) (string, error) {
 }
 
 func (c *Client) ApproveEthTftSpending(ctx context.Context, input string) (string, error) {
+	if err := c.requireContract(c.Chain.SwapRouter, "swap router"); err != nil {
+		return "", err
+	}
 	tftC, err := c.GetTftTokenContract()
 	if err != nil {
 		return "", err
@@ -129,30 +148,24 @@ func (c *Client) ApproveEthTftSpending(ctx context.Context, input string) (strin
 	ctxWithCancel, cancel := context.WithTimeout(ctx, time.Minute*10)
 	defer cancel()
 
-	opts, err := c.getDefaultTransactionOpts(ctxWithCancel)
-	if err != nil {
-		return "", err
-	}
-
 	amount := helper.FloatStringToBigInt(input, int(tftC.Decimals()))
-	tx, err := tft.Approve(opts, SwapRouter, amount)
+	tx, err := c.transact(ctxWithCancel, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return tft.Approve(opts, c.Chain.SwapRouter, amount)
+	})
 	if err != nil {
 		log.Err(err).Msg("failed to approve tft spending")
 		return "", err
 	}
 
-	r, err := bind.WaitMined(ctxWithCancel, c.Eth, tx)
-	if err != nil {
-		log.Err(err).Msg("failed to wait for tft approval")
-		return "", err
-	}
-
-	log.Debug().Msgf("Approve spend tx mined: %s, block %d, gas: %d, status: %d", tx.Hash().Hex(), r.BlockNumber, r.GasUsed, r.Status)
+	log.Debug().Msgf("Approve spend tx submitted: %s", tx.Hash().Hex())
 
 	return tx.Hash().Hex(), nil
 }
 
 func (c *Client) EthTftSpendingAllowance(ctx context.Context) (string, error) {
+	if err := c.requireContract(c.Chain.SwapRouter, "swap router"); err != nil {
+		return "", err
+	}
 	tftC, err := c.GetTftTokenContract()
 	if err != nil {
 		return "", err
@@ -168,7 +181,7 @@ func (c *Client) EthTftSpendingAllowance(ctx context.Context) (string, error) {
 
 	allowed, err := tft.Allowance(&bind.CallOpts{
 		Context: ctxWithCancel,
-	}, c.Address, SwapRouter)
+	}, c.Address, c.Chain.SwapRouter)
 	if err != nil {
 		return "", err
 	}
@@ -2,36 +2,256 @@ package goethclient
 
 import (
 	"context"
+	"math/big"
+	"time"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 )
 
-func (c *Client) sendTransaction(ctx context.Context, tx *types.Transaction) (string, error) {
-	chainID, err := c.Eth.NetworkID(ctx)
+const (
+	TransactionStatusPending  = "pending"
+	TransactionStatusSuccess  = "success"
+	TransactionStatusFailed   = "failed"
+	TransactionStatusNotFound = "not_found"
+
+	// ReplacementFeeBump is the percentage the fees of a replacement transaction are raised by at least. Nodes reject
+	// replacements which don't raise both the fee cap and the tip by 10%.
+	ReplacementFeeBump = 15
+
+	receiptPollInterval = 2 * time.Second
+)
+
+var (
+	// ErrTransactionNotPending is returned when replacing a transaction which was already mined or is unknown
+	ErrTransactionNotPending = errors.New("transaction is not pending")
+)
+
+// TransactionStatus of a submitted transaction
+type TransactionStatus struct {
+	Hash string
+	// Status is one of pending, success, failed or not_found
+	Status string
+	// BlockNumber the transaction was included in, 0 if not mined yet
+	BlockNumber uint64
+	// Confirmations is the amount of blocks on top of and including the block of the transaction
+	Confirmations uint64
+	GasUsed       uint64
+	// EffectiveGasPrice paid per gas, in wei
+	EffectiveGasPrice *big.Int
+}
+
+// sendTransaction signs a transaction with the loaded key and submits it, without waiting for it to be mined
+func (c *Client) sendTransaction(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	chainID, err := c.Eth.ChainID(ctx)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get chainID")
+		return nil, errors.Wrap(err, "failed to get chainID")
 	}
 
 	log.Debug().Msg("signing tx")
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), c.Key)
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), c.Key)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to sign tx")
+		return nil, errors.Wrap(err, "failed to sign tx")
 	}
 
 	err = c.Eth.SendTransaction(ctx, signedTx)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to send transaction")
+		return nil, errors.Wrap(err, "failed to send transaction")
+	}
+
+	return signedTx, nil
+}
+
+// TransactionStatus returns the status of a transaction
+func (c *Client) TransactionStatus(ctx context.Context, hash string) (TransactionStatus, error) {
+	status := TransactionStatus{Hash: hash}
+
+	_, isPending, err := c.Eth.TransactionByHash(ctx, common.HexToHash(hash))
+	if errors.Is(err, ethereum.NotFound) {
+		status.Status = TransactionStatusNotFound
+		return status, nil
+	}
+	if err != nil {
+		return status, errors.Wrapf(err, "failed to load transaction %s", hash)
+	}
+	if isPending {
+		status.Status = TransactionStatusPending
+		return status, nil
+	}
+
+	receipt, err := c.Eth.TransactionReceipt(ctx, common.HexToHash(hash))
+	if errors.Is(err, ethereum.NotFound) {
+		// The transaction was just mined and the receipt is not indexed yet
+		status.Status = TransactionStatusPending
+		return status, nil
+	}
+	if err != nil {
+		return status, errors.Wrapf(err, "failed to load receipt of transaction %s", hash)
+	}
+
+	height, err := c.Eth.BlockNumber(ctx)
+	if err != nil {
+		return status, errors.Wrap(err, "failed to get current height")
+	}
+
+	status.Status = TransactionStatusFailed
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		status.Status = TransactionStatusSuccess
+	}
+	status.BlockNumber = receipt.BlockNumber.Uint64()
+	if height >= status.BlockNumber {
+		status.Confirmations = height - status.BlockNumber + 1
+	}
+	status.GasUsed = receipt.GasUsed
+	status.EffectiveGasPrice = receipt.EffectiveGasPrice
+
+	return status, nil
+}
+
+// WaitForTransaction waits till a transaction is mined and has at least the given amount of confirmations, or the
+// timeout expires. A transaction which is mined but failed is returned as soon as it has enough confirmations.
+func (c *Client) WaitForTransaction(ctx context.Context, hash string, confirmations uint64, timeout time.Duration) (TransactionStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if confirmations == 0 {
+		confirmations = 1
+	}
+
+	for {
+		status, err := c.TransactionStatus(ctx, hash)
+		if err != nil && ctx.Err() == nil {
+			return status, err
+		}
+		if err == nil && status.Status != TransactionStatusPending && status.Status != TransactionStatusNotFound &&
+			status.Confirmations >= confirmations {
+			return status, nil
+		}
+
+		select {
+		case <-time.After(receiptPollInterval):
+		case <-ctx.Done():
+			return status, errors.Wrapf(ctx.Err(), "transaction %s has %d of %d confirmations", hash, status.Confirmations, confirmations)
+		}
+	}
+}
+
+// SpeedUp replaces a pending transaction of the loaded address with the same transaction paying a higher fee. The
+// hash of the replacement is returned.
+func (c *Client) SpeedUp(ctx context.Context, hash string) (string, error) {
+	return c.replaceTransaction(ctx, hash, false)
+}
+
+// Cancel replaces a pending transaction of the loaded address with an empty transfer to itself paying a higher fee.
+// Once the replacement is mined the original transaction can not be executed anymore. The hash of the replacement
+// is returned.
+func (c *Client) Cancel(ctx context.Context, hash string) (string, error) {
+	return c.replaceTransaction(ctx, hash, true)
+}
+
+// replaceTransaction submits a transaction with the same nonce as a pending transaction, with the fees raised by at
+// least ReplacementFeeBump percent
+func (c *Client) replaceTransaction(ctx context.Context, hash string, cancel bool) (string, error) {
+	old, isPending, err := c.Eth.TransactionByHash(ctx, common.HexToHash(hash))
+	if errors.Is(err, ethereum.NotFound) {
+		return "", ErrTransactionNotPending
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load transaction %s", hash)
+	}
+	if !isPending {
+		return "", ErrTransactionNotPending
+	}
+
+	chainID, err := c.Eth.ChainID(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get chainID")
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), old)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get sender of transaction")
+	}
+	// the replacement is signed by the account of the wallet which sent the transaction
+	signer, err := c.Account(sender.Hex())
+	if err != nil {
+		return "", errors.Errorf("transaction %s was not sent by an account of the wallet", hash)
 	}
 
-	res, err := bind.WaitMined(ctx, c.Eth, signedTx)
+	fees, err := c.EstimateFees(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	log.Debug().Msgf("Swap tx mined: %s, block %d, gas: %d, status: %d", tx.Hash().Hex(), res.BlockNumber, res.GasUsed, res.Status)
+	to, value, data, gas := old.To(), old.Value(), old.Data(), old.Gas()
+	if cancel {
+		to, value, data, gas = &signer.Address, big.NewInt(0), nil, params.TxGas
+	}
+
+	var tx *types.Transaction
+	if fees.BaseFee == nil || old.Type() == types.LegacyTxType {
+		gasPrice := maxBigInt(fees.GasPrice, bumpFee(old.GasPrice()))
+		if err := c.checkFeeCap(gasPrice); err != nil {
+			return "", err
+		}
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    old.Nonce(),
+			GasPrice: gasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		})
+	} else {
+		tip := maxBigInt(fees.MaxPriorityFeePerGas, bumpFee(old.GasTipCap()))
+		feeCap := maxBigInt(fees.MaxFeePerGas, bumpFee(old.GasFeeCap()))
+		feeCap = maxBigInt(feeCap, tip)
+		if err := c.checkFeeCap(feeCap); err != nil {
+			return "", err
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     old.Nonce(),
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       gas,
+			To:        to,
+			Value:     value,
+			Data:      data,
+		})
+	}
+
+	signedTx, err := signer.sendTransaction(ctx, tx)
+	if err != nil {
+		return "", err
+	}
+	signer.nonces().replaced(old.Nonce(), signedTx.Hash())
+	log.Debug().Msgf("replaced tx %s by %s", hash, signedTx.Hash().Hex())
 
-	return tx.Hash().Hex(), nil
+	return signedTx.Hash().Hex(), nil
+}
+
+// checkFeeCap returns an error if the fee per gas of a replacement exceeds the configured max fee per gas
+func (c *Client) checkFeeCap(fee *big.Int) error {
+	if c.feeCaps.MaxFeePerGas != nil && fee.Cmp(c.feeCaps.MaxFeePerGas) > 0 {
+		return errors.Errorf("replacement fee %s exceeds the max fee per gas of %s", fee, c.feeCaps.MaxFeePerGas)
+	}
+	return nil
+}
+
+// bumpFee raises a fee by ReplacementFeeBump percent, rounding up
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+ReplacementFeeBump))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBigInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
 }
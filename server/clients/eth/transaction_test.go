@@ -0,0 +1,15 @@
+package goethclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpFee(t *testing.T) {
+	assert.Equal(t, "115", bumpFee(big.NewInt(100)).String())
+	// rounded up so the replacement is never below the required bump
+	assert.Equal(t, "2", bumpFee(big.NewInt(1)).String())
+	assert.Equal(t, "0", bumpFee(big.NewInt(0)).String())
+}
@@ -2,49 +2,24 @@ package goethclient
 
 import (
 	"context"
-	"crypto/ecdsa"
 
 	"github.com/daoleno/uniswapv3-sdk/examples/helper"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
 )
 
 const EthDecimals = 18
 
+// TransferEth submits a transfer of eth to the destination and returns the transaction hash without waiting for it
+// to be mined
 func (c *Client) TransferEth(ctx context.Context, amount string, destination string) (string, error) {
-	tx, err := c.createTransferTransaction(amount, destination)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to create transfer transaction")
-	}
-
-	return c.sendTransaction(ctx, tx)
-}
-
-func (c *Client) createTransferTransaction(amount string, destination string) (*types.Transaction, error) {
-	publicKey := c.Key.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
-
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-
-	nonce, err := c.Eth.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get nonce")
-	}
+	toAddress := common.HexToAddress(destination)
+	amountIn := helper.FloatStringToBigInt(amount, EthDecimals)
 
-	gasPrice, err := c.Eth.SuggestGasPrice(context.Background())
+	tx, err := c.sendNewTransaction(ctx, &toAddress, amountIn, nil, 0)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to suggest gas price")
+		return "", errors.Wrap(err, "failed to send transfer transaction")
 	}
 
-	toAddress := common.HexToAddress(destination)
-
-	amountIn := helper.FloatStringToBigInt(amount, EthDecimals)
-	tx := types.NewTransaction(nonce, toAddress, amountIn, GasLimit, gasPrice, nil)
-
-	return tx, nil
+	return tx.Hash().Hex(), nil
 }
@@ -0,0 +1,226 @@
+package goethclient
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/cosmos/go-bip39"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBasePath is the BIP-44 path of ethereum accounts, without the account index
+	DefaultBasePath = "m/44'/60'/0'/0"
+
+	// mnemonicEntropy is the entropy in bits of generated mnemonics, resulting in 24 words
+	mnemonicEntropy = 256
+)
+
+// Account of the wallet of a client
+type Account struct {
+	Address common.Address
+	// Path the key is derived at, empty for keys which are not derived from the mnemonic
+	Path string
+}
+
+// wallet holds the keys a client can sign with: accounts derived from a mnemonic and imported keys
+type wallet struct {
+	lock sync.RWMutex
+
+	mnemonic string
+	// master key of the mnemonic, nil if no mnemonic is loaded
+	master *hdkeychain.ExtendedKey
+	base   accounts.DerivationPath
+
+	// accounts in the order they were added
+	accounts []Account
+	keys     map[common.Address]*ecdsa.PrivateKey
+}
+
+// loadMnemonic loads the wallet from a mnemonic, see NewClientFromMnemonic
+func (w *wallet) loadMnemonic(mnemonic, path string) error {
+	if mnemonic == "" {
+		entropy, err := bip39.NewEntropy(mnemonicEntropy)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate entropy")
+		}
+		mnemonic, err = bip39.NewMnemonic(entropy)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate mnemonic")
+		}
+	}
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+
+	if path == "" {
+		path = DefaultBasePath
+	}
+	base, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return errors.Wrapf(err, "invalid derivation path %s", path)
+	}
+
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return errors.Wrap(err, "invalid mnemonic")
+	}
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return errors.Wrap(err, "failed to create master key")
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.mnemonic = mnemonic
+	w.master = master
+	w.base = base
+
+	return nil
+}
+
+// derive the account at an index of the base path and add it to the wallet
+func (w *wallet) derive(index uint32) (Account, *ecdsa.PrivateKey, error) {
+	if index >= hdkeychain.HardenedKeyStart {
+		return Account{}, nil, fmt.Errorf("account index %d is out of range", index)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.master == nil {
+		return Account{}, nil, errors.New("no mnemonic loaded")
+	}
+
+	path := make(accounts.DerivationPath, len(w.base), len(w.base)+1)
+	copy(path, w.base)
+	path = append(path, index)
+
+	extended := w.master
+	for _, child := range path {
+		var err error
+		extended, err = extended.Derive(child)
+		if err != nil {
+			return Account{}, nil, errors.Wrapf(err, "failed to derive %s", path)
+		}
+	}
+	private, err := extended.ECPrivKey()
+	if err != nil {
+		return Account{}, nil, errors.Wrapf(err, "failed to derive %s", path)
+	}
+	key, err := crypto.ToECDSA(private.Serialize())
+	if err != nil {
+		return Account{}, nil, errors.Wrapf(err, "failed to derive %s", path)
+	}
+
+	return w.add(key, path.String()), key, nil
+}
+
+// addKey adds a key to the wallet, returning its address
+func (w *wallet) addKey(key *ecdsa.PrivateKey, path string) common.Address {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.add(key, path).Address
+}
+
+// add a key to the wallet, the lock must be held
+func (w *wallet) add(key *ecdsa.PrivateKey, path string) Account {
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	if _, ok := w.keys[address]; ok {
+		for _, account := range w.accounts {
+			if account.Address == address {
+				return account
+			}
+		}
+	}
+
+	if w.keys == nil {
+		w.keys = map[common.Address]*ecdsa.PrivateKey{}
+	}
+	w.keys[address] = key
+
+	account := Account{Address: address, Path: path}
+	w.accounts = append(w.accounts, account)
+	return account
+}
+
+// key of an account of the wallet
+func (w *wallet) key(address common.Address) (*ecdsa.PrivateKey, bool) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	key, ok := w.keys[address]
+	return key, ok
+}
+
+// DeriveAccount derives the account at an index of the base path of the loaded mnemonic and adds it to the wallet
+func (c *Client) DeriveAccount(index uint32) (Account, error) {
+	account, _, err := c.wallet.derive(index)
+	return account, err
+}
+
+// ListAccounts returns the accounts of the wallet, in the order they were added
+func (c *Client) ListAccounts() []Account {
+	c.wallet.lock.RLock()
+	defer c.wallet.lock.RUnlock()
+
+	return append([]Account(nil), c.wallet.accounts...)
+}
+
+// GetMnemonic returns the mnemonic the wallet was loaded from, empty if it was loaded from a secret
+func (c *Client) GetMnemonic() string {
+	c.wallet.lock.RLock()
+	defer c.wallet.lock.RUnlock()
+
+	return c.wallet.mnemonic
+}
+
+// ImportKeystore decrypts a V3 JSON keystore and adds its key to the wallet
+func (c *Client) ImportKeystore(keystoreJSON, password string) (Account, error) {
+	key, err := keystore.DecryptKey([]byte(keystoreJSON), password)
+	if err != nil {
+		return Account{}, errors.Wrap(err, "failed to decrypt keystore")
+	}
+
+	c.wallet.lock.Lock()
+	defer c.wallet.lock.Unlock()
+
+	return c.wallet.add(key.PrivateKey, ""), nil
+}
+
+// ExportKeystore encrypts the key of an account of the wallet as a V3 JSON keystore
+func (c *Client) ExportKeystore(address, password string) (string, error) {
+	return exportKeystore(c, address, password, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+func exportKeystore(c *Client, address, password string, scryptN, scryptP int) (string, error) {
+	account, err := c.Account(address)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate keystore id")
+	}
+
+	encrypted, err := keystore.EncryptKey(&keystore.Key{
+		Id:         id,
+		Address:    account.Address,
+		PrivateKey: account.Key,
+	}, password, scryptN, scryptP)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encrypt keystore")
+	}
+
+	return string(encrypted), nil
+}
@@ -0,0 +1,96 @@
+package goethclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestWalletDerive(t *testing.T) {
+	var w wallet
+	require.NoError(t, w.loadMnemonic(testMnemonic, ""))
+
+	account, key, err := w.derive(0)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"), account.Address)
+	assert.Equal(t, "m/44'/60'/0'/0/0", account.Path)
+	assert.Equal(t, account.Address, crypto.PubkeyToAddress(key.PublicKey))
+
+	account, _, err = w.derive(1)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"), account.Address)
+
+	// deriving an account again does not add it twice
+	_, _, err = w.derive(1)
+	require.NoError(t, err)
+	assert.Len(t, w.accounts, 2)
+
+	_, _, err = w.derive(1 << 31)
+	assert.Error(t, err)
+}
+
+func TestWalletLoadMnemonic(t *testing.T) {
+	var w wallet
+	require.NoError(t, w.loadMnemonic("", ""))
+	assert.Len(t, strings.Fields(w.mnemonic), 24)
+
+	assert.Error(t, w.loadMnemonic("test test test", ""))
+	assert.Error(t, w.loadMnemonic(testMnemonic, "m/44'/x"))
+
+	var empty wallet
+	_, _, err := empty.derive(0)
+	assert.Error(t, err)
+}
+
+func TestClientAccount(t *testing.T) {
+	c := &Client{shared: &shared{}}
+	require.NoError(t, c.wallet.loadMnemonic(testMnemonic, ""))
+	first, key, err := c.wallet.derive(0)
+	require.NoError(t, err)
+	c.Key, c.Address = key, first.Address
+
+	second, err := c.DeriveAccount(1)
+	require.NoError(t, err)
+
+	account, err := c.Account("")
+	require.NoError(t, err)
+	assert.Same(t, c, account)
+
+	account, err = c.Account(second.Address.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, second.Address, account.Address)
+	assert.Equal(t, second.Address, crypto.PubkeyToAddress(account.Key.PublicKey))
+	assert.Equal(t, first.Address, c.Address)
+
+	_, err = c.Account("0x0000000000000000000000000000000000000001")
+	assert.Error(t, err)
+	_, err = c.Account("not an address")
+	assert.Error(t, err)
+
+	assert.Equal(t, []Account{first, second}, c.ListAccounts())
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	c := &Client{Key: key, Address: crypto.PubkeyToAddress(key.PublicKey), shared: &shared{}}
+
+	encrypted, err := exportKeystore(c, "", "secret", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+
+	other := &Client{shared: &shared{}}
+	_, err = other.ImportKeystore(encrypted, "wrong")
+	assert.Error(t, err)
+
+	account, err := other.ImportKeystore(encrypted, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, c.Address, account.Address)
+	assert.Empty(t, account.Path)
+}
@@ -0,0 +1,89 @@
+package safehttp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeout is the timeout of a whole request, including reading the body
+const DefaultTimeout = 30 * time.Second
+
+// sharedAddressSpace is the carrier grade nat range, which is not covered by net.IP.IsPrivate
+var sharedAddressSpace = net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// NewClient returns an http client for urls given by callers of the proxy. It gives up after timeout and refuses to
+// connect to loopback, private, link-local and other non public addresses, including after redirects.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// Control is called with the resolved address, so hostnames resolving to internal addresses are refused too
+		Control: allowPublic,
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
+
+// IsPublic returns true if ip is a globally routable unicast address
+func IsPublic(ip net.IP) bool {
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+func allowPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublic(ip) {
+		return errors.Errorf("refusing to connect to non public address %s", host)
+	}
+	return nil
+}
+
+// Get returns the body of uri, failing if the status is not ok or the body is larger than maxSize
+func Get(ctx context.Context, client *http.Client, uri string, maxSize int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return ReadAll(resp.Body, maxSize)
+}
+
+// ReadAll reads r until EOF, failing if it has more than maxSize bytes
+func ReadAll(r io.Reader, maxSize int64) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, errors.Errorf("content is larger than %d bytes", maxSize)
+	}
+	return content, nil
+}
@@ -0,0 +1,50 @@
+package safehttp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublic(t *testing.T) {
+	for _, ip := range []string{"1.1.1.1", "8.8.8.8", "2606:4700:4700::1111"} {
+		assert.True(t, IsPublic(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{
+		"127.0.0.1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0",
+		"224.0.0.1", "255.255.255.255", "::1", "::", "fe80::1", "fc00::1", "::ffff:127.0.0.1",
+	} {
+		assert.False(t, IsPublic(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+
+	_, err := Get(context.Background(), NewClient(time.Second), server.URL, 1024)
+	assert.ErrorContains(t, err, "refusing to connect")
+
+	// the server itself is reachable
+	content, err := Get(context.Background(), server.Client(), server.URL, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(content))
+}
+
+func TestReadAll(t *testing.T) {
+	content, err := ReadAll(strings.NewReader("1234"), 4)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", string(content))
+
+	_, err = ReadAll(strings.NewReader("12345"), 4)
+	assert.Error(t, err)
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/stellar/go/txnbuild"
 )
 
-func (c *Client) Load(secret string) error {
+// Load the keypair from the secret. If addTftTrustline is set, a TFT trustline is added to the account if it
+// doesn't have one yet.
+func (c *Client) Load(secret string, addTftTrustline bool) error {
 	k, err := GetKeypairFromSeed(secret)
 	if err != nil {
 		return err
@@ -26,9 +28,11 @@ func (c *Client) Load(secret string) error {
 		return errors.Wrap(err, "account does not exist")
 	}
 
-	if !hasTrustline(hAccount, c.GetTftBaseAsset()) {
+	if addTftTrustline && !hasTrustline(hAccount, c.GetTftBaseAsset()) {
 		log.Debug().Msgf("Adding trustline for account %s", k.Address())
-		c.setTrustLine()
+		if err := c.setTrustLine(); err != nil {
+			log.Error().Err(err).Msgf("Failed to add TFT trustline for account %s", k.Address())
+		}
 	}
 
 	return nil
@@ -157,7 +161,7 @@ func (c *Client) SetAccountOptions(keypairs []keypair.Full) error {
 		BaseFee:              txnbuild.MinBaseFee,
 		Memo:                 nil,
 		Preconditions: txnbuild.Preconditions{
-			TimeBounds: txnbuild.NewInfiniteTimeout(),
+			TimeBounds: c.timeBounds(),
 		},
 	}
 	tx, err := txnbuild.NewTransaction(params)
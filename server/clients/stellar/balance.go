@@ -1,19 +1,45 @@
 package stellargoclient
 
+import (
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// GetBalance returns the TFT balance of an account, or of the loaded account if empty
 func (c *Client) GetBalance(account string) (string, error) {
-	if account == "" {
-		account = c.kp.Address()
-	}
-	hAccount, err := c.AccountData(account)
+	return c.GetAssetBalance(account, c.GetTftAsset())
+}
+
+// GetAssetBalance returns the balance of an asset of an account, or of the loaded account if empty. An empty
+// balance is returned if the account has no trustline for the asset.
+func (c *Client) GetAssetBalance(account string, asset txnbuild.Asset) (string, error) {
+	balances, err := c.GetBalances(account)
 	if err != nil {
 		return "", err
 	}
 
-	for _, b := range hAccount.Balances {
-		if b.Asset == c.GetTftBaseAsset() {
+	baseAsset, err := toBaseAsset(asset)
+	if err != nil {
+		return "", err
+	}
+	for _, b := range balances {
+		if b.Asset == baseAsset {
 			return b.Balance, nil
 		}
 	}
 
 	return "", nil
 }
+
+// GetBalances returns all balances of an account, or of the loaded account if empty
+func (c *Client) GetBalances(account string) ([]horizon.Balance, error) {
+	if account == "" {
+		account = c.kp.Address()
+	}
+	hAccount, err := c.AccountData(account)
+	if err != nil {
+		return nil, err
+	}
+
+	return hAccount.Balances, nil
+}
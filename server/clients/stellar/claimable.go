@@ -0,0 +1,208 @@
+package stellargoclient
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	PredicateUnconditional      = "unconditional"
+	PredicateBeforeAbsoluteTime = "before_absolute_time"
+	PredicateBeforeRelativeTime = "before_relative_time"
+	PredicateNot                = "not"
+	PredicateAnd                = "and"
+	PredicateOr                 = "or"
+)
+
+// ClaimPredicate is a condition under which a claimant can claim a balance. An empty predicate is unconditional.
+type ClaimPredicate struct {
+	Type string `json:"type"`
+	// AbsBefore is a unix timestamp in seconds, used by before_absolute_time
+	AbsBefore int64 `json:"abs_before,omitempty"`
+	// RelBefore is a number of seconds after the creation of the balance, used by before_relative_time
+	RelBefore int64 `json:"rel_before,omitempty"`
+	// Predicates are the operands of not (1), and (2) and or (2)
+	Predicates []ClaimPredicate `json:"predicates,omitempty"`
+}
+
+// Claimant is an account that can claim a balance
+type Claimant struct {
+	Destination string         `json:"destination"`
+	Predicate   ClaimPredicate `json:"predicate"`
+}
+
+// toXDR converts the predicate to its xdr representation
+func (p ClaimPredicate) toXDR() (xdr.ClaimPredicate, error) {
+	operands := func(n int) ([]xdr.ClaimPredicate, error) {
+		if len(p.Predicates) != n {
+			return nil, fmt.Errorf("predicate %s requires %d predicates, got %d", p.Type, n, len(p.Predicates))
+		}
+		result := make([]xdr.ClaimPredicate, 0, n)
+		for _, operand := range p.Predicates {
+			converted, err := operand.toXDR()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, converted)
+		}
+		return result, nil
+	}
+
+	switch p.Type {
+	case PredicateUnconditional, "":
+		return txnbuild.UnconditionalPredicate, nil
+	case PredicateBeforeAbsoluteTime:
+		return txnbuild.BeforeAbsoluteTimePredicate(p.AbsBefore), nil
+	case PredicateBeforeRelativeTime:
+		return txnbuild.BeforeRelativeTimePredicate(p.RelBefore), nil
+	case PredicateNot:
+		ops, err := operands(1)
+		if err != nil {
+			return xdr.ClaimPredicate{}, err
+		}
+		return txnbuild.NotPredicate(ops[0]), nil
+	case PredicateAnd:
+		ops, err := operands(2)
+		if err != nil {
+			return xdr.ClaimPredicate{}, err
+		}
+		return txnbuild.AndPredicate(ops[0], ops[1]), nil
+	case PredicateOr:
+		ops, err := operands(2)
+		if err != nil {
+			return xdr.ClaimPredicate{}, err
+		}
+		return txnbuild.OrPredicate(ops[0], ops[1]), nil
+	default:
+		return xdr.ClaimPredicate{}, fmt.Errorf("unknown predicate type %s", p.Type)
+	}
+}
+
+// CreateClaimableBalance locks an amount of an asset of the loaded account in a claimable balance which can be claimed
+// by one of the claimants. The claimants don't need a trustline for the asset. The ID of the balance is returned.
+func (c *Client) CreateClaimableBalance(asset txnbuild.Asset, amount string, claimants []Claimant) (string, error) {
+	if len(claimants) == 0 {
+		return "", errors.New("at least one claimant is required")
+	}
+	destinations := make([]txnbuild.Claimant, 0, len(claimants))
+	for _, claimant := range claimants {
+		predicate, err := claimant.Predicate.toXDR()
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid predicate for claimant %s", claimant.Destination)
+		}
+		destinations = append(destinations, txnbuild.NewClaimant(claimant.Destination, &predicate))
+	}
+
+	hAccount, err := c.AccountData(c.kp.Address())
+	if err != nil {
+		return "", errors.Wrap(err, "account does not exist")
+	}
+
+	createBalance := txnbuild.CreateClaimableBalance{
+		Amount:       amount,
+		Asset:        asset,
+		Destinations: destinations,
+	}
+
+	params := txnbuild.TransactionParams{
+		SourceAccount:        &hAccount,
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{&createBalance},
+		BaseFee:              BaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: c.timeBounds(),
+		},
+	}
+
+	tx, err := txnbuild.NewTransaction(params)
+	if err != nil {
+		return "", err
+	}
+
+	balanceID, err := tx.ClaimableBalanceID(0)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to compute claimable balance id")
+	}
+
+	if err := c.SignAndSubmit(tx); err != nil {
+		return "", err
+	}
+
+	return balanceID, nil
+}
+
+// ListClaimableBalances returns the claimable balances an account can claim, or the loaded account if empty,
+// optionally filtered on an asset
+func (c *Client) ListClaimableBalances(claimant string, asset txnbuild.Asset) ([]horizon.ClaimableBalance, error) {
+	if claimant == "" {
+		claimant = c.kp.Address()
+	}
+
+	request := horizonclient.ClaimableBalanceRequest{Claimant: claimant, Limit: horizonPageLimit}
+	if asset != nil {
+		request.Asset = assetString(asset)
+	}
+
+	balances := []horizon.ClaimableBalance{}
+	for {
+		page, err := c.horizon.ClaimableBalances(request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load claimable balances of %s", claimant)
+		}
+		balances = append(balances, page.Embedded.Records...)
+		if len(page.Embedded.Records) < horizonPageLimit {
+			return balances, nil
+		}
+		request.Cursor = page.Embedded.Records[len(page.Embedded.Records)-1].PagingToken()
+	}
+}
+
+// ClaimBalance claims a claimable balance on the loaded account. If the account does not have a trustline for the
+// asset of the balance yet, it is added in the same transaction.
+func (c *Client) ClaimBalance(balanceID string) error {
+	balance, err := c.horizon.ClaimableBalance(balanceID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load claimable balance %s", balanceID)
+	}
+
+	hAccount, err := c.AccountData(c.kp.Address())
+	if err != nil {
+		return errors.Wrap(err, "account does not exist")
+	}
+
+	operations := []txnbuild.Operation{}
+	asset, err := txnbuild.ParseAssetString(balance.Asset)
+	if err != nil {
+		return errors.Wrapf(err, "invalid asset %s of claimable balance", balance.Asset)
+	}
+	if c.requireTrustline(hAccount, asset) != nil {
+		line, err := asset.ToChangeTrustAsset()
+		if err != nil {
+			return err
+		}
+		operations = append(operations, &txnbuild.ChangeTrust{Line: line, Limit: txnbuild.MaxTrustlineLimit})
+	}
+	operations = append(operations, &txnbuild.ClaimClaimableBalance{BalanceID: balanceID})
+
+	params := txnbuild.TransactionParams{
+		SourceAccount:        &hAccount,
+		IncrementSequenceNum: true,
+		Operations:           operations,
+		BaseFee:              BaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: c.timeBounds(),
+		},
+	}
+
+	tx, err := txnbuild.NewTransaction(params)
+	if err != nil {
+		return err
+	}
+
+	return c.SignAndSubmit(tx)
+}
@@ -0,0 +1,41 @@
+package stellargoclient
+
+import (
+	"testing"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimPredicateToXDR(t *testing.T) {
+	t.Run("unconditional", func(t *testing.T) {
+		predicate, err := ClaimPredicate{}.toXDR()
+		assert.NoError(t, err)
+		assert.Equal(t, txnbuild.UnconditionalPredicate, predicate)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		predicate, err := ClaimPredicate{
+			Type: PredicateOr,
+			Predicates: []ClaimPredicate{
+				{Type: PredicateBeforeAbsoluteTime, AbsBefore: 1700000000},
+				{Type: PredicateNot, Predicates: []ClaimPredicate{{Type: PredicateBeforeRelativeTime, RelBefore: 3600}}},
+			},
+		}.toXDR()
+		assert.NoError(t, err)
+		assert.Equal(t, xdr.ClaimPredicateTypeClaimPredicateOr, predicate.Type)
+		assert.Equal(t, txnbuild.OrPredicate(
+			txnbuild.BeforeAbsoluteTimePredicate(1700000000),
+			txnbuild.NotPredicate(txnbuild.BeforeRelativeTimePredicate(3600)),
+		), predicate)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ClaimPredicate{Type: PredicateAnd, Predicates: []ClaimPredicate{{}}}.toXDR()
+		assert.Error(t, err)
+
+		_, err = ClaimPredicate{Type: "after"}.toXDR()
+		assert.Error(t, err)
+	})
+}
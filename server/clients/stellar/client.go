@@ -1,15 +1,23 @@
 package stellargoclient
 
 import (
+	"time"
+
 	"github.com/rs/zerolog/log"
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
 )
 
+// DefaultTransactionTimeout is the time after which a submitted transaction is no longer valid
+const DefaultTransactionTimeout = 5 * time.Minute
+
 type Client struct {
 	stellarNetwork string
 	horizon        *horizonclient.Client
 	kp             *keypair.Full
+	// transactions built by the client are only valid for this long, 0 means forever
+	txTimeout time.Duration
 }
 
 // NewClient creates a new client
@@ -22,9 +30,34 @@ func NewClient(stellarNetwork string) *Client {
 		stellarNetwork: stellarNetwork,
 		horizon:        GetHorizonClient(stellarNetwork),
 		kp:             nil,
+		txTimeout:      DefaultTransactionTimeout,
 	}
 }
 
+// SetTransactionTimeout sets how long transactions built by the client are valid after being built. A timeout of 0
+// makes them valid forever.
+func (c *Client) SetTransactionTimeout(timeout time.Duration) {
+	c.txTimeout = timeout
+}
+
+// timeBounds returns the time bounds for a transaction built now
+func (c *Client) timeBounds() txnbuild.TimeBounds {
+	if c.txTimeout <= 0 {
+		return txnbuild.NewInfiniteTimeout()
+	}
+	return txnbuild.NewTimeout(int64(c.txTimeout.Seconds()))
+}
+
+// Network the client is connected to, either "testnet" or "public"
+func (c *Client) Network() string {
+	return c.stellarNetwork
+}
+
+// HasKeyPair checks if a keypair is loaded in the client
+func (c *Client) HasKeyPair() bool {
+	return c.kp != nil
+}
+
 // Address of the loaded keypair
 func (c *Client) Address() string {
 	return c.kp.Address()
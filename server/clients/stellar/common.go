@@ -1,15 +1,18 @@
 package stellargoclient
 
 import (
-	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/keypair"
 	stellarNetwork "github.com/stellar/go/network"
 	"github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/protocols/horizon/base"
 	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
 )
 
 const (
@@ -80,16 +83,43 @@ func (c *Client) GetXlmAsset() txnbuild.CreditAsset {
 	return txnbuild.CreditAsset{}
 }
 
-// GetTftAsset returns the tft asset for the stellar network
+// GetAssetFromString parses an asset, either "tft", "xlm" (or "native") or a "CODE:ISSUER" pair
 func (c *Client) GetAssetFromString(asset string) (txnbuild.Asset, error) {
-	assetLower := strings.ToLower(asset)
-	if assetLower == "tft" {
+	switch strings.ToLower(asset) {
+	case "tft":
 		return c.GetTftAsset(), nil
-	} else if assetLower == "xlm" {
+	case "xlm", "native":
 		return txnbuild.NativeAsset{}, nil
-	} else {
-		return txnbuild.CreditAsset{}, errors.New("unsupported asset")
 	}
+	if !strings.Contains(asset, ":") {
+		return txnbuild.CreditAsset{}, fmt.Errorf("unsupported asset %s, expected tft, xlm or CODE:ISSUER", asset)
+	}
+	parsed, err := txnbuild.ParseAssetString(asset)
+	if err != nil {
+		return txnbuild.CreditAsset{}, errors.Wrapf(err, "invalid asset %s", asset)
+	}
+	return parsed, nil
+}
+
+// toBaseAsset converts an asset to its horizon representation, as used in the balances of an account
+func toBaseAsset(asset txnbuild.Asset) (base.Asset, error) {
+	assetType, err := asset.GetType()
+	if err != nil {
+		return base.Asset{}, err
+	}
+	return base.Asset{
+		Type:   xdr.AssetTypeToString[xdr.AssetType(assetType)],
+		Code:   asset.GetCode(),
+		Issuer: asset.GetIssuer(),
+	}, nil
+}
+
+// assetString formats an asset as "CODE:ISSUER", or "native" for XLM
+func assetString(asset txnbuild.Asset) string {
+	if asset.IsNative() {
+		return "native"
+	}
+	return asset.GetCode() + ":" + asset.GetIssuer()
 }
 
 // GetStellarNetworkPassphrase returns the passphrase for the stellar network
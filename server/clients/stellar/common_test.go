@@ -0,0 +1,41 @@
+package stellargoclient
+
+import (
+	"testing"
+
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAssetFromString(t *testing.T) {
+	c := NewClient("testnet")
+
+	t.Run("known_assets", func(t *testing.T) {
+		asset, err := c.GetAssetFromString("TFT")
+		assert.NoError(t, err)
+		assert.Equal(t, TestnetTft, asset)
+
+		asset, err = c.GetAssetFromString("xlm")
+		assert.NoError(t, err)
+		assert.True(t, asset.IsNative())
+	})
+
+	t.Run("code_and_issuer", func(t *testing.T) {
+		asset, err := c.GetAssetFromString("USDC:" + MAINNET_ISSUER)
+		assert.NoError(t, err)
+		assert.Equal(t, txnbuild.CreditAsset{Code: "USDC", Issuer: MAINNET_ISSUER}, asset)
+
+		baseAsset, err := toBaseAsset(asset)
+		assert.NoError(t, err)
+		assert.Equal(t, base.Asset{Type: "credit_alphanum4", Code: "USDC", Issuer: MAINNET_ISSUER}, baseAsset)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := c.GetAssetFromString("BTC")
+		assert.Error(t, err)
+
+		_, err = c.GetAssetFromString("USDC:notanissuer")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,445 @@
+package stellargoclient
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/price"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// QuoteStrictSend finds paths for sending an exact amount of the source asset
+	QuoteStrictSend = "strict_send"
+	// QuoteStrictReceive finds paths for receiving an exact amount of the destination asset
+	QuoteStrictReceive = "strict_receive"
+
+	// DefaultSlippage is the slippage tolerance in percent used if none is given
+	DefaultSlippage = "1"
+)
+
+// Quote is a path on the DEX to convert one asset into another
+type Quote struct {
+	SourceAsset       string   `json:"source_asset"`
+	SourceAmount      string   `json:"source_amount"`
+	DestinationAsset  string   `json:"destination_asset"`
+	DestinationAmount string   `json:"destination_amount"`
+	Path              []string `json:"path"`
+
+	path []txnbuild.Asset
+}
+
+// Quote returns the paths to convert the source asset into the destination asset, best path first. In strict_send
+// mode the amount is the amount of the source asset to send, in strict_receive mode it is the amount of the
+// destination asset to receive.
+func (c *Client) Quote(mode string, source, destination txnbuild.Asset, value string) ([]Quote, error) {
+	sourceAsset, err := toBaseAsset(source)
+	if err != nil {
+		return nil, err
+	}
+	destinationAsset, err := toBaseAsset(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths horizon.PathsPage
+	switch mode {
+	case QuoteStrictSend, "":
+		mode = QuoteStrictSend
+		paths, err = c.horizon.StrictSendPaths(horizonclient.StrictSendPathsRequest{
+			SourceAssetType:   horizonclient.AssetType(sourceAsset.Type),
+			SourceAssetCode:   sourceAsset.Code,
+			SourceAssetIssuer: sourceAsset.Issuer,
+			SourceAmount:      value,
+			DestinationAssets: assetString(destination),
+		})
+	case QuoteStrictReceive:
+		paths, err = c.horizon.StrictReceivePaths(horizonclient.PathsRequest{
+			DestinationAssetType:   horizonclient.AssetType(destinationAsset.Type),
+			DestinationAssetCode:   destinationAsset.Code,
+			DestinationAssetIssuer: destinationAsset.Issuer,
+			DestinationAmount:      value,
+			SourceAssets:           assetString(source),
+		})
+	default:
+		return nil, fmt.Errorf("unknown quote mode %s, expected %s or %s", mode, QuoteStrictSend, QuoteStrictReceive)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find paths")
+	}
+
+	quotes := make([]Quote, 0, len(paths.Embedded.Records))
+	// the amount the best path is chosen on, parsed once so malformed amounts are reported instead of sorted on
+	ranks := make([]int64, 0, len(paths.Embedded.Records))
+	for _, p := range paths.Embedded.Records {
+		rank := p.SourceAmount
+		if mode == QuoteStrictSend {
+			rank = p.DestinationAmount
+		}
+		parsed, err := amount.ParseInt64(rank)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid amount %s in path", rank)
+		}
+		ranks = append(ranks, parsed)
+
+		quote := Quote{
+			SourceAsset:       assetString(source),
+			SourceAmount:      p.SourceAmount,
+			DestinationAsset:  assetString(destination),
+			DestinationAmount: p.DestinationAmount,
+			Path:              make([]string, 0, len(p.Path)),
+		}
+		for _, hop := range p.Path {
+			asset := fromBaseAsset(base.Asset(hop))
+			quote.path = append(quote.path, asset)
+			quote.Path = append(quote.Path, assetString(asset))
+		}
+		quotes = append(quotes, quote)
+	}
+
+	// The best path sends the least or receives the most, depending on what is fixed
+	sort.Stable(quoteSorter{quotes: quotes, ranks: ranks, descending: mode == QuoteStrictSend})
+
+	return quotes, nil
+}
+
+// SwapStrictSend sends an exact amount of the source asset and receives the destination asset on the loaded account,
+// using the best path on the DEX. The transaction fails if less than the quoted amount minus the slippage tolerance
+// (in percent) would be received. The hash of the transaction is returned.
+func (c *Client) SwapStrictSend(source, destination txnbuild.Asset, sendAmount string, slippage string) (string, error) {
+	quote, err := c.bestQuote(QuoteStrictSend, source, destination, sendAmount)
+	if err != nil {
+		return "", err
+	}
+	destMin, err := applySlippage(quote.DestinationAmount, slippage, false)
+	if err != nil {
+		return "", err
+	}
+
+	return c.submitSwap(source, destination, &txnbuild.PathPaymentStrictSend{
+		SendAsset:     source,
+		SendAmount:    sendAmount,
+		DestAsset:     destination,
+		DestMin:       destMin,
+		Destination:   c.kp.Address(),
+		Path:          quote.path,
+		SourceAccount: c.kp.Address(),
+	}, sendAmount)
+}
+
+// SwapStrictReceive receives an exact amount of the destination asset on the loaded account, paying with the source
+// asset using the best path on the DEX. The transaction fails if more than the quoted amount plus the slippage
+// tolerance (in percent) would be sent. The hash of the transaction is returned.
+func (c *Client) SwapStrictReceive(source, destination txnbuild.Asset, destAmount string, slippage string) (string, error) {
+	quote, err := c.bestQuote(QuoteStrictReceive, source, destination, destAmount)
+	if err != nil {
+		return "", err
+	}
+	sendMax, err := applySlippage(quote.SourceAmount, slippage, true)
+	if err != nil {
+		return "", err
+	}
+
+	return c.submitSwap(source, destination, &txnbuild.PathPaymentStrictReceive{
+		SendAsset:     source,
+		SendMax:       sendMax,
+		DestAsset:     destination,
+		DestAmount:    destAmount,
+		Destination:   c.kp.Address(),
+		Path:          quote.path,
+		SourceAccount: c.kp.Address(),
+	}, quote.SourceAmount)
+}
+
+func (c *Client) bestQuote(mode string, source, destination txnbuild.Asset, value string) (Quote, error) {
+	quotes, err := c.Quote(mode, source, destination, value)
+	if err != nil {
+		return Quote{}, err
+	}
+	if len(quotes) == 0 {
+		return Quote{}, fmt.Errorf("no path found to swap %s to %s", assetString(source), assetString(destination))
+	}
+	return quotes[0], nil
+}
+
+func (c *Client) submitSwap(source, destination txnbuild.Asset, payment txnbuild.Operation, amount string) (string, error) {
+	hAccount, err := c.AccountData(c.kp.Address())
+	if err != nil {
+		return "", errors.Wrap(err, "account does not exist")
+	}
+
+	if err := c.requireTrustline(hAccount, destination); err != nil {
+		return "", err
+	}
+
+	params := txnbuild.TransactionParams{
+		SourceAccount:        &hAccount,
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{payment},
+		BaseFee:              BaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: c.timeBounds(),
+		},
+		Memo: txnbuild.MemoText(swapMemo(amount, source, destination)),
+	}
+
+	tx, err := txnbuild.NewTransaction(params)
+	if err != nil {
+		return "", err
+	}
+
+	hTx, err := c.signAndSubmit(tx)
+	if err != nil {
+		return "", err
+	}
+	return hTx.Hash, nil
+}
+
+// applySlippage lowers (or raises if up is set) an amount by a slippage tolerance in percent
+func applySlippage(value string, slippage string, up bool) (string, error) {
+	if slippage == "" {
+		slippage = DefaultSlippage
+	}
+	tolerance, ok := new(big.Rat).SetString(slippage)
+	if !ok || tolerance.Sign() < 0 || tolerance.Cmp(big.NewRat(100, 1)) >= 0 {
+		return "", fmt.Errorf("invalid slippage %s, expected a percentage between 0 and 100", slippage)
+	}
+	stroops, err := amount.ParseInt64(value)
+	if err != nil {
+		return "", err
+	}
+
+	factor := new(big.Rat).Sub(big.NewRat(1, 1), new(big.Rat).Quo(tolerance, big.NewRat(100, 1)))
+	if up {
+		factor = new(big.Rat).Add(big.NewRat(1, 1), new(big.Rat).Quo(tolerance, big.NewRat(100, 1)))
+	}
+	result := new(big.Rat).Mul(new(big.Rat).SetInt64(stroops), factor)
+
+	// Round in the safe direction: down for a minimum, up for a maximum
+	limit := new(big.Int).Quo(result.Num(), result.Denom())
+	if up && !result.IsInt() {
+		limit.Add(limit, big.NewInt(1))
+	}
+	if !limit.IsInt64() {
+		return "", fmt.Errorf("amount %s out of range after applying slippage", value)
+	}
+	return amount.StringFromInt64(limit.Int64()), nil
+}
+
+// fromBaseAsset converts an asset in its horizon representation
+func fromBaseAsset(asset base.Asset) txnbuild.Asset {
+	if asset.Type == "native" {
+		return txnbuild.NativeAsset{}
+	}
+	return txnbuild.CreditAsset{Code: asset.Code, Issuer: asset.Issuer}
+}
+
+// CreateSellOffer creates an offer on the DEX selling an amount of an asset for another asset at a price (in units
+// of buying per unit of selling). The ID of the offer is returned, or 0 if the offer was filled immediately.
+func (c *Client) CreateSellOffer(selling, buying txnbuild.Asset, sellAmount, offerPrice string) (int64, error) {
+	p, err := price.Parse(offerPrice)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid price %s", offerPrice)
+	}
+	return c.submitOffer(buying, &txnbuild.ManageSellOffer{
+		Selling: selling,
+		Buying:  buying,
+		Amount:  sellAmount,
+		Price:   p,
+	})
+}
+
+// CreateBuyOffer creates an offer on the DEX buying an amount of an asset with another asset at a price (in units
+// of selling per unit of buying). The ID of the offer is returned, or 0 if the offer was filled immediately.
+func (c *Client) CreateBuyOffer(selling, buying txnbuild.Asset, buyAmount, offerPrice string) (int64, error) {
+	p, err := price.Parse(offerPrice)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid price %s", offerPrice)
+	}
+	return c.submitOffer(buying, &txnbuild.ManageBuyOffer{
+		Selling: selling,
+		Buying:  buying,
+		Amount:  buyAmount,
+		Price:   p,
+	})
+}
+
+// UpdateOffer changes the amount and/or price of an offer of the loaded account. Empty values are left unchanged.
+// Offers are always updated as sell offers, amount and price are expressed in the selling asset.
+func (c *Client) UpdateOffer(offerID int64, sellAmount, offerPrice string) error {
+	offer, err := c.horizon.OfferDetails(strconv.FormatInt(offerID, 10))
+	if err != nil {
+		return errors.Wrapf(err, "failed to load offer %d", offerID)
+	}
+	if offer.Seller != c.kp.Address() {
+		return fmt.Errorf("offer %d is not owned by the loaded account", offerID)
+	}
+
+	if sellAmount == "" {
+		sellAmount = offer.Amount
+	}
+	p := xdr.Price{N: xdr.Int32(offer.PriceR.N), D: xdr.Int32(offer.PriceR.D)}
+	if offerPrice != "" {
+		if p, err = price.Parse(offerPrice); err != nil {
+			return errors.Wrapf(err, "invalid price %s", offerPrice)
+		}
+	}
+
+	buying := fromBaseAsset(base.Asset(offer.Buying))
+	_, err = c.submitOffer(buying, &txnbuild.ManageSellOffer{
+		Selling: fromBaseAsset(base.Asset(offer.Selling)),
+		Buying:  buying,
+		Amount:  sellAmount,
+		Price:   p,
+		OfferID: offerID,
+	})
+	return err
+}
+
+// CancelOffer removes an offer of the loaded account from the DEX
+func (c *Client) CancelOffer(offerID int64) error {
+	return c.UpdateOffer(offerID, "0", "")
+}
+
+// ListOffers returns the open offers of an account, or of the loaded account if empty
+func (c *Client) ListOffers(account string) ([]horizon.Offer, error) {
+	if account == "" {
+		account = c.kp.Address()
+	}
+
+	request := horizonclient.OfferRequest{ForAccount: account, Limit: horizonPageLimit}
+	offers := []horizon.Offer{}
+	for {
+		page, err := c.horizon.Offers(request)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load offers of %s", account)
+		}
+		offers = append(offers, page.Embedded.Records...)
+		if len(page.Embedded.Records) < horizonPageLimit {
+			return offers, nil
+		}
+		request.Cursor = page.Embedded.Records[len(page.Embedded.Records)-1].PagingToken()
+	}
+}
+
+// OrderBook returns the bids and asks on the DEX for an asset pair
+func (c *Client) OrderBook(selling, buying txnbuild.Asset, limit uint) (horizon.OrderBookSummary, error) {
+	sellingAsset, err := toBaseAsset(selling)
+	if err != nil {
+		return horizon.OrderBookSummary{}, err
+	}
+	buyingAsset, err := toBaseAsset(buying)
+	if err != nil {
+		return horizon.OrderBookSummary{}, err
+	}
+
+	return c.horizon.OrderBook(horizonclient.OrderBookRequest{
+		SellingAssetType:   horizonclient.AssetType(sellingAsset.Type),
+		SellingAssetCode:   sellingAsset.Code,
+		SellingAssetIssuer: sellingAsset.Issuer,
+		BuyingAssetType:    horizonclient.AssetType(buyingAsset.Type),
+		BuyingAssetCode:    buyingAsset.Code,
+		BuyingAssetIssuer:  buyingAsset.Issuer,
+		Limit:              limit,
+	})
+}
+
+// submitOffer submits a manage offer operation, returning the ID of the resulting offer
+func (c *Client) submitOffer(buying txnbuild.Asset, offer txnbuild.Operation) (int64, error) {
+	hAccount, err := c.AccountData(c.kp.Address())
+	if err != nil {
+		return 0, errors.Wrap(err, "account does not exist")
+	}
+
+	if err := c.requireTrustline(hAccount, buying); err != nil {
+		return 0, err
+	}
+
+	params := txnbuild.TransactionParams{
+		SourceAccount:        &hAccount,
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{offer},
+		BaseFee:              BaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: c.timeBounds(),
+		},
+	}
+
+	tx, err := txnbuild.NewTransaction(params)
+	if err != nil {
+		return 0, err
+	}
+
+	hTx, err := c.signAndSubmit(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	return offerIDFromResult(hTx.ResultXdr)
+}
+
+// offerIDFromResult extracts the ID of the offer created or updated by the first operation of a transaction
+func offerIDFromResult(resultXdr string) (int64, error) {
+	var txResult xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXdr, &txResult); err != nil {
+		return 0, errors.Wrap(err, "failed to decode transaction result")
+	}
+	results, ok := txResult.OperationResults()
+	if !ok || len(results) == 0 {
+		return 0, errors.New("transaction result has no operation results")
+	}
+	tr, ok := results[0].GetTr()
+	if !ok {
+		return 0, errors.New("invalid operation result")
+	}
+
+	var success xdr.ManageOfferSuccessResult
+	if sell, ok := tr.GetManageSellOfferResult(); ok {
+		success, ok = sell.GetSuccess()
+		if !ok {
+			return 0, errors.New("manage sell offer failed")
+		}
+	} else if buy, ok := tr.GetManageBuyOfferResult(); ok {
+		success, ok = buy.GetSuccess()
+		if !ok {
+			return 0, errors.New("manage buy offer failed")
+		}
+	} else {
+		return 0, errors.New("operation is not a manage offer operation")
+	}
+
+	if success.Offer.Offer == nil {
+		// The offer was fully filled or deleted
+		return 0, nil
+	}
+	return int64(success.Offer.Offer.OfferId), nil
+}
+
+// quoteSorter sorts quotes on their parsed ranking amounts
+type quoteSorter struct {
+	quotes     []Quote
+	ranks      []int64
+	descending bool
+}
+
+func (s quoteSorter) Len() int { return len(s.quotes) }
+
+func (s quoteSorter) Less(i, j int) bool {
+	if s.descending {
+		return s.ranks[i] > s.ranks[j]
+	}
+	return s.ranks[i] < s.ranks[j]
+}
+
+func (s quoteSorter) Swap(i, j int) {
+	s.quotes[i], s.quotes[j] = s.quotes[j], s.quotes[i]
+	s.ranks[i], s.ranks[j] = s.ranks[j], s.ranks[i]
+}
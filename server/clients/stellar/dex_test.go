@@ -0,0 +1,55 @@
+package stellargoclient
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySlippage(t *testing.T) {
+	t.Run("minimum", func(t *testing.T) {
+		limit, err := applySlippage("100", "1", false)
+		assert.NoError(t, err)
+		assert.Equal(t, "99.0000000", limit)
+
+		limit, err = applySlippage("0.0000003", "50", false)
+		assert.NoError(t, err)
+		assert.Equal(t, "0.0000001", limit)
+	})
+
+	t.Run("maximum", func(t *testing.T) {
+		limit, err := applySlippage("100", "0.5", true)
+		assert.NoError(t, err)
+		assert.Equal(t, "100.5000000", limit)
+
+		limit, err = applySlippage("0.0000003", "50", true)
+		assert.NoError(t, err)
+		assert.Equal(t, "0.0000005", limit)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		limit, err := applySlippage("10", "", false)
+		assert.NoError(t, err)
+		assert.Equal(t, "9.9000000", limit)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := applySlippage("10", "100", false)
+		assert.Error(t, err)
+		_, err = applySlippage("10", "-1", false)
+		assert.Error(t, err)
+		_, err = applySlippage("10", "abc", false)
+		assert.Error(t, err)
+	})
+}
+
+func TestQuoteSorter(t *testing.T) {
+	quotes := []Quote{{SourceAmount: "3"}, {SourceAmount: "1"}, {SourceAmount: "2"}}
+
+	sort.Stable(quoteSorter{quotes: quotes, ranks: []int64{3, 1, 2}})
+	assert.Equal(t, []string{"1", "2", "3"}, []string{quotes[0].SourceAmount, quotes[1].SourceAmount, quotes[2].SourceAmount})
+
+	sort.Stable(quoteSorter{quotes: quotes, ranks: []int64{1, 2, 3}, descending: true})
+	assert.Equal(t, []string{"3", "2", "1"}, []string{quotes[0].SourceAmount, quotes[1].SourceAmount, quotes[2].SourceAmount})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/txnbuild"
 )
 
@@ -23,25 +24,34 @@ func (c *Client) SignTransactionXdr(txXdr string) error {
 }
 
 func (c *Client) SignAndSubmit(txn *txnbuild.Transaction) error {
+	_, err := c.signAndSubmit(txn)
+	return err
+}
+
+// signAndSubmit signs and submits a transaction, returning the transaction as processed by horizon
+func (c *Client) signAndSubmit(txn *txnbuild.Transaction) (horizon.Transaction, error) {
 	// Sign the transaction, and base 64 encode its XDR representation
 	signedTx, err := txn.Sign(c.GetStellarNetworkPassphrase(), c.kp)
 	if err != nil {
-		return errors.Wrap(err, "failed to sign transaction")
+		return horizon.Transaction{}, errors.Wrap(err, "failed to sign transaction")
 	}
 
 	txeBase64, err := signedTx.Base64()
 	if err != nil {
-		return errors.Wrap(err, "failed to base64 encode transaction")
+		return horizon.Transaction{}, errors.Wrap(err, "failed to base64 encode transaction")
 	}
 
 	// Submit the transaction
-	_, err = c.horizon.SubmitTransactionXDR(txeBase64)
+	tx, err := c.horizon.SubmitTransactionXDR(txeBase64)
 	if err != nil {
-		hError := err.(*horizonclient.Error)
-		return hError
+		var hError *horizonclient.Error
+		if errors.As(err, &hError) {
+			return horizon.Transaction{}, hError
+		}
+		return horizon.Transaction{}, err
 	}
 
-	return nil
+	return tx, nil
 }
 
 func (c *Client) SignFundAndSubmitTransaction(tx *txnbuild.Transaction) error {
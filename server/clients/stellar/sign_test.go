@@ -0,0 +1,40 @@
+package stellargoclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndSubmitNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	kp, err := keypair.Random()
+	require.NoError(t, err)
+	c := &Client{
+		stellarNetwork: "testnet",
+		horizon:        &horizonclient.Client{HorizonURL: server.URL + "/"},
+		kp:             kp,
+	}
+
+	account := txnbuild.NewSimpleAccount(kp.Address(), 1)
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &account,
+		IncrementSequenceNum: true,
+		BaseFee:              txnbuild.MinBaseFee,
+		Operations:           []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 10}},
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+
+	// errors which don't come from horizon are returned instead of panicking
+	_, err = c.signAndSubmit(tx)
+	assert.Error(t, err)
+}
@@ -2,12 +2,9 @@ package stellargoclient
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -43,45 +40,27 @@ func (c *Client) getTFTTransactionFundingCondition() (feeWallet, fee string, err
 	return
 }
 
-func (c *Client) AwaitTransactionWithMemo(ctx context.Context, account string, memo string, timeout int) error {
-	memo = strings.TrimPrefix(memo, "0x")
-	for i := 0; i < int(timeout); i++ {
-		select {
-		case <-time.After(1 * time.Second):
-			transactions, err := c.Transactions(account, 10, false, "", horizonclient.OrderDesc)
-			if err != nil {
-				return err
-			}
-			for _, tx := range transactions {
-				decodedMemo, err := base64.StdEncoding.DecodeString(tx.Memo)
-				if err == nil {
-					hexDecodedMemo := hex.EncodeToString(decodedMemo)
-					if hexDecodedMemo == memo {
-						return nil
-					}
-				}
-			}
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	}
-	return errors.New("transaction not found")
+// AwaitTransactionWithMemo waits till a transaction with the given memo is made on the account, looking at the
+// payments after cursor. Take the cursor with PaymentsCursor before the transaction is submitted.
+func (c *Client) AwaitTransactionWithMemo(ctx context.Context, account, cursor, memo string, timeout int) error {
+	_, err := c.AwaitPayment(ctx, account, cursor, PaymentFilter{Memo: memo}, time.Duration(timeout)*time.Second)
+	return err
 }
 
-func (c *Client) AwaitTransactionWithMemoOnEthBridge(ctx context.Context, memo string, timeout int) error {
+func (c *Client) AwaitTransactionWithMemoOnEthBridge(ctx context.Context, cursor, memo string, timeout int) error {
 	bridgeAddress, err := c.GetEthBridgeAddress()
 	if err != nil {
 		return err
 	}
-	return c.AwaitTransactionWithMemo(ctx, bridgeAddress, memo, timeout)
+	return c.AwaitTransactionWithMemo(ctx, bridgeAddress, cursor, memo, timeout)
 }
 
-func (c *Client) AwaitForTransactionWithMemoOnTfchainBridge(ctx context.Context, memo string, timeout int) error {
+func (c *Client) AwaitForTransactionWithMemoOnTfchainBridge(ctx context.Context, cursor, memo string, timeout int) error {
 	bridgeAddress, err := c.GetTfchainBridgeAddress()
 	if err != nil {
 		return err
 	}
-	return c.AwaitTransactionWithMemo(ctx, bridgeAddress, memo, timeout)
+	return c.AwaitTransactionWithMemo(ctx, bridgeAddress, cursor, memo, timeout)
 }
 
 func (c *Client) Transactions(account string, limit uint, includeFailed bool, cursor string, order horizonclient.Order) ([]horizon.Transaction, error) {
@@ -127,7 +106,7 @@ func (c *Client) FundTransactionUsingTft(destination string, amount string) erro
 		BaseFee:              0,
 		Operations:           []txnbuild.Operation{&payment, &feePayment},
 		Preconditions: txnbuild.Preconditions{
-			TimeBounds: txnbuild.NewInfiniteTimeout(),
+			TimeBounds: c.timeBounds(),
 		},
 	})
 	if err != nil {
@@ -19,60 +19,52 @@ const (
 	// Tfchain
 	stellarPublicNetworkTfchainBridgeAddress  = "GBNOTAYUMXVO5QDYWYO2SOCOYIJ3XFIP65GKOQN7H65ZZSO6BK4SLWSC"
 	stellarTestnetNetworkTfchainBridgeAddress = "GDHJP6TF3UXYXTNEZ2P36J5FH7W4BJJQ4AYYAXC66I2Q2AH5B6O6BCFG"
-
-	minimumDestAmountToReceive = "0.001"
 )
 
-func (c *Client) Swap(sourceAsset string, destinationAsset string, amount string) error {
+// Swap sends an amount of the source asset and receives the destination asset on the loaded account, allowing the
+// default slippage
+func (c *Client) Swap(sourceAsset string, destinationAsset string, amount string) (string, error) {
 	assetFrom, err := c.GetAssetFromString(sourceAsset)
 	if err != nil {
-		return err
+		return "", err
 	}
 	assetTo, err := c.GetAssetFromString(destinationAsset)
 	if err != nil {
-		return err
-	}
-	hAccount, err := c.AccountData(c.kp.Address())
-	if err != nil {
-		return errors.Wrap(err, "account does not exist")
+		return "", err
 	}
 
-	payment := txnbuild.PathPaymentStrictSend{
-		SendAsset:     assetFrom,
-		DestAsset:     assetTo,
-		SourceAccount: c.kp.Address(),
-		Destination:   c.kp.Address(),
-		SendAmount:    amount,
-		DestMin:       minimumDestAmountToReceive,
-	}
+	return c.SwapStrictSend(assetFrom, assetTo, amount, DefaultSlippage)
+}
 
-	params := txnbuild.TransactionParams{
-		SourceAccount:        &hAccount,
-		IncrementSequenceNum: true,
-		Operations:           []txnbuild.Operation{&payment},
-		BaseFee:              BaseFee,
-		Preconditions: txnbuild.Preconditions{
-			TimeBounds: txnbuild.NewInfiniteTimeout(),
-		},
-		Memo: txnbuild.MemoText(fmt.Sprintf("swap %s %s for %s", amount, sourceAsset, destinationAsset)),
+// swapMemo describes a swap in at most 28 bytes, the maximum length of a text memo
+func swapMemo(amount string, from, to txnbuild.Asset) string {
+	code := func(asset txnbuild.Asset) string {
+		if asset.IsNative() {
+			return "XLM"
+		}
+		return asset.GetCode()
 	}
-
-	tx, err := txnbuild.NewTransaction(params)
-	if err != nil {
-		return err
+	memo := fmt.Sprintf("swap %s %s for %s", amount, code(from), code(to))
+	if len(memo) > 28 {
+		memo = memo[:28]
 	}
-
-	return c.SignAndSubmit(tx)
+	return memo
 }
 
+// Transfer an amount of TFT to the destination
 func (c *Client) Transfer(destination, memo string, amount string) (string, error) {
+	return c.TransferAsset(c.GetTftAsset(), destination, memo, amount)
+}
+
+// TransferAsset transfers an amount of any asset to the destination, returning the hash of the transaction
+func (c *Client) TransferAsset(asset txnbuild.Asset, destination, memo string, amount string) (string, error) {
 	hAccount, err := c.AccountData(c.kp.Address())
 	if err != nil {
 		return "", errors.Wrap(err, "account does not exist")
 	}
 
-	if !hasTrustline(hAccount, c.GetTftBaseAsset()) {
-		return "", errors.New("source account does not have trustline")
+	if err := c.requireTrustline(hAccount, asset); err != nil {
+		return "", errors.Wrap(err, "source account")
 	}
 
 	destHAccount, err := c.AccountData(destination)
@@ -80,14 +72,14 @@ func (c *Client) Transfer(destination, memo string, amount string) (string, erro
 		return "", errors.Wrap(err, "account does not exist")
 	}
 
-	if !hasTrustline(destHAccount, c.GetTftBaseAsset()) {
-		return "", errors.New("destination account does not have trustline")
+	if err := c.requireTrustline(destHAccount, asset); err != nil {
+		return "", errors.Wrap(err, "destination account")
 	}
 
 	transferTx := txnbuild.Payment{
 		Destination: destination,
 		Amount:      amount,
-		Asset:       c.GetTftAsset(),
+		Asset:       asset,
 	}
 
 	params := txnbuild.TransactionParams{
@@ -96,7 +88,7 @@ func (c *Client) Transfer(destination, memo string, amount string) (string, erro
 		Operations:           []txnbuild.Operation{&transferTx},
 		BaseFee:              BaseFee,
 		Preconditions: txnbuild.Preconditions{
-			TimeBounds: txnbuild.NewInfiniteTimeout(),
+			TimeBounds: c.timeBounds(),
 		},
 	}
 	if memo != "" {
@@ -112,7 +104,7 @@ func (c *Client) Transfer(destination, memo string, amount string) (string, erro
 	if err != nil {
 		return "", err
 	}
-	hash, err := tx.HashHex(c.stellarNetwork)
+	hash, err := tx.HashHex(c.GetStellarNetworkPassphrase())
 	if err != nil {
 		return "", err
 	}
@@ -120,7 +112,7 @@ func (c *Client) Transfer(destination, memo string, amount string) (string, erro
 }
 
 func (c *Client) TransferToEthBridge(destination, amount string) (string, error) {
-	b, err := hex.DecodeString(strings.TrimPrefix(destination, "0x"))
+	memo, err := EthBridgeMemo(destination)
 	if err != nil {
 		return "", err
 	}
@@ -130,7 +122,17 @@ func (c *Client) TransferToEthBridge(destination, amount string) (string, error)
 		return "", err
 	}
 
-	return c.Transfer(bridgeAddr, fmt.Sprintf("%s=", base64.RawStdEncoding.EncodeToString(b)), amount)
+	return c.Transfer(bridgeAddr, memo, amount)
+}
+
+// EthBridgeMemo returns the memo of a deposit on the eth bridge for an eth address
+func EthBridgeMemo(destination string) (string, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(destination, "0x"))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s=", base64.RawStdEncoding.EncodeToString(b)), nil
 }
 
 func (c *Client) GetEthBridgeAddress() (string, error) {
@@ -143,6 +145,18 @@ func (c *Client) GetEthBridgeAddress() (string, error) {
 	}
 }
 
+// GetBridgeAddress returns the stellar address of a bridge, either "eth" or "tfchain"
+func (c *Client) GetBridgeAddress(bridge string) (string, error) {
+	switch strings.ToLower(bridge) {
+	case "eth":
+		return c.GetEthBridgeAddress()
+	case "tfchain":
+		return c.GetTfchainBridgeAddress()
+	default:
+		return "", fmt.Errorf("unknown bridge %s", bridge)
+	}
+}
+
 // Reinstate later
 
 // func (c *Client) TransferToBscBridge(destination, amount string) error {
@@ -165,7 +179,12 @@ func (c *Client) TransferToTfchainBridge(amount string, twinID uint32) (string,
 		return "", err
 	}
 
-	return c.Transfer(bridgeAddr, fmt.Sprintf("twin_%d", twinID), amount)
+	return c.Transfer(bridgeAddr, TfchainBridgeMemo(twinID), amount)
+}
+
+// TfchainBridgeMemo returns the memo of a deposit on the tfchain bridge for a twin
+func TfchainBridgeMemo(twinID uint32) string {
+	return fmt.Sprintf("twin_%d", twinID)
 }
 
 // func (c *Client) GetBscBridgeAddress() (string, error) {
@@ -0,0 +1,89 @@
+package stellargoclient
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+)
+
+// requireTrustline returns an error if the account can't hold the asset. Native assets and the issuer of an
+// asset don't need a trustline.
+func (c *Client) requireTrustline(hAccount horizon.Account, asset txnbuild.Asset) error {
+	if asset.IsNative() || hAccount.AccountID == asset.GetIssuer() {
+		return nil
+	}
+	baseAsset, err := toBaseAsset(asset)
+	if err != nil {
+		return err
+	}
+	if !hasTrustline(hAccount, baseAsset) {
+		return fmt.Errorf("account %s does not have a trustline for %s", hAccount.AccountID, assetString(asset))
+	}
+	return nil
+}
+
+// AddTrustline creates a trustline for the asset on the loaded account, or changes the limit of an existing one.
+// If limit is empty, the maximum limit is used.
+func (c *Client) AddTrustline(asset txnbuild.Asset, limit string) error {
+	if asset.IsNative() {
+		return errors.New("can not add a trustline for the native asset")
+	}
+	if limit == "" {
+		limit = txnbuild.MaxTrustlineLimit
+	}
+	return c.changeTrust(asset, limit)
+}
+
+// RemoveTrustline removes the trustline for the asset from the loaded account. The balance of the asset must be 0.
+func (c *Client) RemoveTrustline(asset txnbuild.Asset) error {
+	if asset.IsNative() {
+		return errors.New("can not remove a trustline for the native asset")
+	}
+	balance, err := c.GetAssetBalance("", asset)
+	if err != nil {
+		return err
+	}
+	if balance == "" {
+		return fmt.Errorf("account does not have a trustline for %s", assetString(asset))
+	}
+	if stroops, err := amount.ParseInt64(balance); err != nil || stroops != 0 {
+		return fmt.Errorf("can not remove trustline for %s with a balance of %s", assetString(asset), balance)
+	}
+	return c.changeTrust(asset, "0")
+}
+
+func (c *Client) changeTrust(asset txnbuild.Asset, limit string) error {
+	line, err := asset.ToChangeTrustAsset()
+	if err != nil {
+		return err
+	}
+	hAccount, err := c.AccountData(c.kp.Address())
+	if err != nil {
+		return errors.Wrap(err, "account does not exist")
+	}
+
+	changeTrust := txnbuild.ChangeTrust{
+		Line:  line,
+		Limit: limit,
+	}
+
+	params := txnbuild.TransactionParams{
+		SourceAccount:        &hAccount,
+		IncrementSequenceNum: true,
+		Operations:           []txnbuild.Operation{&changeTrust},
+		BaseFee:              BaseFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: c.timeBounds(),
+		},
+	}
+
+	tx, err := txnbuild.NewTransaction(params)
+	if err != nil {
+		return err
+	}
+
+	return c.SignAndSubmit(tx)
+}
@@ -0,0 +1,346 @@
+package stellargoclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+const (
+	// maximum amount of records horizon returns in a single page
+	horizonPageLimit = 200
+	// cursor before the first operation of an account
+	firstCursor = "0"
+	// delay before reconnecting a broken payment stream, doubled on every consecutive failure
+	streamRetryDelay    = time.Second
+	maxStreamRetryDelay = 30 * time.Second
+)
+
+var (
+	// ErrTransactionNotFound is returned if no matching payment was seen before the timeout expired
+	ErrTransactionNotFound = errors.New("transaction not found")
+
+	watchersLock sync.Mutex
+	// payment watchers, keyed by horizon url and account, shared by all clients
+	watchers = map[string]*paymentWatcher{}
+)
+
+// PaymentFilter describes the payment an awaiter is interested in. Empty fields are not matched on.
+type PaymentFilter struct {
+	// Memo of the transaction containing the payment. Text memos are compared exactly, hash memos hex encoded in any
+	// case, with an optional 0x prefix.
+	Memo string
+	// Destination account of the payment
+	Destination string
+	// Amount of the payment
+	Amount string
+}
+
+// matches checks if a payment in a transaction satisfies the filter
+func (f PaymentFilter) matches(payment operations.Payment, tx *horizon.Transaction) bool {
+	if f.Memo != "" {
+		if tx == nil || !memoMatches(f.Memo, tx) {
+			return false
+		}
+	}
+	if f.Destination != "" && f.Destination != payment.To {
+		return false
+	}
+	if f.Amount != "" {
+		expected, err := amount.ParseInt64(f.Amount)
+		if err != nil {
+			return false
+		}
+		actual, err := amount.ParseInt64(payment.Amount)
+		if err != nil || expected != actual {
+			return false
+		}
+	}
+	return true
+}
+
+// memoMatches compares a memo to the memo of a transaction
+func memoMatches(memo string, tx *horizon.Transaction) bool {
+	switch tx.MemoType {
+	case "hash", "return":
+		return strings.EqualFold(strings.TrimPrefix(memo, "0x"), transactionMemo(tx))
+	default:
+		return memo == tx.Memo
+	}
+}
+
+// transactionMemo returns the memo of a transaction, hex encoding binary memos
+func transactionMemo(tx *horizon.Transaction) string {
+	switch tx.MemoType {
+	case "hash", "return":
+		decoded, err := base64.StdEncoding.DecodeString(tx.Memo)
+		if err != nil {
+			return tx.Memo
+		}
+		return hex.EncodeToString(decoded)
+	default:
+		return tx.Memo
+	}
+}
+
+type paymentWaiter struct {
+	filter PaymentFilter
+	// receives the hash of the matching transaction
+	found chan string
+}
+
+// paymentWatcher streams the payments of an account to all registered waiters. The stream is only
+// open as long as there is at least one waiter.
+type paymentWatcher struct {
+	horizon *horizonclient.Client
+	account string
+
+	lock    sync.Mutex
+	waiters map[*paymentWaiter]struct{}
+	cancel  context.CancelFunc
+}
+
+// paymentWatcherFor returns the shared payment watcher for an account
+func (c *Client) paymentWatcherFor(account string) *paymentWatcher {
+	key := c.horizon.HorizonURL + account
+
+	watchersLock.Lock()
+	defer watchersLock.Unlock()
+
+	w, ok := watchers[key]
+	if !ok {
+		w = &paymentWatcher{
+			horizon: c.horizon,
+			account: account,
+			waiters: map[*paymentWaiter]struct{}{},
+		}
+		watchers[key] = w
+	}
+	return w
+}
+
+// await blocks until a payment matching the filter is seen, starting from the given cursor. If cursor is empty,
+// the most recent page of payments is checked before waiting for new ones.
+func (w *paymentWatcher) await(ctx context.Context, cursor string, filter PaymentFilter) (string, error) {
+	// Register before looking at history so payments arriving in the meantime are delivered by the stream. Without a
+	// running stream, it is started from the latest payment, the backfill covers the history up to there.
+	waiter, streaming := w.register(filter)
+	defer w.unregister(waiter)
+	if !streaming {
+		latest, err := latestPaymentCursor(w.horizon, w.account)
+		if err != nil {
+			return "", err
+		}
+		w.startStream(latest)
+	}
+
+	// the backfill runs next to the stream, so awaiters of the account don't wait for each other's backfill
+	backfilled := make(chan backfillResult, 1)
+	go func() {
+		hash, err := w.backfill(ctx, cursor, filter)
+		backfilled <- backfillResult{hash: hash, err: err}
+	}()
+
+	for {
+		select {
+		case hash := <-waiter.found:
+			return hash, nil
+		case result := <-backfilled:
+			if result.err != nil {
+				return "", result.err
+			}
+			if result.hash != "" {
+				return result.hash, nil
+			}
+			backfilled = nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+type backfillResult struct {
+	hash string
+	err  error
+}
+
+// register adds a waiter and reports if the stream is running
+func (w *paymentWatcher) register(filter PaymentFilter) (*paymentWaiter, bool) {
+	waiter := &paymentWaiter{filter: filter, found: make(chan string, 1)}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.waiters[waiter] = struct{}{}
+	return waiter, w.cancel != nil
+}
+
+// startStream starts streaming from a cursor if the stream is not running and there are waiters
+func (w *paymentWatcher) startStream(cursor string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.cancel == nil && len(w.waiters) > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		w.cancel = cancel
+		go w.stream(ctx, cursor)
+	}
+}
+
+func (w *paymentWatcher) unregister(waiter *paymentWaiter) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	delete(w.waiters, waiter)
+	if len(w.waiters) == 0 && w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
+
+// stream follows the payments of the account after the cursor until the context is canceled, resuming from the
+// last seen paging token if the connection to horizon is lost
+func (w *paymentWatcher) stream(ctx context.Context, cursor string) {
+	delay := streamRetryDelay
+	for {
+		request := horizonclient.OperationRequest{
+			ForAccount: w.account,
+			Cursor:     cursor,
+			Join:       "transactions",
+		}
+		err := w.horizon.StreamPayments(ctx, request, func(op operations.Operation) {
+			cursor = op.PagingToken()
+			delay = streamRetryDelay
+			w.dispatch(op)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Debug().Err(err).Msgf("payment stream of %s interrupted, resuming from cursor %s in %s", w.account, cursor, delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		if delay < maxStreamRetryDelay {
+			delay *= 2
+		}
+	}
+}
+
+// dispatch hands a payment to all waiters it matches
+func (w *paymentWatcher) dispatch(op operations.Operation) {
+	payment, ok := op.(operations.Payment)
+	if !ok {
+		return
+	}
+	tx := w.transactionOf(payment)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for waiter := range w.waiters {
+		if waiter.filter.matches(payment, tx) {
+			select {
+			case waiter.found <- payment.TransactionHash:
+			default:
+			}
+		}
+	}
+}
+
+// backfill looks for a matching payment in the history of the account after the cursor, or in the latest page of
+// payments if cursor is empty. It stops once ctx is done.
+func (w *paymentWatcher) backfill(ctx context.Context, cursor string, filter PaymentFilter) (string, error) {
+	request := horizonclient.OperationRequest{
+		ForAccount: w.account,
+		Limit:      horizonPageLimit,
+		Join:       "transactions",
+	}
+	if cursor == "" {
+		request.Order = horizonclient.OrderDesc
+	} else {
+		request.Order = horizonclient.OrderAsc
+		request.Cursor = cursor
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		page, err := w.horizon.Payments(request)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to load payments of %s", w.account)
+		}
+		records := page.Embedded.Records
+		for _, op := range records {
+			payment, ok := op.(operations.Payment)
+			if ok && filter.matches(payment, w.transactionOf(payment)) {
+				return payment.TransactionHash, nil
+			}
+		}
+		// Without a cursor only the latest page is inspected
+		if cursor == "" || len(records) < horizonPageLimit {
+			return "", nil
+		}
+		request.Cursor = records[len(records)-1].PagingToken()
+	}
+}
+
+// transactionOf returns the transaction of a payment, loading it if it was not joined in the response
+func (w *paymentWatcher) transactionOf(payment operations.Payment) *horizon.Transaction {
+	if payment.Transaction != nil {
+		return payment.Transaction
+	}
+	tx, err := w.horizon.TransactionDetail(payment.TransactionHash)
+	if err != nil {
+		log.Debug().Err(err).Msgf("failed to load transaction %s", payment.TransactionHash)
+		return nil
+	}
+	return &tx
+}
+
+// latestPaymentCursor returns the paging token of the latest payment of an account
+func latestPaymentCursor(client *horizonclient.Client, account string) (string, error) {
+	page, err := client.Payments(horizonclient.OperationRequest{
+		ForAccount: account,
+		Order:      horizonclient.OrderDesc,
+		Limit:      1,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load payments of %s", account)
+	}
+	if len(page.Embedded.Records) == 0 {
+		return firstCursor, nil
+	}
+	return page.Embedded.Records[0].PagingToken(), nil
+}
+
+// PaymentsCursor returns a cursor pointing at the latest payment of an account. Pass it to AwaitPayment to
+// only consider payments made after this call.
+func (c *Client) PaymentsCursor(account string) (string, error) {
+	return latestPaymentCursor(c.horizon, account)
+}
+
+// AwaitPayment waits till a payment matching the filter is made on the account, starting from the cursor. The hash
+// of the matching transaction is returned. Concurrent awaits on the same account share a single horizon stream.
+func (c *Client) AwaitPayment(ctx context.Context, account string, cursor string, filter PaymentFilter, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	hash, err := c.paymentWatcherFor(account).await(ctx, cursor, filter)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", ErrTransactionNotFound
+	}
+	return hash, err
+}
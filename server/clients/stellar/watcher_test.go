@@ -0,0 +1,46 @@
+package stellargoclient
+
+import (
+	"testing"
+
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentFilter(t *testing.T) {
+	payment := operations.Payment{
+		To:     "GDESTINATION",
+		Amount: "100.0000000",
+	}
+	hashMemoTx := &horizon.Transaction{
+		MemoType: "hash",
+		Memo:     "3q2+7wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+	}
+	hashMemo := "0xdeadbeef00000000000000000000000000000000000000000000000000000000"
+
+	t.Run("hash_memo", func(t *testing.T) {
+		assert.True(t, PaymentFilter{Memo: hashMemo}.matches(payment, hashMemoTx))
+		assert.True(t, PaymentFilter{Memo: "DEADBEEF00000000000000000000000000000000000000000000000000000000"}.matches(payment, hashMemoTx))
+		assert.False(t, PaymentFilter{Memo: "0xdeadbeef"}.matches(payment, hashMemoTx))
+	})
+
+	t.Run("text_memo", func(t *testing.T) {
+		tx := &horizon.Transaction{MemoType: "text", Memo: "twin_42"}
+		assert.True(t, PaymentFilter{Memo: "twin_42"}.matches(payment, tx))
+		assert.False(t, PaymentFilter{Memo: "twin_4"}.matches(payment, tx))
+		assert.False(t, PaymentFilter{Memo: "TWIN_42"}.matches(payment, tx))
+	})
+
+	t.Run("missing_transaction", func(t *testing.T) {
+		assert.False(t, PaymentFilter{Memo: hashMemo}.matches(payment, nil))
+		assert.True(t, PaymentFilter{Destination: "GDESTINATION"}.matches(payment, nil))
+	})
+
+	t.Run("destination_and_amount", func(t *testing.T) {
+		assert.True(t, PaymentFilter{Memo: hashMemo, Destination: "GDESTINATION", Amount: "100"}.matches(payment, hashMemoTx))
+		assert.False(t, PaymentFilter{Memo: hashMemo, Destination: "GOTHER"}.matches(payment, hashMemoTx))
+		assert.False(t, PaymentFilter{Memo: hashMemo, Amount: "99.9"}.matches(payment, hashMemoTx))
+		assert.False(t, PaymentFilter{Amount: "not a number"}.matches(payment, hashMemoTx))
+	})
+}
@@ -0,0 +1,249 @@
+package tfchain
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+// Call is a tfchain call which can be submitted in a batch or have its fee estimated
+type Call struct {
+	// Name of the call as Pallet.call
+	Name string
+	Args []interface{}
+}
+
+// BatchResult is the outcome of a batch which was included in a block
+type BatchResult struct {
+	BlockHash   string
+	BlockNumber uint32
+	// ContractIDs are the ids of the node, name and rent contracts the batch created for the twin of the caller
+	ContractIDs []uint64
+	// ServiceContractIDs are the ids of the service contracts the batch created for the twin of the caller
+	ServiceContractIDs []uint64
+}
+
+type feeInfo struct {
+	// PartialFee is a number or a string depending on the version of the node
+	PartialFee json.Number `json:"partialFee"`
+}
+
+// CreateNodeContractCall creates a contract deploying a deployment with hash on a node
+func CreateNodeContractCall(node uint32, body string, hash string, publicIPs uint32, solutionProviderID *uint64) Call {
+	return Call{
+		Name: "SmartContractModule.create_node_contract",
+		Args: []interface{}{node, substrate.NewHexHash(hash), body, publicIPs, optionU64(solutionProviderID)},
+	}
+}
+
+// CreateNameContractCall creates a contract reserving a name for gateways
+func CreateNameContractCall(name string) Call {
+	return Call{Name: "SmartContractModule.create_name_contract", Args: []interface{}{name}}
+}
+
+// CreateRentContractCall creates a contract renting a whole node
+func CreateRentContractCall(node uint32, solutionProviderID *uint64) Call {
+	return Call{
+		Name: "SmartContractModule.create_rent_contract",
+		Args: []interface{}{node, optionU64(solutionProviderID)},
+	}
+}
+
+// CancelContractCall cancels a node, name or rent contract
+func CancelContractCall(contract uint64) Call {
+	return Call{Name: "SmartContractModule.cancel_contract", Args: []interface{}{contract}}
+}
+
+// TransferCall transfers an amount in units of 10^-7 TFT to destination
+func TransferCall(amount uint64, destination substrate.AccountID) (Call, error) {
+	dest, err := types.NewMultiAddressFromAccountID(destination[:])
+	if err != nil {
+		return Call{}, errors.Wrap(err, "invalid destination")
+	}
+	return Call{
+		Name: "Balances.transfer",
+		Args: []interface{}{dest, types.NewUCompact(new(big.Int).SetUint64(amount))},
+	}, nil
+}
+
+// ServiceContractCreateCall creates a service contract between a service and a consumer
+func ServiceContractCreateCall(service, consumer substrate.AccountID) Call {
+	return Call{Name: "SmartContractModule.service_contract_create", Args: []interface{}{service, consumer}}
+}
+
+// ServiceContractSetMetadataCall sets the metadata of a service contract
+func ServiceContractSetMetadataCall(contract uint64, metadata string) Call {
+	return Call{Name: "SmartContractModule.service_contract_set_metadata", Args: []interface{}{contract, metadata}}
+}
+
+// ServiceContractSetFeesCall sets the fees of a service contract
+func ServiceContractSetFeesCall(contract uint64, baseFee, variableFee uint64) Call {
+	return Call{
+		Name: "SmartContractModule.service_contract_set_fees",
+		Args: []interface{}{contract, baseFee, variableFee},
+	}
+}
+
+// ServiceContractApproveCall approves a service contract
+func ServiceContractApproveCall(contract uint64) Call {
+	return Call{Name: "SmartContractModule.service_contract_approve", Args: []interface{}{contract}}
+}
+
+// ServiceContractRejectCall rejects a service contract
+func ServiceContractRejectCall(contract uint64) Call {
+	return Call{Name: "SmartContractModule.service_contract_reject", Args: []interface{}{contract}}
+}
+
+// ServiceContractCancelCall cancels a service contract
+func ServiceContractCancelCall(contract uint64) Call {
+	return Call{Name: "SmartContractModule.service_contract_cancel", Args: []interface{}{contract}}
+}
+
+// ServiceContractBillCall bills the consumer of a service contract
+func ServiceContractBillCall(contract uint64, variableAmount uint64, metadata string) Call {
+	return Call{
+		Name: "SmartContractModule.service_contract_bill",
+		Args: []interface{}{contract, variableAmount, metadata},
+	}
+}
+
+func optionU64(v *uint64) types.OptionU64 {
+	if v == nil {
+		return types.OptionU64{}
+	}
+	return types.NewOptionU64(types.U64(*v))
+}
+
+// Batch submits calls as one extrinsic with Utility.batch_all, so either all of them succeed or none does
+func Batch(client *substrate.Substrate, identity substrate.Identity, calls []Call) (BatchResult, error) {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	call, err := batchCall(meta, calls)
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	// look the twin up before submitting, so a failing lookup does not hide a batch which was included. Accounts
+	// without a twin can still submit batches, like transfers, they just do not create contracts.
+	twin, err := client.GetTwinByPubKey(identity.PublicKey())
+	if err != nil && !errors.Is(err, substrate.ErrNotFound) {
+		return BatchResult{}, errors.Wrap(err, "failed to get twin")
+	}
+
+	response, err := client.Call(cl, meta, identity, call)
+	if err != nil {
+		return BatchResult{}, errors.Wrap(err, "failed to submit batch")
+	}
+
+	result := BatchResult{
+		BlockHash:          response.Hash.Hex(),
+		BlockNumber:        uint32(response.Block.Block.Header.Number),
+		ContractIDs:        []uint64{},
+		ServiceContractIDs: []uint64{},
+	}
+	if twin == 0 {
+		return result, nil
+	}
+	for _, e := range response.Events.SmartContractModule_ContractCreated {
+		if uint32(e.Contract.TwinID) == twin {
+			result.ContractIDs = append(result.ContractIDs, uint64(e.Contract.ContractID))
+		}
+	}
+	// service contracts can be created by the twin of the service or the consumer
+	for _, e := range response.Events.SmartContractModule_ServiceContractCreated {
+		sc := e.ServiceContract
+		if uint32(sc.ServiceTwinID) == twin || uint32(sc.ConsumerTwinID) == twin {
+			result.ServiceContractIDs = append(result.ServiceContractIDs, uint64(sc.ServiceContractID))
+		}
+	}
+
+	return result, nil
+}
+
+// EstimateFee returns the fee in units of 10^-7 TFT identity pays for submitting calls. A single call is estimated
+// on its own, several calls as a batch.
+func EstimateFee(client *substrate.Substrate, identity substrate.Identity, calls []Call) (uint64, error) {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return 0, err
+	}
+
+	var call types.Call
+	if len(calls) == 1 {
+		call, err = calls[0].build(meta)
+	} else {
+		call, err = batchCall(meta, calls)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// the fee only depends on the length and weight of the extrinsic, so it is not signed for real
+	signer, err := types.NewMultiAddressFromAccountID(identity.PublicKey())
+	if err != nil {
+		return 0, err
+	}
+	ext := types.NewExtrinsic(call)
+	ext.Version |= types.ExtrinsicBitSigned
+	ext.Signature = types.ExtrinsicSignatureV4{
+		Signer:    signer,
+		Signature: identity.MultiSignature(make([]byte, 64)),
+		Era:       types.ExtrinsicEra{IsImmortalEra: true},
+		Nonce:     types.NewUCompactFromUInt(0),
+		Tip:       types.NewUCompactFromUInt(0),
+	}
+
+	encoded, err := codec.EncodeToHex(ext)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to encode extrinsic")
+	}
+
+	var info feeInfo
+	if err := cl.Client.Call(&info, "payment_queryInfo", encoded); err != nil {
+		return 0, errors.Wrap(err, "failed to query fee")
+	}
+
+	fee, err := strconv.ParseUint(info.PartialFee.String(), 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid fee %s", info.PartialFee)
+	}
+	return fee, nil
+}
+
+// build the call with the metadata of the chain
+func (c Call) build(meta *types.Metadata) (types.Call, error) {
+	call, err := types.NewCall(meta, c.Name, c.Args...)
+	if err != nil {
+		return types.Call{}, errors.Wrapf(err, "failed to create call %s", c.Name)
+	}
+	return call, nil
+}
+
+func batchCall(meta *types.Metadata, calls []Call) (types.Call, error) {
+	if len(calls) == 0 {
+		return types.Call{}, errors.New("no calls to submit")
+	}
+
+	built := make([]types.Call, 0, len(calls))
+	for _, c := range calls {
+		call, err := c.build(meta)
+		if err != nil {
+			return types.Call{}, err
+		}
+		built = append(built, call)
+	}
+
+	call, err := types.NewCall(meta, "Utility.batch_all", built)
+	if err != nil {
+		return types.Call{}, errors.Wrap(err, "failed to create batch call")
+	}
+	return call, nil
+}
@@ -0,0 +1,40 @@
+package tfchain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+func TestCalls(t *testing.T) {
+	provider := uint64(7)
+	call := CreateNodeContractCall(1, "body", "hash", 2, &provider)
+	assert.Equal(t, "SmartContractModule.create_node_contract", call.Name)
+	assert.Equal(t, types.NewOptionU64(7), call.Args[4])
+
+	call = CreateRentContractCall(1, nil)
+	assert.Equal(t, types.OptionU64{}, call.Args[1])
+
+	transfer, err := TransferCall(10, substrate.AccountID{1})
+	require.NoError(t, err)
+	assert.Equal(t, "Balances.transfer", transfer.Name)
+	dest := transfer.Args[0].(types.MultiAddress)
+	assert.True(t, dest.IsID)
+}
+
+func TestBatchCallEmpty(t *testing.T) {
+	_, err := batchCall(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestFeeInfo(t *testing.T) {
+	for _, raw := range []string{`{"partialFee": "1230000"}`, `{"partialFee": 1230000}`} {
+		var info feeInfo
+		require.NoError(t, json.Unmarshal([]byte(raw), &info))
+		assert.Equal(t, "1230000", info.PartialFee.String())
+	}
+}
@@ -0,0 +1,295 @@
+package tfchain
+
+import (
+	"math"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+const (
+	// tfchain amounts of USD and TFT have 7 decimals
+	unitsPerUSD = 10_000_000
+	unitsPerTFT = 10_000_000
+	// contracts are billed for 30 days a month
+	hoursPerMonth = 720
+
+	gib = 1 << 30
+
+	// DefaultPricingPolicy is the id of the pricing policy of farms which were not given another one
+	DefaultPricingPolicy = 1
+
+	DiscountNone    = "none"
+	DiscountDefault = "default"
+	DiscountBronze  = "bronze"
+	DiscountSilver  = "silver"
+	DiscountGold    = "gold"
+)
+
+// discount levels by the amount of months the balance of the twin can pay for a contract, highest first
+var discounts = []struct {
+	months     uint64
+	level      string
+	multiplier float64
+}{
+	{36, DiscountGold, 0.4},
+	{12, DiscountSilver, 0.6},
+	{6, DiscountBronze, 0.7},
+	{3, DiscountDefault, 0.8},
+	{0, DiscountNone, 1},
+}
+
+type (
+	// PricingPolicy holds the prices of the resources, per hour in units of 10^-7 USD
+	PricingPolicy struct {
+		CU         uint64
+		SU         uint64
+		IPU        uint64
+		UniqueName uint64
+		// DedicatedDiscount is the discount in percent on nodes which are rented as a whole
+		DedicatedDiscount uint8
+	}
+
+	// Resources reserved by a contract, sizes are in bytes
+	Resources struct {
+		CRU       uint64
+		MRU       uint64
+		SRU       uint64
+		HRU       uint64
+		PublicIPs uint32
+	}
+
+	// CostOptions are the conditions a contract is billed under
+	CostOptions struct {
+		// Certified nodes cost 25% more
+		Certified bool
+		// Dedicated is set for contracts renting a whole node, which get the dedicated discount of the policy
+		Dedicated bool
+		// Balance of the twin paying the contract in units of 10^-7 TFT. The more months it can pay for, the higher
+		// the discount.
+		Balance uint64
+	}
+
+	// Cost of a contract per month
+	Cost struct {
+		USD float64
+		TFT float64
+		// Discount level the balance of the twin gives
+		Discount string
+	}
+
+	// BillingInfo is what tfchain keeps to bill a contract
+	BillingInfo struct {
+		// AmountUnbilled is the cost in USD of the reported network usage which is not billed yet
+		AmountUnbilled float64
+		// LastUpdated is the unix time the contract was last billed or its usage was last reported
+		LastUpdated uint64
+		// PreviousNUReported is the network usage of the last report, in bytes
+		PreviousNUReported uint64
+	}
+
+	contractBillingInformation struct {
+		PreviousNUReported types.U64
+		LastUpdated        types.U64
+		AmountUnbilled     types.U64
+	}
+)
+
+// Units returns the compute and storage units of resources. A CU is the smallest of 2 cores with 4GB of memory,
+// 1 core with 8GB and 4 cores with 2GB. A SU is 1200GB of HDD or 200GB of SSD.
+func Units(res Resources) (cu, su float64) {
+	mru := float64(res.MRU) / gib
+	cru := float64(res.CRU)
+	cu = math.Min(math.Max(mru/4, cru/2), math.Min(math.Max(mru/8, cru), math.Max(mru/2, cru/4)))
+	su = float64(res.HRU)/gib/1200 + float64(res.SRU)/gib/200
+	return cu, su
+}
+
+// HourlyCost returns the cost of resources per hour under a policy, in units of 10^-7 USD
+func (p PricingPolicy) HourlyCost(res Resources) float64 {
+	cu, su := Units(res)
+	return cu*float64(p.CU) + su*float64(p.SU) + float64(res.PublicIPs)*float64(p.IPU)
+}
+
+// EstimateCost returns the monthly cost of a contract reserving resources, with the TFT price in mUSD, the way tfchain
+// bills it
+func EstimateCost(policy PricingPolicy, tftPrice uint32, res Resources, opts CostOptions) (Cost, error) {
+	hourly := policy.HourlyCost(res)
+	if opts.Dedicated {
+		hourly = hourly * float64(100-int(policy.DedicatedDiscount)) / 100
+	}
+	return monthlyCost(hourly, tftPrice, opts)
+}
+
+// EstimateNameCost returns the monthly cost of a name contract, with the TFT price in mUSD
+func EstimateNameCost(policy PricingPolicy, tftPrice uint32, opts CostOptions) (Cost, error) {
+	return monthlyCost(float64(policy.UniqueName), tftPrice, opts)
+}
+
+// monthlyCost converts an hourly cost in units of 10^-7 USD to a monthly cost in USD and TFT, applying the discount
+// of the balance and the price of certified nodes
+func monthlyCost(hourly float64, tftPrice uint32, opts CostOptions) (Cost, error) {
+	if tftPrice == 0 {
+		return Cost{}, errors.New("tft price is not set")
+	}
+
+	monthly := hourly * hoursPerMonth
+	// the discount depends on how many months the balance can pay for at the full price
+	monthlyTFT := monthly * 1000 / float64(tftPrice)
+	discount := discounts[len(discounts)-1]
+	if monthlyTFT > 0 {
+		months := uint64(float64(opts.Balance) / monthlyTFT)
+		for _, d := range discounts {
+			if months >= d.months {
+				discount = d
+				break
+			}
+		}
+	}
+
+	monthly *= discount.multiplier
+	if opts.Certified {
+		monthly = monthly * 5 / 4
+	}
+
+	return Cost{
+		USD:      monthly / unitsPerUSD,
+		TFT:      monthly * 1000 / float64(tftPrice) / unitsPerTFT,
+		Discount: discount.level,
+	}, nil
+}
+
+// EstimateContractCost estimates the monthly cost for account of a contract reserving resources on a node, using
+// the pricing policy of the farm of the node and its certification. If node is 0, the default pricing policy and the
+// certification in opts are used. Dedicated contracts without resources reserve the whole node.
+func EstimateContractCost(client *substrate.Substrate, account substrate.AccountID, node uint32, res Resources, opts CostOptions) (Cost, error) {
+	policyID := uint32(DefaultPricingPolicy)
+	if node != 0 {
+		n, err := client.GetNode(node)
+		if err != nil {
+			return Cost{}, errors.Wrapf(err, "failed to get node %d", node)
+		}
+		farm, err := client.GetFarm(uint32(n.FarmID))
+		if err != nil {
+			return Cost{}, errors.Wrapf(err, "failed to get farm %d", n.FarmID)
+		}
+		policyID = uint32(farm.PricingPolicyID)
+		opts.Certified = n.Certification.IsCertified
+
+		if opts.Dedicated && res.CRU == 0 && res.MRU == 0 && res.SRU == 0 && res.HRU == 0 {
+			res.CRU = uint64(n.Resources.CRU)
+			res.MRU = uint64(n.Resources.MRU)
+			res.SRU = uint64(n.Resources.SRU)
+			res.HRU = uint64(n.Resources.HRU)
+		}
+	}
+
+	policy, err := GetPricingPolicy(client, policyID)
+	if err != nil {
+		return Cost{}, err
+	}
+	price, err := GetTFTPrice(client)
+	if err != nil {
+		return Cost{}, err
+	}
+	if opts.Balance, err = UsableBalance(client, account); err != nil {
+		return Cost{}, err
+	}
+
+	return EstimateCost(policy, price, res, opts)
+}
+
+// GetPricingPolicy returns the prices of a pricing policy
+func GetPricingPolicy(client *substrate.Substrate, id uint32) (PricingPolicy, error) {
+	var policy substrate.PricingPolicy
+	if err := getStorage(client, "TfgridModule", "PricingPolicies", &policy, id); err != nil {
+		return PricingPolicy{}, errors.Wrapf(err, "failed to get pricing policy %d", id)
+	}
+
+	return PricingPolicy{
+		CU:                uint64(policy.CU.Value),
+		SU:                uint64(policy.SU.Value),
+		IPU:               uint64(policy.IPU.Value),
+		UniqueName:        uint64(policy.UniqueName.Value),
+		DedicatedDiscount: uint8(policy.DedicatedNodesDiscount),
+	}, nil
+}
+
+// GetTFTPrice returns the price of TFT tfchain bills with, in mUSD
+func GetTFTPrice(client *substrate.Substrate) (uint32, error) {
+	var price types.U32
+	if err := getStorage(client, "TFTPriceModule", "TftPrice", &price); err != nil {
+		return 0, errors.Wrap(err, "failed to get tft price")
+	}
+	return uint32(price), nil
+}
+
+// GetBillingInfo returns the billing information of a contract
+func GetBillingInfo(client *substrate.Substrate, contractID uint64) (BillingInfo, error) {
+	var info contractBillingInformation
+	if err := getStorage(client, "SmartContractModule", "ContractBillingInformationByID", &info, contractID); err != nil {
+		return BillingInfo{}, errors.Wrapf(err, "failed to get billing information of contract %d", contractID)
+	}
+
+	return BillingInfo{
+		AmountUnbilled:     float64(info.AmountUnbilled) / unitsPerUSD,
+		LastUpdated:        uint64(info.LastUpdated),
+		PreviousNUReported: uint64(info.PreviousNUReported),
+	}, nil
+}
+
+// UsableBalance returns the balance of an account which can pay for contracts, in units of 10^-7 TFT
+func UsableBalance(client *substrate.Substrate, account substrate.AccountID) (uint64, error) {
+	balance, err := client.GetBalance(account)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get balance")
+	}
+
+	free := balance.Free.Uint64()
+	frozen := balance.MiscFrozen.Uint64()
+	if frozen >= free {
+		return 0, nil
+	}
+	return free - frozen, nil
+}
+
+// getStorage reads a storage entry into value, failing if it does not exist. The key is the encoded args.
+func getStorage(client *substrate.Substrate, module, entry string, value interface{}, args ...interface{}) error {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return err
+	}
+
+	key, err := storageKey(meta, module, entry, args...)
+	if err != nil {
+		return err
+	}
+
+	ok, err := cl.RPC.State.GetStorageLatest(key, value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("%s.%s not found", module, entry)
+	}
+	return nil
+}
+
+// storageKey creates the key of a storage entry, encoding the args of the key
+func storageKey(meta *types.Metadata, module, entry string, args ...interface{}) (types.StorageKey, error) {
+	var keyArgs [][]byte
+	for _, arg := range args {
+		encoded, err := substrate.Encode(arg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode storage key")
+		}
+		keyArgs = append(keyArgs, encoded)
+	}
+
+	key, err := types.CreateStorageKey(meta, module, entry, keyArgs...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create storage key")
+	}
+	return key, nil
+}
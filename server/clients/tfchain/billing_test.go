@@ -0,0 +1,61 @@
+package tfchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnits(t *testing.T) {
+	cu, su := Units(Resources{CRU: 2, MRU: 4 * gib, SRU: 100 * gib, HRU: 600 * gib})
+	assert.Equal(t, 1.0, cu)
+	assert.Equal(t, 1.0, su)
+
+	// memory heavy resources are limited by the cores
+	cu, _ = Units(Resources{CRU: 1, MRU: 16 * gib})
+	assert.Equal(t, 2.0, cu)
+}
+
+func TestEstimateCost(t *testing.T) {
+	policy := PricingPolicy{CU: 100_000, SU: 50_000, IPU: 50_000, UniqueName: 10_000, DedicatedDiscount: 50}
+	res := Resources{CRU: 2, MRU: 4 * gib, SRU: 100 * gib, HRU: 600 * gib, PublicIPs: 1}
+	// 0.02 USD an hour, at 0.05 USD a TFT
+	const price = 50
+
+	t.Run("no_discount", func(t *testing.T) {
+		cost, err := EstimateCost(policy, price, res, CostOptions{})
+		require.NoError(t, err)
+		assert.InDelta(t, 14.4, cost.USD, 1e-9)
+		assert.InDelta(t, 288, cost.TFT, 1e-9)
+		assert.Equal(t, DiscountNone, cost.Discount)
+	})
+
+	t.Run("balance_discount", func(t *testing.T) {
+		cost, err := EstimateCost(policy, price, res, CostOptions{Balance: 12 * 288 * unitsPerTFT})
+		require.NoError(t, err)
+		assert.InDelta(t, 14.4*0.6, cost.USD, 1e-9)
+		assert.Equal(t, DiscountSilver, cost.Discount)
+
+		cost, err = EstimateCost(policy, price, res, CostOptions{Balance: 36 * 288 * unitsPerTFT})
+		require.NoError(t, err)
+		assert.Equal(t, DiscountGold, cost.Discount)
+	})
+
+	t.Run("certified_dedicated", func(t *testing.T) {
+		cost, err := EstimateCost(policy, price, res, CostOptions{Certified: true, Dedicated: true})
+		require.NoError(t, err)
+		assert.InDelta(t, 14.4*0.5*1.25, cost.USD, 1e-9)
+	})
+
+	t.Run("name", func(t *testing.T) {
+		cost, err := EstimateNameCost(policy, price, CostOptions{})
+		require.NoError(t, err)
+		assert.InDelta(t, 0.72, cost.USD, 1e-9)
+	})
+
+	t.Run("no_price", func(t *testing.T) {
+		_, err := EstimateCost(policy, 0, res, CostOptions{})
+		assert.Error(t, err)
+	})
+}
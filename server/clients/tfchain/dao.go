@@ -0,0 +1,440 @@
+package tfchain
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/xxhash"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+const (
+	// farms to query at once when scanning the farms
+	farmQueryChunk = 256
+	// farmIndexTTL is how long the farms of twins are reused before scanning the farms again
+	farmIndexTTL = 10 * time.Minute
+	// ClosedProposalWindow is how many blocks back proposals closed by the council are looked for by default, a day
+	ClosedProposalWindow = 14400
+	// blocks read at once when scanning for closed proposals
+	closedScanWorkers = 16
+)
+
+// Outcomes of a proposal closed by the council
+const (
+	OutcomeApproved    = "approved"
+	OutcomeDisapproved = "disapproved"
+	// OutcomeVetoed means the council vetoed the proposal
+	OutcomeVetoed = "vetoed"
+)
+
+// VoteWeight is a vote of a farm on a proposal, weighted by the resources of the farm
+type VoteWeight struct {
+	FarmID uint32
+	Weight uint64
+}
+
+// Proposal is a dao proposal which has not been closed yet. Once a council member closes it, the proposal and its
+// votes are removed from storage, the outcome is only recorded in the Dao events of the block closing it, see
+// ClosedProposals.
+type Proposal struct {
+	Hash        types.Hash
+	Index       uint32
+	Description string
+	Link        string
+	// Threshold is the minimal number of votes for the proposal to pass
+	Threshold uint32
+	Ayes      []VoteWeight
+	Nays      []VoteWeight
+	// End is the block voting ends in
+	End   uint32
+	Vetos []substrate.AccountID
+}
+
+type daoProposal struct {
+	Index       uint32
+	Description []byte
+	Link        []byte
+}
+
+type daoVotes struct {
+	Index     uint32
+	Threshold uint32
+	Ayes      []VoteWeight
+	Nays      []VoteWeight
+	End       uint32
+	Vetos     []substrate.AccountID
+}
+
+// Open returns whether voting on the proposal is still possible at height
+func (p *Proposal) Open(height uint32) bool {
+	return height < p.End
+}
+
+// Voted returns whether a farm voted on the proposal
+func (p *Proposal) Voted(farm uint32) bool {
+	for _, votes := range [][]VoteWeight{p.Ayes, p.Nays} {
+		for _, vote := range votes {
+			if vote.FarmID == farm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Unvoted returns the farms which did not vote on the proposal
+func (p *Proposal) Unvoted(farms []uint32) []uint32 {
+	var unvoted []uint32
+	for _, farm := range farms {
+		if !p.Voted(farm) {
+			unvoted = append(unvoted, farm)
+		}
+	}
+	return unvoted
+}
+
+// ListProposals returns the dao proposals in storage. Proposals the council closed are removed from storage, so they
+// are not returned, the ones whose voting ended are returned until a council member closes them.
+func ListProposals(client *substrate.Substrate) ([]Proposal, error) {
+	var hashes []types.Hash
+	if err := getStorage(client, "Dao", "ProposalList", &hashes); err != nil {
+		return nil, errors.Wrap(err, "failed to get proposal list")
+	}
+
+	proposals := make([]Proposal, 0, len(hashes))
+	for _, hash := range hashes {
+		proposal, err := GetProposal(client, hash)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals, nil
+}
+
+// GetProposal returns a dao proposal with its votes
+func GetProposal(client *substrate.Substrate, hash types.Hash) (Proposal, error) {
+	var proposal daoProposal
+	if err := getStorage(client, "Dao", "Proposals", &proposal, hash); err != nil {
+		return Proposal{}, errors.Wrapf(err, "failed to get proposal %s", hash.Hex())
+	}
+
+	var votes daoVotes
+	if err := getStorage(client, "Dao", "Voting", &votes, hash); err != nil {
+		return Proposal{}, errors.Wrapf(err, "failed to get votes of proposal %s", hash.Hex())
+	}
+
+	return newProposal(hash, proposal, votes), nil
+}
+
+// getProposalAt returns a dao proposal with its votes as they were in storage after block, which needs a node
+// keeping the state of that block
+func getProposalAt(client *substrate.Substrate, hash types.Hash, block uint32) (Proposal, error) {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return Proposal{}, err
+	}
+	blockHash, err := cl.RPC.Chain.GetBlockHash(uint64(block))
+	if err != nil {
+		return Proposal{}, errors.Wrapf(err, "failed to get hash of block %d", block)
+	}
+
+	var proposal daoProposal
+	var votes daoVotes
+	for entry, value := range map[string]interface{}{"Proposals": &proposal, "Voting": &votes} {
+		key, err := storageKey(meta, "Dao", entry, hash)
+		if err != nil {
+			return Proposal{}, err
+		}
+		ok, err := cl.RPC.State.GetStorage(key, value, blockHash)
+		if err != nil {
+			return Proposal{}, errors.Wrapf(err, "failed to get proposal %s at block %d", hash.Hex(), block)
+		}
+		if !ok {
+			return Proposal{}, errors.Errorf("Dao.%s of proposal %s not found at block %d", entry, hash.Hex(), block)
+		}
+	}
+
+	return newProposal(hash, proposal, votes), nil
+}
+
+func newProposal(hash types.Hash, proposal daoProposal, votes daoVotes) Proposal {
+	return Proposal{
+		Hash:        hash,
+		Index:       proposal.Index,
+		Description: string(proposal.Description),
+		Link:        string(proposal.Link),
+		Threshold:   votes.Threshold,
+		Ayes:        votes.Ayes,
+		Nays:        votes.Nays,
+		End:         votes.End,
+		Vetos:       votes.Vetos,
+	}
+}
+
+// Vote on a dao proposal for a farm of the twin of identity
+func Vote(client *substrate.Substrate, identity substrate.Identity, farm uint32, hash types.Hash, approve bool) error {
+	return submit(client, identity, "Dao.vote", farm, hash, approve)
+}
+
+// ClosedProposal is a dao proposal the council closed, with its votes as they were when it was closed
+type ClosedProposal struct {
+	Proposal
+	// Block the proposal was closed in
+	Block uint32
+	// Outcome is approved, disapproved or vetoed
+	Outcome string
+}
+
+// ClosedProposals finds the proposals the council closed in the Dao events of the chain. The proposals found are
+// remembered with the blocks which were scanned, so later lookups only scan the blocks which were not scanned yet.
+type ClosedProposals struct {
+	lock sync.Mutex
+	// first and next delimit the blocks which were scanned, next is 0 if nothing was scanned
+	first, next uint32
+	// closed are the proposals found in the scanned blocks, ordered by block
+	closed []ClosedProposal
+
+	// events and load read from the client if nil, they are replaced in tests
+	events EventSource
+	load   func(client *substrate.Substrate, hash types.Hash, block uint32) (Proposal, error)
+}
+
+// List returns the proposals the council closed from block from up to the current height, most recent first. The
+// proposals are read from the state before the block closing them, their description and votes are empty if the
+// node no longer has that state.
+func (c *ClosedProposals) List(client *substrate.Substrate, from uint32) ([]ClosedProposal, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var events EventSource = client
+	if c.events != nil {
+		events = c.events
+	}
+	height, err := events.GetCurrentHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get current height")
+	}
+
+	if c.next == 0 {
+		closed, err := c.scan(client, events, from, height)
+		if err != nil {
+			return nil, err
+		}
+		c.first, c.next, c.closed = from, height+1, closed
+	} else {
+		if from < c.first {
+			earlier, err := c.scan(client, events, from, c.first-1)
+			if err != nil {
+				return nil, err
+			}
+			c.first, c.closed = from, append(earlier, c.closed...)
+		}
+		if c.next <= height {
+			later, err := c.scan(client, events, c.next, height)
+			if err != nil {
+				return nil, err
+			}
+			c.next, c.closed = height+1, append(c.closed, later...)
+		}
+	}
+
+	listed := []ClosedProposal{}
+	for i := len(c.closed) - 1; i >= 0; i-- {
+		if c.closed[i].Block >= from {
+			listed = append(listed, c.closed[i])
+		}
+	}
+	return listed, nil
+}
+
+// Invalidate drops the scanned proposals, so the blocks are scanned again on the next lookup
+func (c *ClosedProposals) Invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.first, c.next, c.closed = 0, 0, nil
+}
+
+// scan the blocks from start to end for closed proposals, ordered by block
+func (c *ClosedProposals) scan(client *substrate.Substrate, events EventSource, start, end uint32) ([]ClosedProposal, error) {
+	if start > end {
+		return nil, nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		closed []ClosedProposal
+		failed error
+	)
+	blocks := make(chan uint32)
+	for i := 0; i < closedScanWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for block := range blocks {
+				records, err := events.GetEventsForBlock(block)
+				lock.Lock()
+				if err != nil && failed == nil {
+					failed = errors.Wrapf(err, "failed to get events of block %d", block)
+				}
+				if err == nil {
+					closed = append(closed, closedIn(block, records)...)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for block := start; block <= end; block++ {
+		lock.Lock()
+		stop := failed != nil
+		lock.Unlock()
+		if stop {
+			break
+		}
+		blocks <- block
+	}
+	close(blocks)
+	wg.Wait()
+	if failed != nil {
+		return nil, failed
+	}
+
+	load := c.load
+	if load == nil {
+		load = getProposalAt
+	}
+	for i := range closed {
+		// the proposal is removed from storage in the block closing it
+		proposal, err := load(client, closed[i].Hash, closed[i].Block-1)
+		if err != nil {
+			continue
+		}
+		closed[i].Proposal = proposal
+	}
+
+	sort.Slice(closed, func(i, j int) bool {
+		return closed[i].Block < closed[j].Block
+	})
+	return closed, nil
+}
+
+// closedIn returns the proposals closed in a block, with their outcome. Closing a proposal emits Closed followed by
+// Approved or Disapproved, a veto of the council only emits ClosedByCouncil.
+func closedIn(block uint32, events *substrate.EventRecords) []ClosedProposal {
+	outcomes := map[types.Hash]string{}
+	var hashes []types.Hash
+	add := func(hash types.Hash, outcome string) {
+		if _, ok := outcomes[hash]; !ok {
+			hashes = append(hashes, hash)
+			outcomes[hash] = outcome
+		} else if outcome != "" {
+			outcomes[hash] = outcome
+		}
+	}
+
+	for _, e := range events.Dao_Closed {
+		add(e.ProposalHash, "")
+	}
+	for _, e := range events.Dao_Approved {
+		add(e.ProposalHash, OutcomeApproved)
+	}
+	for _, e := range events.Dao_Disapproved {
+		add(e.ProposalHash, OutcomeDisapproved)
+	}
+	for _, e := range events.Dao_ClosedByCouncil {
+		add(e.ProposalHash, OutcomeVetoed)
+	}
+
+	closed := make([]ClosedProposal, 0, len(hashes))
+	for _, hash := range hashes {
+		closed = append(closed, ClosedProposal{Proposal: Proposal{Hash: hash}, Block: block, Outcome: outcomes[hash]})
+	}
+	return closed
+}
+
+// FarmIndex looks up the farms of twins. Farms are not indexed by twin on chain, so all farms are scanned to build
+// the index, which is reused until it is older than farmIndexTTL or invalidated.
+type FarmIndex struct {
+	lock    sync.Mutex
+	owners  map[uint32][]uint32
+	updated time.Time
+
+	// scan returns the ids of the farms of every twin, it is replaced in tests
+	scan func(client *substrate.Substrate) (map[uint32][]uint32, error)
+}
+
+// FarmsOfTwin returns the ids of the farms of a twin, scanning all farms if the index is missing or stale
+func (i *FarmIndex) FarmsOfTwin(client *substrate.Substrate, twin uint32) ([]uint32, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.owners == nil || time.Since(i.updated) > farmIndexTTL {
+		scan := i.scan
+		if scan == nil {
+			scan = scanFarmOwners
+		}
+		owners, err := scan(client)
+		if err != nil {
+			return nil, err
+		}
+		i.owners = owners
+		i.updated = time.Now()
+	}
+
+	return i.owners[twin], nil
+}
+
+// Invalidate drops the index, so the farms are scanned again on the next lookup
+func (i *FarmIndex) Invalidate() {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.owners = nil
+}
+
+// scanFarmOwners returns the ids of the farms of every twin owning farms
+func scanFarmOwners(client *substrate.Substrate) (map[uint32][]uint32, error) {
+	cl, _, err := client.GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := append(xxhash.New128([]byte("TfgridModule")).Sum(nil), xxhash.New128([]byte("Farms")).Sum(nil)...)
+	keys, err := cl.RPC.State.GetKeysLatest(prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get farm keys")
+	}
+
+	owners := make(map[uint32][]uint32)
+	for start := 0; start < len(keys); start += farmQueryChunk {
+		end := start + farmQueryChunk
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		sets, err := cl.RPC.State.QueryStorageAtLatest(keys[start:end])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get farms")
+		}
+		for _, set := range sets {
+			for _, change := range set.Changes {
+				if !change.HasStorageData {
+					continue
+				}
+				var farm substrate.Farm
+				if err := substrate.Decode(change.StorageData, &farm); err != nil {
+					return nil, errors.Wrap(err, "failed to decode farm")
+				}
+				owners[uint32(farm.TwinID)] = append(owners[uint32(farm.TwinID)], uint32(farm.ID))
+			}
+		}
+	}
+
+	return owners, nil
+}
@@ -0,0 +1,127 @@
+package tfchain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+func TestProposalVotes(t *testing.T) {
+	proposal := Proposal{
+		Ayes: []VoteWeight{{FarmID: 1, Weight: 10}},
+		Nays: []VoteWeight{{FarmID: 3, Weight: 5}},
+		End:  100,
+	}
+
+	assert.True(t, proposal.Voted(1))
+	assert.True(t, proposal.Voted(3))
+	assert.False(t, proposal.Voted(2))
+	assert.Equal(t, []uint32{2, 4}, proposal.Unvoted([]uint32{1, 2, 3, 4}))
+	assert.Nil(t, proposal.Unvoted([]uint32{1, 3}))
+
+	assert.True(t, proposal.Open(99))
+	assert.False(t, proposal.Open(100))
+}
+
+func TestDecodeVotes(t *testing.T) {
+	votes := daoVotes{
+		Index:     2,
+		Threshold: 5,
+		Ayes:      []VoteWeight{{FarmID: 1, Weight: 10}},
+		End:       100,
+		Vetos:     []substrate.AccountID{{1}},
+	}
+	encoded, err := substrate.Encode(votes)
+	require.NoError(t, err)
+
+	var decoded daoVotes
+	require.NoError(t, substrate.Decode(encoded, &decoded))
+	assert.Equal(t, votes, decoded)
+}
+
+func TestFarmIndex(t *testing.T) {
+	scans := 0
+	index := FarmIndex{scan: func(*substrate.Substrate) (map[uint32][]uint32, error) {
+		scans++
+		return map[uint32][]uint32{1: {10, 11}, 2: {12}}, nil
+	}}
+
+	farms, err := index.FarmsOfTwin(nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{10, 11}, farms)
+
+	farms, err = index.FarmsOfTwin(nil, 3)
+	require.NoError(t, err)
+	assert.Empty(t, farms)
+	assert.Equal(t, 1, scans)
+
+	index.Invalidate()
+	_, err = index.FarmsOfTwin(nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, scans)
+
+	index.updated = time.Now().Add(-farmIndexTTL - time.Second)
+	_, err = index.FarmsOfTwin(nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, scans)
+}
+
+func TestClosedProposals(t *testing.T) {
+	approved, disapproved, vetoed := types.Hash{1}, types.Hash{2}, types.Hash{3}
+	chain := newFakeChain(10)
+	chain.blocks[3] = &substrate.EventRecords{
+		Dao_Closed:   []substrate.Closed{{ProposalHash: approved}},
+		Dao_Approved: []substrate.Approved{{ProposalHash: approved}},
+	}
+	chain.blocks[8] = &substrate.EventRecords{
+		Dao_Closed:      []substrate.Closed{{ProposalHash: disapproved}},
+		Dao_Disapproved: []substrate.Disapproved{{ProposalHash: disapproved}},
+	}
+
+	loads := map[uint32]int{}
+	index := ClosedProposals{events: chain, load: func(_ *substrate.Substrate, hash types.Hash, block uint32) (Proposal, error) {
+		loads[block]++
+		if hash == disapproved {
+			return Proposal{}, errors.New("state pruned")
+		}
+		return Proposal{Hash: hash, Description: "raise the fee", Threshold: 5}, nil
+	}}
+
+	closed, err := index.List(nil, 5)
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, disapproved, closed[0].Hash)
+	assert.Equal(t, uint32(8), closed[0].Block)
+	assert.Equal(t, OutcomeDisapproved, closed[0].Outcome)
+	assert.Empty(t, closed[0].Description)
+
+	// earlier blocks are scanned once, the proposal is read from the state before the block closing it
+	closed, err = index.List(nil, 1)
+	require.NoError(t, err)
+	require.Len(t, closed, 2)
+	assert.Equal(t, approved, closed[1].Hash)
+	assert.Equal(t, OutcomeApproved, closed[1].Outcome)
+	assert.Equal(t, "raise the fee", closed[1].Description)
+	assert.Equal(t, map[uint32]int{2: 1, 7: 1}, loads)
+
+	// only the new blocks are scanned
+	chain.produce(&substrate.EventRecords{Dao_ClosedByCouncil: []substrate.ClosedByCouncil{{ProposalHash: vetoed}}})
+	closed, err = index.List(nil, 1)
+	require.NoError(t, err)
+	require.Len(t, closed, 3)
+	assert.Equal(t, vetoed, closed[0].Hash)
+	assert.Equal(t, OutcomeVetoed, closed[0].Outcome)
+	for block := uint32(1); block <= 11; block++ {
+		assert.Equal(t, 1, chain.reads[block], "block %d", block)
+	}
+
+	chain.err = errors.New("connection reset")
+	chain.height++
+	_, err = index.List(nil, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,290 @@
+package tfchain
+
+import (
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+var (
+	accountIDType          = reflect.TypeOf(types.AccountID{})
+	substrateAccountIDType = reflect.TypeOf(substrate.AccountID{})
+	u128Type               = reflect.TypeOf(types.U128{})
+	phaseType              = reflect.TypeOf(types.Phase{})
+	bigIntType             = reflect.TypeOf(big.Int{})
+)
+
+type (
+	// Event emitted by a tfchain pallet
+	Event struct {
+		Block uint32
+		// Extrinsic is the index of the extrinsic which emitted the event in the block, nil for events emitted while
+		// initializing or finalizing the block
+		Extrinsic *uint32
+		Pallet    string
+		Name      string
+		// Data of the event, accounts are encoded as ss58 addresses and binary data which is not text as hex
+		Data map[string]interface{}
+	}
+
+	// EventFilter selects events. Empty fields are not filtered on.
+	EventFilter struct {
+		// Pallets emitting the events, e.g. SmartContractModule, TfgridModule or TFTBridgeModule
+		Pallets []string
+		// Names of the events, e.g. ContractCreated, NodeUptimeReported or MintCompleted
+		Names []string
+		// Account which must appear in the event, as ss58 address
+		Account string
+		// TwinID which the event must be about
+		TwinID uint32
+		// NodeID which the event must be about
+		NodeID uint32
+		// ContractID which the event must be about
+		ContractID uint64
+	}
+)
+
+// DecodeEvents converts the events of a block, ordered by the extrinsic which emitted them
+func DecodeEvents(block uint32, records *substrate.EventRecords) []Event {
+	var events []Event
+	decodeRecords(block, reflect.ValueOf(records).Elem(), &events)
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return phaseOrder(events[i].Extrinsic) < phaseOrder(events[j].Extrinsic)
+	})
+	return events
+}
+
+// decodeRecords converts the events in the Pallet_Event fields of records, including those of embedded records
+func decodeRecords(block uint32, records reflect.Value, events *[]Event) {
+	for i := 0; i < records.NumField(); i++ {
+		field := records.Type().Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			decodeRecords(block, records.Field(i), events)
+			continue
+		}
+		pallet, name, ok := strings.Cut(field.Name, "_")
+		if !ok || field.Type.Kind() != reflect.Slice {
+			continue
+		}
+
+		values := records.Field(i)
+		for j := 0; j < values.Len(); j++ {
+			*events = append(*events, decodeEvent(block, pallet, name, values.Index(j)))
+		}
+	}
+}
+
+func decodeEvent(block uint32, pallet, name string, value reflect.Value) Event {
+	event := Event{Block: block, Pallet: pallet, Name: name, Data: map[string]interface{}{}}
+	if value.Kind() != reflect.Struct {
+		return event
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		switch {
+		case field.Type == phaseType:
+			phase := value.Field(i).Interface().(types.Phase)
+			if phase.IsApplyExtrinsic {
+				index := phase.AsApplyExtrinsic
+				event.Extrinsic = &index
+			}
+		case field.Name == "Topics" || !field.IsExported():
+		default:
+			event.Data[fieldName(field)] = dataValue(value.Field(i))
+		}
+	}
+	return event
+}
+
+// dataValue converts a decoded value to plain values which encode well to json
+func dataValue(value reflect.Value) interface{} {
+	switch value.Type() {
+	case accountIDType, substrateAccountIDType:
+		var account substrate.AccountID
+		reflect.Copy(reflect.ValueOf(account[:]), value)
+		return account.String()
+	case u128Type:
+		n := value.Interface().(types.U128)
+		if n.Int == nil {
+			return "0"
+		}
+		return n.String()
+	case bigIntType:
+		n := value.Interface().(big.Int)
+		return n.String()
+	}
+
+	// options like types.OptionU64
+	if unwrap := value.MethodByName("Unwrap"); unwrap.IsValid() && unwrap.Type().NumIn() == 0 && unwrap.Type().NumOut() == 2 {
+		out := unwrap.Call(nil)
+		if ok, _ := out[0].Interface().(bool); !ok {
+			return nil
+		}
+		return dataValue(out[1])
+	}
+
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return value.Uint()
+	case reflect.String:
+		return value.String()
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return dataValue(value.Elem())
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return bytesValue(value)
+		}
+		list := make([]interface{}, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			list = append(list, dataValue(value.Index(i)))
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]interface{}, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			m[reflect.ValueOf(iter.Key().Interface()).String()] = dataValue(iter.Value())
+		}
+		return m
+	case reflect.Struct:
+		m := map[string]interface{}{}
+		structValue(value, m)
+		return m
+	}
+	return nil
+}
+
+// structValue adds the fields of a struct to m, flattening embedded structs
+func structValue(value reflect.Value, m map[string]interface{}) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			structValue(value.Field(i), m)
+			continue
+		}
+		m[fieldName(field)] = dataValue(value.Field(i))
+	}
+}
+
+// bytesValue returns binary data as text if it is printable, and as hex otherwise
+func bytesValue(value reflect.Value) string {
+	data := make([]byte, value.Len())
+	reflect.Copy(reflect.ValueOf(data), value)
+
+	if len(data) > 0 && utf8.Valid(data) && strings.IndexFunc(string(data), func(r rune) bool { return !unicode.IsPrint(r) }) < 0 {
+		return string(data)
+	}
+	return "0x" + hex.EncodeToString(data)
+}
+
+// fieldName returns the json name of a field, or its name in snake case if it has none
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+
+	var b strings.Builder
+	runes := []rune(field.Name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// phaseOrder orders events emitted while initializing a block first, and those emitted while finalizing it last
+func phaseOrder(extrinsic *uint32) uint64 {
+	if extrinsic == nil {
+		return 0
+	}
+	return uint64(*extrinsic) + 1
+}
+
+// Match checks if an event passes the filter
+func (f EventFilter) Match(event Event) bool {
+	if len(f.Pallets) > 0 && !contains(f.Pallets, event.Pallet) {
+		return false
+	}
+	if len(f.Names) > 0 && !contains(f.Names, event.Name) {
+		return false
+	}
+	if f.Account != "" && !containsValue(event.Data, "", "", func(parent, key string, value interface{}) bool {
+		return value == f.Account
+	}) {
+		return false
+	}
+	if f.TwinID != 0 && !containsValue(event.Data, "", "", idMatcher("twin", uint64(f.TwinID))) {
+		return false
+	}
+	if f.NodeID != 0 && !containsValue(event.Data, "", "", idMatcher("node", uint64(f.NodeID))) {
+		return false
+	}
+	if f.ContractID != 0 && !containsValue(event.Data, "", "", idMatcher("contract", f.ContractID)) {
+		return false
+	}
+	return true
+}
+
+// idMatcher matches the id of an object, in an <object>_id field or in the id field of an <object> field
+func idMatcher(object string, id uint64) func(parent, key string, value interface{}) bool {
+	return func(parent, key string, value interface{}) bool {
+		n, ok := value.(uint64)
+		if !ok || n != id {
+			return false
+		}
+		return key == object+"_id" || (parent == object && key == "id")
+	}
+}
+
+// containsValue walks data, calling match with every value, the key it is stored under and the key of the map
+// containing it
+func containsValue(data interface{}, parent, key string, match func(parent, key string, value interface{}) bool) bool {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if containsValue(value, key, k, match) {
+				return true
+			}
+		}
+		return false
+	case []interface{}:
+		for _, value := range v {
+			if containsValue(value, parent, key, match) {
+				return true
+			}
+		}
+		return false
+	default:
+		return match(parent, key, data)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
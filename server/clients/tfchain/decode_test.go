@@ -0,0 +1,109 @@
+package tfchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+func testRecords() *substrate.EventRecords {
+	var account substrate.AccountID
+	account[0] = 1
+
+	records := &substrate.EventRecords{
+		SmartContractModule_ContractCreated: []substrate.ContractCreated{{
+			Phase: extrinsic(2),
+			Contract: substrate.Contract{
+				ContractID: 12,
+				TwinID:     3,
+				ContractType: substrate.ContractType{
+					IsNodeContract: true,
+					NodeContract:   substrate.NodeContract{Node: 7},
+				},
+			},
+		}},
+		SmartContractModule_ContractBilled: []substrate.ContractBilled{{
+			Phase: extrinsic(1),
+			ContractBill: substrate.ContractBill{
+				ContractID:   12,
+				AmountBilled: types.NewU128(*big.NewInt(1500)),
+			},
+		}},
+		TfgridModule_NodeUptimeReported: []substrate.NodeUptimeReported{{Phase: extrinsic(3), Node: 7, Uptime: 60}},
+		TfgridModule_TwinStored:         []substrate.TwinStored{{Phase: types.Phase{IsFinalization: true}, Twin: substrate.Twin{ID: 3}}},
+		TFTBridgeModule_MintCompleted: []substrate.MintCompleted{{
+			Phase:           extrinsic(4),
+			MintTransaction: substrate.MintTransaction{Target: types.AccountID(account), Amount: 100},
+		}},
+		TFTBridgeModule_BurnTransactionCreated: []substrate.BridgeBurnTransactionCreated{{
+			Phase:  extrinsic(5),
+			Target: []byte("GDHJP6TF3UXYXTNEZ2P36J5FH7W4BJJQ4AYYAXC66I2Q2AH5B6O6BCFG"),
+		}},
+	}
+	records.System_ExtrinsicSuccess = []types.EventSystemExtrinsicSuccess{{Phase: types.Phase{IsInitialization: true}}}
+	return records
+}
+
+func TestDecodeEvents(t *testing.T) {
+	var account substrate.AccountID
+	account[0] = 1
+
+	events := DecodeEvents(10, testRecords())
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		assert.Equal(t, uint32(10), e.Block)
+		names = append(names, e.Pallet+"."+e.Name)
+	}
+	assert.Equal(t, []string{
+		"System.ExtrinsicSuccess",
+		"TfgridModule.TwinStored",
+		"SmartContractModule.ContractBilled",
+		"SmartContractModule.ContractCreated",
+		"TfgridModule.NodeUptimeReported",
+		"TFTBridgeModule.MintCompleted",
+		"TFTBridgeModule.BurnTransactionCreated",
+	}, names)
+
+	billed := events[2]
+	require.NotNil(t, billed.Extrinsic)
+	assert.Equal(t, uint32(1), *billed.Extrinsic)
+	bill := billed.Data["contract_bill"].(map[string]interface{})
+	assert.Equal(t, uint64(12), bill["contract_id"])
+	assert.Equal(t, "1500", bill["amount_billed"])
+
+	// events emitted while initializing or finalizing the block have no extrinsic
+	assert.Nil(t, events[0].Extrinsic)
+
+	mint := events[5].Data["mint_transaction"].(map[string]interface{})
+	assert.Equal(t, account.String(), mint["target"])
+	assert.Equal(t, uint64(100), mint["amount"])
+
+	assert.Equal(t, "GDHJP6TF3UXYXTNEZ2P36J5FH7W4BJJQ4AYYAXC66I2Q2AH5B6O6BCFG", events[6].Data["target"])
+}
+
+func TestEventFilter(t *testing.T) {
+	var account substrate.AccountID
+	account[0] = 1
+
+	match := func(filter EventFilter) []string {
+		var names []string
+		for _, e := range DecodeEvents(10, testRecords()) {
+			if filter.Match(e) {
+				names = append(names, e.Name)
+			}
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"ContractBilled", "ContractCreated"}, match(EventFilter{Pallets: []string{"SmartContractModule"}}))
+	assert.Equal(t, []string{"ContractCreated", "NodeUptimeReported"}, match(EventFilter{NodeID: 7}))
+	assert.Equal(t, []string{"TwinStored", "ContractCreated"}, match(EventFilter{TwinID: 3}))
+	assert.Equal(t, []string{"ContractBilled", "ContractCreated"}, match(EventFilter{ContractID: 12}))
+	assert.Equal(t, []string{"ContractBilled"}, match(EventFilter{Names: []string{"ContractBilled"}, ContractID: 12}))
+	assert.Equal(t, []string{"MintCompleted"}, match(EventFilter{Account: account.String()}))
+	assert.Empty(t, match(EventFilter{Pallets: []string{"Dao"}}))
+}
@@ -0,0 +1,117 @@
+package tfchain
+
+import (
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+var (
+	// ErrMintExpired is returned when the validators did not agree on a mint in time
+	ErrMintExpired = errors.New("mint transaction expired")
+	// ErrBurnExpired is returned when the validators did not pay out a burn in time
+	ErrBurnExpired = errors.New("burn transaction expired")
+)
+
+// MintProposed matches the block in which the bridge validators proposed to mint the stellar transaction with hash
+// txID
+func MintProposed(txID string) Matcher {
+	return func(block uint32, events *substrate.EventRecords) (bool, error) {
+		for _, proposed := range events.TFTBridgeModule_MintTransactionProposed {
+			if proposed.TxHash == txID {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// MintCompleted matches the block in which a deposit on the stellar bridge was minted on the target account. If
+// txID is set only the mint of the stellar transaction with that hash matches, otherwise any mint on target does.
+// ErrMintExpired is returned if the mint of txID expires.
+func MintCompleted(target substrate.AccountID, txID string) Matcher {
+	return func(block uint32, events *substrate.EventRecords) (bool, error) {
+		// the vote which completes a mint is in the same extrinsic as the mint
+		var votes []types.Phase
+		for _, proposed := range events.TFTBridgeModule_MintTransactionProposed {
+			if proposed.TxHash == txID {
+				votes = append(votes, proposed.Phase)
+			}
+		}
+		for _, voted := range events.TFTBridgeModule_MintTransactionVoted {
+			if voted.TxHash == txID {
+				votes = append(votes, voted.Phase)
+			}
+		}
+		for _, expired := range events.TFTBridgeModule_MintTransactionExpired {
+			if expired.TxHash == txID {
+				return false, errors.Wrap(ErrMintExpired, txID)
+			}
+		}
+
+		for _, completed := range events.TFTBridgeModule_MintCompleted {
+			if substrate.AccountID(completed.MintTransaction.Target) != target {
+				continue
+			}
+			if txID == "" || containsPhase(votes, completed.Phase) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// BurnCreated matches the block in which source burned TFT to be paid out to the stellar address target, calling
+// found with the id of the burn and the amount paid out, in stroops
+func BurnCreated(source substrate.AccountID, target string, found func(burnID, amount uint64)) Matcher {
+	return func(block uint32, events *substrate.EventRecords) (bool, error) {
+		for _, created := range events.TFTBridgeModule_BurnTransactionCreated {
+			if substrate.AccountID(created.Source) == source && string(created.Target) == target {
+				found(uint64(created.BurnTransactionID), uint64(created.Amount))
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// BurnProcessed matches the block in which the bridge validators paid out a burn to the stellar address target. If
+// burnID is not 0 only the burn with that id matches, the scan must then start at or before the block the burn was
+// created in. ErrBurnExpired is returned if the burn with burnID expires.
+func BurnProcessed(target string, burnID uint64) Matcher {
+	// processed burns refer to the block they were created in rather than their id
+	var createdIn uint32
+	return func(block uint32, events *substrate.EventRecords) (bool, error) {
+		if burnID != 0 {
+			for _, created := range events.TFTBridgeModule_BurnTransactionCreated {
+				if uint64(created.BurnTransactionID) == burnID {
+					createdIn = block
+				}
+			}
+			for _, expired := range events.TFTBridgeModule_BurnTransactionExpired {
+				if uint64(expired.BurnTransactionID) == burnID {
+					return false, errors.Wrapf(ErrBurnExpired, "%d", burnID)
+				}
+			}
+		}
+
+		for _, processed := range events.TFTBridgeModule_BurnTransactionProcessed {
+			if processed.Burn.Target != target {
+				continue
+			}
+			if burnID == 0 || (createdIn != 0 && uint32(processed.Burn.Block) == createdIn) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func containsPhase(phases []types.Phase, phase types.Phase) bool {
+	for _, p := range phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package tfchain
+
+import (
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+func extrinsic(index uint32) types.Phase {
+	return types.Phase{IsApplyExtrinsic: true, AsApplyExtrinsic: index}
+}
+
+func TestMintCompleted(t *testing.T) {
+	var target, other substrate.AccountID
+	target[0] = 1
+	other[0] = 2
+
+	completed := func(phase types.Phase, account substrate.AccountID) substrate.MintCompleted {
+		return substrate.MintCompleted{
+			Phase:           phase,
+			MintTransaction: substrate.MintTransaction{Target: types.AccountID(account)},
+		}
+	}
+
+	events := &substrate.EventRecords{
+		TFTBridgeModule_MintTransactionVoted: []substrate.MintTransactionVoted{
+			{Phase: extrinsic(1), TxHash: "abc"},
+			{Phase: extrinsic(2), TxHash: "def"},
+		},
+		TFTBridgeModule_MintCompleted: []substrate.MintCompleted{
+			completed(extrinsic(1), other),
+			completed(extrinsic(2), target),
+		},
+	}
+
+	found, err := MintCompleted(target, "def")(1, events)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// the mint of abc went to another account
+	found, err = MintCompleted(target, "abc")(1, events)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	found, err = MintCompleted(target, "")(1, events)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	expired := &substrate.EventRecords{
+		TFTBridgeModule_MintTransactionExpired: []substrate.MintTransactionExpired{{TxHash: "abc"}},
+	}
+	_, err = MintCompleted(target, "abc")(1, expired)
+	assert.True(t, errors.Is(err, ErrMintExpired))
+}
+
+func TestBurnProcessed(t *testing.T) {
+	const target = "GDHJP6TF3UXYXTNEZ2P36J5FH7W4BJJQ4AYYAXC66I2Q2AH5B6O6BCFG"
+
+	processed := func(createdIn uint32) *substrate.EventRecords {
+		return &substrate.EventRecords{
+			TFTBridgeModule_BurnTransactionProcessed: []substrate.BurnTransactionProcessed{
+				{Burn: substrate.BurnTransaction{Target: target, Block: types.U32(createdIn)}},
+			},
+		}
+	}
+
+	match := BurnProcessed(target, 7)
+	found, err := match(5, &substrate.EventRecords{
+		TFTBridgeModule_BurnTransactionCreated: []substrate.BridgeBurnTransactionCreated{
+			{BurnTransactionID: 6},
+			{BurnTransactionID: 7},
+		},
+	})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// the payout of another burn to the same address
+	found, err = match(6, processed(4))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	found, err = match(7, processed(5))
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = BurnProcessed(target, 0)(7, processed(4))
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	_, err = BurnProcessed(target, 7)(8, &substrate.EventRecords{
+		TFTBridgeModule_BurnTransactionExpired: []substrate.BridgeBurnTransactionExpired{{BurnTransactionID: 7}},
+	})
+	assert.True(t, errors.Is(err, ErrBurnExpired))
+}
@@ -0,0 +1,190 @@
+package tfchain
+
+import (
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+const (
+	// nodes report their uptime every 40 minutes, reports are looked for in the last 2 hours of 6 second blocks so a
+	// missed report does not make a node look down
+	uptimeReportWindow = 2 * 600
+	// blocks of events to fetch at once
+	uptimeScanChunk = 200
+)
+
+// ErrNoUptimeReport is returned when a node did not report its uptime recently
+var ErrNoUptimeReport = errors.New("no recent uptime report")
+
+// NodeUptime is the last uptime a node reported
+type NodeUptime struct {
+	// Uptime of the node in seconds
+	Uptime uint64
+	// Timestamp of the report in unix time
+	Timestamp uint64
+	// Block the report was included in
+	Block uint32
+}
+
+// AddFarmIP adds a public ip with its gateway to a farm
+func AddFarmIP(client *substrate.Substrate, identity substrate.Identity, farm uint32, ip, gateway string) error {
+	return submit(client, identity, "TfgridModule.add_farm_ip", farm, ip, gateway)
+}
+
+// RemoveFarmIP removes a public ip from a farm, it can't be in use by a contract
+func RemoveFarmIP(client *substrate.Substrate, identity substrate.Identity, farm uint32, ip string) error {
+	return submit(client, identity, "TfgridModule.remove_farm_ip", farm, ip)
+}
+
+// SetFarmCertification sets the certification of a farm. This is sudo only: the council sets it on the public
+// networks, the call is submitted through sudo so it only works on networks where identity is the sudo key, like a
+// local dev node. Farmers can't call it.
+func SetFarmCertification(client *substrate.Substrate, identity substrate.Identity, farm uint32, gold bool) error {
+	cert := substrate.FarmCertification{IsNotCertified: !gold, IsGold: gold}
+	return submitSudo(client, identity, "TfgridModule.set_farm_certification", farm, cert)
+}
+
+// SetFarmDedicated marks the nodes of a farm as dedicated, so they can only be rented as a whole. Like
+// SetFarmCertification this is sudo only, farmers can't call it.
+func SetFarmDedicated(client *substrate.Substrate, identity substrate.Identity, farm uint32, dedicated bool) error {
+	return submitSudo(client, identity, "TfgridModule.set_farm_dedicated", farm, dedicated)
+}
+
+// SetFarmPayoutStellarAddress sets the stellar address the farming rewards of a farm are paid to
+func SetFarmPayoutStellarAddress(client *substrate.Substrate, identity substrate.Identity, farm uint32, address string) error {
+	return submit(client, identity, "TfgridModule.add_stellar_payout_v2address", farm, address)
+}
+
+// GetFarmPayoutStellarAddress returns the stellar address the farming rewards of a farm are paid to
+func GetFarmPayoutStellarAddress(client *substrate.Substrate, farm uint32) (string, error) {
+	var address []byte
+	if err := getStorage(client, "TfgridModule", "FarmPayoutV2AddressByFarmID", &address, farm); err != nil {
+		return "", errors.Wrapf(err, "failed to get payout address of farm %d", farm)
+	}
+	return string(address), nil
+}
+
+// SetNodePowerTarget sets whether a node should be up or down, the farmerbot powers nodes on and off to reach it
+func SetNodePowerTarget(client *substrate.Substrate, identity substrate.Identity, node uint32, up bool) error {
+	return submit(client, identity, "TfgridModule.change_power_target", node, substrate.Power{IsUp: up, IsDown: !up})
+}
+
+// SetNodePublicConfig sets the public config of a node of a farm, removing it if config is nil
+func SetNodePublicConfig(client *substrate.Substrate, identity substrate.Identity, farm, node uint32, config *substrate.PublicConfig) error {
+	var option substrate.OptionPublicConfig
+	if config != nil {
+		option = substrate.OptionPublicConfig{HasValue: true, AsValue: *config}
+	}
+	return submit(client, identity, "TfgridModule.add_node_public_config", farm, node, option)
+}
+
+// GetNodeUptime returns the last uptime a node reported in the recent blocks
+func GetNodeUptime(client *substrate.Substrate, node uint32) (NodeUptime, error) {
+	height, err := client.GetCurrentHeight()
+	if err != nil {
+		return NodeUptime{}, errors.Wrap(err, "failed to get current height")
+	}
+	_, meta, err := client.GetClient()
+	if err != nil {
+		return NodeUptime{}, err
+	}
+
+	// scan back in chunks, the most recent report is in the first chunk which has one
+	for end := height; end > 0 && height-end < uptimeReportWindow; {
+		start := uint32(0)
+		if end > uptimeScanChunk {
+			start = end - uptimeScanChunk
+		}
+
+		_, changes, err := client.FetchEventsForBlockRange(start, end)
+		if err != nil {
+			return NodeUptime{}, errors.Wrapf(err, "failed to get events of blocks %d to %d", start, end)
+		}
+
+		var found *NodeUptime
+		var foundIn types.Hash
+		for _, set := range changes {
+			for _, change := range set.Changes {
+				if !change.HasStorageData {
+					continue
+				}
+				var events substrate.EventRecords
+				if err := types.EventRecordsRaw(change.StorageData).DecodeEventRecords(meta, &events); err != nil {
+					return NodeUptime{}, errors.Wrap(err, "failed to decode events")
+				}
+				if uptime, ok := lastUptimeReport(&events, node); ok {
+					found, foundIn = &uptime, set.Block
+				}
+			}
+		}
+		if found != nil {
+			block, err := client.GetBlock(foundIn)
+			if err != nil {
+				return NodeUptime{}, errors.Wrap(err, "failed to get block of uptime report")
+			}
+			found.Block = uint32(block.Block.Header.Number)
+			return *found, nil
+		}
+
+		if start == 0 {
+			break
+		}
+		end = start - 1
+	}
+
+	return NodeUptime{}, errors.Wrapf(ErrNoUptimeReport, "node %d", node)
+}
+
+// lastUptimeReport returns the last uptime report of a node in the events of a block
+func lastUptimeReport(events *substrate.EventRecords, node uint32) (NodeUptime, bool) {
+	var uptime NodeUptime
+	var found bool
+	for _, e := range events.TfgridModule_NodeUptimeReported {
+		if uint32(e.Node) == node {
+			uptime = NodeUptime{Uptime: uint64(e.Uptime), Timestamp: uint64(e.Timestamp)}
+			found = true
+		}
+	}
+	return uptime, found
+}
+
+// submit a call signed by identity
+func submit(client *substrate.Substrate, identity substrate.Identity, name string, args ...interface{}) error {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return err
+	}
+
+	call, err := Call{Name: name, Args: args}.build(meta)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Call(cl, meta, identity, call); err != nil {
+		return errors.Wrapf(err, "failed to submit %s", name)
+	}
+	return nil
+}
+
+// submitSudo submits a call through sudo
+func submitSudo(client *substrate.Substrate, identity substrate.Identity, name string, args ...interface{}) error {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return err
+	}
+
+	call, err := Call{Name: name, Args: args}.build(meta)
+	if err != nil {
+		return err
+	}
+	sudo, err := types.NewCall(meta, "Sudo.sudo", call)
+	if err != nil {
+		return errors.Wrap(err, "failed to create sudo call")
+	}
+
+	if _, err := client.Call(cl, meta, identity, sudo); err != nil {
+		return errors.Wrapf(err, "failed to submit %s", name)
+	}
+	return nil
+}
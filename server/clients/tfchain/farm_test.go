@@ -0,0 +1,28 @@
+package tfchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+func TestLastUptimeReport(t *testing.T) {
+	events := substrate.EventRecords{
+		TfgridModule_NodeUptimeReported: []substrate.NodeUptimeReported{
+			{Node: 1, Uptime: 100, Timestamp: 1000},
+			{Node: 2, Uptime: 200, Timestamp: 1000},
+			{Node: 1, Uptime: 160, Timestamp: 1060},
+		},
+	}
+
+	uptime, ok := lastUptimeReport(&events, 1)
+	assert.True(t, ok)
+	assert.Equal(t, NodeUptime{Uptime: 160, Timestamp: 1060}, uptime)
+
+	_, ok = lastUptimeReport(&events, 3)
+	assert.False(t, ok)
+
+	_, ok = lastUptimeReport(&substrate.EventRecords{}, 1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,160 @@
+package tfchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/threefoldtech/web3_proxy/server/clients/safehttp"
+)
+
+const (
+	// DevFunderURI is the uri of the sudo account of a local tfchain dev node
+	DevFunderURI = "//Alice"
+	// DefaultFundingAmount is the amount a new account is funded with by a transfer or sudo funder, 10 TFT
+	DefaultFundingAmount = 10 * substrate.TFT
+
+	// time an account gets to show up on chain after funding it
+	fundingTimeout  = 30 * time.Second
+	fundingInterval = 2 * time.Second
+)
+
+// activationClient calls activation services, their url can be given by callers so internal addresses are refused
+var activationClient = safehttp.NewClient(safehttp.DefaultTimeout)
+
+// Funder funds accounts on tfchain so they exist on chain
+type Funder interface {
+	Fund(client *substrate.Substrate, account substrate.AccountID) error
+}
+
+// ActivationService funds accounts through the activation service of a network
+type ActivationService struct {
+	URL string
+}
+
+// TransferFunder funds accounts by transferring Amount from the account of Identity
+type TransferFunder struct {
+	Identity substrate.Identity
+	Amount   uint64
+}
+
+// SudoFunder funds accounts by setting their balance to Amount through sudo. Identity must be the sudo key, like //Alice
+// on a local dev node.
+type SudoFunder struct {
+	Identity substrate.Identity
+	Amount   uint64
+}
+
+// NewDevFunder creates a funder using the sudo account of a local tfchain dev node
+func NewDevFunder() (SudoFunder, error) {
+	identity, err := substrate.NewIdentityFromSr25519Phrase(DevFunderURI)
+	if err != nil {
+		return SudoFunder{}, errors.Wrap(err, "failed to load dev funder identity")
+	}
+	return SudoFunder{Identity: identity, Amount: DefaultFundingAmount}, nil
+}
+
+// Fund implements Funder
+func (f ActivationService) Fund(client *substrate.Substrate, account substrate.AccountID) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]string{
+		"substrateAccountID": account.String(),
+	}); err != nil {
+		return errors.Wrap(err, "failed to encode activation request")
+	}
+
+	resp, err := activationClient.Post(f.URL, "application/json", &buf)
+	if err != nil {
+		return errors.Wrap(err, "failed to call activation service")
+	}
+	defer resp.Body.Close()
+
+	// conflict means the account was activated before
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		return errors.Errorf("failed to activate account: %s", resp.Status)
+	}
+	return nil
+}
+
+// Fund implements Funder
+func (f TransferFunder) Fund(client *substrate.Substrate, account substrate.AccountID) error {
+	if err := client.Transfer(f.Identity, f.amount(), account); err != nil {
+		return errors.Wrap(err, "failed to transfer funds to account")
+	}
+	return nil
+}
+
+func (f TransferFunder) amount() uint64 {
+	if f.Amount == 0 {
+		return DefaultFundingAmount
+	}
+	return f.Amount
+}
+
+// Fund implements Funder
+func (f SudoFunder) Fund(client *substrate.Substrate, account substrate.AccountID) error {
+	dest, err := types.NewMultiAddressFromAccountID(account[:])
+	if err != nil {
+		return errors.Wrap(err, "failed to create destination address")
+	}
+
+	amount := f.Amount
+	if amount == 0 {
+		amount = DefaultFundingAmount
+	}
+
+	return submitSudo(client, f.Identity, "Balances.set_balance", dest,
+		types.NewUCompactFromUInt(amount), types.NewUCompactFromUInt(0))
+}
+
+// EnsureAccount makes sure the account of identity exists on chain and accepted the terms and conditions. The account
+// is funded by funder if it does not exist yet or its free balance dropped below the reactivation threshold.
+func EnsureAccount(client *substrate.Substrate, identity substrate.Identity, funder Funder, termsLink, termsHash string) error {
+	account, err := substrate.FromAddress(identity.Address())
+	if err != nil {
+		return errors.Wrap(err, "failed to get account id of identity")
+	}
+
+	balance, err := client.GetBalance(account)
+	if err != nil && !errors.Is(err, substrate.ErrAccountNotFound) {
+		return errors.Wrap(err, "failed to get account balance")
+	}
+
+	if errors.Is(err, substrate.ErrAccountNotFound) || balance.Free.Int == nil || balance.Free.Uint64() <= substrate.ReactivateThreshold {
+		if err := funder.Fund(client, account); err != nil {
+			return err
+		}
+		if err := waitForAccount(client, account); err != nil {
+			return err
+		}
+	}
+
+	conditions, err := client.SignedTermsAndConditions(account)
+	if err != nil {
+		return errors.Wrap(err, "failed to get signed terms and conditions")
+	}
+	if len(conditions) > 0 {
+		return nil
+	}
+
+	return client.AcceptTermsAndConditions(identity, termsLink, termsHash)
+}
+
+// waitForAccount waits until a funded account shows up on chain
+func waitForAccount(client *substrate.Substrate, account substrate.AccountID) error {
+	deadline := time.Now().Add(fundingTimeout)
+	for {
+		_, err := client.GetBalance(account)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, substrate.ErrAccountNotFound) || time.Now().After(deadline) {
+			return errors.Wrap(err, "account was not funded")
+		}
+		time.Sleep(fundingInterval)
+	}
+}
@@ -0,0 +1,15 @@
+package tfchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDevFunder(t *testing.T) {
+	funder, err := NewDevFunder()
+	require.NoError(t, err)
+	assert.Equal(t, "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", funder.Identity.Address())
+	assert.Equal(t, uint64(DefaultFundingAmount), funder.Amount)
+}
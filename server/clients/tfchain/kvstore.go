@@ -0,0 +1,144 @@
+package tfchain
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/hash"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/xxhash"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+// keys to query at once when listing the key-value store
+const kvQueryChunk = 256
+
+// ErrKeyNotFound is returned when a key is not in the key-value store of an account
+var ErrKeyNotFound = errors.New("key not found")
+
+// KV is an entry of the key-value store of an account
+type KV struct {
+	Key   string
+	Value string
+}
+
+// KVSet sets a key in the key-value store of the account of identity
+func KVSet(client *substrate.Substrate, identity substrate.Identity, key, value string) error {
+	return submit(client, identity, "TFKVStore.set", []byte(key), []byte(value))
+}
+
+// KVDelete removes a key from the key-value store of the account of identity
+func KVDelete(client *substrate.Substrate, identity substrate.Identity, key string) error {
+	return submit(client, identity, "TFKVStore.delete", []byte(key))
+}
+
+// KVGet returns the value of a key in the key-value store of an account
+func KVGet(client *substrate.Substrate, account substrate.AccountID, key string) (string, error) {
+	cl, meta, err := client.GetClient()
+	if err != nil {
+		return "", err
+	}
+
+	encodedKey, err := substrate.Encode([]byte(key))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode key")
+	}
+	storageKey, err := types.CreateStorageKey(meta, "TFKVStore", "TFKVStore", account[:], encodedKey)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create storage key")
+	}
+
+	var value []byte
+	ok, err := cl.RPC.State.GetStorageLatest(storageKey, &value)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get key %s", key)
+	}
+	if !ok {
+		return "", errors.Wrapf(ErrKeyNotFound, "key %s", key)
+	}
+	return string(value), nil
+}
+
+// KVList returns the entries in the key-value store of an account with keys starting with prefix, sorted on key
+func KVList(client *substrate.Substrate, account substrate.AccountID, prefix string) ([]KV, error) {
+	cl, _, err := client.GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	accountPrefix, err := kvAccountPrefix(account)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := cl.RPC.State.GetKeysLatest(accountPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get keys")
+	}
+
+	entries := []KV{}
+	for start := 0; start < len(keys); start += kvQueryChunk {
+		end := start + kvQueryChunk
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		sets, err := cl.RPC.State.QueryStorageAtLatest(keys[start:end])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get values")
+		}
+		for _, set := range sets {
+			for _, change := range set.Changes {
+				if !change.HasStorageData {
+					continue
+				}
+				key, err := kvKey(change.StorageKey, len(accountPrefix))
+				if err != nil {
+					return nil, err
+				}
+				if !strings.HasPrefix(key, prefix) {
+					continue
+				}
+				var value []byte
+				if err := substrate.Decode(change.StorageData, &value); err != nil {
+					return nil, errors.Wrapf(err, "failed to decode value of key %s", key)
+				}
+				entries = append(entries, KV{Key: key, Value: string(value)})
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	return entries, nil
+}
+
+// kvAccountPrefix is the prefix of the storage keys of the key-value store of an account
+func kvAccountPrefix(account substrate.AccountID) (types.StorageKey, error) {
+	hasher, err := hash.NewBlake2b128Concat(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := hasher.Write(account[:]); err != nil {
+		return nil, err
+	}
+
+	prefix := append(xxhash.New128([]byte("TFKVStore")).Sum(nil), xxhash.New128([]byte("TFKVStore")).Sum(nil)...)
+	return append(prefix, hasher.Sum(nil)...), nil
+}
+
+// kvKey decodes the key of the key-value store from a storage key, which is the account prefix followed by the
+// blake2_128 concat hash of the encoded key
+func kvKey(storageKey types.StorageKey, prefixLen int) (string, error) {
+	const blake2b128Len = 16
+	if len(storageKey) < prefixLen+blake2b128Len {
+		return "", errors.New("invalid key-value store storage key")
+	}
+
+	var key []byte
+	if err := substrate.Decode(storageKey[prefixLen+blake2b128Len:], &key); err != nil {
+		return "", errors.Wrap(err, "failed to decode key-value store key")
+	}
+	return string(key), nil
+}
@@ -0,0 +1,31 @@
+package tfchain
+
+import (
+	"testing"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+func TestKVKey(t *testing.T) {
+	prefix, err := kvAccountPrefix(substrate.AccountID{1})
+	require.NoError(t, err)
+	// module and storage hashes, then the blake2_128 concat of the account
+	assert.Len(t, prefix, 16+16+16+32)
+
+	encoded, err := substrate.Encode([]byte("config/app"))
+	require.NoError(t, err)
+
+	storageKey := append(types.StorageKey{}, prefix...)
+	storageKey = append(storageKey, make([]byte, 16)...)
+	storageKey = append(storageKey, encoded...)
+
+	key, err := kvKey(storageKey, len(prefix))
+	require.NoError(t, err)
+	assert.Equal(t, "config/app", key)
+
+	_, err = kvKey(prefix, len(prefix))
+	assert.Error(t, err)
+}
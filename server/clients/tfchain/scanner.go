@@ -0,0 +1,300 @@
+package tfchain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+const (
+	// tfchain produces a block every 6 seconds
+	defaultPollInterval = 6 * time.Second
+	// maxAttempts is the amount of times a failing read is retried before the awaits depending on it fail
+	maxAttempts = 5
+)
+
+var (
+	// scanners which are following a chain, by the source they read from
+	scanners     = map[EventSource]*Scanner{}
+	scannersLock sync.Mutex
+)
+
+// EventSource is the part of the tfchain client a scanner reads blocks from
+type EventSource interface {
+	GetCurrentHeight() (uint32, error)
+	GetEventsForBlock(block uint32) (*substrate.EventRecords, error)
+}
+
+// Matcher is called with the events of every scanned block till it matches. Returning an error ends the await with
+// that error.
+type Matcher func(block uint32, events *substrate.EventRecords) (bool, error)
+
+// Scanner reads the events of every block of a chain and hands them to the awaiters which are interested in that
+// block. The head of the chain is followed once for all awaiters which caught up with it, so any number of them can
+// follow the chain with one read per block. Awaiters starting in the past backfill the blocks up to the head on
+// their own, so they do not hold up the others.
+type Scanner struct {
+	source   EventSource
+	interval time.Duration
+
+	lock    sync.Mutex
+	waiters map[*waiter]struct{}
+	running bool
+	// head is the next block read for the awaiters which caught up, 0 till the height of the chain is known
+	head uint32
+	// highest block known to exist
+	height uint32
+}
+
+type waiter struct {
+	// next block to match
+	next  uint32
+	match Matcher
+	// live is set once the waiter caught up with the head, from then on the head is read for it
+	live   bool
+	result chan scanResult
+}
+
+type scanResult struct {
+	block uint32
+	err   error
+}
+
+// NewScanner creates a scanner reading from source. It only polls the chain while something is awaited.
+func NewScanner(source EventSource) *Scanner {
+	return &Scanner{
+		source:   source,
+		interval: defaultPollInterval,
+		waiters:  map[*waiter]struct{}{},
+	}
+}
+
+// ScannerFor returns the scanner which is following source, creating one if there is none, so all awaits on a
+// client share the same scanner
+func ScannerFor(source EventSource) *Scanner {
+	scannersLock.Lock()
+	defer scannersLock.Unlock()
+
+	s, ok := scanners[source]
+	if !ok {
+		s = NewScanner(source)
+		scanners[source] = s
+	}
+	return s
+}
+
+// Await calls match with the events of every block from block from on, including blocks produced while waiting,
+// till it matches. The number of the matching block is returned.
+func (s *Scanner) Await(ctx context.Context, from uint32, match Matcher) (uint32, error) {
+	w := &waiter{next: from, match: match, result: make(chan scanResult, 1)}
+
+	s.lock.Lock()
+	s.waiters[w] = struct{}{}
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+	s.lock.Unlock()
+
+	go s.backfill(w)
+
+	select {
+	case res := <-w.result:
+		return res.block, res.err
+	case <-ctx.Done():
+		s.lock.Lock()
+		delete(s.waiters, w)
+		s.lock.Unlock()
+		return 0, ctx.Err()
+	}
+}
+
+// run follows the head of the chain for the awaiters which caught up with it, till there are no awaiters left.
+// Failing reads are retried, the live awaiters fail once a read failed maxAttempts times in a row.
+func (s *Scanner) run() {
+	failures := 0
+	fail := func(err error) {
+		if failures++; failures < maxAttempts {
+			time.Sleep(s.interval)
+			return
+		}
+		failures = 0
+		s.finishLive(err)
+	}
+
+	for !s.stopIdle() {
+		s.lock.Lock()
+		head, height := s.head, s.height
+		s.lock.Unlock()
+
+		if head == 0 || head > height {
+			current, err := s.source.GetCurrentHeight()
+			if err != nil {
+				fail(errors.Wrap(err, "failed to get current height"))
+				continue
+			}
+			s.lock.Lock()
+			s.height = current
+			if s.head == 0 {
+				s.head = current + 1
+			}
+			head = s.head
+			s.lock.Unlock()
+
+			if head > current {
+				failures = 0
+				time.Sleep(s.interval)
+				continue
+			}
+		}
+
+		events, err := s.source.GetEventsForBlock(head)
+		if err != nil {
+			fail(errors.Wrapf(err, "failed to get events of block %d", head))
+			continue
+		}
+		failures = 0
+		s.dispatch(head, events)
+	}
+}
+
+// backfill scans the blocks from the start of an await till it caught up with the head, and hands it over to the
+// head from there. Failing reads are retried, the await fails once a read failed maxAttempts times in a row.
+func (s *Scanner) backfill(w *waiter) {
+	failures := 0
+	fail := func(err error) bool {
+		if failures++; failures < maxAttempts {
+			time.Sleep(s.interval)
+			return false
+		}
+		s.finish(w, scanResult{err: err})
+		return true
+	}
+
+	for {
+		s.lock.Lock()
+		_, waiting := s.waiters[w]
+		if s.head != 0 && w.next >= s.head {
+			w.live = true
+		}
+		live, next, height := w.live, w.next, s.height
+		s.lock.Unlock()
+		if !waiting || live {
+			return
+		}
+
+		if next > height {
+			current, err := s.source.GetCurrentHeight()
+			if err != nil {
+				if fail(errors.Wrap(err, "failed to get current height")) {
+					return
+				}
+				continue
+			}
+			s.lock.Lock()
+			if current > s.height {
+				s.height = current
+			}
+			s.lock.Unlock()
+
+			if next > current {
+				// the head takes over once it knows the height
+				time.Sleep(s.interval)
+			}
+			continue
+		}
+
+		events, err := s.source.GetEventsForBlock(next)
+		if err != nil {
+			if fail(errors.Wrapf(err, "failed to get events of block %d", next)) {
+				return
+			}
+			continue
+		}
+		failures = 0
+		if s.matchBlock(w, next, events) {
+			return
+		}
+	}
+}
+
+// dispatch the events of the head block to the live awaiters which are at that block, moving the head forward
+func (s *Scanner) dispatch(block uint32, events *substrate.EventRecords) {
+	s.lock.Lock()
+	var at []*waiter
+	for w := range s.waiters {
+		if w.live && w.next == block {
+			at = append(at, w)
+		}
+	}
+	s.head = block + 1
+	s.lock.Unlock()
+
+	for _, w := range at {
+		s.matchBlock(w, block, events)
+	}
+}
+
+// matchBlock hands the events of a block to an awaiter, returning true if the await ended
+func (s *Scanner) matchBlock(w *waiter, block uint32, events *substrate.EventRecords) bool {
+	found, err := w.match(block, events)
+	switch {
+	case err != nil:
+		s.finish(w, scanResult{err: err})
+		return true
+	case found:
+		s.finish(w, scanResult{block: block})
+		return true
+	default:
+		s.lock.Lock()
+		w.next = block + 1
+		s.lock.Unlock()
+		return false
+	}
+}
+
+// finishLive ends the awaits which are following the head with an error
+func (s *Scanner) finishLive(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for w := range s.waiters {
+		if w.live {
+			delete(s.waiters, w)
+			w.result <- scanResult{err: err}
+		}
+	}
+}
+
+// finish ends an await if it was not cancelled in the meantime
+func (s *Scanner) finish(w *waiter, res scanResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.waiters[w]; !ok {
+		return
+	}
+	delete(s.waiters, w)
+	w.result <- res
+}
+
+// stopIdle stops the scanner if there are no awaiters left, removing it from the shared scanners so idle clients
+// are not kept around
+func (s *Scanner) stopIdle() bool {
+	scannersLock.Lock()
+	defer scannersLock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.waiters) > 0 {
+		return false
+	}
+	s.running = false
+	if scanners[s.source] == s {
+		delete(scanners, s.source)
+	}
+	return true
+}
@@ -0,0 +1,208 @@
+package tfchain
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+// fakeChain serves the events of the blocks it was given
+type fakeChain struct {
+	lock   sync.Mutex
+	height uint32
+	blocks map[uint32]*substrate.EventRecords
+	reads  map[uint32]int
+	err    error
+	// failures is the amount of reads which fail before the chain recovers
+	failures int
+}
+
+func newFakeChain(height uint32) *fakeChain {
+	return &fakeChain{height: height, blocks: map[uint32]*substrate.EventRecords{}, reads: map[uint32]int{}}
+}
+
+func (c *fakeChain) GetCurrentHeight() (uint32, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return 0, errors.New("timeout")
+	}
+	return c.height, c.err
+}
+
+func (c *fakeChain) GetEventsForBlock(block uint32) (*substrate.EventRecords, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return nil, errors.New("timeout")
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.reads[block]++
+	if events, ok := c.blocks[block]; ok {
+		return events, nil
+	}
+	return &substrate.EventRecords{}, nil
+}
+
+// produce a block with events
+func (c *fakeChain) produce(events *substrate.EventRecords) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.height++
+	c.blocks[c.height] = events
+}
+
+func newTestScanner(source EventSource) *Scanner {
+	s := NewScanner(source)
+	s.interval = time.Millisecond
+	return s
+}
+
+func mintProposed(txID string) *substrate.EventRecords {
+	return &substrate.EventRecords{
+		TFTBridgeModule_MintTransactionProposed: []substrate.MintTransactionProposed{{TxHash: txID}},
+	}
+}
+
+func TestScannerAwait(t *testing.T) {
+	t.Run("past_blocks", func(t *testing.T) {
+		chain := newFakeChain(10)
+		chain.blocks[3] = mintProposed("abc")
+		s := newTestScanner(chain)
+
+		block, err := s.Await(context.Background(), 1, MintProposed("abc"))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3), block)
+	})
+
+	t.Run("blocks_between_polls", func(t *testing.T) {
+		chain := newFakeChain(10)
+		s := newTestScanner(chain)
+
+		done := make(chan uint32)
+		go func() {
+			block, err := s.Await(context.Background(), 11, MintProposed("abc"))
+			assert.NoError(t, err)
+			done <- block
+		}()
+
+		// the matching block is not the latest one once the scanner gets to it
+		chain.produce(&substrate.EventRecords{})
+		chain.produce(mintProposed("abc"))
+		chain.produce(&substrate.EventRecords{})
+
+		select {
+		case block := <-done:
+			assert.Equal(t, uint32(12), block)
+		case <-time.After(time.Second):
+			t.Fatal("block not found")
+		}
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		chain := newFakeChain(20)
+		chain.blocks[15] = mintProposed("abc")
+		chain.blocks[18] = mintProposed("def")
+		s := newTestScanner(chain)
+
+		var wg sync.WaitGroup
+		for _, tx := range []string{"abc", "def", "abc"} {
+			wg.Add(1)
+			go func(tx string) {
+				defer wg.Done()
+				_, err := s.Await(context.Background(), 10, MintProposed(tx))
+				assert.NoError(t, err)
+			}(tx)
+		}
+		wg.Wait()
+
+		chain.lock.Lock()
+		defer chain.lock.Unlock()
+		for block, reads := range chain.reads {
+			// awaiters which start later may read some blocks again, but they catch up with the others
+			assert.LessOrEqualf(t, reads, 3, "block %d", block)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		chain := newFakeChain(10)
+		s := newTestScanner(chain)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Await(ctx, 1, MintProposed("abc"))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+		require.Eventually(t, func() bool {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			return !s.running
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("lagging_awaiter", func(t *testing.T) {
+		chain := newFakeChain(10)
+		s := newTestScanner(chain)
+
+		// an awaiter far behind which is slow to match does not hold up the awaiters at the head
+		release := make(chan struct{})
+		defer close(release)
+		go func() {
+			_, _ = s.Await(context.Background(), 1, func(block uint32, events *substrate.EventRecords) (bool, error) {
+				<-release
+				return false, nil
+			})
+		}()
+
+		done := make(chan uint32)
+		go func() {
+			block, err := s.Await(context.Background(), 11, MintProposed("abc"))
+			assert.NoError(t, err)
+			done <- block
+		}()
+		chain.produce(mintProposed("abc"))
+
+		select {
+		case block := <-done:
+			assert.Equal(t, uint32(11), block)
+		case <-time.After(time.Second):
+			t.Fatal("block not found")
+		}
+	})
+
+	t.Run("transient_error", func(t *testing.T) {
+		chain := newFakeChain(10)
+		chain.blocks[3] = mintProposed("abc")
+		chain.failures = maxAttempts - 1
+		s := newTestScanner(chain)
+
+		block, err := s.Await(context.Background(), 1, MintProposed("abc"))
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3), block)
+	})
+
+	t.Run("source_error", func(t *testing.T) {
+		chain := newFakeChain(10)
+		chain.err = errors.New("connection closed")
+		s := newTestScanner(chain)
+
+		_, err := s.Await(context.Background(), 1, MintProposed("abc"))
+		assert.Error(t, err)
+	})
+}
+
+func TestScannerFor(t *testing.T) {
+	chain := newFakeChain(10)
+	assert.Same(t, ScannerFor(chain), ScannerFor(chain))
+	assert.NotSame(t, ScannerFor(chain), ScannerFor(newFakeChain(10)))
+}
@@ -0,0 +1,198 @@
+package tfchain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+const (
+	// maximum amount of events kept for a subscription, older events are dropped when it is full
+	eventBufferSize = 1000
+	// delay before scanning again after the chain could not be read
+	rescanDelay = 10 * time.Second
+)
+
+type (
+	// Subscriptions follows the events of a chain for a set of filters. The events are buffered per subscription
+	// until they are taken.
+	Subscriptions struct {
+		lock          sync.Mutex
+		subscriptions map[string]*eventSubscription
+	}
+
+	eventSubscription struct {
+		id     string
+		cancel context.CancelFunc
+
+		lock   sync.Mutex
+		events []Event
+	}
+
+	// finalized reads the blocks of a chain up to the last finalized block
+	finalized struct {
+		*substrate.Substrate
+	}
+)
+
+// Finalized returns a source reading the blocks of client up to the last finalized block, instead of the latest one
+func Finalized(client *substrate.Substrate) EventSource {
+	return finalized{client}
+}
+
+// GetCurrentHeight returns the number of the last finalized block
+func (f finalized) GetCurrentHeight() (uint32, error) {
+	cl, _, err := f.GetClient()
+	if err != nil {
+		return 0, err
+	}
+
+	hash, err := cl.RPC.Chain.GetFinalizedHead()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get finalized head")
+	}
+	header, err := cl.RPC.Chain.GetHeader(hash)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get finalized header")
+	}
+
+	return uint32(header.Number), nil
+}
+
+// Subscribe follows the events of source matching the filter, starting at block from, or at the next block if from
+// is 0. The events are buffered until they are taken with Events. The id of the subscription is returned.
+func (s *Subscriptions) Subscribe(source EventSource, filter EventFilter, from uint32) (string, error) {
+	if from == 0 {
+		height, err := source.GetCurrentHeight()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get current height")
+		}
+		from = height + 1
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &eventSubscription{id: hex.EncodeToString(id), cancel: cancel}
+	go sub.follow(ctx, ScannerFor(source), filter, from)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.subscriptions == nil {
+		s.subscriptions = map[string]*eventSubscription{}
+	}
+	s.subscriptions[sub.id] = sub
+
+	return sub.id, nil
+}
+
+// Events takes up to count buffered events of a subscription, oldest first. All events are taken if count is 0.
+func (s *Subscriptions) Events(id string, count uint32) ([]Event, error) {
+	s.lock.Lock()
+	sub, ok := s.subscriptions[id]
+	s.lock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("subscription %s not found", id)
+	}
+
+	return sub.take(count), nil
+}
+
+// Ids returns the ids of the active subscriptions
+func (s *Subscriptions) Ids() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ids := make([]string, 0, len(s.subscriptions))
+	for id := range s.subscriptions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Close stops a subscription and drops its buffered events
+func (s *Subscriptions) Close(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if sub, ok := s.subscriptions[id]; ok {
+		sub.cancel()
+		delete(s.subscriptions, id)
+	}
+}
+
+// CloseAll stops all subscriptions
+func (s *Subscriptions) CloseAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for id, sub := range s.subscriptions {
+		sub.cancel()
+		delete(s.subscriptions, id)
+	}
+}
+
+// follow buffers the matching events of every block from block from on until the context is canceled. If the chain
+// can't be read, the scan is resumed from the block it stopped at.
+func (sub *eventSubscription) follow(ctx context.Context, scanner *Scanner, filter EventFilter, from uint32) {
+	next := from
+	for {
+		_, err := scanner.Await(ctx, next, func(block uint32, records *substrate.EventRecords) (bool, error) {
+			for _, event := range DecodeEvents(block, records) {
+				if filter.Match(event) {
+					sub.push(event)
+				}
+			}
+			next = block + 1
+			return false, nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Debug().Err(err).Msgf("event subscription %s interrupted at block %d, scanning again in %s", sub.id, next, rescanDelay)
+
+		select {
+		case <-time.After(rescanDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// push buffers an event, dropping the oldest event if the buffer is full
+func (sub *eventSubscription) push(event Event) {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+
+	if len(sub.events) >= eventBufferSize {
+		sub.events = sub.events[1:]
+	}
+	sub.events = append(sub.events, event)
+}
+
+// take removes and returns up to count events from the buffer, all if count is 0
+func (sub *eventSubscription) take(count uint32) []Event {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+
+	n := len(sub.events)
+	if count != 0 && int(count) < n {
+		n = int(count)
+	}
+	taken := make([]Event, n)
+	copy(taken, sub.events[:n])
+	sub.events = sub.events[n:]
+	return taken
+}
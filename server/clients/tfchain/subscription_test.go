@@ -0,0 +1,61 @@
+package tfchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+)
+
+func TestSubscriptions(t *testing.T) {
+	chain := newFakeChain(10)
+	scanner := ScannerFor(chain)
+	scanner.interval = time.Millisecond
+
+	var subs Subscriptions
+	id, err := subs.Subscribe(chain, EventFilter{Names: []string{"NodeUptimeReported"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{id}, subs.Ids())
+
+	uptime := func(node uint32) *substrate.EventRecords {
+		return &substrate.EventRecords{
+			TfgridModule_NodeUptimeReported: []substrate.NodeUptimeReported{{Phase: extrinsic(1), Node: types.U32(node)}},
+			TfgridModule_NodeDeleted:        []substrate.NodeDeleted{{Phase: extrinsic(2), Node: 8}},
+		}
+	}
+	chain.produce(uptime(7))
+	chain.produce(&substrate.EventRecords{})
+	chain.produce(uptime(7))
+
+	var events []Event
+	require.Eventually(t, func() bool {
+		taken, err := subs.Events(id, 0)
+		require.NoError(t, err)
+		events = append(events, taken...)
+		return len(events) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, uint32(11), events[0].Block)
+	assert.Equal(t, uint32(13), events[1].Block)
+
+	subs.Close(id)
+	assert.Empty(t, subs.Ids())
+	_, err = subs.Events(id, 0)
+	assert.Error(t, err)
+}
+
+func TestSubscriptionTake(t *testing.T) {
+	sub := &eventSubscription{}
+	for i := 0; i < eventBufferSize+5; i++ {
+		sub.push(Event{Block: uint32(i)})
+	}
+
+	taken := sub.take(2)
+	require.Len(t, taken, 2)
+	// the oldest events were dropped when the buffer was full
+	assert.Equal(t, uint32(5), taken[0].Block)
+	assert.Len(t, sub.take(0), eventBufferSize-2)
+	assert.Empty(t, sub.take(0))
+}
@@ -0,0 +1,70 @@
+package tfchain
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/web3_proxy/server/clients/safehttp"
+)
+
+// maxTermsDocumentSize is the maximum size of a terms and conditions document
+const maxTermsDocumentSize = 1 << 20
+
+// TermsDocuments are the documents which make up the terms and conditions of the grid, in the order they are hashed
+var TermsDocuments = []string{
+	"https://raw.githubusercontent.com/threefoldfoundation/info_legal/master/wiki/terms_conditions_griduser.md",
+	"https://raw.githubusercontent.com/threefoldfoundation/info_legal/master/wiki/disclaimer.md",
+	"https://raw.githubusercontent.com/threefoldfoundation/info_legal/master/wiki/privacypolicy.md",
+}
+
+// termsClient fetches the terms and conditions documents, the urls can be given by callers so internal addresses are
+// refused
+var termsClient = safehttp.NewClient(safehttp.DefaultTimeout)
+
+var termsHashes = struct {
+	sync.Mutex
+	hashes map[string]string
+}{hashes: map[string]string{}}
+
+// TermsHash returns the hash to accept the terms and conditions with, the md5 of the concatenated documents. Documents
+// are https urls on public addresses, of at most 1 MiB each. The hash of a set of documents is only computed once.
+func TermsHash(documents []string) (string, error) {
+	if len(documents) == 0 {
+		return "", errors.New("no terms and conditions documents")
+	}
+
+	key := strings.Join(documents, "\n")
+
+	termsHashes.Lock()
+	defer termsHashes.Unlock()
+
+	if hash, ok := termsHashes.hashes[key]; ok {
+		return hash, nil
+	}
+
+	hasher := md5.New()
+	for _, document := range documents {
+		content, err := readDocument(document)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read terms and conditions document %s", document)
+		}
+		hasher.Write(content)
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	termsHashes.hashes[key] = hash
+
+	return hash, nil
+}
+
+// readDocument fetches a document from an https url
+func readDocument(document string) ([]byte, error) {
+	if !strings.HasPrefix(document, "https://") {
+		return nil, errors.New("documents must be https urls")
+	}
+	return safehttp.Get(context.Background(), termsClient, document, maxTermsDocumentSize)
+}
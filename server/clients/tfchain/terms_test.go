@@ -0,0 +1,73 @@
+package tfchain
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTermsHash(t *testing.T) {
+	var lock sync.Mutex
+	documents := map[string]string{"/terms.md": "terms", "/privacy.md": "privacy"}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		content, ok := documents[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	// the test server listens on a loopback address, which the terms client refuses
+	client := termsClient
+	termsClient = server.Client()
+	defer func() { termsClient = client }()
+
+	terms := server.URL + "/terms.md"
+	privacy := server.URL + "/privacy.md"
+	expected := md5.Sum([]byte("termsprivacy"))
+
+	hash, err := TermsHash([]string{terms, privacy})
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(expected[:]), hash)
+
+	// the hash is cached
+	lock.Lock()
+	delete(documents, "/terms.md")
+	lock.Unlock()
+	cached, err := TermsHash([]string{terms, privacy})
+	require.NoError(t, err)
+	assert.Equal(t, hash, cached)
+
+	// errors are not
+	_, err = TermsHash([]string{privacy, terms})
+	assert.Error(t, err)
+	lock.Lock()
+	documents["/terms.md"] = "terms"
+	lock.Unlock()
+	_, err = TermsHash([]string{privacy, terms})
+	assert.NoError(t, err)
+
+	_, err = TermsHash(nil)
+	assert.Error(t, err)
+}
+
+func TestTermsHashRefusesLocalDocuments(t *testing.T) {
+	_, err := TermsHash([]string{"/etc/hostname"})
+	assert.Error(t, err)
+
+	_, err = TermsHash([]string{"http://example.com/terms.md"})
+	assert.Error(t, err)
+
+	_, err = TermsHash([]string{"https://127.0.0.1/terms.md"})
+	assert.ErrorContains(t, err, "refusing to connect")
+}
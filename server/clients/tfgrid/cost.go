@@ -0,0 +1,149 @@
+package tfgrid
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+// EstimateCostParams holds the deployment to estimate, either machines or a kubernetes cluster
+type EstimateCostParams struct {
+	Machines *MachinesModel `json:"machines"`
+	K8s      *K8sCluster    `json:"k8s"`
+}
+
+// DeploymentCost is the monthly cost of a deployment, it is the sum of the costs of its node contracts
+type DeploymentCost struct {
+	USDPerMonth float64        `json:"usd_per_month"`
+	TFTPerMonth float64        `json:"tft_per_month"`
+	Contracts   []ContractCost `json:"contracts"`
+}
+
+// ContractCost is the monthly cost of the node contract deploying workloads on a node
+type ContractCost struct {
+	// NodeID is 0 for workloads which are not assigned a node yet, they are priced with the default pricing policy
+	NodeID        uint32   `json:"node_id"`
+	Workloads     []string `json:"workloads"`
+	USDPerMonth   float64  `json:"usd_per_month"`
+	TFTPerMonth   float64  `json:"tft_per_month"`
+	DiscountLevel string   `json:"discount_level"`
+}
+
+// nodeReservation is what a node contract reserves
+type nodeReservation struct {
+	node      uint32
+	workloads []string
+	resources tfchainclient.Resources
+}
+
+// EstimateCost estimates the monthly cost of deploying machines or a kubernetes cluster. The workloads on the same
+// node are deployed in one contract. Workloads without a node each get their own contract.
+func (c *Client) EstimateCost(ctx context.Context, params EstimateCostParams) (DeploymentCost, error) {
+	var reservations []*nodeReservation
+	switch {
+	case params.Machines != nil && params.K8s != nil:
+		return DeploymentCost{}, errors.New("only one of machines and k8s can be estimated at once")
+	case params.Machines != nil:
+		reservations = machinesReservations(params.Machines)
+	case params.K8s != nil:
+		reservations = k8sReservations(params.K8s)
+	default:
+		return DeploymentCost{}, errors.New("machines or k8s must be given")
+	}
+
+	cost := DeploymentCost{Contracts: []ContractCost{}}
+	for _, r := range reservations {
+		contract, err := c.GridClient.EstimateContractCost(r.node, r.resources, tfchainclient.CostOptions{})
+		if err != nil {
+			return DeploymentCost{}, errors.Wrapf(err, "failed to estimate cost of %v", r.workloads)
+		}
+
+		cost.USDPerMonth += contract.USD
+		cost.TFTPerMonth += contract.TFT
+		cost.Contracts = append(cost.Contracts, ContractCost{
+			NodeID:        r.node,
+			Workloads:     r.workloads,
+			USDPerMonth:   contract.USD,
+			TFTPerMonth:   contract.TFT,
+			DiscountLevel: contract.Discount,
+		})
+	}
+
+	return cost, nil
+}
+
+func machinesReservations(model *MachinesModel) []*nodeReservation {
+	var reservations reservationsByNode
+	for _, machine := range model.Machines {
+		res := tfchainclient.Resources{
+			CRU: uint64(machine.CPU),
+			MRU: uint64(machine.Memory) * uint64(gridtypes.Megabyte),
+			SRU: uint64(machine.RootfsSize) * uint64(gridtypes.Megabyte),
+		}
+		for _, disk := range machine.Disks {
+			res.SRU += uint64(disk.SizeGB) * uint64(gridtypes.Gigabyte)
+		}
+		// zos reserves a core and a gigabyte of memory for each qsfs, and stores its cache on ssd
+		for _, qsfs := range machine.QSFSs {
+			res.CRU++
+			res.MRU += uint64(gridtypes.Gigabyte)
+			res.SRU += uint64(qsfs.Cache) * uint64(gridtypes.Gigabyte)
+		}
+		if machine.PublicIP {
+			res.PublicIPs = 1
+		}
+		reservations.add(machine.NodeID, machine.Name, res)
+	}
+	return reservations.reservations
+}
+
+func k8sReservations(cluster *K8sCluster) []*nodeReservation {
+	var reservations reservationsByNode
+	nodes := cluster.Workers
+	if cluster.Master != nil {
+		nodes = append([]K8sNode{*cluster.Master}, nodes...)
+	}
+	for _, node := range nodes {
+		res := tfchainclient.Resources{
+			CRU: uint64(node.CPU),
+			MRU: uint64(node.Memory) * uint64(gridtypes.Megabyte),
+			SRU: uint64(node.DiskSize) * uint64(gridtypes.Gigabyte),
+		}
+		if node.PublicIP {
+			res.PublicIPs = 1
+		}
+		reservations.add(node.NodeID, node.Name, res)
+	}
+	return reservations.reservations
+}
+
+// reservationsByNode groups the workloads of a deployment by the node they are deployed on, in the order the nodes
+// are first seen
+type reservationsByNode struct {
+	nodes        map[uint32]*nodeReservation
+	reservations []*nodeReservation
+}
+
+func (r *reservationsByNode) add(node uint32, workload string, res tfchainclient.Resources) {
+	if r.nodes == nil {
+		r.nodes = map[uint32]*nodeReservation{}
+	}
+
+	reservation, ok := r.nodes[node]
+	if !ok {
+		reservation = &nodeReservation{node: node}
+		r.reservations = append(r.reservations, reservation)
+		// workloads without a node can end up on different nodes, so they are not grouped
+		if node != 0 {
+			r.nodes[node] = reservation
+		}
+	}
+	reservation.workloads = append(reservation.workloads, workload)
+	reservation.resources.CRU += res.CRU
+	reservation.resources.MRU += res.MRU
+	reservation.resources.SRU += res.SRU
+	reservation.resources.HRU += res.HRU
+	reservation.resources.PublicIPs += res.PublicIPs
+}
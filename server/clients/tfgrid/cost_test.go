@@ -0,0 +1,95 @@
+package tfgrid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/web3_proxy/server/clients/tfgrid/mocks"
+	"github.com/threefoldtech/zos/pkg/gridtypes"
+)
+
+func TestEstimateCost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cl := mocks.NewMockTFGridClient(ctrl)
+
+	r := Client{
+		GridClient: cl,
+		Projects:   map[string]ProjectState{},
+	}
+
+	t.Run("machines", func(t *testing.T) {
+		model := MachinesModel{
+			Name: "machines",
+			Machines: []Machine{
+				{Name: "vm1", NodeID: 1, CPU: 2, Memory: 2048, RootfsSize: 1024, PublicIP: true, Disks: []Disk{{SizeGB: 10}}},
+				{Name: "vm2", NodeID: 1, CPU: 1, Memory: 1024, QSFSs: []QSFS{{Cache: 5}}},
+				{Name: "vm3", CPU: 1, Memory: 1024},
+				{Name: "vm4", CPU: 1, Memory: 1024},
+			},
+		}
+
+		cl.EXPECT().EstimateContractCost(uint32(1), tfchainclient.Resources{
+			CRU:       4,
+			MRU:       4 * uint64(gridtypes.Gigabyte),
+			SRU:       16 * uint64(gridtypes.Gigabyte),
+			PublicIPs: 1,
+		}, tfchainclient.CostOptions{}).Return(tfchainclient.Cost{USD: 10, TFT: 500, Discount: "none"}, nil)
+		cl.EXPECT().EstimateContractCost(uint32(0), tfchainclient.Resources{
+			CRU: 1,
+			MRU: uint64(gridtypes.Gigabyte),
+		}, tfchainclient.CostOptions{}).Return(tfchainclient.Cost{USD: 2, TFT: 100, Discount: "none"}, nil).Times(2)
+
+		cost, err := r.EstimateCost(context.Background(), EstimateCostParams{Machines: &model})
+		require.NoError(t, err)
+
+		assert.Equal(t, 14.0, cost.USDPerMonth)
+		assert.Equal(t, 700.0, cost.TFTPerMonth)
+		require.Len(t, cost.Contracts, 3)
+		assert.Equal(t, []string{"vm1", "vm2"}, cost.Contracts[0].Workloads)
+		assert.Equal(t, []string{"vm3"}, cost.Contracts[1].Workloads)
+		assert.Equal(t, []string{"vm4"}, cost.Contracts[2].Workloads)
+	})
+
+	t.Run("k8s", func(t *testing.T) {
+		cluster := K8sCluster{
+			Name:    "cluster",
+			Master:  &K8sNode{Name: "master", NodeID: 1, CPU: 2, Memory: 4096, DiskSize: 10, PublicIP: true},
+			Workers: []K8sNode{{Name: "worker", NodeID: 2, CPU: 1, Memory: 2048, DiskSize: 5}},
+		}
+
+		cl.EXPECT().EstimateContractCost(uint32(1), tfchainclient.Resources{
+			CRU:       2,
+			MRU:       4 * uint64(gridtypes.Gigabyte),
+			SRU:       10 * uint64(gridtypes.Gigabyte),
+			PublicIPs: 1,
+		}, tfchainclient.CostOptions{}).Return(tfchainclient.Cost{USD: 6, TFT: 300, Discount: "gold"}, nil)
+		cl.EXPECT().EstimateContractCost(uint32(2), tfchainclient.Resources{
+			CRU: 1,
+			MRU: 2 * uint64(gridtypes.Gigabyte),
+			SRU: 5 * uint64(gridtypes.Gigabyte),
+		}, tfchainclient.CostOptions{}).Return(tfchainclient.Cost{USD: 3, TFT: 150, Discount: "gold"}, nil)
+
+		cost, err := r.EstimateCost(context.Background(), EstimateCostParams{K8s: &cluster})
+		require.NoError(t, err)
+
+		assert.Equal(t, 9.0, cost.USDPerMonth)
+		assert.Equal(t, 450.0, cost.TFTPerMonth)
+		require.Len(t, cost.Contracts, 2)
+		assert.Equal(t, uint32(1), cost.Contracts[0].NodeID)
+		assert.Equal(t, "gold", cost.Contracts[1].DiscountLevel)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := r.EstimateCost(context.Background(), EstimateCostParams{})
+		assert.Error(t, err)
+
+		_, err = r.EstimateCost(context.Background(), EstimateCostParams{Machines: &MachinesModel{}, K8s: &K8sCluster{}})
+		assert.Error(t, err)
+	})
+}
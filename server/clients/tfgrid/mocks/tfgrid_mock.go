@@ -14,6 +14,7 @@ import (
 	state "github.com/threefoldtech/tfgrid-sdk-go/grid-client/state"
 	workloads "github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
 	types "github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+	tfchain "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
 )
 
 // MockTFGridClient is a mock of TFGridClient interface.
@@ -163,6 +164,21 @@ func (mr *MockTFGridClientMockRecorder) DeployNetwork(ctx, znet interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeployNetwork", reflect.TypeOf((*MockTFGridClient)(nil).DeployNetwork), ctx, znet)
 }
 
+// EstimateContractCost mocks base method.
+func (m *MockTFGridClient) EstimateContractCost(nodeID uint32, res tfchain.Resources, opts tfchain.CostOptions) (tfchain.Cost, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateContractCost", nodeID, res, opts)
+	ret0, _ := ret[0].(tfchain.Cost)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateContractCost indicates an expected call of EstimateContractCost.
+func (mr *MockTFGridClientMockRecorder) EstimateContractCost(nodeID, res, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateContractCost", reflect.TypeOf((*MockTFGridClient)(nil).EstimateContractCost), nodeID, res, opts)
+}
+
 // FilterFarms mocks base method.
 func (m *MockTFGridClient) FilterFarms(filter types.FarmFilter, pagination types.Limit) ([]types.Farm, int, error) {
 	m.ctrl.T.Helper()
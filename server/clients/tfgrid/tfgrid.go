@@ -5,12 +5,15 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/deployer"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/graphql"
 	client "github.com/threefoldtech/tfgrid-sdk-go/grid-client/node"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/state"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/subi"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-client/workloads"
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
 )
 
 type TFGridClient interface {
@@ -28,6 +31,7 @@ type TFGridClient interface {
 	GetNode(nodeID uint32) (res types.NodeWithNestedCapacity, err error)
 	GetNodeDomain(ctx context.Context, nodeID uint32) (string, error)
 	GetNodeFarm(nodeID uint32) (uint32, error)
+	EstimateContractCost(nodeID uint32, res tfchainclient.Resources, opts tfchainclient.CostOptions) (tfchainclient.Cost, error)
 
 	SetContractState(contracts map[uint32]state.ContractIDs)
 
@@ -139,6 +143,19 @@ func (c *tfgridClient) GetNodeFarm(nodeID uint32) (uint32, error) {
 	return uint32(node.FarmID), nil
 }
 
+func (c *tfgridClient) EstimateContractCost(nodeID uint32, res tfchainclient.Resources, opts tfchainclient.CostOptions) (tfchainclient.Cost, error) {
+	sub, ok := c.client.SubstrateConn.(*subi.SubstrateImpl)
+	if !ok {
+		return tfchainclient.Cost{}, errors.New("substrate connection does not support cost estimation")
+	}
+	account, err := substrate.FromAddress(c.client.Identity.Address())
+	if err != nil {
+		return tfchainclient.Cost{}, err
+	}
+
+	return tfchainclient.EstimateContractCost(sub.Substrate, account, nodeID, res, opts)
+}
+
 func (c *tfgridClient) GetNodeDomain(ctx context.Context, nodeID uint32) (string, error) {
 	nodeClient, err := c.GetNodeClient(nodeID)
 	if err != nil {
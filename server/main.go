@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/LeeSmet/go-jsonrpc"
@@ -15,6 +16,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 	atomicswap "github.com/threefoldtech/web3_proxy/server/pkg/atomic_swap"
+	"github.com/threefoldtech/web3_proxy/server/pkg/bridge"
 	"github.com/threefoldtech/web3_proxy/server/pkg/btc"
 	"github.com/threefoldtech/web3_proxy/server/pkg/eth"
 	"github.com/threefoldtech/web3_proxy/server/pkg/explorer"
@@ -43,7 +45,7 @@ const (
 func main() {
 	var enableIpfs, debug bool
 	var port, ipfsPort uint64
-	var sftpConfigDir string
+	var sftpConfigDir, dataDir string
 
 	flag.Uint64Var(&port, "port", 8080, "RPC Port to listen on")
 	flag.Uint64Var(&ipfsPort, "ipfs-port", 4001, "IPFS Port to listen on")
@@ -51,6 +53,7 @@ func main() {
 	flag.BoolVar(&enableIpfs, "ipfs", false, "Enable IPFS")
 	flag.BoolVar(&debug, "debug", false, "sets debug level log output")
 	flag.StringVar(&sftpConfigDir, "sftp-config-dir", "", "directory that includes sftpgo config file and will host sftpgo generated files")
+	flag.StringVar(&dataDir, "data-dir", "", "directory to persist local indexes and bridge transfers in, defaults to a directory in the user cache directory")
 
 	flag.Parse()
 
@@ -61,9 +64,18 @@ func main() {
 		log.Debug().Msg("debug mode enabled")
 	}
 
+	if dataDir == "" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			dataDir = filepath.Join(cacheDir, "web3_proxy")
+		}
+	}
+	eth.SetHistoryDir(dataDir)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	bridge.SetDataDir(ctx, dataDir)
+
 	// Register custom error codes
 	errors := jsonrpc.NewErrors()
 	errors.Register(-1001, &pkg.ErrClientNotConnected{})
@@ -78,6 +90,7 @@ func main() {
 	rpcServer.Register("nostr", nostr.NewClient())
 	rpcServer.Register("explorer", explorer.NewClient())
 	rpcServer.Register("atomicswap", atomicswap.NewClient())
+	rpcServer.Register("bridge", bridge.NewClient())
 	s := http.Server{
 		Addr: fmt.Sprintf(":%d", port),
 	}
@@ -91,6 +104,7 @@ func main() {
 				panic(err)
 			}
 			rpcServer.Register("ipfs", ipfs.NewClient(lite))
+			eth.SetIPFSFetcher(ipfs.Fetcher(lite))
 		}()
 	}
 
@@ -0,0 +1,522 @@
+package bridge
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"sync"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/threefoldtech/web3_proxy/server/clients/bridge"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
+	stellargoclient "github.com/threefoldtech/web3_proxy/server/clients/stellar"
+	"github.com/threefoldtech/web3_proxy/server/pkg/eth"
+	"github.com/threefoldtech/web3_proxy/server/pkg/stellar"
+	"github.com/threefoldtech/web3_proxy/server/pkg/tfchain"
+)
+
+type (
+	// Client exposes bridge transfers between stellar, ethereum and tfchain
+	Client struct {
+	}
+
+	Transfer struct {
+		// FromChain and ToChain are stellar, ethereum or tfchain. One of them must be stellar.
+		FromChain string `json:"from_chain"`
+		ToChain   string `json:"to_chain"`
+		// Amount of TFT to send to the bridge, the bridge fee is deducted from it
+		Amount string `json:"amount"`
+		// Destination is an eth address, a twin id on tfchain or a stellar address
+		Destination string `json:"destination"`
+	}
+
+	EstimateFee struct {
+		FromChain string `json:"from_chain"`
+		ToChain   string `json:"to_chain"`
+		Amount    string `json:"amount"`
+		// Destination is only needed to estimate the gas of withdrawals from ethereum
+		Destination string `json:"destination"`
+	}
+
+	// Fee of a bridge transfer
+	Fee struct {
+		// NetworkFee is the maximum fee of the transaction sending the funds to the bridge, empty if it can not be
+		// estimated
+		NetworkFee string `json:"network_fee"`
+		// NetworkFeeAsset is the asset the network fee is paid in
+		NetworkFeeAsset string `json:"network_fee_asset"`
+		// BridgeFee is the TFT the bridge deducts from the amount, empty if the bridge does not publish it
+		BridgeFee string `json:"bridge_fee"`
+		// Received is the TFT arriving on the destination chain, empty if the bridge fee is unknown
+		Received string `json:"received"`
+	}
+)
+
+var (
+	ErrNoStellarClient = errors.New("no stellar client loaded")
+	ErrNoEthClient     = errors.New("no eth client loaded")
+	ErrNoTfchainClient = errors.New("no tfchain client loaded")
+
+	trackerLock sync.Mutex
+	// tracker of the transfers of all connections, transfers are kept in memory till SetDataDir is called
+	tracker *bridge.Tracker
+	// trackerCtx bounds following transfers in the background, so it must outlive the requests starting them
+	trackerCtx = context.Background()
+)
+
+// NewClient creates a new Client ready for use
+func NewClient() *Client {
+	return &Client{}
+}
+
+// SetDataDir loads the transfers persisted in dir and resumes following the transfers which are not done. Transfers
+// are followed till ctx is cancelled.
+func SetDataDir(ctx context.Context, dir string) {
+	tr, err := bridge.NewTracker(dir)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load bridge transfers, new transfers are not persisted")
+		tr, _ = bridge.NewTracker("")
+	}
+
+	trackerLock.Lock()
+	tracker = tr
+	trackerCtx = ctx
+	trackerLock.Unlock()
+
+	tr.Resume(ctx, resumeWatcher)
+}
+
+// getTracker returns the shared tracker and the context to follow transfers with
+func getTracker() (*bridge.Tracker, context.Context) {
+	trackerLock.Lock()
+	defer trackerLock.Unlock()
+
+	if tracker == nil {
+		tracker, _ = bridge.NewTracker("")
+	}
+	return tracker, trackerCtx
+}
+
+// resumeWatcher reconnects to the chains of a transfer loaded from disk
+func resumeWatcher(t bridge.Transfer) (bridge.Watcher, error) {
+	clients := bridge.Clients{Stellar: stellargoclient.NewClient(t.StellarNetwork)}
+
+	if t.EthUrl != "" {
+		cl, err := ethclient.Dial(t.EthUrl)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to connect to %s", t.EthUrl)
+		}
+		clients.Eth = cl
+	}
+	if t.TfchainNetwork != "" {
+		cl, err := tfchain.Connect(t.TfchainNetwork)
+		if err != nil {
+			clients.Close()
+			return nil, errors.Wrapf(err, "failed to connect to tfchain %s", t.TfchainNetwork)
+		}
+		clients.Tfchain = cl
+	}
+
+	w, err := bridge.NewWatcher(t, clients)
+	if err != nil {
+		clients.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Transfer TFT from one chain to another through a bridge, sending it with the client of the source chain loaded on
+// this connection. The transfer is followed in the background till the funds arrived, and can be looked up with
+// Status after a restart. The transfer is saved before the funds are sent, and once they are sent it is returned
+// without error. The transfer is followed with its own clients, as the clients of the connection are closed with it.
+func (c *Client) Transfer(ctx context.Context, conState jsonrpc.State, args Transfer) (bridge.Transfer, error) {
+	from, to, err := direction(args.FromChain, args.ToChain)
+	if err != nil {
+		return bridge.Transfer{}, err
+	}
+	if _, err := amount.ParseInt64(args.Amount); err != nil {
+		return bridge.Transfer{}, errors.Wrapf(err, "invalid amount %s", args.Amount)
+	}
+
+	t := bridge.Transfer{
+		FromChain:   from,
+		ToChain:     to,
+		Amount:      args.Amount,
+		Destination: args.Destination,
+	}
+
+	var clients bridge.Clients
+	var send sendFunc
+	switch from {
+	case bridge.ChainStellar:
+		clients, send, err = prepareFromStellar(ctx, conState, &t)
+	case bridge.ChainEthereum:
+		clients, send, err = prepareFromEth(ctx, conState, &t)
+	default:
+		clients, send, err = prepareFromTfchain(conState, &t)
+	}
+	if err != nil {
+		clients.Close()
+		return bridge.Transfer{}, err
+	}
+
+	// save the transfer before the funds move, so it is not lost if the proxy stops while sending them
+	tr, trCtx := getTracker()
+	t, err = tr.Create(t)
+	if err != nil {
+		clients.Close()
+		return bridge.Transfer{}, err
+	}
+
+	if t.SubmitTx, err = send(); err != nil {
+		clients.Close()
+		tr.Fail(t, err)
+		return bridge.Transfer{}, err
+	}
+
+	// the funds moved, from here on the transfer is returned so it can be looked up and resumed
+	w, err := bridge.NewWatcher(t, clients)
+	if err != nil {
+		clients.Close()
+		return tr.Stall(t, err), nil
+	}
+	return tr.Start(trCtx, t, w), nil
+}
+
+// Status of a transfer sent from one of the accounts loaded on this connection
+func (c *Client) Status(ctx context.Context, conState jsonrpc.State, id string) (bridge.Transfer, error) {
+	tr, _ := getTracker()
+	t, err := tr.Get(id)
+	if err != nil {
+		return bridge.Transfer{}, err
+	}
+	if !sentFrom(t, loadedAddresses(conState)) {
+		return bridge.Transfer{}, errors.Wrap(bridge.ErrTransferNotFound, id)
+	}
+	return t, nil
+}
+
+// List the transfers sent from the accounts loaded on this connection, most recent first
+func (c *Client) List(ctx context.Context, conState jsonrpc.State) ([]bridge.Transfer, error) {
+	tr, _ := getTracker()
+	addresses := loadedAddresses(conState)
+
+	transfers := []bridge.Transfer{}
+	for _, t := range tr.List() {
+		if sentFrom(t, addresses) {
+			transfers = append(transfers, t)
+		}
+	}
+	return transfers, nil
+}
+
+// Resume following a transfer which stalled because the bridge did not progress in time, the transfer must be sent
+// from one of the accounts loaded on this connection
+func (c *Client) Resume(ctx context.Context, conState jsonrpc.State, id string) (bridge.Transfer, error) {
+	if _, err := c.Status(ctx, conState, id); err != nil {
+		return bridge.Transfer{}, err
+	}
+
+	tr, trCtx := getTracker()
+	return tr.ResumeTransfer(trCtx, id, resumeWatcher)
+}
+
+// loadedAddresses returns the addresses of the stellar, eth and tfchain accounts loaded on a connection
+func loadedAddresses(conState jsonrpc.State) []string {
+	addresses := []string{}
+	if state := stellar.State(conState); state.Client != nil && state.Client.HasKeyPair() {
+		addresses = append(addresses, state.Client.Address())
+	}
+	if state := eth.State(conState); state.Client != nil && state.Client.Address != (common.Address{}) {
+		addresses = append(addresses, state.Client.Address.Hex())
+	}
+	if state := tfchain.State(conState); state.Identity() != nil {
+		addresses = append(addresses, state.Identity().Address())
+	}
+	return addresses
+}
+
+// sentFrom checks if a transfer was sent from one of the addresses, which are formatted as the source of transfers
+func sentFrom(t bridge.Transfer, addresses []string) bool {
+	for _, address := range addresses {
+		if t.Source == address {
+			return true
+		}
+	}
+	return false
+}
+
+// EstimateFee estimates the fees of a transfer, using the client of the source chain loaded on this connection
+func (c *Client) EstimateFee(ctx context.Context, conState jsonrpc.State, args EstimateFee) (Fee, error) {
+	from, to, err := direction(args.FromChain, args.ToChain)
+	if err != nil {
+		return Fee{}, err
+	}
+
+	fee := Fee{}
+	switch from {
+	case bridge.ChainStellar:
+		state := stellar.State(conState)
+		if state.Client == nil {
+			return Fee{}, ErrNoStellarClient
+		}
+		// bridge transfers are a single payment
+		fee.NetworkFee = amount.StringFromInt64(stellargoclient.BaseFee)
+		fee.NetworkFeeAsset = "XLM"
+
+		if to == bridge.ChainTfchain {
+			client, err := tfchainFor(conState, state.Client.Network())
+			if err != nil {
+				return Fee{}, err
+			}
+			if client != tfchain.State(conState).Substrate() {
+				defer client.Close()
+			}
+			if fee.BridgeFee, err = bridge.TfchainDepositFee(client); err != nil {
+				return Fee{}, err
+			}
+		}
+	case bridge.ChainEthereum:
+		state := eth.State(conState)
+		if state.Client == nil {
+			return Fee{}, ErrNoEthClient
+		}
+		wei, err := state.Client.EstimateBridgeToStellarFee(ctx, args.Destination, args.Amount)
+		if err != nil {
+			return Fee{}, err
+		}
+		fee.NetworkFee = goethclient.WeiToString(wei)
+		fee.NetworkFeeAsset = state.Client.Chain.NativeCurrency
+	default:
+		state := tfchain.State(conState)
+		if state.Substrate() == nil {
+			return Fee{}, ErrNoTfchainClient
+		}
+		fee.NetworkFeeAsset = "TFT"
+		if fee.BridgeFee, err = bridge.TfchainWithdrawFee(state.Substrate()); err != nil {
+			return Fee{}, err
+		}
+	}
+
+	if fee.BridgeFee != "" {
+		if fee.Received, err = bridge.Received(args.Amount, fee.BridgeFee); err != nil {
+			return Fee{}, err
+		}
+	}
+
+	return fee, nil
+}
+
+// direction parses the chains of a transfer and checks they are connected by a bridge
+func direction(fromChain, toChain string) (string, string, error) {
+	from, err := bridge.ParseChain(fromChain)
+	if err != nil {
+		return "", "", err
+	}
+	to, err := bridge.ParseChain(toChain)
+	if err != nil {
+		return "", "", err
+	}
+	return from, to, bridge.CheckDirection(from, to)
+}
+
+// sendFunc sends the funds of a prepared transfer to the bridge, returning the hash of the transaction if the chain
+// returns it
+type sendFunc func() (string, error)
+
+// prepareFromStellar prepares depositing the amount on the stellar bridge account to the destination chain. The
+// clients to follow the transfer with are returned, also on failure so they can be closed.
+func prepareFromStellar(ctx context.Context, conState jsonrpc.State, t *bridge.Transfer) (bridge.Clients, sendFunc, error) {
+	state := stellar.State(conState)
+	if state.Client == nil {
+		return bridge.Clients{}, nil, ErrNoStellarClient
+	}
+	client := state.Client
+	t.StellarNetwork = client.Network()
+	t.Source = client.Address()
+
+	bridgeAddress, err := client.GetBridgeAddress(bridge.StellarBridge(t.ToChain))
+	if err != nil {
+		return bridge.Clients{}, nil, err
+	}
+	// take the cursor before depositing, so the deposit is not missed
+	if t.StellarCursor, err = client.PaymentsCursor(bridgeAddress); err != nil {
+		return bridge.Clients{}, nil, err
+	}
+
+	clients := bridge.Clients{Stellar: stellargoclient.NewClient(t.StellarNetwork)}
+	if t.ToChain == bridge.ChainEthereum {
+		if !common.IsHexAddress(t.Destination) {
+			return clients, nil, errors.Errorf("invalid eth address %s", t.Destination)
+		}
+		if clients.Eth, err = ethFor(ctx, conState, t); err != nil {
+			return clients, nil, err
+		}
+		if t.Memo, err = stellargoclient.EthBridgeMemo(t.Destination); err != nil {
+			return clients, nil, err
+		}
+		destination, units := t.Destination, t.Amount
+		return clients, func() (string, error) {
+			return client.TransferToEthBridge(destination, units)
+		}, nil
+	}
+
+	twinID, err := strconv.ParseUint(t.Destination, 10, 32)
+	if err != nil {
+		return clients, nil, errors.Errorf("invalid twin id %s", t.Destination)
+	}
+	if t.TfchainNetwork, err = bridge.TfchainNetwork(t.StellarNetwork); err != nil {
+		return clients, nil, err
+	}
+	if clients.Tfchain, err = tfchain.Connect(t.TfchainNetwork); err != nil {
+		return clients, nil, errors.Wrapf(err, "failed to connect to tfchain %s", t.TfchainNetwork)
+	}
+	if t.TfchainBlock, err = clients.Tfchain.GetCurrentHeight(); err != nil {
+		return clients, nil, errors.Wrap(err, "failed to get tfchain height")
+	}
+	t.Memo = stellargoclient.TfchainBridgeMemo(uint32(twinID))
+	units := t.Amount
+	return clients, func() (string, error) {
+		return client.TransferToTfchainBridge(units, uint32(twinID))
+	}, nil
+}
+
+// prepareFromEth prepares withdrawing the amount to stellar with the eth client of the connection. The clients to
+// follow the transfer with are returned, also on failure so they can be closed.
+func prepareFromEth(ctx context.Context, conState jsonrpc.State, t *bridge.Transfer) (bridge.Clients, sendFunc, error) {
+	state := eth.State(conState)
+	if state.Client == nil {
+		return bridge.Clients{}, nil, ErrNoEthClient
+	}
+	client := state.Client
+	if client.Chain.TftBridge == (common.Address{}) {
+		return bridge.Clients{}, nil, errors.Errorf("no TFT bridge on chain %s", client.Chain.Name)
+	}
+	if _, err := keypair.ParseAddress(t.Destination); err != nil {
+		return bridge.Clients{}, nil, errors.Errorf("invalid stellar address %s", t.Destination)
+	}
+
+	t.StellarNetwork = bridge.StellarNetworkOfEth(client.Chain)
+	t.Source = client.Address.Hex()
+	t.EthUrl = client.Url
+	t.EthToken = client.Chain.TftBridge.Hex()
+
+	clients := bridge.Clients{Stellar: stellargoclient.NewClient(t.StellarNetwork)}
+	cursor, err := payoutCursor(clients.Stellar, t.FromChain)
+	if err != nil {
+		return clients, nil, err
+	}
+	t.StellarCursor = cursor
+
+	if clients.Eth, err = ethclient.DialContext(ctx, t.EthUrl); err != nil {
+		return clients, nil, errors.Wrapf(err, "failed to connect to %s", t.EthUrl)
+	}
+
+	destination, units := t.Destination, t.Amount
+	return clients, func() (string, error) {
+		return client.BridgeToStellar(ctx, destination, units)
+	}, nil
+}
+
+// prepareFromTfchain prepares swapping the amount to stellar with the tfchain client of the connection. The clients
+// to follow the transfer with are returned, also on failure so they can be closed. Tfchain does not return the hash of
+// the swap.
+func prepareFromTfchain(conState jsonrpc.State, t *bridge.Transfer) (bridge.Clients, sendFunc, error) {
+	state := tfchain.State(conState)
+	client := state.Substrate()
+	if client == nil {
+		return bridge.Clients{}, nil, ErrNoTfchainClient
+	}
+	if _, err := keypair.ParseAddress(t.Destination); err != nil {
+		return bridge.Clients{}, nil, errors.Errorf("invalid stellar address %s", t.Destination)
+	}
+	units, err := amount.ParseInt64(t.Amount)
+	if err != nil {
+		return bridge.Clients{}, nil, errors.Wrapf(err, "invalid amount %s", t.Amount)
+	}
+
+	t.TfchainNetwork = state.Network()
+	if t.StellarNetwork, err = bridge.StellarNetworkOfTfchain(t.TfchainNetwork); err != nil {
+		return bridge.Clients{}, nil, err
+	}
+	t.Source = state.Identity().Address()
+
+	clients := bridge.Clients{Stellar: stellargoclient.NewClient(t.StellarNetwork)}
+	if t.StellarCursor, err = payoutCursor(clients.Stellar, t.FromChain); err != nil {
+		return clients, nil, err
+	}
+	if clients.Tfchain, err = tfchain.Connect(t.TfchainNetwork); err != nil {
+		return clients, nil, errors.Wrapf(err, "failed to connect to tfchain %s", t.TfchainNetwork)
+	}
+	if t.TfchainBlock, err = clients.Tfchain.GetCurrentHeight(); err != nil {
+		return clients, nil, errors.Wrap(err, "failed to get tfchain height")
+	}
+
+	identity, destination := state.Identity(), t.Destination
+	return clients, func() (string, error) {
+		return "", client.SwapToStellar(identity, destination, *big.NewInt(units))
+	}, nil
+}
+
+// payoutCursor returns the cursor of the payments of the stellar bridge account paying out transfers from a chain
+func payoutCursor(client *stellargoclient.Client, chain string) (string, error) {
+	bridgeAddress, err := client.GetBridgeAddress(bridge.StellarBridge(chain))
+	if err != nil {
+		return "", err
+	}
+	return client.PaymentsCursor(bridgeAddress)
+}
+
+// ethFor connects to the eth chain to follow a mint on, the chain of the connection if it is loaded for the chain
+// bridged to the stellar network of the transfer. The eth fields of the transfer are filled in.
+func ethFor(ctx context.Context, conState jsonrpc.State, t *bridge.Transfer) (*ethclient.Client, error) {
+	state := eth.State(conState)
+	if state.Client != nil && bridge.StellarNetworkOfEth(state.Client.Chain) == t.StellarNetwork &&
+		state.Client.Chain.TftBridge != (common.Address{}) {
+		t.EthUrl = state.Client.Url
+		t.EthToken = state.Client.Chain.TftBridge.Hex()
+	} else {
+		chain, err := bridge.EthChain(t.StellarNetwork)
+		if err != nil {
+			return nil, err
+		}
+		t.EthUrl = chain.RPCURL
+		t.EthToken = chain.TftBridge.Hex()
+	}
+
+	client, err := ethclient.DialContext(ctx, t.EthUrl)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", t.EthUrl)
+	}
+
+	block, err := client.BlockNumber(ctx)
+	if err != nil {
+		client.Close()
+		return nil, errors.Wrap(err, "failed to get eth height")
+	}
+	t.EthBlock = block
+
+	return client, nil
+}
+
+// tfchainFor returns the tfchain client bridged to a stellar network, the one of the connection if it is loaded for
+// that network. Clients which are not the one of the connection must be closed by the caller.
+func tfchainFor(conState jsonrpc.State, stellarNetwork string) (*substrate.Substrate, error) {
+	network, err := bridge.TfchainNetwork(stellarNetwork)
+	if err != nil {
+		return nil, err
+	}
+
+	state := tfchain.State(conState)
+	if state.Substrate() != nil && state.Network() == network {
+		return state.Substrate(), nil
+	}
+	return tfchain.Connect(network)
+}
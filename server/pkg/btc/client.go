@@ -9,6 +9,7 @@ import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	btcRpcClient "github.com/btcsuite/btcd/rpcclient"
 	"github.com/rs/zerolog/log"
+	btcclient "github.com/threefoldtech/web3_proxy/server/clients/btc"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 )
 
@@ -24,6 +25,7 @@ type (
 	// state managed by nostr client
 	btcState struct {
 		client *btcRpcClient.Client
+		wallet *btcclient.Wallet
 	}
 
 	Load struct {
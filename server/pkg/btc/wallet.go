@@ -0,0 +1,454 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	btcclient "github.com/threefoldtech/web3_proxy/server/clients/btc"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+// confirmation target of the fee rate if no fee rate is given
+const defaultConfTarget = 6
+
+type (
+	LoadWallet struct {
+		Mnemonic   string `json:"mnemonic"`
+		Passphrase string `json:"passphrase"`
+		// Network is mainnet, testnet, signet or regtest
+		Network string `json:"network"`
+	}
+
+	WalletAddress struct {
+		// AddressType is p2wpkh or p2tr, defaults to p2wpkh
+		AddressType string `json:"address_type"`
+		Change      bool   `json:"change"`
+	}
+
+	WalletUTXO struct {
+		TxID        string         `json:"txid"`
+		Vout        uint32         `json:"vout"`
+		Address     string         `json:"address"`
+		AddressType string         `json:"address_type"`
+		Amount      btcutil.Amount `json:"amount"`
+		Height      int64          `json:"height"`
+	}
+
+	FeeOptions struct {
+		// FeeRate in satoshi per vbyte, estimated by the node for ConfTarget if 0
+		FeeRate btcutil.Amount `json:"fee_rate"`
+		// ConfTarget is the number of blocks to confirm in, defaults to 6
+		ConfTarget int64 `json:"conf_target"`
+		// ChangeType is the address type of the change, defaults to p2wpkh
+		ChangeType string `json:"change_type"`
+	}
+
+	WalletSend struct {
+		Address string         `json:"address"`
+		Amount  btcutil.Amount `json:"amount"`
+		FeeOptions
+	}
+
+	PsbtInput struct {
+		TxID string `json:"txid"`
+		Vout uint32 `json:"vout"`
+	}
+
+	PsbtOutput struct {
+		Address string         `json:"address"`
+		Amount  btcutil.Amount `json:"amount"`
+	}
+
+	CreatePsbt struct {
+		// Inputs are always spent, coins of the wallet are added as needed. Inputs of other wallets must be segwit.
+		Inputs  []PsbtInput  `json:"inputs"`
+		Outputs []PsbtOutput `json:"outputs"`
+		FeeOptions
+	}
+
+	SignPsbtResult struct {
+		Psbt         string `json:"psbt"`
+		SignedInputs int    `json:"signed_inputs"`
+	}
+
+	FinalizePsbt struct {
+		Psbt string `json:"psbt"`
+		// Broadcast the transaction if the psbt is complete
+		Broadcast bool `json:"broadcast"`
+	}
+
+	FinalizePsbtResult struct {
+		Psbt     string `json:"psbt"`
+		Complete bool   `json:"complete"`
+		// Tx is the hex encoded signed transaction, empty if the psbt is not complete
+		Tx   string `json:"tx"`
+		TxID string `json:"txid"`
+	}
+)
+
+func networkParams(network string) (*chaincfg.Params, error) {
+	switch network {
+	case "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &chaincfg.SigNetParams, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	}
+	return nil, errors.Errorf("unknown network %s, it should be mainnet, testnet, signet or regtest", network)
+}
+
+func addressType(t string) btcclient.AddressType {
+	if t == "" {
+		return btcclient.AddressP2WPKH
+	}
+	return btcclient.AddressType(t)
+}
+
+// walletState returns the state of a connection with a wallet, connected to a node if withNode is set
+func walletState(conState jsonrpc.State, withNode bool) (*btcState, error) {
+	state := State(conState)
+	if state.wallet == nil || (withNode && state.client == nil) {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+	return state, nil
+}
+
+func (s *btcState) feeRate(opts FeeOptions) (btcutil.Amount, error) {
+	if opts.FeeRate > 0 {
+		return opts.FeeRate, nil
+	}
+	confTarget := opts.ConfTarget
+	if confTarget == 0 {
+		confTarget = defaultConfTarget
+	}
+	return btcclient.EstimateFeeRate(s.client, confTarget)
+}
+
+func (s *btcState) decodeAddress(address string) (btcutil.Address, error) {
+	params := s.wallet.Params()
+	decoded, err := btcutil.DecodeAddress(address, params)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(params) {
+		return nil, errors.Errorf("address %s is not for network %s", address, params.Name)
+	}
+	return decoded, nil
+}
+
+// createPsbt creates a psbt funded by the coins of the wallet
+func (s *btcState) createPsbt(inputs []PsbtInput, outputs []PsbtOutput, opts FeeOptions) (*psbt.Packet, error) {
+	outs := make([]*wire.TxOut, 0, len(outputs))
+	for _, output := range outputs {
+		address, err := s.decodeAddress(output.Address)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := txscript.PayToAddrScript(address)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, wire.NewTxOut(int64(output.Amount), pkScript))
+	}
+
+	feeRate, err := s.feeRate(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := s.wallet.ListUnspent(s.client)
+	if err != nil {
+		return nil, err
+	}
+
+	outpoints := make([]wire.OutPoint, 0, len(inputs))
+	for _, input := range inputs {
+		hash, err := chainhash.NewHashFromStr(input.TxID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid txid %s", input.TxID)
+		}
+		outpoints = append(outpoints, *wire.NewOutPoint(hash, input.Vout))
+	}
+	required, err := btcclient.LookupUTXOs(s.client, outpoints, available)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.wallet.CreatePsbt(required, available, outs, feeRate, addressType(opts.ChangeType))
+}
+
+func decodePsbt(encoded string) (*psbt.Packet, error) {
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader([]byte(encoded)), true)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid psbt")
+	}
+	return packet, nil
+}
+
+// LoadWallet loads a wallet from a bip39 mnemonic, keys are derived and used by the proxy instead of the node
+func (c *Client) LoadWallet(ctx context.Context, conState jsonrpc.State, args LoadWallet) error {
+	log.Debug().Msgf("BTC: loading wallet for network %s", args.Network)
+
+	params, err := networkParams(args.Network)
+	if err != nil {
+		return err
+	}
+
+	wallet, err := btcclient.NewWallet(args.Mnemonic, args.Passphrase, params)
+	if err != nil {
+		return err
+	}
+
+	state := State(conState)
+	state.wallet = wallet
+
+	return nil
+}
+
+// WalletAddress returns the next unused receive or change address of the loaded wallet
+func (c *Client) WalletAddress(ctx context.Context, conState jsonrpc.State, args WalletAddress) (string, error) {
+	log.Debug().Msgf("BTC: getting new %s wallet address", args.AddressType)
+
+	state, err := walletState(conState, false)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := state.wallet.NewAddress(addressType(args.AddressType), args.Change)
+	if err != nil {
+		return "", err
+	}
+
+	return key.Address.EncodeAddress(), nil
+}
+
+// WalletDescriptors returns the public descriptors of the receive and change addresses of the loaded wallet
+func (c *Client) WalletDescriptors(ctx context.Context, conState jsonrpc.State) ([]string, error) {
+	log.Debug().Msg("BTC: getting wallet descriptors")
+
+	state, err := walletState(conState, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var descriptors []string
+	for _, t := range btcclient.AddressTypes {
+		for _, change := range []bool{false, true} {
+			descriptor, err := state.wallet.Descriptor(t, change)
+			if err != nil {
+				return nil, err
+			}
+			descriptors = append(descriptors, descriptor)
+		}
+	}
+
+	return descriptors, nil
+}
+
+// WalletImportDescriptors imports the public descriptors of the loaded wallet in the watch-only descriptor wallet of
+// the node, with rescan the node looks for past transactions of the wallet
+func (c *Client) WalletImportDescriptors(ctx context.Context, conState jsonrpc.State, rescan bool) error {
+	log.Debug().Msgf("BTC: importing wallet descriptors with rescan %t", rescan)
+
+	state, err := walletState(conState, true)
+	if err != nil {
+		return err
+	}
+
+	return state.wallet.ImportDescriptors(state.client, rescan)
+}
+
+// WalletListUnspent lists the unspent outputs of the loaded wallet, found by scanning the utxo set of the node and
+// updated with the unconfirmed transactions the wallet broadcast
+func (c *Client) WalletListUnspent(ctx context.Context, conState jsonrpc.State) ([]WalletUTXO, error) {
+	log.Debug().Msg("BTC: listing wallet unspent outputs")
+
+	state, err := walletState(conState, true)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := state.wallet.ListUnspent(state.client)
+	if err != nil {
+		return nil, err
+	}
+
+	unspent := make([]WalletUTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		unspent = append(unspent, WalletUTXO{
+			TxID:        utxo.OutPoint.Hash.String(),
+			Vout:        utxo.OutPoint.Index,
+			Address:     utxo.Key.Address.EncodeAddress(),
+			AddressType: string(utxo.Key.Type),
+			Amount:      utxo.Amount,
+			Height:      utxo.Height,
+		})
+	}
+
+	return unspent, nil
+}
+
+// WalletBalance returns the balance of the loaded wallet in satoshi, including the unconfirmed transactions it
+// broadcast
+func (c *Client) WalletBalance(ctx context.Context, conState jsonrpc.State) (btcutil.Amount, error) {
+	log.Debug().Msg("BTC: getting wallet balance")
+
+	state, err := walletState(conState, true)
+	if err != nil {
+		return 0, err
+	}
+
+	utxos, err := state.wallet.ListUnspent(state.client)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance btcutil.Amount
+	for _, utxo := range utxos {
+		balance += utxo.Amount
+	}
+
+	return balance, nil
+}
+
+// WalletSend sends an amount in satoshi from the loaded wallet to an address. The transaction is signed by the proxy
+// and broadcast through the node, its id is returned.
+func (c *Client) WalletSend(ctx context.Context, conState jsonrpc.State, args WalletSend) (string, error) {
+	log.Debug().Msgf("BTC: sending %d from wallet to address %s", args.Amount, args.Address)
+
+	state, err := walletState(conState, true)
+	if err != nil {
+		return "", err
+	}
+
+	packet, err := state.createPsbt(nil, []PsbtOutput{{Address: args.Address, Amount: args.Amount}}, args.FeeOptions)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := state.wallet.SignPsbt(packet); err != nil {
+		return "", err
+	}
+
+	tx, err := btcclient.FinalizePsbt(packet)
+	if err != nil {
+		return "", err
+	}
+	if tx == nil {
+		return "", errors.New("failed to sign all inputs")
+	}
+
+	hash, err := state.client.SendRawTransaction(tx, false)
+	if err != nil {
+		return "", err
+	}
+	state.wallet.TrackBroadcast(packet)
+
+	return hash.String(), nil
+}
+
+// CreatePsbt creates an unsigned psbt paying outputs, funded by the coins of the loaded wallet and inputs. The base64
+// encoded psbt is returned. The change address is only used up once the psbt is broadcast with FinalizePsbt.
+func (c *Client) CreatePsbt(ctx context.Context, conState jsonrpc.State, args CreatePsbt) (string, error) {
+	log.Debug().Msgf("BTC: creating psbt with %d inputs and %d outputs", len(args.Inputs), len(args.Outputs))
+
+	state, err := walletState(conState, true)
+	if err != nil {
+		return "", err
+	}
+
+	packet, err := state.createPsbt(args.Inputs, args.Outputs, args.FeeOptions)
+	if err != nil {
+		return "", err
+	}
+
+	return packet.B64Encode()
+}
+
+// SignPsbt signs the inputs of a base64 encoded psbt which belong to the loaded wallet
+func (c *Client) SignPsbt(ctx context.Context, conState jsonrpc.State, encoded string) (SignPsbtResult, error) {
+	log.Debug().Msg("BTC: signing psbt")
+
+	state, err := walletState(conState, false)
+	if err != nil {
+		return SignPsbtResult{}, err
+	}
+
+	packet, err := decodePsbt(encoded)
+	if err != nil {
+		return SignPsbtResult{}, err
+	}
+
+	signed, err := state.wallet.SignPsbt(packet)
+	if err != nil {
+		return SignPsbtResult{}, err
+	}
+
+	encoded, err = packet.B64Encode()
+	if err != nil {
+		return SignPsbtResult{}, err
+	}
+
+	return SignPsbtResult{Psbt: encoded, SignedInputs: signed}, nil
+}
+
+// FinalizePsbt finalizes the signed inputs of a base64 encoded psbt. Once all inputs are final the signed transaction
+// is returned and, if asked, broadcast through the node.
+func (c *Client) FinalizePsbt(ctx context.Context, conState jsonrpc.State, args FinalizePsbt) (FinalizePsbtResult, error) {
+	log.Debug().Msgf("BTC: finalizing psbt with broadcast %t", args.Broadcast)
+
+	state := State(conState)
+	if args.Broadcast && state.client == nil {
+		return FinalizePsbtResult{}, pkg.ErrClientNotConnected{}
+	}
+
+	packet, err := decodePsbt(args.Psbt)
+	if err != nil {
+		return FinalizePsbtResult{}, err
+	}
+
+	tx, err := btcclient.FinalizePsbt(packet)
+	if err != nil {
+		return FinalizePsbtResult{}, err
+	}
+
+	var result FinalizePsbtResult
+	if result.Psbt, err = packet.B64Encode(); err != nil {
+		return FinalizePsbtResult{}, err
+	}
+	if tx == nil {
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return FinalizePsbtResult{}, err
+	}
+	result.Complete = true
+	result.Tx = hex.EncodeToString(buf.Bytes())
+	result.TxID = tx.TxHash().String()
+
+	if args.Broadcast {
+		if _, err := state.client.SendRawTransaction(tx, false); err != nil {
+			return FinalizePsbtResult{}, err
+		}
+		if state.wallet != nil {
+			state.wallet.TrackBroadcast(packet)
+		}
+	}
+
+	return result, nil
+}
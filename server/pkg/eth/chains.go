@@ -0,0 +1,48 @@
+package eth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/pkg/errors"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+// RegisterChain adds a chain to the chains known by this connection, or overrides a known chain with the same name.
+// The chain can then be selected in Load.
+func (c *Client) RegisterChain(ctx context.Context, conState jsonrpc.State, args goethclient.Chain) error {
+	if args.Name == "" {
+		return errors.New("chain name is required")
+	}
+	if args.ChainID == 0 {
+		return errors.New("chain id is required")
+	}
+	if args.NativeCurrency == "" {
+		args.NativeCurrency = "ETH"
+	}
+	args.Name = strings.ToLower(args.Name)
+
+	state := State(conState)
+	state.chains[args.Name] = args
+
+	return nil
+}
+
+// ListChains returns the chains known by this connection
+func (c *Client) ListChains(ctx context.Context, conState jsonrpc.State) ([]goethclient.Chain, error) {
+	state := State(conState)
+
+	return goethclient.ListChains(state.chains), nil
+}
+
+// Chain returns the chain the client is loaded for
+func (c *Client) Chain(ctx context.Context, conState jsonrpc.State) (goethclient.Chain, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return goethclient.Chain{}, pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.Chain, nil
+}
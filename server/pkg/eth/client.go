@@ -2,8 +2,11 @@ package eth
 
 import (
 	"context"
+	"fmt"
+	"math/big"
 
 	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/pkg/errors"
 	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 )
@@ -16,16 +19,68 @@ type (
 	// EthState managed by ethereum client
 	EthState struct {
 		Client *goethclient.Client
+		// chains registered on this connection, keyed by name
+		chains map[string]goethclient.Chain
 	}
 
 	Load struct {
+		// Url of the rpc endpoint, the default url of the chain is used if empty
 		Url    string `json:"url"`
 		Secret string `json:"secret"`
+		// Chain name or chain id, detected from the rpc endpoint if empty
+		Chain string `json:"chain"`
+	}
+
+	LoadMnemonic struct {
+		// Url of the rpc endpoint, the default url of the chain is used if empty
+		Url string `json:"url"`
+		// Mnemonic of the wallet, a new one is generated if empty
+		Mnemonic string `json:"mnemonic"`
+		// Path accounts are derived at, followed by the account index. Defaults to m/44'/60'/0'/0
+		Path string `json:"path"`
+		// Chain name or chain id, detected from the rpc endpoint if empty
+		Chain string `json:"chain"`
+	}
+
+	// Account of the wallet of the loaded client
+	Account struct {
+		Address string `json:"address"`
+		// Path the key is derived at, empty for imported keys
+		Path string `json:"path"`
+	}
+
+	ImportKeystore struct {
+		// Keystore is an encrypted V3 JSON keystore
+		Keystore string `json:"keystore"`
+		Password string `json:"password"`
+	}
+
+	ExportKeystore struct {
+		// Address of the account to export, the loaded account if empty
+		Address  string `json:"address"`
+		Password string `json:"password"`
 	}
 
 	Transfer struct {
 		Amount      string `json:"amount"`
 		Destination string `json:"destination"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	// Fees in wei, as decimal strings
+	Fees struct {
+		// BaseFee of the latest block, empty if the chain does not support EIP-1559
+		BaseFee              string `json:"base_fee"`
+		MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas"`
+		MaxFeePerGas         string `json:"max_fee_per_gas"`
+		GasPrice             string `json:"gas_price"`
+	}
+
+	// FeeCaps in wei, as decimal strings. Empty values are not capped.
+	FeeCaps struct {
+		MaxFeePerGas         string `json:"max_fee_per_gas"`
+		MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas"`
 	}
 )
 
@@ -45,6 +100,7 @@ func State(conState jsonrpc.State) *EthState {
 	if !exists {
 		ns := &EthState{
 			Client: nil,
+			chains: map[string]goethclient.Chain{},
 		}
 		conState[EthID] = ns
 		return ns
@@ -58,22 +114,153 @@ func State(conState jsonrpc.State) *EthState {
 }
 
 // Close implements jsonrpc.Closer
-func (s *EthState) Close() {}
+func (s *EthState) Close() {
+	if s.Client != nil {
+		s.Client.Close()
+	}
+}
 
-// Load a client, connecting to the rpc endpoint at the given URL and loading a keypair from the given secret
+// Load a client, connecting to the rpc endpoint at the given URL and loading a keypair from the given secret. If a
+// chain is given, its contracts are used and the endpoint must serve it.
 func (c *Client) Load(ctx context.Context, conState jsonrpc.State, args Load) error {
-	cl, err := goethclient.NewClient(args.Url, args.Secret)
+	state := State(conState)
+
+	chain, err := state.chain(args.Chain)
+	if err != nil {
+		return err
+	}
+
+	cl, err := goethclient.NewClientForChain(args.Url, args.Secret, chain)
 	if err != nil {
 		return err
 	}
+	state.setClient(cl)
+
+	return nil
+}
 
+// LoadMnemonic loads a client with a BIP-44 wallet, connecting to the rpc endpoint at the given URL. The account at
+// index 0 of the derivation path is loaded, other accounts can be added with DeriveAccount.
+func (c *Client) LoadMnemonic(ctx context.Context, conState jsonrpc.State, args LoadMnemonic) error {
 	state := State(conState)
 
-	state.Client = cl
+	chain, err := state.chain(args.Chain)
+	if err != nil {
+		return err
+	}
+
+	cl, err := goethclient.NewClientFromMnemonic(args.Url, args.Mnemonic, args.Path, chain)
+	if err != nil {
+		return err
+	}
+	state.setClient(cl)
 
 	return nil
 }
 
+// chain looks up a chain by name or id, returning nil if name is empty
+func (s *EthState) chain(name string) (*goethclient.Chain, error) {
+	if name == "" {
+		return nil, nil
+	}
+	found, ok := goethclient.LookupChain(s.chains, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown chain %s", name)
+	}
+	return &found, nil
+}
+
+// setClient replaces the loaded client
+func (s *EthState) setClient(cl *goethclient.Client) {
+	if s.Client != nil {
+		s.Client.Close()
+	}
+	s.Client = cl
+}
+
+// DeriveAccount derives the account at an index of the derivation path of the loaded mnemonic and adds it to the
+// wallet
+func (c *Client) DeriveAccount(ctx context.Context, conState jsonrpc.State, index uint32) (Account, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return Account{}, pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.DeriveAccount(index)
+	if err != nil {
+		return Account{}, err
+	}
+
+	return newAccount(account), nil
+}
+
+// ListAccounts returns the accounts of the wallet, starting with the loaded account
+func (c *Client) ListAccounts(ctx context.Context, conState jsonrpc.State) ([]Account, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	accounts := []Account{}
+	for _, account := range state.Client.ListAccounts() {
+		accounts = append(accounts, newAccount(account))
+	}
+
+	return accounts, nil
+}
+
+// GetMnemonic returns the mnemonic of the wallet, empty if the client was loaded from a secret
+func (c *Client) GetMnemonic(ctx context.Context, conState jsonrpc.State) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.GetMnemonic(), nil
+}
+
+// ImportKeystore decrypts a V3 JSON keystore and adds its key to the wallet
+func (c *Client) ImportKeystore(ctx context.Context, conState jsonrpc.State, args ImportKeystore) (Account, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return Account{}, pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.ImportKeystore(args.Keystore, args.Password)
+	if err != nil {
+		return Account{}, err
+	}
+
+	return newAccount(account), nil
+}
+
+// ExportKeystore encrypts the key of an account of the wallet as a V3 JSON keystore
+func (c *Client) ExportKeystore(ctx context.Context, conState jsonrpc.State, args ExportKeystore) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.ExportKeystore(args.Address, args.Password)
+}
+
+func newAccount(account goethclient.Account) Account {
+	return Account{
+		Address: account.Address.Hex(),
+		Path:    account.Path,
+	}
+}
+
+// ownerAccount returns the account of the wallet owning tokens, or the loaded account if the owner is not in the
+// wallet, e.g. because the loaded account is an approved operator of the owner
+func ownerAccount(cl *goethclient.Client, owner string) *goethclient.Client {
+	account, err := cl.Account(owner)
+	if err != nil {
+		return cl
+	}
+	return account
+}
+
 // Balance of an address
 func (c *Client) Balance(ctx context.Context, conState jsonrpc.State, address string) (string, error) {
 	state := State(conState)
@@ -106,7 +293,12 @@ func (c *Client) Transfer(ctx context.Context, conState jsonrpc.State, args Tran
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.TransferEth(ctx, args.Amount, args.Destination)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.TransferEth(ctx, args.Amount, args.Destination)
 }
 
 // Address of the loaded client
@@ -136,3 +328,67 @@ func (c *Client) CreateAndActivateStellarAccount(ctx context.Context, conState j
 
 	return state.Client.CreateAndActivateStellarAccount(ctx, network)
 }
+
+// EstimateFees suggests the fees in wei for a new transaction, based on the base fee of the latest block and the
+// suggested priority fee, limited by the configured fee caps.
+func (c *Client) EstimateFees(ctx context.Context, conState jsonrpc.State) (Fees, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return Fees{}, pkg.ErrClientNotConnected{}
+	}
+
+	fees, err := state.Client.EstimateFees(ctx)
+	if err != nil {
+		return Fees{}, err
+	}
+
+	return Fees{
+		BaseFee:              bigIntString(fees.BaseFee),
+		MaxPriorityFeePerGas: bigIntString(fees.MaxPriorityFeePerGas),
+		MaxFeePerGas:         bigIntString(fees.MaxFeePerGas),
+		GasPrice:             bigIntString(fees.GasPrice),
+	}, nil
+}
+
+// SetFeeCaps limits the max fee and priority fee per gas (in wei) of all transactions sent by this connection.
+func (c *Client) SetFeeCaps(ctx context.Context, conState jsonrpc.State, args FeeCaps) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	maxFee, err := parseBigInt(args.MaxFeePerGas)
+	if err != nil {
+		return errors.Wrap(err, "invalid max fee per gas")
+	}
+	maxPriorityFee, err := parseBigInt(args.MaxPriorityFeePerGas)
+	if err != nil {
+		return errors.Wrap(err, "invalid max priority fee per gas")
+	}
+
+	state.Client.SetFeeCaps(goethclient.FeeCaps{
+		MaxFeePerGas:         maxFee,
+		MaxPriorityFeePerGas: maxPriorityFee,
+	})
+	return nil
+}
+
+// bigIntString formats a big int in base 10, or an empty string if it is nil
+func bigIntString(i *big.Int) string {
+	if i == nil {
+		return ""
+	}
+	return i.String()
+}
+
+// parseBigInt parses a base 10 integer, returning nil if the string is empty
+func parseBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a valid integer", s)
+	}
+	return i, nil
+}
@@ -0,0 +1,168 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+type (
+	RegisterABI struct {
+		Name string `json:"name"`
+		// ABI json of the contract
+		ABI string `json:"abi"`
+	}
+
+	CallContract struct {
+		ContractAddress string `json:"contract_address"`
+		// ABI json, or the name of a registered ABI
+		ABI    string            `json:"abi"`
+		Method string            `json:"method"`
+		Args   []json.RawMessage `json:"args"`
+	}
+
+	TransactContract struct {
+		ContractAddress string `json:"contract_address"`
+		// ABI json, or the name of a registered ABI
+		ABI    string            `json:"abi"`
+		Method string            `json:"method"`
+		Args   []json.RawMessage `json:"args"`
+		// Value to send along in wei
+		Value string `json:"value"`
+		// GasLimit of the transaction, estimated if 0
+		GasLimit uint64 `json:"gas_limit"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	DeployContract struct {
+		// ABI json, or the name of a registered ABI
+		ABI string `json:"abi"`
+		// Bytecode of the contract, hex encoded
+		Bytecode string `json:"bytecode"`
+		// Args for the constructor
+		Args []json.RawMessage `json:"args"`
+		// Value to send along in wei
+		Value string `json:"value"`
+		// GasLimit of the transaction, estimated if 0
+		GasLimit uint64 `json:"gas_limit"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	DeployedContract struct {
+		Address string `json:"address"`
+		Hash    string `json:"hash"`
+	}
+)
+
+// RegisterABI stores an ABI under a name for this connection, so it can be referenced by name in contract calls
+func (c *Client) RegisterABI(ctx context.Context, conState jsonrpc.State, args RegisterABI) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.RegisterABI(args.Name, args.ABI)
+}
+
+// ListABIs returns the names of the ABIs registered on this connection
+func (c *Client) ListABIs(ctx context.Context, conState jsonrpc.State) ([]string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.ListABIs(), nil
+}
+
+// CallContract calls a read only contract method and returns the decoded outputs
+func (c *Client) CallContract(ctx context.Context, conState jsonrpc.State, args CallContract) ([]interface{}, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	callArgs, err := decodeArgs(args.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return state.Client.CallContract(ctx, args.ContractAddress, args.ABI, args.Method, callArgs)
+}
+
+// TransactContract submits a transaction calling a state changing contract method. The transaction hash is returned.
+func (c *Client) TransactContract(ctx context.Context, conState jsonrpc.State, args TransactContract) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	callArgs, err := decodeArgs(args.Args)
+	if err != nil {
+		return "", err
+	}
+	value, err := parseBigInt(args.Value)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid value")
+	}
+
+	return account.TransactContract(ctx, args.ContractAddress, args.ABI, args.Method, callArgs, value, args.GasLimit)
+}
+
+// DeployContract submits a transaction deploying a contract. The address of the contract and the transaction hash
+// are returned.
+func (c *Client) DeployContract(ctx context.Context, conState jsonrpc.State, args DeployContract) (DeployedContract, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return DeployedContract{}, pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return DeployedContract{}, err
+	}
+
+	callArgs, err := decodeArgs(args.Args)
+	if err != nil {
+		return DeployedContract{}, err
+	}
+	value, err := parseBigInt(args.Value)
+	if err != nil {
+		return DeployedContract{}, errors.Wrap(err, "invalid value")
+	}
+
+	deployed, err := account.DeployContract(ctx, args.ABI, args.Bytecode, callArgs, value, args.GasLimit)
+	if err != nil {
+		return DeployedContract{}, err
+	}
+
+	return DeployedContract{
+		Address: deployed.Address,
+		Hash:    deployed.Hash,
+	}, nil
+}
+
+// decodeArgs decodes json contract arguments, keeping numbers as json.Number so big integers don't lose precision
+func decodeArgs(raw []json.RawMessage) ([]interface{}, error) {
+	args := make([]interface{}, 0, len(raw))
+	for i, r := range raw {
+		decoder := json.NewDecoder(bytes.NewReader(r))
+		decoder.UseNumber()
+		var arg interface{}
+		if err := decoder.Decode(&arg); err != nil {
+			return nil, errors.Wrapf(err, "invalid argument %d", i)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
@@ -0,0 +1,176 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+type (
+	Erc1155BalanceOf struct {
+		ContractAddress string `json:"contract_address"`
+		Owner           string `json:"owner"`
+		TokenID         string `json:"token_id"`
+	}
+
+	Erc1155BalanceOfBatch struct {
+		ContractAddress string `json:"contract_address"`
+		// Owners and TokenIDs are paired by index
+		Owners   []string `json:"owners"`
+		TokenIDs []string `json:"token_ids"`
+	}
+
+	Erc1155Transfer struct {
+		ContractAddress string `json:"contract_address"`
+		From            string `json:"from"`
+		To              string `json:"to"`
+		TokenID         string `json:"token_id"`
+		Amount          string `json:"amount"`
+		// Data passed to a receiving contract, hex encoded
+		Data string `json:"data"`
+	}
+
+	Erc1155BatchTransfer struct {
+		ContractAddress string `json:"contract_address"`
+		From            string `json:"from"`
+		To              string `json:"to"`
+		// TokenIDs and Amounts are paired by index
+		TokenIDs []string `json:"token_ids"`
+		Amounts  []string `json:"amounts"`
+		// Data passed to a receiving contract, hex encoded
+		Data string `json:"data"`
+	}
+)
+
+// Erc1155BalanceOf returns the amount of a token of an erc1155 contract owned by an address
+func (c *Client) Erc1155BalanceOf(ctx context.Context, conState jsonrpc.State, args Erc1155BalanceOf) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return "", err
+	}
+
+	balance, err := state.Client.Erc1155BalanceOf(ctx, args.ContractAddress, args.Owner, tokenID)
+	if err != nil {
+		return "", err
+	}
+
+	return balance.String(), nil
+}
+
+// Erc1155BalanceOfBatch returns the balances of multiple owner and token pairs
+func (c *Client) Erc1155BalanceOfBatch(ctx context.Context, conState jsonrpc.State, args Erc1155BalanceOfBatch) ([]string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	tokenIDs, err := parseUint256s(args.TokenIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := state.Client.Erc1155BalanceOfBatch(ctx, args.ContractAddress, args.Owners, tokenIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(balances))
+	for _, balance := range balances {
+		result = append(result, balance.String())
+	}
+	return result, nil
+}
+
+// Erc1155Transfer transfers an amount of a token
+func (c *Client) Erc1155Transfer(ctx context.Context, conState jsonrpc.State, args Erc1155Transfer) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account := ownerAccount(state.Client, args.From)
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return "", err
+	}
+	amount, err := parseUint256(args.Amount)
+	if err != nil {
+		return "", err
+	}
+	data, err := decodeHexData(args.Data)
+	if err != nil {
+		return "", err
+	}
+
+	return account.Erc1155Transfer(ctx, args.ContractAddress, args.From, args.To, tokenID, amount, data)
+}
+
+// Erc1155BatchTransfer transfers amounts of multiple tokens in one transaction
+func (c *Client) Erc1155BatchTransfer(ctx context.Context, conState jsonrpc.State, args Erc1155BatchTransfer) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account := ownerAccount(state.Client, args.From)
+
+	tokenIDs, err := parseUint256s(args.TokenIDs)
+	if err != nil {
+		return "", err
+	}
+	amounts, err := parseUint256s(args.Amounts)
+	if err != nil {
+		return "", err
+	}
+	data, err := decodeHexData(args.Data)
+	if err != nil {
+		return "", err
+	}
+
+	return account.Erc1155BatchTransfer(ctx, args.ContractAddress, args.From, args.To, tokenIDs, amounts, data)
+}
+
+// Erc1155SetApprovalForAll allows or disallows an operator to transfer all tokens of the loaded address
+func (c *Client) Erc1155SetApprovalForAll(ctx context.Context, conState jsonrpc.State, args NftSetApprovalForAll) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.Erc1155SetApprovalForAll(ctx, args.ContractAddress, args.Operator, args.Approved)
+}
+
+// Erc1155IsApprovedForAll returns whether an operator can transfer all tokens of the owner
+func (c *Client) Erc1155IsApprovedForAll(ctx context.Context, conState jsonrpc.State, args NftIsApprovedForAll) (bool, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return false, pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.Erc1155IsApprovedForAll(ctx, args.ContractAddress, args.Owner, args.Operator)
+}
+
+func parseUint256s(values []string) ([]*big.Int, error) {
+	ids := make([]*big.Int, 0, len(values))
+	for _, value := range values {
+		id, err := parseUint256(value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
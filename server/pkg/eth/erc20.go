@@ -2,9 +2,10 @@ package eth
 
 import (
 	"context"
+	"time"
 
 	"github.com/LeeSmet/go-jsonrpc"
-	"github.com/ethereum/go-ethereum/common"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 )
 
@@ -18,6 +19,8 @@ type (
 		ContractAddress string `json:"contract_address"`
 		Destination     string `json:"destination"`
 		Amount          string `json:"amount"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
 	}
 
 	TokenTransferFrom struct {
@@ -25,13 +28,70 @@ type (
 		From            string `json:"from"`
 		Destination     string `json:"destination"`
 		Amount          string `json:"amount"`
+		// Spender is the account of the wallet spending the allowance, the loaded account if empty
+		Spender string `json:"spender"`
 	}
 
 	ApproveTokenSpending struct {
 		ContractAddress string `json:"contract_address"`
 		Spender         string `json:"spender"`
 		Amount          string `json:"amount"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
 	}
+
+	// TokenInfo is the metadata of an erc20 token
+	TokenInfo struct {
+		Address  string `json:"address"`
+		Name     string `json:"name"`
+		Symbol   string `json:"symbol"`
+		Decimals uint8  `json:"decimals"`
+		// TotalSupply in tokens
+		TotalSupply string `json:"total_supply"`
+	}
+
+	TokenAllowance struct {
+		ContractAddress string `json:"contract_address"`
+		Owner           string `json:"owner"`
+		Spender         string `json:"spender"`
+	}
+
+	SignPermit struct {
+		ContractAddress string `json:"contract_address"`
+		Spender         string `json:"spender"`
+		// Amount in tokens
+		Amount string `json:"amount"`
+		// Validity of the permit in seconds, defaults to 3600
+		Validity uint64 `json:"validity"`
+		// From is the account of the wallet signing the permit, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	ApproveWithPermit struct {
+		Permit
+		// From is the account of the wallet paying for the transaction, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	// Permit is a signed EIP-2612 approval
+	Permit struct {
+		Token   string `json:"token"`
+		Owner   string `json:"owner"`
+		Spender string `json:"spender"`
+		// Value in the smallest unit of the token
+		Value string `json:"value"`
+		Nonce string `json:"nonce"`
+		// Deadline as unix timestamp
+		Deadline int64  `json:"deadline"`
+		V        uint8  `json:"v"`
+		R        string `json:"r"`
+		S        string `json:"s"`
+	}
+)
+
+const (
+	// defaultPermitValidity is the amount of seconds a permit is valid if no validity is given
+	defaultPermitValidity = 3600
 )
 
 // GetTokenBalance fetches the balance for an erc20 compatible contract
@@ -41,7 +101,7 @@ func (c *Client) GetTokenBalance(ctx context.Context, conState jsonrpc.State, co
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.GetTokenBalance(contractAddress)
+	return state.Client.GetTokenBalance(ctx, contractAddress)
 }
 
 // TransferToken transfer an erc20 compatible token to a destination
@@ -51,7 +111,12 @@ func (c *Client) TransferTokens(ctx context.Context, conState jsonrpc.State, arg
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.TransferTokens(ctx, common.HexToAddress(args.ContractAddress), args.Destination, args.Amount)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.TransferTokens(ctx, args.ContractAddress, args.Destination, args.Amount)
 }
 
 // TransferFromTokens transfer tokens from an account to another account (can be executed by anyone that is approved to spend)
@@ -61,7 +126,12 @@ func (c *Client) TransferFromTokens(ctx context.Context, conState jsonrpc.State,
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.TransferFromTokens(ctx, args.ContractAddress, args.From, args.Destination, args.Amount)
+	account, err := state.Client.Account(args.Spender)
+	if err != nil {
+		return "", err
+	}
+
+	return account.TransferFromTokens(ctx, args.ContractAddress, args.From, args.Destination, args.Amount)
 }
 
 // ApproveTokenSpending approves spending from a token contract with a limit
@@ -71,5 +141,92 @@ func (c *Client) ApproveTokenSpending(ctx context.Context, conState jsonrpc.Stat
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.ApproveTokenSpending(ctx, args.ContractAddress, args.Spender, args.Amount)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.ApproveTokenSpending(ctx, args.ContractAddress, args.Spender, args.Amount)
+}
+
+// TokenInfo returns the name, symbol, decimals and total supply of an erc20 token
+func (c *Client) TokenInfo(ctx context.Context, conState jsonrpc.State, contractAddress string) (TokenInfo, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return TokenInfo{}, pkg.ErrClientNotConnected{}
+	}
+
+	info, err := state.Client.TokenInfo(ctx, contractAddress)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+
+	return TokenInfo{
+		Address:     info.Address,
+		Name:        info.Name,
+		Symbol:      info.Symbol,
+		Decimals:    info.Decimals,
+		TotalSupply: info.TotalSupply,
+	}, nil
+}
+
+// TokenBalanceOf fetches the balance of any address for an erc20 compatible contract
+func (c *Client) TokenBalanceOf(ctx context.Context, conState jsonrpc.State, args GetTokenBalance) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.TokenBalanceOf(ctx, args.ContractAddress, args.Target)
+}
+
+// TokenAllowance fetches the amount of tokens a spender can still transfer from the owner
+func (c *Client) TokenAllowance(ctx context.Context, conState jsonrpc.State, args TokenAllowance) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.TokenAllowance(ctx, args.ContractAddress, args.Owner, args.Spender)
+}
+
+// SignPermit signs an EIP-2612 permit allowing the spender to transfer tokens of the loaded address. The permit is
+// returned without submitting it.
+func (c *Client) SignPermit(ctx context.Context, conState jsonrpc.State, args SignPermit) (Permit, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return Permit{}, pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return Permit{}, err
+	}
+
+	validity := args.Validity
+	if validity == 0 {
+		validity = defaultPermitValidity
+	}
+
+	permit, err := account.SignPermit(ctx, args.ContractAddress, args.Spender, args.Amount, time.Duration(validity)*time.Second)
+	if err != nil {
+		return Permit{}, err
+	}
+
+	return Permit(permit), nil
+}
+
+// ApproveWithPermit submits a signed permit, paid by the From account. The transaction hash is returned.
+func (c *Client) ApproveWithPermit(ctx context.Context, conState jsonrpc.State, args ApproveWithPermit) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.ApproveWithPermit(ctx, goethclient.Permit(args.Permit))
 }
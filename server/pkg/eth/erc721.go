@@ -2,12 +2,75 @@ package eth
 
 import (
 	"context"
+	"math/big"
+	"strings"
+	"sync"
 
 	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 )
 
+var (
+	ipfsFetcherLock sync.RWMutex
+	// ipfsFetcher fetches content through the ipfs peer of the proxy, nil if ipfs is disabled
+	ipfsFetcher goethclient.IPFSFetcher
+)
+
 type (
+	NftBalanceOf struct {
+		ContractAddress string `json:"contract_address"`
+		Owner           string `json:"owner"`
+	}
+
+	NftToken struct {
+		ContractAddress string `json:"contract_address"`
+		// TokenID as decimal or 0x prefixed hex string
+		TokenID string `json:"token_id"`
+	}
+
+	NftTransfer struct {
+		ContractAddress string `json:"contract_address"`
+		From            string `json:"from"`
+		To              string `json:"to"`
+		TokenID         string `json:"token_id"`
+	}
+
+	NftApprove struct {
+		ContractAddress string `json:"contract_address"`
+		To              string `json:"to"`
+		TokenID         string `json:"token_id"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	NftSetApprovalForAll struct {
+		ContractAddress string `json:"contract_address"`
+		Operator        string `json:"operator"`
+		Approved        bool   `json:"approved"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	NftIsApprovedForAll struct {
+		ContractAddress string `json:"contract_address"`
+		Owner           string `json:"owner"`
+		Operator        string `json:"operator"`
+	}
+
+	// NftMetadata of a token, following the ERC-721 metadata JSON schema
+	NftMetadata struct {
+		TokenURI    string        `json:"token_uri"`
+		Name        string        `json:"name"`
+		Description string        `json:"description"`
+		Image       string        `json:"image"`
+		Attributes  []interface{} `json:"attributes"`
+		// Raw is the complete metadata document
+		Raw map[string]interface{} `json:"raw"`
+	}
+
 	GetFungibleBalance struct {
 		ContractAddress string `json:"contract_address"`
 		Target          string `json:"target"`
@@ -46,14 +109,22 @@ type (
 	}
 )
 
-// GetFungibleBalance returns the balance of the given address for the given fungible token contract
-func (c *Client) GetFungibleBalance(ctx context.Context, conState jsonrpc.State, args GetFungibleBalance) (string, error) {
+// SetIPFSFetcher makes NftMetadata fetch ipfs content through the ipfs peer of the proxy instead of a public gateway
+func SetIPFSFetcher(fetcher goethclient.IPFSFetcher) {
+	ipfsFetcherLock.Lock()
+	defer ipfsFetcherLock.Unlock()
+
+	ipfsFetcher = fetcher
+}
+
+// NftBalanceOf returns the amount of tokens of an erc721 contract owned by an address
+func (c *Client) NftBalanceOf(ctx context.Context, conState jsonrpc.State, args NftBalanceOf) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	balance, err := state.Client.GetFungibleBalance(args.ContractAddress, args.Target)
+	balance, err := state.Client.NftBalanceOf(ctx, args.ContractAddress, args.Owner)
 	if err != nil {
 		return "", err
 	}
@@ -61,72 +132,260 @@ func (c *Client) GetFungibleBalance(ctx context.Context, conState jsonrpc.State,
 	return balance.String(), nil
 }
 
+// NftOwnerOf returns the owner of a token
+func (c *Client) NftOwnerOf(ctx context.Context, conState jsonrpc.State, args NftToken) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return "", err
+	}
+
+	return state.Client.NftOwnerOf(ctx, args.ContractAddress, tokenID)
+}
+
+// NftTokensOf returns the ids of the tokens of an erc721 contract owned by an address
+func (c *Client) NftTokensOf(ctx context.Context, conState jsonrpc.State, args NftBalanceOf) ([]string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	tokens, err := state.Client.NftTokensOf(ctx, args.ContractAddress, args.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		ids = append(ids, token.String())
+	}
+	return ids, nil
+}
+
+// NftMetadata fetches and parses the metadata document of a token
+func (c *Client) NftMetadata(ctx context.Context, conState jsonrpc.State, args NftToken) (NftMetadata, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return NftMetadata{}, pkg.ErrClientNotConnected{}
+	}
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return NftMetadata{}, err
+	}
+
+	ipfsFetcherLock.RLock()
+	fetcher := ipfsFetcher
+	ipfsFetcherLock.RUnlock()
+
+	metadata, err := state.Client.NftMetadata(ctx, args.ContractAddress, tokenID, fetcher)
+	if err != nil {
+		return NftMetadata{}, err
+	}
+
+	return NftMetadata(metadata), nil
+}
+
+// NftSafeTransfer transfers a token, checking that a contract receiving it can handle tokens
+func (c *Client) NftSafeTransfer(ctx context.Context, conState jsonrpc.State, args NftTransfer) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account := ownerAccount(state.Client, args.From)
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return "", err
+	}
+
+	return account.NftSafeTransfer(ctx, args.ContractAddress, args.From, args.To, tokenID)
+}
+
+// NftTransfer transfers a token without checking the receiver
+func (c *Client) NftTransfer(ctx context.Context, conState jsonrpc.State, args NftTransfer) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account := ownerAccount(state.Client, args.From)
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return "", err
+	}
+
+	return account.NftTransfer(ctx, args.ContractAddress, args.From, args.To, tokenID)
+}
+
+// NftApprove allows an address to transfer a token of the loaded address
+func (c *Client) NftApprove(ctx context.Context, conState jsonrpc.State, args NftApprove) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return "", err
+	}
+
+	return account.NftApprove(ctx, args.ContractAddress, args.To, tokenID)
+}
+
+// NftGetApproved returns the address allowed to transfer a token
+func (c *Client) NftGetApproved(ctx context.Context, conState jsonrpc.State, args NftToken) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	tokenID, err := parseUint256(args.TokenID)
+	if err != nil {
+		return "", err
+	}
+
+	return state.Client.NftGetApproved(ctx, args.ContractAddress, tokenID)
+}
+
+// NftSetApprovalForAll allows or disallows an operator to transfer all tokens of the loaded address
+func (c *Client) NftSetApprovalForAll(ctx context.Context, conState jsonrpc.State, args NftSetApprovalForAll) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.NftSetApprovalForAll(ctx, args.ContractAddress, args.Operator, args.Approved)
+}
+
+// NftIsApprovedForAll returns whether an operator can transfer all tokens of the owner
+func (c *Client) NftIsApprovedForAll(ctx context.Context, conState jsonrpc.State, args NftIsApprovedForAll) (bool, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return false, pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.NftIsApprovedForAll(ctx, args.ContractAddress, args.Owner, args.Operator)
+}
+
+// GetFungibleBalance returns the balance of the given address for the given fungible token contract
+//
+// Deprecated: use NftBalanceOf
+func (c *Client) GetFungibleBalance(ctx context.Context, conState jsonrpc.State, args GetFungibleBalance) (string, error) {
+	return c.NftBalanceOf(ctx, conState, NftBalanceOf{ContractAddress: args.ContractAddress, Owner: args.Target})
+}
+
 // OwnerOfFungible returns the owner of the given fungible token
+//
+// Deprecated: use NftOwnerOf
 func (c *Client) OwnerOfFungible(ctx context.Context, conState jsonrpc.State, args OwnerOfFungible) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.OwnerOfFungible(args.ContractAddress, args.TokenID)
+	return state.Client.NftOwnerOf(ctx, args.ContractAddress, big.NewInt(args.TokenID))
 }
 
 // SafeTransferFungible transfers a fungible token from the given address to the given target address
+//
+// Deprecated: use NftSafeTransfer
 func (c *Client) SafeTransferFungible(ctx context.Context, conState jsonrpc.State, args TransferFungible) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.SafeTransferFungible(ctx, args.ContractAddress, args.From, args.To, args.TokenID)
+	return state.Client.NftSafeTransfer(ctx, args.ContractAddress, args.From, args.To, big.NewInt(args.TokenID))
 }
 
 // TransferFungible transfers the given fungible token from the given address to the given target address
+//
+// Deprecated: use NftTransfer
 func (c *Client) TransferFungible(ctx context.Context, conState jsonrpc.State, args TransferFungible) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.TransferFungible(ctx, args.ContractAddress, args.From, args.To, args.TokenID)
+	return state.Client.NftTransfer(ctx, args.ContractAddress, args.From, args.To, big.NewInt(args.TokenID))
 }
 
 // SetFungibleApproval approves the given address to spend the given tokenId of the given fungible token
+//
+// Deprecated: use NftApprove
 func (c *Client) SetFungibleApproval(ctx context.Context, conState jsonrpc.State, args SetFungibleApproval) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.SetFungibleApproval(ctx, args.ContractAddress, args.From, args.To, args.Amount)
+	return state.Client.NftApprove(ctx, args.ContractAddress, args.To, big.NewInt(args.Amount))
 }
 
 // SetFungibleApprovalForAll approves the given address to spend all the given fungible tokens
+//
+// Deprecated: use NftSetApprovalForAll
 func (c *Client) SetFungibleApprovalForAll(ctx context.Context, conState jsonrpc.State, args SetFungibleApprovalForAll) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.SetFungibleApprovalForAll(ctx, args.ContractAddress, args.From, args.To, args.Approved)
+	return state.Client.NftSetApprovalForAll(ctx, args.ContractAddress, args.To, args.Approved)
 }
 
-// GetApprovalForFungible returns whether the given address is approved to spend the given tokenId of the given fungible token
+// GetApprovalForFungible returns whether the given address is approved to spend all the given fungible tokens
+//
+// Deprecated: use NftIsApprovedForAll, or NftGetApproved for the approval of a single token
 func (c *Client) GetApprovalForFungible(ctx context.Context, conState jsonrpc.State, args ApprovalForFungible) (bool, error) {
-	state := State(conState)
-	if state.Client == nil {
-		return false, pkg.ErrClientNotConnected{}
-	}
-
-	return state.Client.GetApprovalForFungible(args.ContractAddress, args.Owner, args.Operator)
+	return c.GetApprovalForAllFungible(ctx, conState, args)
 }
 
 // GetApprovalForAllFungible returns whether the given address is approved to spend all the given fungible tokens
+//
+// Deprecated: use NftIsApprovedForAll
 func (c *Client) GetApprovalForAllFungible(ctx context.Context, conState jsonrpc.State, args ApprovalForFungible) (bool, error) {
-	state := State(conState)
-	if state.Client == nil {
-		return false, pkg.ErrClientNotConnected{}
+	return c.NftIsApprovedForAll(ctx, conState, NftIsApprovedForAll(args))
+}
+
+// parseUint256 parses a token id or amount as decimal or 0x prefixed hex string
+func parseUint256(value string) (*big.Int, error) {
+	s, base := value, 10
+	if strings.HasPrefix(s, "0x") {
+		s, base = s[2:], 16
+	}
+	id, ok := new(big.Int).SetString(s, base)
+	if !ok || id.Sign() < 0 || id.BitLen() > 256 {
+		return nil, errors.Errorf("invalid number %s", value)
 	}
+	return id, nil
+}
 
-	return state.Client.GetApprovalForAllFungible(args.ContractAddress, args.Owner, args.Operator)
+// decodeHexData decodes optional hex encoded call data
+func decodeHexData(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid data")
+	}
+	return data, nil
 }
@@ -0,0 +1,55 @@
+package eth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+var (
+	historyDirLock sync.RWMutex
+	// historyDir is the directory transaction history indexes are persisted in, they are kept in memory if empty
+	historyDir string
+)
+
+type (
+	TransactionHistory struct {
+		// Address to get the history of, the loaded address if empty
+		Address   string `json:"address"`
+		FromBlock uint64 `json:"from_block"`
+		// ToBlock is the last block of the history, the latest block if 0
+		ToBlock uint64 `json:"to_block"`
+	}
+)
+
+// SetHistoryDir sets the directory the transaction history indexes are persisted in
+func SetHistoryDir(dir string) {
+	historyDirLock.Lock()
+	defer historyDirLock.Unlock()
+
+	historyDir = dir
+}
+
+// TransactionHistory returns the incoming and outgoing transfers of native currency, TFT and other erc20 tokens of an
+// address between two blocks. Outgoing transactions include the fee paid, TFT withdrawn through the bridge includes
+// the destination of the withdrawal.
+func (c *Client) TransactionHistory(ctx context.Context, conState jsonrpc.State, args TransactionHistory) ([]goethclient.HistoryEntry, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	address := args.Address
+	if address == "" {
+		address = state.Client.Address.Hex()
+	}
+
+	historyDirLock.RLock()
+	dir := historyDir
+	historyDirLock.RUnlock()
+
+	return state.Client.TransactionHistory(ctx, dir, address, args.FromBlock, args.ToBlock)
+}
@@ -0,0 +1,146 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+type (
+	LogFilter struct {
+		// Addresses of the contracts emitting the logs
+		Addresses []string `json:"addresses"`
+		// Topics to match per position, every position matches any of the given topics
+		Topics [][]string `json:"topics"`
+		// ABI json or name of a registered ABI (erc20, erc721, safe and tft are builtin), used to decode the logs
+		ABI string `json:"abi"`
+		// Event name in the ABI to filter on
+		Event string `json:"event"`
+		// FromBlock is the first block to query, the latest block if not set
+		FromBlock *uint64 `json:"from_block"`
+		// ToBlock is the last block to query, the latest block if not set
+		ToBlock *uint64 `json:"to_block"`
+	}
+
+	Log struct {
+		Address     string                 `json:"address"`
+		Topics      []string               `json:"topics"`
+		Data        string                 `json:"data"`
+		BlockNumber uint64                 `json:"block_number"`
+		BlockHash   string                 `json:"block_hash"`
+		TxHash      string                 `json:"tx_hash"`
+		TxIndex     uint                   `json:"tx_index"`
+		Index       uint                   `json:"index"`
+		Removed     bool                   `json:"removed"`
+		Event       string                 `json:"event"`
+		Args        map[string]interface{} `json:"args"`
+	}
+
+	GetSubscriptionLogs struct {
+		ID string `json:"id"`
+		// Count of logs to take, all buffered logs if 0
+		Count uint32 `json:"count"`
+	}
+)
+
+// GetLogs returns the logs matching a filter, decoded if an ABI is given
+func (c *Client) GetLogs(ctx context.Context, conState jsonrpc.State, args LogFilter) ([]Log, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	logs, err := state.Client.GetLogs(ctx, logFilter(args))
+	if err != nil {
+		return nil, err
+	}
+
+	return toLogs(logs), nil
+}
+
+// SubscribeLogs subscribes to new logs matching a filter. This requires the client to be loaded with a websocket
+// url. The subscription id is returned, the logs are taken with GetSubscriptionLogs.
+func (c *Client) SubscribeLogs(ctx context.Context, conState jsonrpc.State, args LogFilter) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.SubscribeLogs(logFilter(args))
+}
+
+// GetSubscriptionLogs takes the buffered logs of a subscription, oldest first
+func (c *Client) GetSubscriptionLogs(ctx context.Context, conState jsonrpc.State, args GetSubscriptionLogs) ([]Log, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	logs, err := state.Client.SubscriptionLogs(args.ID, args.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	return toLogs(logs), nil
+}
+
+// GetSubscriptionIds returns the ids of the active log subscriptions
+func (c *Client) GetSubscriptionIds(ctx context.Context, conState jsonrpc.State) ([]string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.SubscriptionIds(), nil
+}
+
+// CloseSubscription closes a log subscription by id
+func (c *Client) CloseSubscription(ctx context.Context, conState jsonrpc.State, id string) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	state.Client.CloseSubscription(id)
+
+	return nil
+}
+
+func logFilter(args LogFilter) goethclient.LogFilter {
+	filter := goethclient.LogFilter{
+		Addresses: args.Addresses,
+		Topics:    args.Topics,
+		ABI:       args.ABI,
+		Event:     args.Event,
+	}
+	if args.FromBlock != nil {
+		filter.FromBlock = new(big.Int).SetUint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil {
+		filter.ToBlock = new(big.Int).SetUint64(*args.ToBlock)
+	}
+	return filter
+}
+
+func toLogs(logs []goethclient.Log) []Log {
+	result := make([]Log, 0, len(logs))
+	for _, l := range logs {
+		result = append(result, Log{
+			Address:     l.Address,
+			Topics:      l.Topics,
+			Data:        l.Data,
+			BlockNumber: l.BlockNumber,
+			BlockHash:   l.BlockHash,
+			TxHash:      l.TxHash,
+			TxIndex:     l.TxIndex,
+			Index:       l.Index,
+			Removed:     l.Removed,
+			Event:       l.Event,
+			Args:        l.Args,
+		})
+	}
+	return result
+}
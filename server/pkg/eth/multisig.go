@@ -12,24 +12,33 @@ type (
 		ContractAddress string `json:"contract_address"`
 		Target          string `json:"target"`
 		Threshold       int64  `json:"threshold"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
 	}
 
 	ApproveHash struct {
 		ContractAddress string `json:"contract_address"`
 		Hash            string `json:"hash"`
+		// From is the account of the wallet approving the hash, the loaded account if empty
+		From string `json:"from"`
 	}
 
 	InitiateMultisigEthTransfer struct {
 		ContractAddress string `json:"contract_address"`
 		Destination     string `json:"destination"`
 		Amount          string `json:"amount"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
 	}
 
 	InitiateMultisigTokenTransfer struct {
 		ContractAddress string `json:"contract_address"`
 		TokenAddress    string `json:"token_address"`
 		Destination     string `json:"destination"`
-		Amount          int64  `json:"amount"`
+		// Amount in tokens, using the decimals of the token
+		Amount string `json:"amount"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
 	}
 )
 
@@ -58,24 +67,36 @@ func (c *Client) GetMultisigThreshold(ctx context.Context, conState jsonrpc.Stat
 	return threshold.String(), nil
 }
 
-// AddMultisigOwner adds an owner to a multisig contract
+// AddMultisigOwner proposes adding an owner to a multisig contract. The safe tx hash of the proposal is returned, it
+// is executed with SafeExecute once enough owners signed it.
 func (c *Client) AddMultisigOwner(ctx context.Context, conState jsonrpc.State, args MultisigOwner) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.AddOwner(args.ContractAddress, args.Target, args.Threshold)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.AddOwner(ctx, args.ContractAddress, args.Target, args.Threshold)
 }
 
-// RemoveMultisigOwner adds an owner to a multisig contract
+// RemoveMultisigOwner proposes removing an owner from a multisig contract. The safe tx hash of the proposal is
+// returned.
 func (c *Client) RemoveMultisigOwner(ctx context.Context, conState jsonrpc.State, args MultisigOwner) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.RemoveOwner(args.ContractAddress, args.Target, args.Threshold)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.RemoveOwner(ctx, args.ContractAddress, args.Target, args.Threshold)
 }
 
 // ApproveHash approves a transaction hash
@@ -85,7 +106,12 @@ func (c *Client) ApproveHash(ctx context.Context, conState jsonrpc.State, args A
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.ApproveHash(args.ContractAddress, args.Hash)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.ApproveHash(ctx, args.ContractAddress, args.Hash)
 }
 
 // IsApproved approves a transaction hash
@@ -95,25 +121,40 @@ func (c *Client) IsApproved(ctx context.Context, conState jsonrpc.State, args Ap
 		return false, pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.IsApproved(args.ContractAddress, args.Hash)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return false, err
+	}
+
+	return account.IsApproved(args.ContractAddress, args.Hash)
 }
 
-// InitiateMultisigEthTransfer initiates a multisig eth transfer operation
+// InitiateMultisigEthTransfer proposes a multisig eth transfer. The safe tx hash of the proposal is returned.
 func (c *Client) InitiateMultisigEthTransfer(ctx context.Context, conState jsonrpc.State, args InitiateMultisigEthTransfer) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.InitiateMultisigEthTransfer(args.ContractAddress, args.Destination, args.Amount)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.InitiateMultisigEthTransfer(ctx, args.ContractAddress, args.Destination, args.Amount)
 }
 
-// InitiateMultisigTokenTransfer initiates a multisig eth transfer operation
+// InitiateMultisigTokenTransfer proposes a multisig token transfer. The safe tx hash of the proposal is returned.
 func (c *Client) InitiateMultisigTokenTransfer(ctx context.Context, conState jsonrpc.State, args InitiateMultisigTokenTransfer) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.InitiateMultisigTokenTransfer(args.ContractAddress, args.TokenAddress, args.Destination, args.Amount)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.InitiateMultisigTokenTransfer(ctx, args.ContractAddress, args.TokenAddress, args.Destination, args.Amount)
 }
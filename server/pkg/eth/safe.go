@@ -0,0 +1,175 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+type (
+	SafeProposeTransaction struct {
+		ContractAddress string `json:"contract_address"`
+		To              string `json:"to"`
+		// Value to send along in wei
+		Value string `json:"value"`
+		// Data of the call, hex encoded
+		Data string `json:"data"`
+		// Operation is 0 for a call and 1 for a delegate call
+		Operation uint8 `json:"operation"`
+		// SafeTxGas is the gas available to the transaction in the safe, 0 to use all gas
+		SafeTxGas uint64 `json:"safe_tx_gas"`
+		// Nonce of the safe to use, defaults to the nonce following the pending proposals
+		Nonce *uint64 `json:"nonce"`
+		// From is the owner in the wallet proposing the transaction, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	SafeSignTransaction struct {
+		SafeTxHash string `json:"safe_tx_hash"`
+		// Signature made outside of the proxy, hex encoded. If empty, the From account signs the transaction.
+		Signature string `json:"signature"`
+		// From is the owner in the wallet signing the transaction, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	SafeExecute struct {
+		SafeTxHash string `json:"safe_tx_hash"`
+		// From is the account of the wallet sending the transaction, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	// SafeTransaction is a proposed safe transaction and its collected signatures
+	SafeTransaction struct {
+		SafeTxHash string `json:"safe_tx_hash"`
+		Safe       string `json:"safe"`
+		To         string `json:"to"`
+		// Value in wei
+		Value     string `json:"value"`
+		Data      string `json:"data"`
+		Operation uint8  `json:"operation"`
+		SafeTxGas string `json:"safe_tx_gas"`
+		Nonce     uint64 `json:"nonce"`
+		Proposer  string `json:"proposer"`
+		// ProposedAt is the unix timestamp of the proposal
+		ProposedAt int64 `json:"proposed_at"`
+		// Signatures by owner address, hex encoded
+		Signatures map[string]string `json:"signatures"`
+	}
+)
+
+// SafeProposeTransaction stores a transaction for a safe and signs it with the selected account, which must be an
+// owner. Proposals are shared by all connections, so the other owners can sign it. The safe tx hash is returned.
+func (c *Client) SafeProposeTransaction(ctx context.Context, conState jsonrpc.State, args SafeProposeTransaction) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	if !common.IsHexAddress(args.To) {
+		return "", errors.Errorf("invalid destination %s", args.To)
+	}
+	value, err := parseBigInt(args.Value)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid value")
+	}
+	var data []byte
+	if args.Data != "" {
+		data, err = hexutil.Decode(args.Data)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid data")
+		}
+	}
+
+	return account.SafeProposeTransaction(ctx, args.ContractAddress, goethclient.SafeProposal{
+		To:        common.HexToAddress(args.To),
+		Value:     value,
+		Data:      data,
+		Operation: args.Operation,
+		SafeTxGas: new(big.Int).SetUint64(args.SafeTxGas),
+		Nonce:     args.Nonce,
+	})
+}
+
+// SafeSignTransaction adds a signature to a proposed safe transaction. Without a signature in the arguments, the
+// transaction is signed with the selected account. The signature is returned.
+func (c *Client) SafeSignTransaction(ctx context.Context, conState jsonrpc.State, args SafeSignTransaction) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	if args.Signature == "" {
+		return account.SafeSignTransaction(ctx, args.SafeTxHash)
+	}
+
+	if err := account.SafeAddSignature(ctx, args.SafeTxHash, args.Signature); err != nil {
+		return "", err
+	}
+	return args.Signature, nil
+}
+
+// SafeListPending returns the proposed transactions of a safe which were not executed yet, ordered by nonce
+func (c *Client) SafeListPending(ctx context.Context, conState jsonrpc.State, contractAddress string) ([]SafeTransaction, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	pending, err := state.Client.SafeListPending(ctx, contractAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]SafeTransaction, 0, len(pending))
+	for _, tx := range pending {
+		transactions = append(transactions, safeTransaction(tx))
+	}
+	return transactions, nil
+}
+
+// SafeExecute submits a proposed safe transaction once enough owners signed it. The transaction hash is returned.
+func (c *Client) SafeExecute(ctx context.Context, conState jsonrpc.State, args SafeExecute) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.SafeExecute(ctx, args.SafeTxHash)
+}
+
+func safeTransaction(tx goethclient.SafeTransaction) SafeTransaction {
+	return SafeTransaction{
+		SafeTxHash: tx.SafeTxHash,
+		Safe:       tx.Safe,
+		To:         tx.To,
+		Value:      bigIntString(tx.Value),
+		Data:       tx.Data,
+		Operation:  tx.Operation,
+		SafeTxGas:  bigIntString(tx.SafeTxGas),
+		Nonce:      tx.Nonce,
+		Proposer:   tx.Proposer,
+		ProposedAt: tx.ProposedAt.Unix(),
+		Signatures: tx.Signatures,
+	}
+}
@@ -2,11 +2,94 @@ package eth
 
 import (
 	"context"
+	"time"
 
 	"github.com/LeeSmet/go-jsonrpc"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 )
 
+type (
+	Swap struct {
+		// Path of tokens from the token sold to the token bought, as contract addresses, the symbol of the native
+		// currency or TFT, e.g. [USDC address, ETH, TFT]
+		Path []string `json:"path"`
+		// Fees of the pool of every hop, in hundredths of a basis point. The pools with the most liquidity are used if
+		// empty.
+		Fees []uint32 `json:"fees"`
+		// Amount sold for an exact input swap, or bought for an exact output swap
+		Amount      string `json:"amount"`
+		ExactOutput bool   `json:"exact_output"`
+		// SlippageBps is the slippage tolerance in basis points, defaults to 50
+		SlippageBps uint32 `json:"slippage_bps"`
+		// Deadline in seconds after which the swap is rejected, defaults to 300
+		Deadline uint64 `json:"deadline"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	SwapQuote struct {
+		Path            []string `json:"path"`
+		Fees            []uint32 `json:"fees"`
+		ExactOutput     bool     `json:"exact_output"`
+		AmountIn        string   `json:"amount_in"`
+		AmountOut       string   `json:"amount_out"`
+		MinimumReceived string   `json:"minimum_received"`
+		MaximumSold     string   `json:"maximum_sold"`
+		// PriceImpact in percent, including the fees of the pools
+		PriceImpact string `json:"price_impact"`
+		SlippageBps uint32 `json:"slippage_bps"`
+	}
+
+	TftSwap struct {
+		// Amount sold, in eth for SwapEthForTft and in TFT for SwapTftForEth
+		Amount string `json:"amount"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
+	}
+
+	SwapResult struct {
+		Hash  string    `json:"hash"`
+		Quote SwapQuote `json:"quote"`
+	}
+)
+
+// Quote returns the expected amounts, limits and price impact of a swap
+func (c *Client) Quote(ctx context.Context, conState jsonrpc.State, args Swap) (SwapQuote, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return SwapQuote{}, pkg.ErrClientNotConnected{}
+	}
+
+	quote, err := state.Client.Quote(ctx, swapRequest(args))
+	if err != nil {
+		return SwapQuote{}, err
+	}
+
+	return swapQuote(quote), nil
+}
+
+// Swap submits a swap through uniswap V3 pools. The transaction hash and the quote the limits are based on are
+// returned.
+func (c *Client) Swap(ctx context.Context, conState jsonrpc.State, args Swap) (SwapResult, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return SwapResult{}, pkg.ErrClientNotConnected{}
+	}
+
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return SwapResult{}, err
+	}
+
+	quote, hash, err := account.Swap(ctx, swapRequest(args))
+	if err != nil {
+		return SwapResult{}, err
+	}
+
+	return SwapResult{Hash: hash, Quote: swapQuote(quote)}, nil
+}
+
 func (c *Client) QuoteEthForTft(ctx context.Context, conState jsonrpc.State, amountIn string) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
@@ -16,13 +99,18 @@ func (c *Client) QuoteEthForTft(ctx context.Context, conState jsonrpc.State, amo
 	return state.Client.QuoteEthForTft(ctx, amountIn)
 }
 
-func (c *Client) SwapEthForTft(ctx context.Context, conState jsonrpc.State, amountIn string) (string, error) {
+func (c *Client) SwapEthForTft(ctx context.Context, conState jsonrpc.State, args TftSwap) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.SwapEthForTft(ctx, amountIn)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.SwapEthForTft(ctx, args.Amount)
 }
 
 func (c *Client) QuoteTftForEth(ctx context.Context, conState jsonrpc.State, amountIn string) (string, error) {
@@ -34,11 +122,41 @@ func (c *Client) QuoteTftForEth(ctx context.Context, conState jsonrpc.State, amo
 	return state.Client.QuoteTftForEth(ctx, amountIn)
 }
 
-func (c *Client) SwapTftForEth(ctx context.Context, conState jsonrpc.State, amountIn string) (string, error) {
+func (c *Client) SwapTftForEth(ctx context.Context, conState jsonrpc.State, args TftSwap) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.SwapTftForEth(ctx, amountIn)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.SwapTftForEth(ctx, args.Amount)
+}
+
+func swapRequest(args Swap) goethclient.SwapRequest {
+	return goethclient.SwapRequest{
+		Path:        args.Path,
+		Fees:        args.Fees,
+		Amount:      args.Amount,
+		ExactOutput: args.ExactOutput,
+		SlippageBps: args.SlippageBps,
+		Deadline:    time.Duration(args.Deadline) * time.Second,
+	}
+}
+
+func swapQuote(quote goethclient.SwapQuote) SwapQuote {
+	return SwapQuote{
+		Path:            quote.Path,
+		Fees:            quote.Fees,
+		ExactOutput:     quote.ExactOutput,
+		AmountIn:        quote.AmountIn,
+		AmountOut:       quote.AmountOut,
+		MinimumReceived: quote.MinimumReceived,
+		MaximumSold:     quote.MaximumSold,
+		PriceImpact:     quote.PriceImpact,
+		SlippageBps:     quote.SlippageBps,
+	}
 }
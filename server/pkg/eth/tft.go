@@ -11,6 +11,8 @@ type (
 	TftEthTransfer struct {
 		Destination string `json:"destination"`
 		Amount      string `json:"amount"`
+		// From is the account of the wallet to send from, the loaded account if empty
+		From string `json:"from"`
 	}
 )
 
@@ -20,7 +22,12 @@ func (c *Client) TransferEthTft(ctx context.Context, conState jsonrpc.State, arg
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.TransferEthTft(ctx, args.Destination, args.Amount)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.TransferEthTft(ctx, args.Destination, args.Amount)
 }
 
 func (c *Client) BridgeToStellar(ctx context.Context, conState jsonrpc.State, args TftEthTransfer) (string, error) {
@@ -29,7 +36,12 @@ func (c *Client) BridgeToStellar(ctx context.Context, conState jsonrpc.State, ar
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.BridgeToStellar(ctx, args.Destination, args.Amount)
+	account, err := state.Client.Account(args.From)
+	if err != nil {
+		return "", err
+	}
+
+	return account.BridgeToStellar(ctx, args.Destination, args.Amount)
 }
 
 func (c *Client) GetEthTftBalance(ctx context.Context, conState jsonrpc.State) (string, error) {
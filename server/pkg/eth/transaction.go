@@ -0,0 +1,105 @@
+package eth
+
+import (
+	"context"
+	"time"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	goethclient "github.com/threefoldtech/web3_proxy/server/clients/eth"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+const (
+	// defaultWaitTimeout is the amount of seconds to wait for a transaction if no timeout is given
+	defaultWaitTimeout = 300
+)
+
+type (
+	// TransactionStatus of a submitted transaction
+	TransactionStatus struct {
+		Hash string `json:"hash"`
+		// Status is one of pending, success, failed or not_found
+		Status        string `json:"status"`
+		BlockNumber   uint64 `json:"block_number"`
+		Confirmations uint64 `json:"confirmations"`
+		GasUsed       uint64 `json:"gas_used"`
+		// EffectiveGasPrice in wei
+		EffectiveGasPrice string `json:"effective_gas_price"`
+	}
+
+	WaitForTransaction struct {
+		Hash string `json:"hash"`
+		// Confirmations to wait for, defaults to 1
+		Confirmations uint64 `json:"confirmations"`
+		// Timeout in seconds, defaults to 300
+		Timeout uint64 `json:"timeout"`
+	}
+)
+
+// TransactionStatus returns the status of a transaction and its amount of confirmations
+func (c *Client) TransactionStatus(ctx context.Context, conState jsonrpc.State, hash string) (TransactionStatus, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return TransactionStatus{}, pkg.ErrClientNotConnected{}
+	}
+
+	status, err := state.Client.TransactionStatus(ctx, hash)
+	if err != nil {
+		return TransactionStatus{}, err
+	}
+
+	return transactionStatus(status), nil
+}
+
+// WaitForTransaction waits till a transaction is mined with the requested amount of confirmations
+func (c *Client) WaitForTransaction(ctx context.Context, conState jsonrpc.State, args WaitForTransaction) (TransactionStatus, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return TransactionStatus{}, pkg.ErrClientNotConnected{}
+	}
+
+	timeout := args.Timeout
+	if timeout == 0 {
+		timeout = defaultWaitTimeout
+	}
+
+	status, err := state.Client.WaitForTransaction(ctx, args.Hash, args.Confirmations, time.Duration(timeout)*time.Second)
+	if err != nil {
+		return TransactionStatus{}, err
+	}
+
+	return transactionStatus(status), nil
+}
+
+// SpeedUp replaces a pending transaction with the same transaction paying a higher fee. The hash of the
+// replacement is returned.
+func (c *Client) SpeedUp(ctx context.Context, conState jsonrpc.State, hash string) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.SpeedUp(ctx, hash)
+}
+
+// Cancel replaces a pending transaction with an empty transaction paying a higher fee. The hash of the
+// replacement is returned.
+func (c *Client) Cancel(ctx context.Context, conState jsonrpc.State, hash string) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.Cancel(ctx, hash)
+}
+
+func transactionStatus(status goethclient.TransactionStatus) TransactionStatus {
+	return TransactionStatus{
+		Hash:              status.Hash,
+		Status:            status.Status,
+		BlockNumber:       status.BlockNumber,
+		Confirmations:     status.Confirmations,
+		GasUsed:           status.GasUsed,
+		EffectiveGasPrice: bigIntString(status.EffectiveGasPrice),
+	}
+}
@@ -10,6 +10,7 @@ import (
 	ipfslite "github.com/hsanjuan/ipfs-lite"
 	"github.com/ipfs/go-cid"
 	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/web3_proxy/server/clients/safehttp"
 )
 
 const (
@@ -97,18 +98,36 @@ func (c *Client) GetFile(ctx context.Context, conState jsonrpc.State, contentId
 		return nil, errors.New("contentId not found in state")
 	}
 
-	node, err := c.peer.GetFile(ctx, cId)
-	if err != nil {
-		return nil, err
+	return getFile(ctx, c.peer, cId)
+}
+
+// Fetcher returns a function fetching any content through the ipfs peer, for other namespaces resolving ipfs uris.
+// Content larger than maxSize is refused.
+func Fetcher(peer *ipfslite.Peer) func(ctx context.Context, contentId string, maxSize int64) ([]byte, error) {
+	return func(ctx context.Context, contentId string, maxSize int64) ([]byte, error) {
+		cId, err := cid.Decode(contentId)
+		if err != nil {
+			return nil, err
+		}
+
+		node, err := peer.GetFile(ctx, cId)
+		if err != nil {
+			return nil, err
+		}
+		defer node.Close()
+
+		return safehttp.ReadAll(node, maxSize)
 	}
+}
 
-	defer node.Close()
-	content, err := io.ReadAll(node)
+func getFile(ctx context.Context, peer *ipfslite.Peer, cId cid.Cid) ([]byte, error) {
+	node, err := peer.GetFile(ctx, cId)
 	if err != nil {
 		return nil, err
 	}
 
-	return content, nil
+	defer node.Close()
+	return io.ReadAll(node)
 }
 
 // RemoveFile removes a file from the ipfs client
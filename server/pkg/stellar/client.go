@@ -2,10 +2,14 @@ package stellar
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/pkg/errors"
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
 	stellargoclient "github.com/threefoldtech/web3_proxy/server/clients/stellar"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 )
@@ -13,6 +17,9 @@ import (
 const (
 	stellarNetworkPublic  = "public"
 	stellarNetworkTestnet = "testnet"
+
+	// default time to wait for a bridge payment, in seconds
+	defaultAwaitTimeout = 300
 )
 
 type (
@@ -30,18 +37,74 @@ type (
 	Load struct {
 		Network string `json:"network"`
 		Secret  string `json:"secret"`
+		// DisableTftTrustline prevents adding a TFT trustline to the account if it doesn't have one
+		DisableTftTrustline bool `json:"disable_tft_trustline"`
+		// TransactionTimeout in seconds after which submitted transactions are no longer valid, 300 if 0
+		TransactionTimeout uint64 `json:"transaction_timeout"`
+	}
+
+	CreateClaimableBalance struct {
+		Asset     string                     `json:"asset"`
+		Amount    string                     `json:"amount"`
+		Claimants []stellargoclient.Claimant `json:"claimants"`
+	}
+
+	ListClaimableBalances struct {
+		Claimant string `json:"claimant"`
+		Asset    string `json:"asset"`
 	}
 
 	Swap struct {
 		Amount           string `json:"amount"`
 		SourceAsset      string `json:"source_asset"`
 		DestinationAsset string `json:"destination_asset"`
+		// Mode is either strict_send (default, amount is sent) or strict_receive (amount is received)
+		Mode string `json:"mode"`
+		// Slippage tolerance in percent, 1% if empty
+		Slippage string `json:"slippage"`
+	}
+
+	Quote struct {
+		Amount           string `json:"amount"`
+		SourceAsset      string `json:"source_asset"`
+		DestinationAsset string `json:"destination_asset"`
+		Mode             string `json:"mode"`
+	}
+
+	Offer struct {
+		Selling string `json:"selling"`
+		Buying  string `json:"buying"`
+		Amount  string `json:"amount"`
+		Price   string `json:"price"`
+	}
+
+	UpdateOffer struct {
+		OfferID int64  `json:"offer_id"`
+		Amount  string `json:"amount"`
+		Price   string `json:"price"`
+	}
+
+	OrderBook struct {
+		Selling string `json:"selling"`
+		Buying  string `json:"buying"`
+		Limit   uint   `json:"limit"`
 	}
 
 	Transfer struct {
 		Amount      string `json:"amount"`
 		Destination string `json:"destination"`
 		Memo        string `json:"memo"`
+		Asset       string `json:"asset"`
+	}
+
+	AssetBalance struct {
+		Address string `json:"address"`
+		Asset   string `json:"asset"`
+	}
+
+	Trustline struct {
+		Asset string `json:"asset"`
+		Limit string `json:"limit"`
 	}
 
 	BridgeTransfer struct {
@@ -65,6 +128,21 @@ type (
 	AccountData struct {
 		Account string `json:"account"`
 	}
+
+	AwaitTransactionOnEthBridge struct {
+		Memo string `json:"memo"`
+		// Cursor from BridgeCursor, taken before the bridge transfer was submitted
+		Cursor string `json:"cursor"`
+	}
+
+	AwaitBridgePayment struct {
+		Bridge      string `json:"bridge"`
+		Memo        string `json:"memo"`
+		Cursor      string `json:"cursor"`
+		Destination string `json:"destination"`
+		Amount      string `json:"amount"`
+		Timeout     uint64 `json:"timeout"`
+	}
 )
 
 const (
@@ -116,7 +194,11 @@ func (c *Client) Load(ctx context.Context, conState jsonrpc.State, args Load) er
 		state.network = args.Network
 	}
 
-	return state.Client.Load(args.Secret)
+	if args.TransactionTimeout != 0 {
+		state.Client.SetTransactionTimeout(time.Duration(args.TransactionTimeout) * time.Second)
+	}
+
+	return state.Client.Load(args.Secret, !args.DisableTftTrustline)
 }
 
 func (c *Client) CreateAccount(ctx context.Context, conState jsonrpc.State, network string) (string, error) {
@@ -133,6 +215,18 @@ func (c *Client) CreateAccount(ctx context.Context, conState jsonrpc.State, netw
 	return state.Client.CreateAccount()
 }
 
+// SetTransactionTimeout sets the time in seconds after which transactions submitted by this connection are no longer
+// valid, so they can't be included in a ledger much later. A timeout of 0 disables the time bounds.
+func (c *Client) SetTransactionTimeout(ctx context.Context, conState jsonrpc.State, seconds uint64) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	state.Client.SetTransactionTimeout(time.Duration(seconds) * time.Second)
+	return nil
+}
+
 // Get the public address of the loaded stellar secret
 func (c *Client) Address(ctx context.Context, conState jsonrpc.State) (string, error) {
 	state := State(conState)
@@ -143,39 +237,252 @@ func (c *Client) Address(ctx context.Context, conState jsonrpc.State) (string, e
 	return state.Client.Address(), nil
 }
 
-// Swap some amount from one asset to the other (for example from tft to xlm)
-func (c *Client) Swap(ctx context.Context, conState jsonrpc.State, args Swap) error {
+// Swap some amount from one asset to the other (for example from tft to xlm). Assets are tft, xlm or CODE:ISSUER.
+// The swap uses the best path on the DEX and fails if the price moves more than the slippage tolerance.
+func (c *Client) Swap(ctx context.Context, conState jsonrpc.State, args Swap) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	source, destination, err := parseAssetPair(state.Client, args.SourceAsset, args.DestinationAsset)
+	if err != nil {
+		return "", err
+	}
+
+	switch args.Mode {
+	case stellargoclient.QuoteStrictSend, "":
+		return state.Client.SwapStrictSend(source, destination, args.Amount, args.Slippage)
+	case stellargoclient.QuoteStrictReceive:
+		return state.Client.SwapStrictReceive(source, destination, args.Amount, args.Slippage)
+	default:
+		return "", fmt.Errorf("unknown swap mode %s", args.Mode)
+	}
+}
+
+// Quote lists the paths on the DEX to swap between two assets, best path first.
+func (c *Client) Quote(ctx context.Context, conState jsonrpc.State, args Quote) ([]stellargoclient.Quote, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	source, destination, err := parseAssetPair(state.Client, args.SourceAsset, args.DestinationAsset)
+	if err != nil {
+		return nil, err
+	}
+
+	return state.Client.Quote(args.Mode, source, destination, args.Amount)
+}
+
+// CreateSellOffer places an offer selling an amount of an asset at a price in units of buying per unit of selling.
+// The ID of the offer is returned, 0 if it was filled immediately.
+func (c *Client) CreateSellOffer(ctx context.Context, conState jsonrpc.State, args Offer) (int64, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return 0, pkg.ErrClientNotConnected{}
+	}
+
+	selling, buying, err := parseAssetPair(state.Client, args.Selling, args.Buying)
+	if err != nil {
+		return 0, err
+	}
+
+	return state.Client.CreateSellOffer(selling, buying, args.Amount, args.Price)
+}
+
+// CreateBuyOffer places an offer buying an amount of an asset at a price in units of selling per unit of buying.
+// The ID of the offer is returned, 0 if it was filled immediately.
+func (c *Client) CreateBuyOffer(ctx context.Context, conState jsonrpc.State, args Offer) (int64, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return 0, pkg.ErrClientNotConnected{}
+	}
+
+	selling, buying, err := parseAssetPair(state.Client, args.Selling, args.Buying)
+	if err != nil {
+		return 0, err
+	}
+
+	return state.Client.CreateBuyOffer(selling, buying, args.Amount, args.Price)
+}
+
+// UpdateOffer changes the amount and/or price of an offer of the loaded account, expressed in the selling asset.
+func (c *Client) UpdateOffer(ctx context.Context, conState jsonrpc.State, args UpdateOffer) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.UpdateOffer(args.OfferID, args.Amount, args.Price)
+}
+
+// CancelOffer removes an offer of the loaded account.
+func (c *Client) CancelOffer(ctx context.Context, conState jsonrpc.State, offerID int64) error {
 	state := State(conState)
 	if state.Client == nil {
 		return pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.Swap(args.SourceAsset, args.DestinationAsset, args.Amount)
+	return state.Client.CancelOffer(offerID)
 }
 
-// Transer an amount of TFT from the loaded account to the destination.
+// ListOffers lists the open offers of an account, leave empty for the loaded account.
+func (c *Client) ListOffers(ctx context.Context, conState jsonrpc.State, account string) ([]horizon.Offer, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.ListOffers(account)
+}
+
+// OrderBook of an asset pair on the DEX.
+func (c *Client) OrderBook(ctx context.Context, conState jsonrpc.State, args OrderBook) (horizon.OrderBookSummary, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return horizon.OrderBookSummary{}, pkg.ErrClientNotConnected{}
+	}
+
+	selling, buying, err := parseAssetPair(state.Client, args.Selling, args.Buying)
+	if err != nil {
+		return horizon.OrderBookSummary{}, err
+	}
+
+	return state.Client.OrderBook(selling, buying, args.Limit)
+}
+
+// CreateClaimableBalance locks an amount of an asset (tft, xlm or CODE:ISSUER) in a claimable balance which the
+// claimants can claim when their predicate holds, even if they don't have a trustline yet. Returns the balance id.
+func (c *Client) CreateClaimableBalance(ctx context.Context, conState jsonrpc.State, args CreateClaimableBalance) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	asset, err := state.Client.GetAssetFromString(args.Asset)
+	if err != nil {
+		return "", err
+	}
+
+	return state.Client.CreateClaimableBalance(asset, args.Amount, args.Claimants)
+}
+
+// ListClaimableBalances lists the claimable balances a claimant can claim (the loaded account if empty), optionally
+// only of a single asset.
+func (c *Client) ListClaimableBalances(ctx context.Context, conState jsonrpc.State, args ListClaimableBalances) ([]horizon.ClaimableBalance, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	var asset txnbuild.Asset
+	if args.Asset != "" {
+		var err error
+		if asset, err = state.Client.GetAssetFromString(args.Asset); err != nil {
+			return nil, err
+		}
+	}
+
+	return state.Client.ListClaimableBalances(args.Claimant, asset)
+}
+
+// ClaimBalance claims a claimable balance on the loaded account, adding a trustline for its asset if needed.
+func (c *Client) ClaimBalance(ctx context.Context, conState jsonrpc.State, balanceID string) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.ClaimBalance(balanceID)
+}
+
+func parseAssetPair(client *stellargoclient.Client, first, second string) (txnbuild.Asset, txnbuild.Asset, error) {
+	a, err := client.GetAssetFromString(first)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := client.GetAssetFromString(second)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, b, nil
+}
+
+// Transfer an amount of an asset (TFT if not specified) from the loaded account to the destination.
 func (c *Client) Transfer(ctx context.Context, conState jsonrpc.State, args Transfer) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	return state.Client.Transfer(args.Destination, args.Memo, args.Amount)
+	if args.Asset == "" {
+		return state.Client.Transfer(args.Destination, args.Memo, args.Amount)
+	}
+
+	asset, err := state.Client.GetAssetFromString(args.Asset)
+	if err != nil {
+		return "", err
+	}
+
+	return state.Client.TransferAsset(asset, args.Destination, args.Memo, args.Amount)
 }
 
-// Balance of an account for TFT on stellar.
-func (c *Client) Balance(ctx context.Context, conState jsonrpc.State, address string) (string, error) {
+// Balance of an account for all assets it holds, including XLM.
+func (c *Client) Balance(ctx context.Context, conState jsonrpc.State, address string) ([]horizon.Balance, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	return state.Client.GetBalances(address)
+}
+
+// AssetBalance is the balance of an account for a single asset (tft, xlm or CODE:ISSUER).
+func (c *Client) AssetBalance(ctx context.Context, conState jsonrpc.State, args AssetBalance) (string, error) {
 	state := State(conState)
 	if state.Client == nil {
 		return "", pkg.ErrClientNotConnected{}
 	}
 
-	balance, err := state.Client.GetBalance(address)
+	asset, err := state.Client.GetAssetFromString(args.Asset)
 	if err != nil {
 		return "", err
 	}
 
-	return balance, nil
+	return state.Client.GetAssetBalance(args.Address, asset)
+}
+
+// AddTrustline adds a trustline for an asset (tft or CODE:ISSUER) to the loaded account, or updates its limit.
+// If no limit is given the maximum limit is used.
+func (c *Client) AddTrustline(ctx context.Context, conState jsonrpc.State, args Trustline) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	asset, err := state.Client.GetAssetFromString(args.Asset)
+	if err != nil {
+		return err
+	}
+
+	return state.Client.AddTrustline(asset, args.Limit)
+}
+
+// RemoveTrustline removes the trustline for an asset (tft or CODE:ISSUER) from the loaded account. The account
+// should not hold any of the asset anymore.
+func (c *Client) RemoveTrustline(ctx context.Context, conState jsonrpc.State, asset string) error {
+	state := State(conState)
+	if state.Client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	a, err := state.Client.GetAssetFromString(asset)
+	if err != nil {
+		return err
+	}
+
+	return state.Client.RemoveTrustline(a)
 }
 
 // BridgeToEth transfers TFT from the loaded account to eth bridge and deposits into the destination ethereum account.
@@ -210,14 +517,58 @@ func (c *Client) BridgeToTfchain(ctx context.Context, conState jsonrpc.State, ar
 	return state.Client.TransferToTfchainBridge(args.Amount, args.TwinId)
 }
 
-// Await till a transaction is processed on ethereum bridge that contains a specific memo
-func (c *Client) AwaitTransactionOnEthBridge(ctx context.Context, conState jsonrpc.State, memo string) error {
+// Await till a transaction is processed on ethereum bridge that contains a specific memo, looking at the payments of
+// the bridge after the cursor
+func (c *Client) AwaitTransactionOnEthBridge(ctx context.Context, conState jsonrpc.State, args AwaitTransactionOnEthBridge) error {
 	state := State(conState)
 	if state.Client == nil {
 		return pkg.ErrClientNotConnected{}
 	}
+	if args.Cursor == "" {
+		return errors.New("cursor is required, take it with BridgeCursor before submitting the bridge transfer")
+	}
+
+	return state.Client.AwaitTransactionWithMemoOnEthBridge(ctx, args.Cursor, args.Memo, defaultAwaitTimeout)
+}
+
+// BridgeCursor returns a cursor pointing at the latest payment of the "eth" or "tfchain" bridge account. Take it
+// before initiating a bridge transfer and pass it to AwaitBridgePayment so no payment is missed.
+func (c *Client) BridgeCursor(ctx context.Context, conState jsonrpc.State, bridge string) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	bridgeAddress, err := state.Client.GetBridgeAddress(bridge)
+	if err != nil {
+		return "", err
+	}
+
+	return state.Client.PaymentsCursor(bridgeAddress)
+}
 
-	return state.Client.AwaitTransactionWithMemoOnEthBridge(ctx, memo, 300)
+// AwaitBridgePayment waits till the bridge account makes a payment matching the memo and optionally the destination
+// and amount, starting from the cursor. The hash of the matching transaction is returned.
+func (c *Client) AwaitBridgePayment(ctx context.Context, conState jsonrpc.State, args AwaitBridgePayment) (string, error) {
+	state := State(conState)
+	if state.Client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	bridgeAddress, err := state.Client.GetBridgeAddress(args.Bridge)
+	if err != nil {
+		return "", err
+	}
+	if args.Timeout == 0 {
+		args.Timeout = defaultAwaitTimeout
+	}
+
+	filter := stellargoclient.PaymentFilter{
+		Memo:        args.Memo,
+		Destination: args.Destination,
+		Amount:      args.Amount,
+	}
+	return state.Client.AwaitPayment(ctx, bridgeAddress, args.Cursor, filter, time.Duration(args.Timeout)*time.Second)
 }
 
 // Get the last transactions of your account
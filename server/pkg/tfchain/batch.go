@@ -0,0 +1,176 @@
+package tfchain
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+type (
+	// BatchCall is a call to submit in a batch. Args are the arguments of the rpc with the same name, like
+	// CreateNodeContract for create_node_contract.
+	BatchCall struct {
+		Name string          `json:"name"`
+		Args json.RawMessage `json:"args"`
+	}
+
+	BatchResult struct {
+		BlockHash          string   `json:"block_hash"`
+		BlockNumber        uint32   `json:"block_number"`
+		ContractIDs        []uint64 `json:"contract_ids"`
+		ServiceContractIDs []uint64 `json:"service_contract_ids"`
+	}
+)
+
+// batchCalls decode the arguments of a call into the call to submit
+var batchCalls = map[string]func(args json.RawMessage) (tfchainclient.Call, error){
+	"create_node_contract": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var a CreateNodeContract
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.CreateNodeContractCall(a.NodeID, a.Body, a.Hash, a.PublicIPs, a.SolutionProviderID), nil
+	},
+	"create_name_contract": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var name string
+		if err := json.Unmarshal(args, &name); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.CreateNameContractCall(name), nil
+	},
+	"create_rent_contract": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var a CreateRentContract
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.CreateRentContractCall(a.NodeID, a.SolutionProviderID), nil
+	},
+	"cancel_contract":          contractCall(tfchainclient.CancelContractCall),
+	"service_contract_approve": contractCall(tfchainclient.ServiceContractApproveCall),
+	"service_contract_reject":  contractCall(tfchainclient.ServiceContractRejectCall),
+	"service_contract_cancel":  contractCall(tfchainclient.ServiceContractCancelCall),
+	"transfer": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var a Transfer
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		dest, err := substrate.FromAddress(a.Destination)
+		if err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.TransferCall(a.Amount, dest)
+	},
+	"service_contract_create": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var a ServiceContractCreate
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		service, err := substrate.FromAddress(a.Service)
+		if err != nil {
+			return tfchainclient.Call{}, err
+		}
+		consumer, err := substrate.FromAddress(a.Consumer)
+		if err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.ServiceContractCreateCall(service, consumer), nil
+	},
+	"service_contract_bill": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var a ServiceContractBill
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.ServiceContractBillCall(a.ContractID, a.VariableAmount, a.Metadata), nil
+	},
+	"service_contract_set_fees": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var a SetServiceContractFees
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.ServiceContractSetFeesCall(a.ContractID, a.BaseFee, a.VariableFee), nil
+	},
+	"service_contract_set_metadata": func(args json.RawMessage) (tfchainclient.Call, error) {
+		var a ServiceContractSetMetadata
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return tfchainclient.ServiceContractSetMetadataCall(a.ContractID, a.Metadata), nil
+	},
+}
+
+// contractCall decodes calls which only take a contract id
+func contractCall(call func(contract uint64) tfchainclient.Call) func(args json.RawMessage) (tfchainclient.Call, error) {
+	return func(args json.RawMessage) (tfchainclient.Call, error) {
+		var contract uint64
+		if err := json.Unmarshal(args, &contract); err != nil {
+			return tfchainclient.Call{}, err
+		}
+		return call(contract), nil
+	}
+}
+
+func decodeBatchCalls(calls []BatchCall) ([]tfchainclient.Call, error) {
+	decoded := make([]tfchainclient.Call, 0, len(calls))
+	for _, c := range calls {
+		decode, ok := batchCalls[c.Name]
+		if !ok {
+			return nil, errors.Errorf("unsupported call %s", c.Name)
+		}
+		call, err := decode(c.Args)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid arguments for %s", c.Name)
+		}
+		decoded = append(decoded, call)
+	}
+	return decoded, nil
+}
+
+// Batch submits calls in one extrinsic, either all of them succeed or none does. The ids of the contracts the batch
+// created are returned.
+func (c *Client) Batch(ctx context.Context, conState jsonrpc.State, calls []BatchCall) (BatchResult, error) {
+	state := State(conState)
+	if state.client == nil {
+		return BatchResult{}, pkg.ErrClientNotConnected{}
+	}
+
+	decoded, err := decodeBatchCalls(calls)
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	result, err := tfchainclient.Batch(state.client, state.identity, decoded)
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	return BatchResult{
+		BlockHash:          result.BlockHash,
+		BlockNumber:        result.BlockNumber,
+		ContractIDs:        result.ContractIDs,
+		ServiceContractIDs: result.ServiceContractIDs,
+	}, nil
+}
+
+// EstimateFee returns the transaction fee in units of 10^-7 TFT for submitting calls with the loaded account. A single
+// call is estimated on its own, several calls as a batch.
+func (c *Client) EstimateFee(ctx context.Context, conState jsonrpc.State, calls []BatchCall) (uint64, error) {
+	state := State(conState)
+	if state.client == nil {
+		return 0, pkg.ErrClientNotConnected{}
+	}
+
+	decoded, err := decodeBatchCalls(calls)
+	if err != nil {
+		return 0, err
+	}
+	if len(decoded) == 0 {
+		return 0, errors.New("no calls to estimate")
+	}
+
+	return tfchainclient.EstimateFee(state.client, state.identity, decoded)
+}
@@ -0,0 +1,98 @@
+package tfchain
+
+import (
+	"context"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+const gigabyte = 1 << 30
+
+type (
+	ContractBillingInfo struct {
+		// AmountUnbilled is the cost in USD of the reported network usage which is not billed yet
+		AmountUnbilled float64 `json:"amount_unbilled"`
+		// LastBilled is the unix time the contract was last billed or its network usage was last reported
+		LastBilled uint64 `json:"last_billed"`
+		// PreviousNUReported is the network usage of the last report, in bytes
+		PreviousNUReported uint64 `json:"previous_nu_reported"`
+	}
+
+	EstimateContractCost struct {
+		CRU uint64 `json:"cru"`
+		// MRU, SRU and HRU are in GB
+		MRU       float64 `json:"mru"`
+		SRU       float64 `json:"sru"`
+		HRU       float64 `json:"hru"`
+		PublicIPs uint32  `json:"public_ips"`
+		// Certified node, ignored if a node id is given
+		Certified bool `json:"certified"`
+		// Dedicated is set to estimate a rent contract, which gets the discount for dedicated nodes. If a node id is
+		// given and no resources, the resources of the node are used.
+		Dedicated bool `json:"dedicated"`
+		// NodeID to estimate the cost on, its certification and the pricing policy of its farm are used
+		NodeID uint32 `json:"node_id"`
+	}
+
+	ContractCost struct {
+		USDPerMonth float64 `json:"usd_per_month"`
+		TFTPerMonth float64 `json:"tft_per_month"`
+		// DiscountLevel the balance of the loaded account gives: none, default, bronze, silver or gold
+		DiscountLevel string `json:"discount_level"`
+	}
+)
+
+// ContractBillingInfo returns what tfchain keeps to bill a contract
+func (c *Client) ContractBillingInfo(ctx context.Context, conState jsonrpc.State, contractID uint64) (ContractBillingInfo, error) {
+	state := State(conState)
+	if state.client == nil {
+		return ContractBillingInfo{}, pkg.ErrClientNotConnected{}
+	}
+
+	info, err := tfchainclient.GetBillingInfo(state.client, contractID)
+	if err != nil {
+		return ContractBillingInfo{}, err
+	}
+
+	return ContractBillingInfo{
+		AmountUnbilled:     info.AmountUnbilled,
+		LastBilled:         info.LastUpdated,
+		PreviousNUReported: info.PreviousNUReported,
+	}, nil
+}
+
+// EstimateContractCost estimates the monthly cost of a contract reserving resources, using the pricing policy and
+// TFT price on chain. The discount is based on the balance of the loaded account.
+func (c *Client) EstimateContractCost(ctx context.Context, conState jsonrpc.State, args EstimateContractCost) (ContractCost, error) {
+	state := State(conState)
+	if state.client == nil {
+		return ContractCost{}, pkg.ErrClientNotConnected{}
+	}
+
+	res := tfchainclient.Resources{
+		CRU:       args.CRU,
+		MRU:       uint64(args.MRU * gigabyte),
+		SRU:       uint64(args.SRU * gigabyte),
+		HRU:       uint64(args.HRU * gigabyte),
+		PublicIPs: args.PublicIPs,
+	}
+	opts := tfchainclient.CostOptions{Certified: args.Certified, Dedicated: args.Dedicated}
+
+	account, err := substrate.FromAddress(state.identity.Address())
+	if err != nil {
+		return ContractCost{}, err
+	}
+	cost, err := tfchainclient.EstimateContractCost(state.client, account, args.NodeID, res, opts)
+	if err != nil {
+		return ContractCost{}, err
+	}
+
+	return ContractCost{
+		USDPerMonth:   cost.USD,
+		TFTPerMonth:   cost.TFT,
+		DiscountLevel: cost.Discount,
+	}, nil
+}
@@ -2,18 +2,16 @@ package tfchain
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"errors"
-	"io"
+	"fmt"
 	"math/big"
-	"net/http"
 	"time"
 
 	"github.com/LeeSmet/go-jsonrpc"
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
 	"github.com/cosmos/go-bip39"
 	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
 	"github.com/threefoldtech/web3_proxy/server/pkg"
 	"github.com/threefoldtech/web3_proxy/server/pkg/state"
 )
@@ -38,9 +36,9 @@ const (
 
 	termsAndConditionsLink = "https://library.threefold.me/info/legal/#/tfgrid/terms_conditions_tfgrid3"
 
-	termsUser     = "https://raw.githubusercontent.com/threefoldfoundation/info_legal/master/wiki/terms_conditions_griduser.md"
-	privacyPolicy = "https://raw.githubusercontent.com/threefoldfoundation/info_legal/master/wiki/privacypolicy.md"
-	disclaimer    = "https://raw.githubusercontent.com/threefoldfoundation/info_legal/master/wiki/disclaimer.md"
+	FundingActivation = "activation"
+	FundingTransfer   = "transfer"
+	FundingSudo       = "sudo"
 
 	stellarPublicNetworkTfchainBridgeAddress  = "GBNOTAYUMXVO5QDYWYO2SOCOYIJ3XFIP65GKOQN7H65ZZSO6BK4SLWSC"
 	stellarTestnetNetworkTfchainBridgeAddress = "GDHJP6TF3UXYXTNEZ2P36J5FH7W4BJJQ4AYYAXC66I2Q2AH5B6O6BCFG"
@@ -59,11 +57,45 @@ type (
 		client   *substrate.Substrate
 		identity substrate.Identity
 		network  string
+
+		subscriptions tfchainclient.Subscriptions
+		// farms of twins, used to find the farms of the loaded twin
+		farms tfchainclient.FarmIndex
+		// proposals closed by the council, found in the events of the chain
+		closed tfchainclient.ClosedProposals
 	}
 
 	Load struct {
 		Network  string `json:"network"`
 		Mnemonic string `json:"mnemonic"`
+		// URL of a tfchain node to connect to instead of the one of the network, like a local dev node
+		URL string `json:"url"`
+	}
+
+	AccountFunding struct {
+		// Method is activation, transfer or sudo, defaults to activation
+		Method string `json:"method"`
+		// URL of the activation service, defaults to the one of the network
+		URL string `json:"url"`
+		// Mnemonic of the account funding with a transfer or sudo, sudo defaults to //Alice of a dev node
+		Mnemonic string `json:"mnemonic"`
+		// Amount in units of 10^-7 TFT to fund with a transfer or sudo, defaults to 10 TFT
+		Amount uint64 `json:"amount"`
+	}
+
+	CreateAccountOptions struct {
+		Network string `json:"network"`
+		// URL of a tfchain node to connect to instead of the one of the network, like a local dev node
+		URL string `json:"url"`
+		// Relay of the twin, defaults to the one of the network or no relay if the network is unknown
+		Relay   string         `json:"relay"`
+		Funding AccountFunding `json:"funding"`
+		// TermsLink is the link to the terms and conditions to accept
+		TermsLink string `json:"terms_link"`
+		// TermsHash is the hash of the terms and conditions, it is computed from TermsDocuments if empty
+		TermsHash string `json:"terms_hash"`
+		// TermsDocuments are https urls of the terms and conditions documents, defaults to the grid documents
+		TermsDocuments []string `json:"terms_documents"`
 	}
 
 	Transfer struct {
@@ -76,6 +108,13 @@ type (
 		Pk    []byte `json:"pk"`
 	}
 
+	UpdateTwin struct {
+		// Relay of the twin, the current relay is kept if nil and removed if empty
+		Relay *string `json:"relay"`
+		// Pk of the twin, the current pk is kept if nil
+		Pk []byte `json:"pk"`
+	}
+
 	AcceptTermsAndConditions struct {
 		Link string `json:"link"`
 		Hash string `json:"hash"`
@@ -158,9 +197,44 @@ func State(conState jsonrpc.State) *TfchainState {
 
 // Close implements jsonrpc.Closer
 func (s *TfchainState) Close() {
+	s.subscriptions.CloseAll()
 	s.client.Close()
 }
 
+// load replaces the loaded client, closing the previous client and the subscriptions following it
+func (s *TfchainState) load(client *substrate.Substrate, identity substrate.Identity, network string) {
+	s.subscriptions.CloseAll()
+	s.farms.Invalidate()
+	s.closed.Invalidate()
+	if s.client != nil {
+		s.client.Close()
+	}
+
+	s.client = client
+	s.identity = identity
+	s.network = network
+}
+
+// Substrate connection of the loaded client, nil if no client is loaded
+func (s *TfchainState) Substrate() *substrate.Substrate {
+	return s.client
+}
+
+// Identity of the loaded client
+func (s *TfchainState) Identity() substrate.Identity {
+	return s.identity
+}
+
+// Network of the loaded client
+func (s *TfchainState) Network() string {
+	return s.network
+}
+
+// Connect to tfchain on a network: main, test, qa or dev
+func Connect(network string) (*substrate.Substrate, error) {
+	return getSubstrateConnectionFromNetwork(network)
+}
+
 // NewClient creates a new Client ready for use
 func NewClient() *Client {
 	return &Client{
@@ -216,58 +290,67 @@ func tfchainBridgeAddressFromNetwork(network string) (string, error) {
 	return "", errors.New("unsupported network")
 }
 
-func getTermsAndConditionsHash() (string, error) {
-	resp, err := http.Get(termsUser)
-	if err != nil {
-		return "", err
-	}
-
-	termsUserData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	resp, err = http.Get(disclaimer)
-	if err != nil {
-		return "", err
-	}
-
-	disclaimerData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	resp, err = http.Get(privacyPolicy)
-	if err != nil {
-		return "", err
-	}
-
-	privacyPolicyData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	termsUserData = append(termsUserData, disclaimerData...)
-	termsUserData = append(termsUserData, privacyPolicyData...)
-
-	termsAndConditionsHash := md5.Sum(termsUserData)
+func getSubstrateConnectionFromNetwork(network string) (*substrate.Substrate, error) {
+	url, err := tfchainNetworkFromNetworkString(network)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return hex.EncodeToString(termsAndConditionsHash[:]), nil
+	mgr := substrate.NewManager(url)
+	return mgr.Substrate()
 }
 
-func getSubstrateConnectionFromNetwork(network string) (*substrate.Substrate, error) {
-	url, err := tfchainNetworkFromNetworkString(network)
-	if err != nil {
-		return nil, err
+// connect to the node at url, or to the network if url is empty
+func connect(network, url string) (*substrate.Substrate, error) {
+	if url == "" {
+		return getSubstrateConnectionFromNetwork(network)
 	}
 
 	mgr := substrate.NewManager(url)
 	return mgr.Substrate()
 }
 
+// funderFromOptions creates the funder of new accounts on a network
+func funderFromOptions(network string, funding AccountFunding) (tfchainclient.Funder, error) {
+	switch funding.Method {
+	case "", FundingActivation:
+		url := funding.URL
+		if url == "" {
+			var err error
+			if url, err = activationURLFromNetwork(network); err != nil {
+				return nil, err
+			}
+		}
+		return tfchainclient.ActivationService{URL: url}, nil
+	case FundingTransfer:
+		if funding.Mnemonic == "" {
+			return nil, errors.New("funding with a transfer requires the mnemonic of the funding account")
+		}
+		identity, err := substrate.NewIdentityFromSr25519Phrase(funding.Mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		return tfchainclient.TransferFunder{Identity: identity, Amount: funding.Amount}, nil
+	case FundingSudo:
+		if funding.Mnemonic == "" {
+			funder, err := tfchainclient.NewDevFunder()
+			if err != nil {
+				return nil, err
+			}
+			if funding.Amount != 0 {
+				funder.Amount = funding.Amount
+			}
+			return funder, nil
+		}
+		identity, err := substrate.NewIdentityFromSr25519Phrase(funding.Mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		return tfchainclient.SudoFunder{Identity: identity, Amount: funding.Amount}, nil
+	}
+	return nil, fmt.Errorf("unknown funding method %s, it should be %s, %s or %s", funding.Method, FundingActivation, FundingTransfer, FundingSudo)
+}
+
 func generateMnemonic() (string, error) {
 	entropy, err := bip39.NewEntropy(256)
 	if err != nil {
@@ -277,72 +360,84 @@ func generateMnemonic() (string, error) {
 	return bip39.NewMnemonic(entropy)
 }
 
-// Load a client, connecting to the rpc endpoint at the given URL and loading a keypair from the given mnemonic
-
+// CreateAccount creates a new account and twin on a network, funding the account through the activation service of
+// the network. The mnemonic of the account is returned and the account is loaded.
 func (c *Client) CreateAccount(ctx context.Context, conState jsonrpc.State, network string) (string, error) {
-	mnemonic, err := generateMnemonic()
+	return c.CreateAccountWithOptions(ctx, conState, CreateAccountOptions{Network: network})
+}
+
+// CreateAccountWithOptions creates a new account and twin, funding the account in the way chosen in the options. The
+// mnemonic of the account is returned and the account is loaded.
+func (c *Client) CreateAccountWithOptions(ctx context.Context, conState jsonrpc.State, args CreateAccountOptions) (string, error) {
+	funder, err := funderFromOptions(args.Network, args.Funding)
 	if err != nil {
 		return "", err
 	}
 
-	identity, err := substrate.NewIdentityFromSr25519Phrase(mnemonic)
-	if err != nil {
-		return "", err
+	termsLink := args.TermsLink
+	if termsLink == "" {
+		termsLink = termsAndConditionsLink
+	}
+	termsHash := args.TermsHash
+	if termsHash == "" {
+		documents := args.TermsDocuments
+		if len(documents) == 0 {
+			documents = tfchainclient.TermsDocuments
+		}
+		if termsHash, err = tfchainclient.TermsHash(documents); err != nil {
+			return "", err
+		}
 	}
 
-	termsAndConditionsHash, err := getTermsAndConditionsHash()
-	if err != nil {
-		return "", err
+	relay := args.Relay
+	if relay == "" {
+		// a twin on an unknown network, like a local dev node, has no relay
+		relay, _ = relayURLFromNetwork(args.Network)
 	}
 
-	activationURL, err := activationURLFromNetwork(network)
+	mnemonic, err := generateMnemonic()
 	if err != nil {
 		return "", err
 	}
 
-	substrateConnection, err := getSubstrateConnectionFromNetwork(network)
+	identity, err := substrate.NewIdentityFromSr25519Phrase(mnemonic)
 	if err != nil {
 		return "", err
 	}
 
-	_, err = substrateConnection.EnsureAccount(identity, activationURL, termsAndConditionsLink, termsAndConditionsHash)
+	substrateConnection, err := connect(args.Network, args.URL)
 	if err != nil {
 		return "", err
 	}
 
-	relayURL, err := relayURLFromNetwork(network)
-	if err != nil {
+	if err = tfchainclient.EnsureAccount(substrateConnection, identity, funder, termsLink, termsHash); err != nil {
+		substrateConnection.Close()
 		return "", err
 	}
 
-	_, err = substrateConnection.CreateTwin(identity, relayURL, identity.PublicKey())
-	if err != nil {
+	if _, err = substrateConnection.CreateTwin(identity, relay, identity.PublicKey()); err != nil {
+		substrateConnection.Close()
 		return "", err
 	}
 
-	state := State(conState)
-	state.client = substrateConnection
-	state.identity = identity
-	state.network = network
+	State(conState).load(substrateConnection, identity, args.Network)
 
 	return mnemonic, nil
 }
 
 // Load a client, connecting to the rpc endpoint at the given URL and loading a keypair from the given mnemonic
 func (c *Client) Load(ctx context.Context, conState jsonrpc.State, args Load) error {
-	substrateConnection, err := getSubstrateConnectionFromNetwork(args.Network)
+	substrateConnection, err := connect(args.Network, args.URL)
 	if err != nil {
 		return err
 	}
 
 	identity, err := substrate.NewIdentityFromSr25519Phrase(args.Mnemonic)
 	if err != nil {
+		substrateConnection.Close()
 		return err
 	}
-	state := State(conState)
-	state.client = substrateConnection
-	state.identity = identity
-	state.network = args.Network
+	State(conState).load(substrateConnection, identity, args.Network)
 
 	return nil
 }
@@ -432,6 +527,38 @@ func (c *Client) CreateTwin(ctx context.Context, conState jsonrpc.State, args Cr
 	return state.client.CreateTwin(state.identity, args.Relay, args.Pk)
 }
 
+// UpdateTwin updates the relay and pk of the twin of the loaded account, returning the id of the twin
+func (c *Client) UpdateTwin(ctx context.Context, conState jsonrpc.State, args UpdateTwin) (uint32, error) {
+	state := State(conState)
+	if state.client == nil {
+		return 0, pkg.ErrClientNotConnected{}
+	}
+
+	id, err := state.client.GetTwinByPubKey(state.identity.PublicKey())
+	if err != nil {
+		return 0, err
+	}
+
+	twin, err := state.client.GetTwin(id)
+	if err != nil {
+		return 0, err
+	}
+
+	relay := twin.Relay.AsValue
+	if args.Relay != nil {
+		relay = *args.Relay
+	}
+
+	pk := args.Pk
+	if pk == nil {
+		if ok, current := twin.Pk.Unwrap(); ok {
+			pk = current
+		}
+	}
+
+	return state.client.UpdateTwin(state.identity, relay, pk)
+}
+
 func (c *Client) AcceptTermsAndConditions(ctx context.Context, conState jsonrpc.State, args AcceptTermsAndConditions) error {
 	state := State(conState)
 	if state.client == nil {
@@ -483,7 +610,11 @@ func (c *Client) CreateFarm(ctx context.Context, conState jsonrpc.State, args Cr
 		return pkg.ErrClientNotConnected{}
 	}
 
-	return state.client.CreateFarm(state.identity, args.Name, args.PublicIPs)
+	if err := state.client.CreateFarm(state.identity, args.Name, args.PublicIPs); err != nil {
+		return err
+	}
+	state.farms.Invalidate()
+	return nil
 }
 
 func (c *Client) GetContract(ctx context.Context, conState jsonrpc.State, contract_id uint64) (*substrate.Contract, error) {
@@ -658,7 +789,9 @@ func (c *Client) SwapToStellar(ctx context.Context, conState jsonrpc.State, args
 	return state.client.SwapToStellar(state.identity, args.TargetStellarAddress, *args.Amount)
 }
 
-func (c *Client) AwaitTransactionOnTfchainBridge(ctx context.Context, conState jsonrpc.State, memo string) error {
+// AwaitTransactionOnTfchainBridge waits till the deposit of the stellar transaction with hash txHash on the tfchain
+// bridge is minted on the loaded account.
+func (c *Client) AwaitTransactionOnTfchainBridge(ctx context.Context, conState jsonrpc.State, txHash string) error {
 	state := State(conState)
 	if state.client == nil {
 		return pkg.ErrClientNotConnected{}
@@ -668,27 +801,27 @@ func (c *Client) AwaitTransactionOnTfchainBridge(ctx context.Context, conState j
 		return err
 	}
 
-	for i := 0; i < int(timeoutAwaitTransaction); i++ {
-		select {
-		case <-time.After(1 * time.Second):
-			height, err := state.client.GetCurrentHeight()
-			if err != nil {
-				return err
-			}
-			events, err := state.client.GetEventsForBlock(height)
-			if err != nil {
-				return err
-			}
-			for i := range events.TFTBridgeModule_MintCompleted {
-				mintCompletedEvent := events.TFTBridgeModule_MintCompleted[i]
-				if mintCompletedEvent.MintTransaction.Target == types.AccountID(state.identity.PublicKey()) {
-					return nil
-				}
-			}
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+	// take the height before checking the executed mints, so a mint completing in between is still scanned
+	height, err := state.client.GetCurrentHeight()
+	if err != nil {
+		return err
+	}
+	_, err = state.client.IsMintedAlready(txHash)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, substrate.ErrMintTransactionNotFound) {
+		return err
+	}
+
+	awaitCtx, cancel := context.WithTimeout(ctx, timeoutAwaitTransaction*time.Second)
+	defer cancel()
+
+	target := substrate.AccountID(types.AccountID(state.identity.PublicKey()))
+	_, err = tfchainclient.ScannerFor(state.client).Await(awaitCtx, height, tfchainclient.MintCompleted(target, txHash))
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return errors.New("event not found")
 	}
 
-	return errors.New("event not found")
+	return err
 }
@@ -0,0 +1,231 @@
+package tfchain
+
+import (
+	"context"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+const (
+	ProposalActive = "active"
+	// ProposalClosed is the status of proposals whose voting ended or which the council closed
+	ProposalClosed = "closed"
+)
+
+type (
+	ListProposals struct {
+		// Status filters the proposals on active or closed, all proposals are listed if empty
+		Status string `json:"status"`
+		// FromBlock is the first block proposals closed by the council are looked for in, the last ClosedProposalWindow
+		// blocks if 0
+		FromBlock uint32 `json:"from_block"`
+	}
+
+	VoteWeight struct {
+		FarmID uint32 `json:"farm_id"`
+		Weight uint64 `json:"weight"`
+	}
+
+	Proposal struct {
+		Hash        string `json:"hash"`
+		Index       uint32 `json:"index"`
+		Description string `json:"description"`
+		Link        string `json:"link"`
+		// Status is active while farms can vote, closed once voting ended or the council closed the proposal
+		Status string `json:"status"`
+		// Outcome is approved, disapproved or vetoed once the council closed the proposal, empty before
+		Outcome string `json:"outcome"`
+		// ClosedBlock is the block the council closed the proposal in, 0 before
+		ClosedBlock uint32 `json:"closed_block"`
+		// Threshold is the minimal number of votes for the proposal to pass
+		Threshold uint32       `json:"threshold"`
+		Ayes      []VoteWeight `json:"ayes"`
+		Nays      []VoteWeight `json:"nays"`
+		AyesCount uint32       `json:"ayes_count"`
+		NaysCount uint32       `json:"nays_count"`
+		// EndBlock is the block voting ends in
+		EndBlock uint32 `json:"end_block"`
+		// Vetos are the addresses of the council members who vetoed the proposal
+		Vetos []string `json:"vetos"`
+	}
+
+	Vote struct {
+		FarmID       uint32 `json:"farm_id"`
+		ProposalHash string `json:"proposal_hash"`
+		Approve      bool   `json:"approve"`
+	}
+
+	UnvotedProposal struct {
+		Proposal Proposal `json:"proposal"`
+		// FarmIDs are the farms of the loaded twin which did not vote on the proposal
+		FarmIDs []uint32 `json:"farm_ids"`
+	}
+)
+
+func newProposal(p tfchainclient.Proposal, height uint32) Proposal {
+	proposal := Proposal{
+		Hash:        p.Hash.Hex(),
+		Index:       p.Index,
+		Description: p.Description,
+		Link:        p.Link,
+		Status:      ProposalClosed,
+		Threshold:   p.Threshold,
+		Ayes:        newVoteWeights(p.Ayes),
+		Nays:        newVoteWeights(p.Nays),
+		AyesCount:   uint32(len(p.Ayes)),
+		NaysCount:   uint32(len(p.Nays)),
+		EndBlock:    p.End,
+		Vetos:       make([]string, 0, len(p.Vetos)),
+	}
+	if p.Open(height) {
+		proposal.Status = ProposalActive
+	}
+	for _, veto := range p.Vetos {
+		proposal.Vetos = append(proposal.Vetos, veto.String())
+	}
+	return proposal
+}
+
+func newVoteWeights(votes []tfchainclient.VoteWeight) []VoteWeight {
+	weights := make([]VoteWeight, 0, len(votes))
+	for _, vote := range votes {
+		weights = append(weights, VoteWeight{FarmID: vote.FarmID, Weight: vote.Weight})
+	}
+	return weights
+}
+
+// ListProposals lists the dao proposals, optionally filtered on their status. Closed proposals include the proposals
+// the council closed from FromBlock on, which are found in the events of the chain.
+func (c *Client) ListProposals(ctx context.Context, conState jsonrpc.State, args ListProposals) ([]Proposal, error) {
+	state := State(conState)
+	if state.client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	if args.Status != "" && args.Status != ProposalActive && args.Status != ProposalClosed {
+		return nil, errors.Errorf("invalid status %s, it should be %s or %s", args.Status, ProposalActive, ProposalClosed)
+	}
+
+	height, err := state.client.GetCurrentHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	proposals, err := tfchainclient.ListProposals(state.client)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]Proposal, 0, len(proposals))
+	for _, p := range proposals {
+		proposal := newProposal(p, height)
+		if args.Status == "" || args.Status == proposal.Status {
+			listed = append(listed, proposal)
+		}
+	}
+	if args.Status == ProposalActive {
+		return listed, nil
+	}
+
+	from := args.FromBlock
+	if from == 0 && height > tfchainclient.ClosedProposalWindow {
+		from = height - tfchainclient.ClosedProposalWindow
+	}
+	closed, err := state.closed.List(state.client, from)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range closed {
+		proposal := newProposal(p.Proposal, height)
+		proposal.Status = ProposalClosed
+		proposal.Outcome = p.Outcome
+		proposal.ClosedBlock = p.Block
+		listed = append(listed, proposal)
+	}
+
+	return listed, nil
+}
+
+// GetProposal returns the dao proposal with a hash
+func (c *Client) GetProposal(ctx context.Context, conState jsonrpc.State, hash string) (Proposal, error) {
+	state := State(conState)
+	if state.client == nil {
+		return Proposal{}, pkg.ErrClientNotConnected{}
+	}
+
+	proposalHash, err := types.NewHashFromHexString(hash)
+	if err != nil {
+		return Proposal{}, errors.Wrap(err, "invalid proposal hash")
+	}
+
+	height, err := state.client.GetCurrentHeight()
+	if err != nil {
+		return Proposal{}, err
+	}
+
+	proposal, err := tfchainclient.GetProposal(state.client, proposalHash)
+	if err != nil {
+		return Proposal{}, err
+	}
+
+	return newProposal(proposal, height), nil
+}
+
+// Vote on a dao proposal with a farm of the loaded account
+func (c *Client) Vote(ctx context.Context, conState jsonrpc.State, args Vote) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	proposalHash, err := types.NewHashFromHexString(args.ProposalHash)
+	if err != nil {
+		return errors.Wrap(err, "invalid proposal hash")
+	}
+
+	return tfchainclient.Vote(state.client, state.identity, args.FarmID, proposalHash, args.Approve)
+}
+
+// ListUnvotedProposals lists the active dao proposals which some farms of the loaded twin did not vote on yet
+func (c *Client) ListUnvotedProposals(ctx context.Context, conState jsonrpc.State) ([]UnvotedProposal, error) {
+	state := State(conState)
+	if state.client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	twin, err := state.client.GetTwinByPubKey(state.identity.PublicKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get twin of loaded account")
+	}
+
+	farms, err := state.farms.FarmsOfTwin(state.client, twin)
+	if err != nil {
+		return nil, err
+	}
+
+	height, err := state.client.GetCurrentHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	proposals, err := tfchainclient.ListProposals(state.client)
+	if err != nil {
+		return nil, err
+	}
+
+	unvoted := []UnvotedProposal{}
+	for _, p := range proposals {
+		if !p.Open(height) {
+			continue
+		}
+		if missing := p.Unvoted(farms); len(missing) > 0 {
+			unvoted = append(unvoted, UnvotedProposal{Proposal: newProposal(p, height), FarmIDs: missing})
+		}
+	}
+
+	return unvoted, nil
+}
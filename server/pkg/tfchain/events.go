@@ -0,0 +1,111 @@
+package tfchain
+
+import (
+	"context"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+type (
+	EventFilter struct {
+		// Pallets emitting the events, e.g. SmartContractModule, TfgridModule or TFTBridgeModule
+		Pallets []string `json:"pallets"`
+		// Names of the events, e.g. ContractCreated, NodeContractCanceled, ContractBilled, NodeUptimeReported,
+		// TwinUpdated, MintCompleted or BurnTransactionProcessed
+		Names []string `json:"names"`
+		// Account which must appear in the event, as ss58 address
+		Account string `json:"account"`
+		// TwinID the event must be about
+		TwinID uint32 `json:"twin_id"`
+		// NodeID the event must be about
+		NodeID uint32 `json:"node_id"`
+		// ContractID the event must be about
+		ContractID uint64 `json:"contract_id"`
+		// FromBlock is the first block to deliver events of, the next finalized block if not set
+		FromBlock uint32 `json:"from_block"`
+	}
+
+	Event struct {
+		Block uint32 `json:"block"`
+		// Extrinsic is the index of the extrinsic which emitted the event in the block, not set for events emitted
+		// while initializing or finalizing the block
+		Extrinsic *uint32                `json:"extrinsic"`
+		Pallet    string                 `json:"pallet"`
+		Name      string                 `json:"name"`
+		Data      map[string]interface{} `json:"data"`
+	}
+
+	GetSubscriptionEvents struct {
+		ID string `json:"id"`
+		// Count of events to take, all buffered events if 0
+		Count uint32 `json:"count"`
+	}
+)
+
+// SubscribeEvents subscribes to the events matching a filter, which are delivered once their block is finalized.
+// The subscription id is returned, the events are taken with GetSubscriptionEvents.
+func (c *Client) SubscribeEvents(ctx context.Context, conState jsonrpc.State, args EventFilter) (string, error) {
+	state := State(conState)
+	if state.client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	filter := tfchainclient.EventFilter{
+		Pallets:    args.Pallets,
+		Names:      args.Names,
+		Account:    args.Account,
+		TwinID:     args.TwinID,
+		NodeID:     args.NodeID,
+		ContractID: args.ContractID,
+	}
+	return state.subscriptions.Subscribe(tfchainclient.Finalized(state.client), filter, args.FromBlock)
+}
+
+// GetSubscriptionEvents takes the buffered events of a subscription, oldest first
+func (c *Client) GetSubscriptionEvents(ctx context.Context, conState jsonrpc.State, args GetSubscriptionEvents) ([]Event, error) {
+	state := State(conState)
+	if state.client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	events, err := state.subscriptions.Events(args.ID, args.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Event, 0, len(events))
+	for _, e := range events {
+		result = append(result, Event{
+			Block:     e.Block,
+			Extrinsic: e.Extrinsic,
+			Pallet:    e.Pallet,
+			Name:      e.Name,
+			Data:      e.Data,
+		})
+	}
+	return result, nil
+}
+
+// GetSubscriptionIds returns the ids of the active event subscriptions
+func (c *Client) GetSubscriptionIds(ctx context.Context, conState jsonrpc.State) ([]string, error) {
+	state := State(conState)
+	if state.client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	return state.subscriptions.Ids(), nil
+}
+
+// CloseSubscription closes an event subscription by id
+func (c *Client) CloseSubscription(ctx context.Context, conState jsonrpc.State, id string) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	state.subscriptions.Close(id)
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package tfchain
+
+import (
+	"context"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+const (
+	PowerUp   = "up"
+	PowerDown = "down"
+)
+
+type (
+	AddFarmIP struct {
+		FarmID  uint32 `json:"farm_id"`
+		IP      string `json:"ip"`
+		Gateway string `json:"gateway"`
+	}
+
+	RemoveFarmIP struct {
+		FarmID uint32 `json:"farm_id"`
+		IP     string `json:"ip"`
+	}
+
+	SetFarmCertification struct {
+		FarmID uint32 `json:"farm_id"`
+		// Gold certifies the farm, false removes the certification
+		Gold bool `json:"gold"`
+	}
+
+	SetFarmDedicated struct {
+		FarmID    uint32 `json:"farm_id"`
+		Dedicated bool   `json:"dedicated"`
+	}
+
+	SetFarmPayoutStellarAddress struct {
+		FarmID         uint32 `json:"farm_id"`
+		StellarAddress string `json:"stellar_address"`
+	}
+
+	SetNodePowerTarget struct {
+		NodeID uint32 `json:"node_id"`
+		// Target is up or down
+		Target string `json:"target"`
+	}
+
+	NodePublicConfig struct {
+		// IPv4 and IPv6 are in cidr notation
+		IPv4   string `json:"ipv4"`
+		GW4    string `json:"gw4"`
+		IPv6   string `json:"ipv6"`
+		GW6    string `json:"gw6"`
+		Domain string `json:"domain"`
+	}
+
+	SetNodePublicConfig struct {
+		FarmID uint32 `json:"farm_id"`
+		NodeID uint32 `json:"node_id"`
+		// PublicConfig is removed from the node if nil
+		PublicConfig *NodePublicConfig `json:"public_config"`
+	}
+
+	NodeStatus struct {
+		// PowerState is up or down
+		PowerState string `json:"power_state"`
+		// DownSince is the block the node went down in, if it is down
+		DownSince uint32 `json:"down_since"`
+		// PowerTarget is the power state the farmer wants the node in, up or down
+		PowerTarget string `json:"power_target"`
+		// Uptime in seconds of the last uptime report, all uptime fields are 0 if the node did not report recently
+		Uptime uint64 `json:"uptime"`
+		// LastUptimeReport is the unix time of the last uptime report
+		LastUptimeReport uint64 `json:"last_uptime_report"`
+		// LastUptimeReportBlock is the block the last uptime report was included in
+		LastUptimeReportBlock uint32 `json:"last_uptime_report_block"`
+	}
+)
+
+// AddFarmIP adds a public ip to a farm of the loaded account
+func (c *Client) AddFarmIP(ctx context.Context, conState jsonrpc.State, args AddFarmIP) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.AddFarmIP(state.client, state.identity, args.FarmID, args.IP, args.Gateway)
+}
+
+// RemoveFarmIP removes a public ip from a farm of the loaded account
+func (c *Client) RemoveFarmIP(ctx context.Context, conState jsonrpc.State, args RemoveFarmIP) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.RemoveFarmIP(state.client, state.identity, args.FarmID, args.IP)
+}
+
+// SetFarmCertification sets the certification of a farm. This is sudo only, the loaded account must be the sudo key
+// of the network like on a local dev node. It is not part of the farmer api.
+func (c *Client) SetFarmCertification(ctx context.Context, conState jsonrpc.State, args SetFarmCertification) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.SetFarmCertification(state.client, state.identity, args.FarmID, args.Gold)
+}
+
+// SetFarmDedicated sets whether the nodes of a farm are dedicated. This is sudo only, the loaded account must be the
+// sudo key of the network like on a local dev node. It is not part of the farmer api.
+func (c *Client) SetFarmDedicated(ctx context.Context, conState jsonrpc.State, args SetFarmDedicated) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.SetFarmDedicated(state.client, state.identity, args.FarmID, args.Dedicated)
+}
+
+// SetFarmPayoutStellarAddress sets the stellar address the farming rewards of a farm of the loaded account are paid to
+func (c *Client) SetFarmPayoutStellarAddress(ctx context.Context, conState jsonrpc.State, args SetFarmPayoutStellarAddress) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.SetFarmPayoutStellarAddress(state.client, state.identity, args.FarmID, args.StellarAddress)
+}
+
+// GetFarmPayoutStellarAddress returns the stellar address the farming rewards of a farm are paid to
+func (c *Client) GetFarmPayoutStellarAddress(ctx context.Context, conState jsonrpc.State, farmID uint32) (string, error) {
+	state := State(conState)
+	if state.client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.GetFarmPayoutStellarAddress(state.client, farmID)
+}
+
+// SetNodePowerTarget sets whether a node of a farm of the loaded account should be up or down
+func (c *Client) SetNodePowerTarget(ctx context.Context, conState jsonrpc.State, args SetNodePowerTarget) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	if args.Target != PowerUp && args.Target != PowerDown {
+		return errors.Errorf("invalid power target %s, it should be %s or %s", args.Target, PowerUp, PowerDown)
+	}
+
+	return tfchainclient.SetNodePowerTarget(state.client, state.identity, args.NodeID, args.Target == PowerUp)
+}
+
+// SetNodePublicConfig sets or removes the public config of a node of a farm of the loaded account
+func (c *Client) SetNodePublicConfig(ctx context.Context, conState jsonrpc.State, args SetNodePublicConfig) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	var config *substrate.PublicConfig
+	if pc := args.PublicConfig; pc != nil {
+		config = &substrate.PublicConfig{
+			IP4: substrate.IP{IP: pc.IPv4, GW: pc.GW4},
+		}
+		if pc.IPv6 != "" {
+			config.IP6 = substrate.OptionIP{HasValue: true, AsValue: substrate.IP{IP: pc.IPv6, GW: pc.GW6}}
+		}
+		if pc.Domain != "" {
+			config.Domain = substrate.OptionDomain{HasValue: true, AsValue: pc.Domain}
+		}
+	}
+
+	return tfchainclient.SetNodePublicConfig(state.client, state.identity, args.FarmID, args.NodeID, config)
+}
+
+// GetNodeStatus returns the power state and target of a node and its last uptime report
+func (c *Client) GetNodeStatus(ctx context.Context, conState jsonrpc.State, nodeID uint32) (NodeStatus, error) {
+	state := State(conState)
+	if state.client == nil {
+		return NodeStatus{}, pkg.ErrClientNotConnected{}
+	}
+
+	power, err := state.client.GetPowerTarget(nodeID)
+	if err != nil {
+		return NodeStatus{}, err
+	}
+
+	status := NodeStatus{PowerState: PowerUp, PowerTarget: PowerUp}
+	if power.State.IsDown {
+		status.PowerState = PowerDown
+		status.DownSince = uint32(power.State.AsDownBlockNumber)
+	}
+	if power.Target.IsDown {
+		status.PowerTarget = PowerDown
+	}
+
+	uptime, err := tfchainclient.GetNodeUptime(state.client, nodeID)
+	if err != nil && !errors.Is(err, tfchainclient.ErrNoUptimeReport) {
+		return NodeStatus{}, err
+	}
+	status.Uptime = uptime.Uptime
+	status.LastUptimeReport = uptime.Timestamp
+	status.LastUptimeReportBlock = uptime.Block
+
+	return status, nil
+}
@@ -0,0 +1,84 @@
+package tfchain
+
+import (
+	"context"
+
+	"github.com/LeeSmet/go-jsonrpc"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	tfchainclient "github.com/threefoldtech/web3_proxy/server/clients/tfchain"
+	"github.com/threefoldtech/web3_proxy/server/pkg"
+)
+
+type (
+	KVSet struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+
+	KV struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+)
+
+// KVSet sets a key in the key-value store of the loaded account. Values are stored as is, encrypt them before storing
+// anything secret.
+func (c *Client) KVSet(ctx context.Context, conState jsonrpc.State, args KVSet) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.KVSet(state.client, state.identity, args.Key, args.Value)
+}
+
+// KVGet returns the value of a key in the key-value store of the loaded account
+func (c *Client) KVGet(ctx context.Context, conState jsonrpc.State, key string) (string, error) {
+	state := State(conState)
+	if state.client == nil {
+		return "", pkg.ErrClientNotConnected{}
+	}
+
+	account, err := substrate.FromAddress(state.identity.Address())
+	if err != nil {
+		return "", err
+	}
+
+	return tfchainclient.KVGet(state.client, account, key)
+}
+
+// KVDelete removes a key from the key-value store of the loaded account
+func (c *Client) KVDelete(ctx context.Context, conState jsonrpc.State, key string) error {
+	state := State(conState)
+	if state.client == nil {
+		return pkg.ErrClientNotConnected{}
+	}
+
+	return tfchainclient.KVDelete(state.client, state.identity, key)
+}
+
+// KVList lists the entries in the key-value store of the loaded account with keys starting with prefix, all entries
+// are listed if prefix is empty
+func (c *Client) KVList(ctx context.Context, conState jsonrpc.State, prefix string) ([]KV, error) {
+	state := State(conState)
+	if state.client == nil {
+		return nil, pkg.ErrClientNotConnected{}
+	}
+
+	account, err := substrate.FromAddress(state.identity.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := tfchainclient.KVList(state.client, account, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	kvs := make([]KV, 0, len(entries))
+	for _, entry := range entries {
+		kvs = append(kvs, KV{Key: entry.Key, Value: entry.Value})
+	}
+
+	return kvs, nil
+}
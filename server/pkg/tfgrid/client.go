@@ -124,6 +124,16 @@ func (c *Client) MachinesRemove(ctx context.Context, conState jsonrpc.State, rem
 	return state.cl.MachineRemove(ctx, removeMachine)
 }
 
+// EstimateCost estimates the monthly cost of machines or a kubernetes cluster before deploying them
+func (c *Client) EstimateCost(ctx context.Context, conState jsonrpc.State, params tfgridBase.EstimateCostParams) (tfgridBase.DeploymentCost, error) {
+	state := State(conState)
+	if state.cl == nil {
+		return tfgridBase.DeploymentCost{}, pkg.ErrClientNotConnected{}
+	}
+
+	return state.cl.EstimateCost(ctx, params)
+}
+
 func (c *Client) K8sDeploy(ctx context.Context, conState jsonrpc.State, model tfgridBase.K8sCluster) (tfgridBase.K8sCluster, error) {
 	state := State(conState)
 	if state.cl == nil {